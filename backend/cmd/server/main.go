@@ -12,7 +12,9 @@ import (
 	"vibe-backend/internal/cache"
 	"vibe-backend/internal/config"
 	"vibe-backend/internal/database"
+	"vibe-backend/internal/jobs"
 	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
 	"vibe-backend/internal/router"
 
 	"go.uber.org/zap"
@@ -60,11 +62,25 @@ func main() {
 				&models.Chapter{},
 				&models.Transcription{},
 				&models.KeyPoint{},
+				&models.InsightBatch{},
 				&models.Insight{},
 				&models.Highlight{},
 				&models.ChatMessage{},
+				&models.ChatMemory{},
+				&models.InsightChapter{},
+				&models.TranscriptChunk{},
+				&models.InsightTranscript{},
+				&models.SearchDocument{},
+				&models.InsightVersion{},
+				&models.TranscriptCache{},
+				&models.TranscriptCacheTrack{},
 				&models.Translation{},
 				&models.DualSubtitle{},
+				&models.LLMUsage{},
+				&models.Job{},
+				&models.ChannelFeed{},
+				&models.ChannelSubscription{},
+				&models.SubscriptionVideo{},
 			); err != nil {
 				log.Error("Failed to auto-migrate database", zap.Error(err))
 				db.Close()
@@ -133,8 +149,19 @@ func main() {
 	}
 
 
+	// Background job queues (require database)
+	var jobManager *jobs.Manager
+	if db != nil {
+		jobManager = jobs.NewManager(repository.NewJobRepository(db.DB), log)
+	}
+
 	// Initialize router
-	r := router.New(cfg, db, redisCache, log)
+	r := router.New(cfg, db, redisCache, jobManager, log)
+
+	// Start background workers once all queues are registered
+	if jobManager != nil {
+		jobManager.Start(context.Background())
+	}
 
 
 	// Create HTTP server
@@ -170,6 +197,13 @@ func main() {
 		log.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Interrupted jobs are returned to the queue for the next instance
+	if jobManager != nil {
+		if err := jobManager.Stop(ctx); err != nil {
+			log.Error("Job workers did not stop in time", zap.Error(err))
+		}
+	}
+
 	log.Info("Server stopped")
 }
 
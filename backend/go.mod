@@ -15,6 +15,7 @@ require (
 	github.com/redis/go-redis/v9 v9.0.5
 	go.uber.org/zap v1.27.1
 	golang.org/x/image v0.23.0
+	golang.org/x/net v0.48.0
 	golang.org/x/oauth2 v0.24.0
 	google.golang.org/api v0.205.0
 	gorm.io/datatypes v1.2.7
@@ -74,7 +75,6 @@ require (
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/arch v0.23.0 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
-	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
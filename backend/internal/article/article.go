@@ -0,0 +1,182 @@
+// Package article fetches web pages such as blog posts and newsletters and
+// extracts their main content, readability-style: boilerplate like
+// navigation, sidebars and comments is dropped and the remaining text is
+// returned as paragraphs, together with the page's author, publish date and
+// lead image.
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"vibe-backend/internal/netguard"
+)
+
+const (
+	userAgent = "Mozilla/5.0 (compatible; vibe-backend article reader)"
+	// maxPageSize bounds downloaded pages.
+	maxPageSize = 5 << 20
+
+	// Reading speeds used for ReadingMinutes
+	wordsPerMinute = 230 // space-separated languages
+	charsPerMinute = 400 // Chinese, Japanese and Korean
+)
+
+var (
+	// ErrNotHTML is returned for URLs that serve something other than a web
+	// page, e.g. a PDF or an image.
+	ErrNotHTML = errors.New("URL is not a web page")
+	// ErrNoContent is returned when no readable text is found on the page.
+	ErrNoContent = errors.New("no article content found")
+)
+
+// StatusError is returned when the page answers with an HTTP error.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("page returned status %d", e.StatusCode)
+}
+
+// Permanent reports whether fetching again cannot help: client errors other
+// than timeouts and rate limiting.
+func (e *StatusError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+// Paragraph is one block of the article's text.
+type Paragraph struct {
+	Text  string `json:"text"`
+	Level int    `json:"level,omitempty"` // 1-6 for headings, 0 for body text
+	// Start is the estimated reading time before the paragraph, which
+	// positions it in the article the way a timestamp does in a video.
+	Start time.Duration `json:"start"`
+}
+
+// Article is the extracted content and metadata of a page.
+type Article struct {
+	URL            string     `json:"url"` // after redirects
+	Title          string     `json:"title"`
+	Author         string     `json:"author,omitempty"`
+	SiteName       string     `json:"site_name,omitempty"`
+	Excerpt        string     `json:"excerpt,omitempty"`
+	Language       string     `json:"language,omitempty"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	LeadImageURL   string     `json:"lead_image_url,omitempty"`
+	WordCount      int        `json:"word_count"`
+	ReadingMinutes int        `json:"reading_minutes"`
+	// ReadingTime is the estimated time to read the whole article.
+	ReadingTime time.Duration `json:"-"`
+
+	Paragraphs []Paragraph `json:"-"`
+}
+
+// Config configures a Client.
+type Config struct {
+	// Timeout bounds fetching a page.
+	Timeout time.Duration
+}
+
+// Client fetches and extracts articles. It only connects to public
+// addresses, as page URLs come from users.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a Client.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Client{httpClient: netguard.NewClient(cfg.Timeout)}
+}
+
+// Fetch downloads a page and extracts its article.
+func (c *Client) Fetch(ctx context.Context, rawURL string) (*Article, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxPageSize), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	article := Extract(doc, resp.Request.URL.String())
+	if len(article.Paragraphs) == 0 {
+		return nil, ErrNoContent
+	}
+	return article, nil
+}
+
+// Text joins the article's paragraphs with blank lines.
+func (a *Article) Text() string {
+	texts := make([]string, len(a.Paragraphs))
+	for i, p := range a.Paragraphs {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// countWords counts words in space-separated scripts and characters in
+// Chinese, Japanese and Korean, which reading speeds are measured in.
+func countWords(text string) (words, cjkChars int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjkChars++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		case r == '\'' || r == '’' || r == '-':
+			// Part of a word such as "don't" or "well-known"
+		default:
+			inWord = false
+		}
+	}
+	return words, cjkChars
+}
+
+// readingTime estimates the time to read text.
+func readingTime(words, cjkChars int) time.Duration {
+	minutes := float64(words)/wordsPerMinute + float64(cjkChars)/charsPerMinute
+	return time.Duration(minutes * float64(time.Minute))
+}
@@ -0,0 +1,512 @@
+package article
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Thresholds of the content scoring, in the spirit of Mozilla's Readability.
+const (
+	minScoredLength  = 25  // shorter blocks are not scored
+	minSiblingScore  = 10  // siblings of the top candidate need at least this
+	siblingScoreRate = 0.2 // or this fraction of the top candidate's score
+	maxLinkDensity   = 0.5 // blocks with more link text are navigation
+	maxExcerptRunes  = 200
+)
+
+var (
+	// unlikelyPattern matches the class or id of page furniture.
+	unlikelyPattern = regexp.MustCompile(`(?i)-ad-|ad-break|agegate|banner|breadcrumb|combx|comment|community|cookie|consent|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|toolbar|widget`)
+	// maybePattern rescues elements matching unlikelyPattern that may still
+	// hold the content, e.g. "main-header-and-content".
+	maybePattern    = regexp.MustCompile(`(?i)and|article|body|column|content|main|post|shadow`)
+	positivePattern = regexp.MustCompile(`(?i)article|blog|body|content|entry|h-entry|hentry|main|page|post|story|text`)
+	negativePattern = regexp.MustCompile(`(?i)-ad-|banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shopping|shoutbox|sidebar|skyscraper|sponsor|tags|widget`)
+	bylinePattern   = regexp.MustCompile(`(?i)byline|author|writtenby|p-author`)
+
+	blankLines = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// boilerplateTags never hold article text.
+var boilerplateTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Canvas: true, atom.Svg: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true,
+	atom.Link: true, atom.Meta: true,
+}
+
+// boilerplateRoles are ARIA roles of page furniture.
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "complementary": true, "contentinfo": true,
+	"dialog": true, "alertdialog": true, "menu": true, "menubar": true, "search": true,
+}
+
+// blockTags start a new paragraph.
+var blockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Dd: true,
+	atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true,
+	atom.Figure: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Hr: true, atom.Li: true, atom.Main: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true,
+	atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Th: true, atom.Thead: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// Extract finds the main content of a parsed page. pageURL resolves relative
+// links such as the lead image. The returned article has no paragraphs if
+// nothing readable was found.
+func Extract(doc *html.Node, pageURL string) *Article {
+	base, _ := url.Parse(pageURL)
+	meta := readMetadata(doc, base)
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	prune(body)
+
+	paragraphs := collectParagraphs(contentNodes(body))
+	if len(paragraphs) > 0 && sameText(paragraphs[0].Text, meta.title) {
+		paragraphs = paragraphs[1:]
+	}
+
+	article := &Article{
+		URL:          pageURL,
+		Title:        meta.title,
+		Author:       meta.author,
+		SiteName:     meta.siteName,
+		Excerpt:      meta.excerpt,
+		Language:     meta.language,
+		PublishedAt:  meta.publishedAt,
+		LeadImageURL: meta.image,
+		Paragraphs:   paragraphs,
+	}
+	if article.Title == "" {
+		for _, p := range paragraphs {
+			if p.Level > 0 {
+				article.Title = p.Text
+				break
+			}
+		}
+	}
+	if article.Excerpt == "" {
+		for _, p := range paragraphs {
+			if p.Level == 0 {
+				article.Excerpt = truncateRunes(p.Text, maxExcerptRunes)
+				break
+			}
+		}
+	}
+
+	var words, cjkChars int
+	for i := range article.Paragraphs {
+		p := &article.Paragraphs[i]
+		p.Start = readingTime(words, cjkChars)
+		w, c := countWords(p.Text)
+		words += w
+		cjkChars += c
+	}
+	article.WordCount = words + cjkChars
+	article.ReadingTime = readingTime(words, cjkChars)
+	if len(paragraphs) > 0 {
+		article.ReadingMinutes = max(1, int(article.ReadingTime.Minutes()+0.5))
+	}
+	return article
+}
+
+// prune removes boilerplate elements, hidden elements, bylines and elements
+// whose class or id marks them as unlikely to be content.
+func prune(root *html.Node) {
+	var remove []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n == root {
+			return true
+		}
+		switch n.Type {
+		case html.CommentNode:
+			remove = append(remove, n)
+			return false
+		case html.ElementNode:
+		default:
+			return true
+		}
+		if boilerplateTags[n.DataAtom] || isHidden(n) || boilerplateRoles[strings.ToLower(attr(n, "role"))] {
+			remove = append(remove, n)
+			return false
+		}
+		if n.DataAtom != atom.Article && n.DataAtom != atom.Main && n.DataAtom != atom.A {
+			match := attr(n, "class") + " " + attr(n, "id")
+			if unlikelyPattern.MatchString(match) && !maybePattern.MatchString(match) {
+				remove = append(remove, n)
+				return false
+			}
+			// Bylines were read as metadata already
+			if (bylinePattern.MatchString(match) || strings.EqualFold(attr(n, "itemprop"), "author")) &&
+				utf8.RuneCountInString(textContent(n)) < 100 {
+				remove = append(remove, n)
+				return false
+			}
+		}
+		return true
+	})
+	for _, n := range remove {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func isHidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// contentNodes scores the page's blocks and returns the best candidate for
+// the article's container together with those of its siblings that look
+// like part of the article too.
+func contentNodes(body *html.Node) []*html.Node {
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walk(body, func(n *html.Node) bool {
+		if !isScorable(n) {
+			return true
+		}
+		text := textContent(n)
+		length := utf8.RuneCountInString(text)
+		if length < minScoredLength {
+			return true
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、"))
+		score += float64(min(length/100, 3))
+
+		// Parents get the full score, grandparents half and further
+		// ancestors a third per level
+		ancestor := n.Parent
+		for level := 0; level < 3 && ancestor != nil && ancestor != body.Parent; level++ {
+			switch level {
+			case 0:
+				addScore(ancestor, score)
+			case 1:
+				addScore(ancestor, score/2)
+			default:
+				addScore(ancestor, score/float64(level*3))
+			}
+			ancestor = ancestor.Parent
+		}
+		return true
+	})
+
+	var top *html.Node
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil || top.Parent == nil {
+		return []*html.Node{body}
+	}
+
+	threshold := max(minSiblingScore, scores[top]*siblingScoreRate)
+	var nodes []*html.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s == top {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.Type != html.ElementNode {
+			continue
+		}
+		score, scored := scores[s]
+		if class := attr(top, "class"); class != "" && class == attr(s, "class") {
+			score += scores[top] * siblingScoreRate
+		}
+		if scored && score >= threshold {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.DataAtom == atom.P {
+			text := textContent(s)
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(s)
+			if (length >= 80 && density < 0.25) ||
+				(length > 0 && density == 0 && endsSentence(text)) {
+				nodes = append(nodes, s)
+			}
+		}
+	}
+	return nodes
+}
+
+// isScorable reports whether n is a paragraph-like block: a <p>, <pre> or
+// table cell, or a <div> or <section> used as a paragraph, i.e. without
+// block children.
+func isScorable(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td:
+		return true
+	case atom.Div, atom.Section:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// initialScore favours containers that usually hold text and penalises
+// lists, headings and elements whose class or id looks like furniture.
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main:
+		score = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativePattern.MatchString(value) {
+			score -= 25
+		}
+		if positivePattern.MatchString(value) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity is the share of n's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(textContent(n))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			linkLength += utf8.RuneCountInString(textContent(c))
+			return false
+		}
+		return true
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// collector turns content nodes into paragraphs. Inline text accumulates
+// until the next block boundary; <br> starts a new line and two of them a
+// new paragraph.
+type collector struct {
+	paragraphs []Paragraph
+	buf        strings.Builder
+}
+
+func collectParagraphs(nodes []*html.Node) []Paragraph {
+	c := &collector{}
+	for _, n := range nodes {
+		c.visit(n, true)
+	}
+	c.flush()
+	return c.paragraphs
+}
+
+func (c *collector) visit(n *html.Node, root bool) {
+	switch n.Type {
+	case html.TextNode:
+		c.buf.WriteString(collapseSpaces(n.Data))
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		c.flush()
+		if text := textContent(n); text != "" {
+			c.paragraphs = append(c.paragraphs, Paragraph{Text: text, Level: level})
+		}
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		c.buf.WriteString("\n")
+		return
+	case atom.Pre:
+		c.flush()
+		if text := strings.Trim(rawText(n), "\n"); strings.TrimSpace(text) != "" {
+			c.paragraphs = append(c.paragraphs, Paragraph{Text: text})
+		}
+		return
+	case atom.Img, atom.Picture, atom.Video, atom.Audio:
+		return
+	}
+
+	block := blockTags[n.DataAtom]
+	if block {
+		// Lists of links inside the article are tables of contents, tag
+		// lists and the like
+		if !root && linkDensity(n) > maxLinkDensity {
+			return
+		}
+		c.flush()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.visit(child, false)
+	}
+	if block {
+		c.flush()
+	}
+}
+
+// flush turns the buffered inline text into paragraphs.
+func (c *collector) flush() {
+	text := c.buf.String()
+	c.buf.Reset()
+	for _, chunk := range blankLines.Split(text, -1) {
+		var lines []string
+		for _, line := range strings.Split(chunk, "\n") {
+			if line = strings.TrimSpace(collapseSpaces(line)); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			c.paragraphs = append(c.paragraphs, Paragraph{Text: strings.Join(lines, "\n")})
+		}
+	}
+}
+
+// walk visits n and its descendants in document order. Returning false from
+// fn skips the node's children.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		walk(c, fn)
+		c = next
+	}
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawText concatenates the text below n as is.
+func rawText(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+		case c.DataAtom == atom.Br:
+			b.WriteString("\n")
+		}
+		return true
+	})
+	return b.String()
+}
+
+// textContent is the text below n with runs of whitespace collapsed.
+func textContent(n *html.Node) string {
+	return strings.TrimSpace(collapseSpaces(rawText(n)))
+}
+
+// collapseSpaces replaces each run of whitespace with a single space.
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func endsSentence(text string) bool {
+	text = strings.TrimSpace(text)
+	for _, end := range []string{".", "!", "?", "。", "！", "？", "…"} {
+		if strings.HasSuffix(text, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameText(a, b string) bool {
+	return a != "" && strings.EqualFold(collapseSpaces(strings.TrimSpace(a)), collapseSpaces(strings.TrimSpace(b)))
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
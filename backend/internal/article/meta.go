@@ -0,0 +1,279 @@
+package article
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// metadata is what the page says about itself in <meta> tags, JSON-LD and
+// a few common markup conventions.
+type metadata struct {
+	title       string
+	author      string
+	siteName    string
+	excerpt     string
+	language    string
+	publishedAt *time.Time
+	image       string
+}
+
+// JSON-LD types that describe an article.
+var articleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "Report": true,
+	"ScholarlyArticle": true, "TechArticle": true, "SocialMediaPosting": true,
+	"OpinionNewsArticle": true, "AnalysisNewsArticle": true, "WebPage": true,
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// readMetadata collects metadata before boilerplate is removed, since
+// bylines and dates often sit in headers that extraction drops.
+func readMetadata(doc *html.Node, base *url.URL) metadata {
+	var m metadata
+	metas := map[string]string{}
+	var titleTag, h1, relAuthor, byline, timeTag string
+	var jsonLD []string
+
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Html:
+			m.language = attr(n, "lang")
+		case atom.Title:
+			if titleTag == "" {
+				titleTag = textContent(n)
+			}
+		case atom.Meta:
+			key := strings.ToLower(firstNonEmpty(attr(n, "property"), attr(n, "name"), attr(n, "itemprop")))
+			if content := strings.TrimSpace(attr(n, "content")); key != "" && content != "" {
+				if _, ok := metas[key]; !ok {
+					metas[key] = content
+				}
+			}
+		case atom.Script:
+			if strings.EqualFold(attr(n, "type"), "application/ld+json") {
+				jsonLD = append(jsonLD, textContent(n))
+			}
+			return false
+		case atom.H1:
+			if h1 == "" {
+				h1 = textContent(n)
+			}
+		case atom.A:
+			if relAuthor == "" && strings.Contains(strings.ToLower(attr(n, "rel")), "author") {
+				relAuthor = textContent(n)
+			}
+		case atom.Time:
+			if timeTag == "" {
+				timeTag = attr(n, "datetime")
+			}
+		}
+		if byline == "" && n.Type == html.ElementNode &&
+			(strings.EqualFold(attr(n, "itemprop"), "author") || bylinePattern.MatchString(attr(n, "class")+" "+attr(n, "id"))) {
+			if text := textContent(n); text != "" && len([]rune(text)) < 100 {
+				byline = text
+			}
+		}
+		return true
+	})
+
+	ld := readJSONLD(jsonLD)
+
+	m.siteName = firstNonEmpty(metas["og:site_name"], ld.publisher)
+	m.title = firstNonEmpty(metas["og:title"], ld.headline, metas["twitter:title"], cleanTitle(titleTag, m.siteName), h1)
+	m.author = firstNonEmpty(ld.author, metas["author"], metas["article:author"], metas["parsely-author"],
+		metas["dc.creator"], relAuthor, cleanByline(byline))
+	if strings.HasPrefix(m.author, "http://") || strings.HasPrefix(m.author, "https://") {
+		// article:author is often a profile URL
+		m.author = firstNonEmpty(ld.author, metas["author"], relAuthor, cleanByline(byline))
+	}
+	m.excerpt = firstNonEmpty(metas["og:description"], metas["description"], metas["twitter:description"])
+	m.publishedAt = parseDate(firstNonEmpty(metas["article:published_time"], ld.datePublished,
+		metas["datepublished"], metas["date"], metas["dc.date"], metas["publish-date"], timeTag))
+	m.image = resolveURL(base, firstNonEmpty(metas["og:image"], metas["og:image:url"], metas["twitter:image"],
+		metas["twitter:image:src"], ld.image))
+	if m.language == "" {
+		m.language = metas["og:locale"]
+	}
+	return m
+}
+
+// jsonLDArticle holds the fields read from JSON-LD.
+type jsonLDArticle struct {
+	headline      string
+	author        string
+	datePublished string
+	image         string
+	publisher     string
+}
+
+// readJSONLD finds the first article object in the page's JSON-LD blocks,
+// which may hold a single object, an array or an @graph.
+func readJSONLD(blocks []string) jsonLDArticle {
+	var found jsonLDArticle
+	var visit func(v any) bool
+	visit = func(v any) bool {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				if visit(item) {
+					return true
+				}
+			}
+		case map[string]any:
+			if graph, ok := v["@graph"]; ok && visit(graph) {
+				return true
+			}
+			if !isArticleType(v["@type"]) {
+				return false
+			}
+			found = jsonLDArticle{
+				headline:      jsonString(v["headline"]),
+				author:        jsonName(v["author"]),
+				datePublished: jsonString(v["datePublished"]),
+				image:         jsonURL(v["image"]),
+				publisher:     jsonName(v["publisher"]),
+			}
+			return true
+		}
+		return false
+	}
+	for _, block := range blocks {
+		var v any
+		if json.Unmarshal([]byte(block), &v) == nil && visit(v) {
+			break
+		}
+	}
+	return found
+}
+
+func isArticleType(v any) bool {
+	switch t := v.(type) {
+	case string:
+		return articleTypes[t]
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && articleTypes[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonString(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// jsonName reads a person or organization given as a string, an object with
+// a name, or a list of those, joining several names with commas.
+func jsonName(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return jsonString(v["name"])
+	case []any:
+		var names []string
+		for _, item := range v {
+			if name := jsonName(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// jsonURL reads an image given as a URL, an ImageObject or a list of those.
+func jsonURL(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return jsonString(v["url"])
+	case []any:
+		for _, item := range v {
+			if u := jsonURL(item); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// cleanTitle strips the site name from a <title> such as "Post | Site".
+func cleanTitle(title, siteName string) string {
+	title = strings.TrimSpace(title)
+	for _, sep := range []string{" | ", " - ", " – ", " — ", " :: ", " · "} {
+		i := strings.LastIndex(title, sep)
+		if i <= 0 {
+			continue
+		}
+		suffix := strings.TrimSpace(title[i+len(sep):])
+		if siteName == "" || strings.EqualFold(suffix, siteName) || len([]rune(suffix)) < len([]rune(title))/3 {
+			return strings.TrimSpace(title[:i])
+		}
+	}
+	return title
+}
+
+// cleanByline removes the "By" in bylines such as "By Jane Doe".
+func cleanByline(byline string) string {
+	byline = strings.TrimSpace(byline)
+	for _, prefix := range []string{"By ", "by ", "BY ", "作者：", "作者:", "文/"} {
+		byline = strings.TrimPrefix(byline, prefix)
+	}
+	return strings.TrimSpace(byline)
+}
+
+// parseDate parses the common machine-readable date formats; it returns nil
+// if none match.
+func parseDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// resolveURL makes a URL from the page absolute.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
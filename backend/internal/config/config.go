@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 )
 
@@ -26,6 +28,100 @@ type Config struct {
 	OpenRouterAPIKey string `env:"OPENROUTER_API_KEY" envDefault:""`
 	// Gemini model configuration
 	GeminiModel string `env:"GEMINI_MODEL" envDefault:"google/gemini-3-flash-preview"`
+	// LLM provider configuration: openrouter | openai (any OpenAI-compatible server) | fake
+	LLMProvider string `env:"LLM_PROVIDER" envDefault:"openrouter"`
+	// Base URL for the provider, e.g. http://localhost:11434/v1 for Ollama
+	LLMBaseURL string `env:"LLM_BASE_URL" envDefault:""`
+	// API key for the provider; falls back to OPENROUTER_API_KEY
+	LLMAPIKey string `env:"LLM_API_KEY" envDefault:""`
+	// Model name; falls back to GEMINI_MODEL
+	LLMModel      string        `env:"LLM_MODEL" envDefault:""`
+	LLMTimeout    time.Duration `env:"LLM_TIMEOUT" envDefault:"120s"`
+	LLMMaxRetries int           `env:"LLM_MAX_RETRIES" envDefault:"2"`
+	// Longest wait for the next chunk of a streamed reply before giving up
+	LLMStreamIdleTimeout time.Duration `env:"LLM_STREAM_IDLE_TIMEOUT" envDefault:"30s"`
+	// Fallback pricing when the provider does not report cost,
+	// as "model=prompt:completion,..." in USD per million tokens ("*" matches any model)
+	LLMPricing string `env:"LLM_PRICING" envDefault:""`
+
+	// Embeddings for transcript retrieval: openai | openrouter | fake (empty = keyword search only)
+	EmbeddingProvider string `env:"EMBEDDING_PROVIDER" envDefault:""`
+	EmbeddingBaseURL  string `env:"EMBEDDING_BASE_URL" envDefault:""`
+	// API key for the embedding provider; falls back to the LLM key
+	EmbeddingAPIKey string `env:"EMBEDDING_API_KEY" envDefault:""`
+	EmbeddingModel  string `env:"EMBEDDING_MODEL" envDefault:""`
+	// Number of transcript chunks given to chat per question
+	RAGTopK int `env:"RAG_TOP_K" envDefault:"6"`
+	// Context window in tokens that chat prompts must fit (0 = known window of the model)
+	ChatContextWindow int `env:"CHAT_CONTEXT_WINDOW" envDefault:"0"`
+
+	// Monthly AI budgets (0 = unlimited)
+	AIUserMonthlyBudgetUSD   float64 `env:"AI_USER_MONTHLY_BUDGET_USD" envDefault:"0"`
+	AIUserMonthlyTokenBudget int64   `env:"AI_USER_MONTHLY_TOKEN_BUDGET" envDefault:"0"`
+	AIGlobalMonthlyBudgetUSD float64 `env:"AI_GLOBAL_MONTHLY_BUDGET_USD" envDefault:"0"`
+
+	// Background job workers
+	JobInsightWorkers int           `env:"JOB_INSIGHT_WORKERS" envDefault:"2"`
+	JobVideoWorkers   int           `env:"JOB_VIDEO_WORKERS" envDefault:"2"`
+	JobMaxAttempts    int           `env:"JOB_MAX_ATTEMPTS" envDefault:"5"`
+	JobLease          time.Duration `env:"JOB_LEASE" envDefault:"5m"`
+	JobPollInterval   time.Duration `env:"JOB_POLL_INTERVAL" envDefault:"2s"`
+	JobRetryBackoff   time.Duration `env:"JOB_RETRY_BACKOFF" envDefault:"30s"`
+
+	// Emails of users allowed to access admin endpoints
+	AdminEmails []string `env:"ADMIN_EMAILS" envSeparator:"," envDefault:""`
+
+	// Order in which transcript providers are tried: innertube | watchpage | ytdlp | stt
+	TranscriptProviders []string `env:"TRANSCRIPT_PROVIDERS" envSeparator:"," envDefault:"innertube,watchpage,ytdlp,stt"`
+	// Caption languages to prefer, most preferred first; manual tracks beat auto-generated ones
+	TranscriptLanguages []string `env:"TRANSCRIPT_LANGUAGES" envSeparator:"," envDefault:"en,zh-Hans,zh-Hant,zh"`
+	// How long fetched transcripts are reused before fetching again (0 = until refreshed)
+	TranscriptCacheTTL time.Duration `env:"TRANSCRIPT_CACHE_TTL" envDefault:"168h"`
+	// Regroup fetched captions into sentences, and restore punctuation of
+	// speech-recognition captions with the LLM instead of from pauses only
+	TranscriptSentences      bool `env:"TRANSCRIPT_SENTENCES" envDefault:"true"`
+	TranscriptLLMPunctuation bool `env:"TRANSCRIPT_LLM_PUNCTUATION" envDefault:"false"`
+
+	// Speech-to-text for videos without captions ("stt" provider): whisper-cli | openai (empty = disabled)
+	STTBackend string `env:"STT_BACKEND" envDefault:""`
+	// whisper.cpp-compatible CLI and its ggml model file
+	STTWhisperBinary string `env:"STT_WHISPER_BINARY" envDefault:"whisper-cli"`
+	STTWhisperModel  string `env:"STT_WHISPER_MODEL" envDefault:""`
+	// OpenAI-compatible /audio/transcriptions endpoint, e.g. https://api.openai.com/v1
+	STTBaseURL string `env:"STT_BASE_URL" envDefault:""`
+	// API key for the STT endpoint; falls back to the LLM key
+	STTAPIKey string `env:"STT_API_KEY" envDefault:""`
+	STTModel  string `env:"STT_MODEL" envDefault:"whisper-1"`
+	// Audio is transcribed in chunks of this length; videos longer than the max are skipped (0 = no limit)
+	STTChunkDuration time.Duration `env:"STT_CHUNK_DURATION" envDefault:"10m"`
+	STTMaxDuration   time.Duration `env:"STT_MAX_DURATION" envDefault:"3h"`
+	STTTimeout       time.Duration `env:"STT_TIMEOUT" envDefault:"10m"`
+
+	// Twitter/X thread fetcher: syndication | oembed (empty = disabled)
+	TwitterFetcher string `env:"TWITTER_FETCHER" envDefault:"syndication"`
+	// Endpoint overrides, e.g. to point at a stub server (empty = public endpoints)
+	TwitterSyndicationURL string        `env:"TWITTER_SYNDICATION_URL" envDefault:""`
+	TwitterTimelineURL    string        `env:"TWITTER_TIMELINE_URL" envDefault:""`
+	TwitterOEmbedURL      string        `env:"TWITTER_OEMBED_URL" envDefault:""`
+	TwitterTimeout        time.Duration `env:"TWITTER_TIMEOUT" envDefault:"15s"`
+
+	// Podcast feeds: request timeout, and whether episodes without a transcript
+	// tag are transcribed with the STT backend (limited by STT_MAX_DURATION)
+	PodcastTimeout time.Duration `env:"PODCAST_TIMEOUT" envDefault:"30s"`
+	PodcastSTT     bool          `env:"PODCAST_STT" envDefault:"true"`
+
+	// Web articles: request timeout for fetching a page
+	ArticleTimeout time.Duration `env:"ARTICLE_TIMEOUT" envDefault:"30s"`
+
+	// YouTube channel subscriptions: how often subscribed channels are polled,
+	// and WebSub push. The callback URL is the public address of
+	// /api/v1/websub/youtube (empty = polling only)
+	SubscriptionPollInterval    time.Duration `env:"SUBSCRIPTION_POLL_INTERVAL" envDefault:"15m"`
+	SubscriptionPushCallbackURL string        `env:"SUBSCRIPTION_PUSH_CALLBACK_URL" envDefault:""`
+	SubscriptionHubURL          string        `env:"SUBSCRIPTION_HUB_URL" envDefault:""`
+	SubscriptionPushLease       time.Duration `env:"SUBSCRIPTION_PUSH_LEASE" envDefault:"120h"`
+	SubscriptionTimeout         time.Duration `env:"SUBSCRIPTION_TIMEOUT" envDefault:"15s"`
+
 	// YouTube Data API v3 configuration
 	YouTubeAPIKey string `env:"YOUTUBE_API_KEY" envDefault:""`
 
@@ -44,6 +140,38 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// LLMKey returns the API key for the LLM provider.
+func (c *Config) LLMKey() string {
+	if c.LLMAPIKey != "" {
+		return c.LLMAPIKey
+	}
+	return c.OpenRouterAPIKey
+}
+
+// EmbeddingKey returns the API key for the embedding provider.
+func (c *Config) EmbeddingKey() string {
+	if c.EmbeddingAPIKey != "" {
+		return c.EmbeddingAPIKey
+	}
+	return c.LLMKey()
+}
+
+// STTKey returns the API key for the speech-to-text endpoint.
+func (c *Config) STTKey() string {
+	if c.STTAPIKey != "" {
+		return c.STTAPIKey
+	}
+	return c.LLMKey()
+}
+
+// LLMModelName returns the default model for the LLM provider.
+func (c *Config) LLMModelName() string {
+	if c.LLMModel != "" {
+		return c.LLMModel
+	}
+	return c.GeminiModel
+}
+
 // IsDevelopment returns true if running in development environment.
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
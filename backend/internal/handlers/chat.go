@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/services"
 
@@ -28,24 +31,13 @@ func NewChatHandler(chatService *services.ChatService, log *zap.Logger) *ChatHan
 	}
 }
 
-// Chat handles POST /api/v1/insights/:id/chat - streaming chat
+// Chat handles POST /api/v1/insights/:id/chat - saves the question and streams
+// the assistant's answer over SSE
 func (h *ChatHandler) Chat(c *gin.Context) {
 	requestID := c.GetString("request_id")
-	
-	// Parse insight ID
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Invalid insight ID format",
-			zap.String("insight_id", idStr),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Code:      "INVALID_ID",
-			Message:   "Invalid insight ID format.",
-			RequestID: requestID,
-		})
+
+	id, ok := h.parseInsightID(c)
+	if !ok {
 		return
 	}
 
@@ -64,61 +56,37 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 		return
 	}
 
-	// Start streaming
-	stream, err := h.chatService.ChatStream(c.Request.Context(), uint(id), req.Message, req.HighlightID)
+	userID := middleware.MustGetUserID(c)
+	stream, err := h.chatService.ChatStream(c.Request.Context(), userID, uint(id), req.Message, req.HighlightID)
 	if err != nil {
-		// Check if it's a "not found" error
-		if strings.Contains(err.Error(), "not found") {
-			h.log.Error("Insight not found",
-				zap.String("error_code", "INSIGHT_NOT_FOUND"),
-				zap.Uint64("insight_id", id),
-				zap.String("request_id", requestID),
-			)
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Code:      "INSIGHT_NOT_FOUND",
-				Message:   "Insight not found.",
-				RequestID: requestID,
-			})
-			return
-		}
-
-		h.log.Error("Failed to start chat stream",
-			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
-			zap.Uint64("insight_id", id),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:      "INTERNAL_SERVER_ERROR",
-			Message:   "Failed to start chat stream.",
-			RequestID: requestID,
-		})
+		h.respondStreamError(c, id, err)
 		return
 	}
 
-	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+	h.writeStream(c, stream)
+}
 
-	// Stream response
-	c.Stream(func(w io.Writer) bool {
-		event, ok := <-stream
-		if !ok {
-			return false
-		}
+// Regenerate handles POST /api/v1/insights/:id/chat/regenerate - replaces the
+// latest answer with a newly streamed one
+func (h *ChatHandler) Regenerate(c *gin.Context) {
+	id, ok := h.parseInsightID(c)
+	if !ok {
+		return
+	}
 
-		c.SSEvent("message", event)
-		return !event.Done
-	})
+	userID := middleware.MustGetUserID(c)
+	stream, err := h.chatService.RegenerateStream(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		h.respondStreamError(c, id, err)
+		return
+	}
+
+	h.writeStream(c, stream)
 }
 
-// GetHistory handles GET /api/v1/insights/:id/chat - get chat history
-func (h *ChatHandler) GetHistory(c *gin.Context) {
+// parseInsightID reads the :id parameter, writing a 400 response if invalid.
+func (h *ChatHandler) parseInsightID(c *gin.Context) (uint64, bool) {
 	requestID := c.GetString("request_id")
-	
-	// Parse insight ID
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
@@ -132,12 +100,53 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 			Message:   "Invalid insight ID format.",
 			RequestID: requestID,
 		})
-		return
+		return 0, false
 	}
+	return id, true
+}
 
-	history, err := h.chatService.GetChatHistory(c.Request.Context(), uint(id))
-	if err != nil {
-		h.log.Error("Failed to get chat history",
+// respondStreamError maps an error from starting a chat stream to a response.
+func (h *ChatHandler) respondStreamError(c *gin.Context, id uint64, err error) {
+	requestID := c.GetString("request_id")
+
+	switch {
+	case errors.Is(err, services.ErrChatInsightNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:      "INSIGHT_NOT_FOUND",
+			Message:   "Insight not found.",
+			RequestID: requestID,
+		})
+	case errors.Is(err, services.ErrChatForbidden):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Code:      models.ErrForbidden,
+			Message:   "You do not have access to this insight.",
+			RequestID: requestID,
+		})
+	case errors.Is(err, services.ErrChatHighlightNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:      "HIGHLIGHT_NOT_FOUND",
+			Message:   "Highlight not found in this insight.",
+			RequestID: requestID,
+		})
+	case errors.Is(err, services.ErrNothingToRegenerate):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:      "NOTHING_TO_REGENERATE",
+			Message:   "There is no question to regenerate an answer for.",
+			RequestID: requestID,
+		})
+	case errors.Is(err, llm.ErrBudgetExceeded):
+		h.log.Warn("AI budget exceeded",
+			zap.Uint64("insight_id", id),
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Code:      models.ErrAIBudgetExceeded,
+			Message:   err.Error(),
+			RequestID: requestID,
+		})
+	default:
+		h.log.Error("Failed to start chat stream",
 			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
 			zap.Uint64("insight_id", id),
 			zap.String("request_id", requestID),
@@ -145,13 +154,41 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 		)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:      "INTERNAL_SERVER_ERROR",
-			Message:   "Failed to get chat history.",
+			Message:   "Failed to start chat stream.",
 			RequestID: requestID,
 		})
-		return
 	}
+}
 
-	c.JSON(http.StatusOK, history)
+// writeStream relays chat events as SSE until the answer is done or the
+// client disconnects. On disconnect the request context is cancelled, which
+// stops the model stream in the service.
+func (h *ChatHandler) writeStream(c *gin.Context, stream <-chan models.ChatStreamEvent) {
+	// Set SSE headers
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	// Stream response
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				return false
+			}
+
+			// Citations and errors are separate event types so clients can ignore them
+			eventType := event.Type
+			if eventType == "" {
+				eventType = models.ChatEventMessage
+			}
+			c.SSEvent(eventType, event)
+			return !event.Done
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
 }
 
 // AnalyzeEntities handles POST /api/v1/insights/:id/analyze-entities
@@ -175,9 +212,25 @@ func (h *ChatHandler) AnalyzeEntities(c *gin.Context) {
 		return
 	}
 
-	result, err := h.chatService.AnalyzeEntities(c.Request.Context(), uint(id))
+	userID := middleware.MustGetUserID(c)
+	result, err := h.chatService.AnalyzeEntities(c.Request.Context(), userID, uint(id))
 	if err != nil {
 		errMsg := err.Error()
+
+		// Check if the insight belongs to another user
+		if errors.Is(err, services.ErrChatForbidden) {
+			h.log.Warn("Entity analysis of another user's insight",
+				zap.Uint64("insight_id", id),
+				zap.Uint("user_id", userID),
+				zap.String("request_id", requestID),
+			)
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Code:      models.ErrForbidden,
+				Message:   "You do not have access to this insight.",
+				RequestID: requestID,
+			})
+			return
+		}
 		
 		// Check if it's a "insight not found" error (more precise check)
 		if strings.Contains(errMsg, "insight not found") || strings.Contains(errMsg, "record not found") {
@@ -194,8 +247,23 @@ func (h *ChatHandler) AnalyzeEntities(c *gin.Context) {
 			return
 		}
 		
-		// Check if it's an OpenRouter API authentication error
-		if strings.Contains(errMsg, "OpenRouter API 认证失败") || strings.Contains(errMsg, "User not found") {
+		// Check if the user's AI budget is used up
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			h.log.Warn("AI budget exceeded",
+				zap.Uint64("insight_id", id),
+				zap.String("request_id", requestID),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Code:      models.ErrAIBudgetExceeded,
+				Message:   err.Error(),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		// Check if it's an LLM provider authentication error
+		if errors.Is(err, llm.ErrAuth) || errors.Is(err, llm.ErrNotConfigured) {
 			h.log.Error("OpenRouter API authentication failed",
 				zap.String("error_code", "OPENROUTER_AUTH_FAILED"),
 				zap.Uint64("insight_id", id),
@@ -6,9 +6,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,19 +20,26 @@ import (
 
 	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
+	"vibe-backend/internal/podcast"
 	"vibe-backend/internal/repository"
+	"vibe-backend/internal/services"
+	"vibe-backend/internal/subtitles"
 )
 
-// InsightProcessor defines the interface for async insight processing.
+// InsightProcessor defines the interface for background insight processing.
 type InsightProcessor interface {
-	ProcessInsightAsync(ctx context.Context, insightID uint)
+	EnqueueInsight(ctx context.Context, insightID uint) error
+	SwitchTranscript(ctx context.Context, insight *models.Insight, language string, kind models.CaptionKind) error
+	RestoreVersion(ctx context.Context, insight *models.Insight, version int, pin bool) error
 }
 
 // InsightHandler handles InsightFlow HTTP requests.
 type InsightHandler struct {
-	repo      *repository.InsightRepository
-	processor InsightProcessor
-	log       *zap.Logger
+	repo       *repository.InsightRepository
+	processor  InsightProcessor
+	search     *services.SearchService
+	youtubeAPI *services.YouTubeAPIService
+	log        *zap.Logger
 }
 
 // NewInsightHandler creates a new InsightHandler.
@@ -42,6 +51,11 @@ func NewInsightHandler(repo *repository.InsightRepository, processor InsightProc
 	}
 }
 
+// SetSearchService sets the service used to re-index insights whose title changes.
+func (h *InsightHandler) SetSearchService(search *services.SearchService) {
+	h.search = search
+}
+
 // List returns a list of insights grouped by date for the current user.
 // GET /api/v1/insights
 func (h *InsightHandler) List(c *gin.Context) {
@@ -128,6 +142,12 @@ func (h *InsightHandler) Create(c *gin.Context) {
 		req.TargetLang = "zh"
 	}
 
+	// A podcast episode is addressed by its feed and GUID
+	if req.EpisodeGUID != "" {
+		req.SourceURL = podcast.EpisodeURL(req.SourceURL, strings.TrimSpace(req.EpisodeGUID))
+		req.SourceType = models.SourceTypePodcast
+	}
+
 	// Extract source ID from URL to check for duplicates
 	sourceID := extractSourceID(req.SourceURL)
 	
@@ -186,6 +206,7 @@ func (h *InsightHandler) Create(c *gin.Context) {
 
 	insight := &models.Insight{
 		UserID:     userID,
+		SourceType: req.SourceType,
 		SourceURL:  req.SourceURL,
 		SourceID:   sourceID,
 		TargetLang: req.TargetLang,
@@ -201,11 +222,15 @@ func (h *InsightHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Trigger async processing if processor is available
+	// Queue background processing if processor is available
 	if h.processor != nil {
-		// Use background context for async processing since request context may be cancelled
-		go h.processor.ProcessInsightAsync(context.Background(), insight.ID)
-		h.log.Info("Triggered async insight processing", zap.Uint("insight_id", insight.ID))
+		// A failed enqueue leaves the insight pending; it is recovered on next startup
+		if err := h.processor.EnqueueInsight(c.Request.Context(), insight.ID); err != nil {
+			h.log.Error("Failed to enqueue insight processing",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -217,7 +242,147 @@ func (h *InsightHandler) Create(c *gin.Context) {
 	})
 }
 
-// extractSourceID extracts the source ID from a URL (e.g., YouTube video ID)
+// CreateFromUpload creates an insight from an uploaded SRT, VTT, TXT or
+// Markdown file, e.g. a meeting transcript exported from another tool.
+// POST /api/v1/insights/upload (multipart: file, title, language, target_lang)
+func (h *InsightHandler) CreateFromUpload(c *gin.Context) {
+	var req models.CreateUploadInsightRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "请上传文件",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	userID := middleware.MustGetUserID(c)
+
+	upload, err := services.ParseUpload(file)
+	if err != nil {
+		status, msg := http.StatusInternalServerError, "读取上传文件失败"
+		switch {
+		case errors.Is(err, services.ErrUnsupportedUpload):
+			status, msg = http.StatusBadRequest, "不支持的文件格式，仅支持 SRT、VTT、TXT 和 Markdown"
+		case errors.Is(err, services.ErrUploadTooLarge):
+			status, msg = http.StatusRequestEntityTooLarge, "文件过大，最大支持 10MB"
+		case errors.Is(err, services.ErrUploadUnreadable):
+			status, msg = http.StatusBadRequest, "文件为空或无法识别内容，请使用 UTF-8 编码"
+		default:
+			h.log.Error("Failed to parse upload", zap.String("filename", file.Filename), zap.Error(err))
+		}
+		c.JSON(status, gin.H{
+			"error":      msg,
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// The same file uploaded again returns the existing insight
+	existing, err := h.repo.GetBySourceID(c.Request.Context(), upload.Hash, userID)
+	if err == nil && (existing.Status == models.InsightStatusCompleted || existing.Status == models.InsightStatusProcessing) {
+		c.JSON(http.StatusOK, gin.H{
+			"data": models.CreateInsightResponse{
+				ID:      existing.ID,
+				Status:  existing.Status,
+				Message: "该文件已上传过，直接返回已有记录",
+			},
+			"existing": true,
+		})
+		return
+	}
+
+	if req.TargetLang == "" {
+		req.TargetLang = "zh"
+	}
+	title := req.Title
+	if title == "" {
+		title = upload.Title
+	}
+
+	insight := &models.Insight{
+		UserID:     userID,
+		SourceType: models.SourceTypeUpload,
+		SourceURL:  models.UploadSourcePrefix + upload.Name,
+		SourceID:   upload.Hash,
+		Title:      title,
+		TargetLang: req.TargetLang,
+		RawContent: upload.Text,
+		Status:     models.InsightStatusPending,
+	}
+	if len(upload.Transcripts) > 0 {
+		insight.TranscriptLanguage = req.Language
+		insight.TranscriptKind = models.CaptionKindManual
+	}
+
+	if err := h.repo.Create(c.Request.Context(), insight); err != nil {
+		h.log.Error("Failed to create insight", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "创建 Insight 失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// Subtitle files are kept as the insight's transcript track, which
+	// processing translates into Transcripts
+	if len(upload.Transcripts) > 0 {
+		items, err := json.Marshal(upload.Transcripts)
+		if err == nil {
+			err = h.repo.ReplaceTranscripts(c.Request.Context(), insight.ID, []models.InsightTranscript{{
+				Language: req.Language,
+				Kind:     models.CaptionKindManual,
+				Name:     upload.Name,
+				Items:    items,
+			}})
+		}
+		if err != nil {
+			h.log.Error("Failed to store uploaded transcript", zap.Uint("insight_id", insight.ID), zap.Error(err))
+			if delErr := h.repo.Delete(c.Request.Context(), insight.ID); delErr != nil {
+				h.log.Error("Failed to delete incomplete upload insight", zap.Uint("insight_id", insight.ID), zap.Error(delErr))
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":      "保存上传的字幕失败",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+	}
+
+	// Queue background processing if processor is available
+	if h.processor != nil {
+		// A failed enqueue leaves the insight pending; it is recovered on next startup
+		if err := h.processor.EnqueueInsight(c.Request.Context(), insight.ID); err != nil {
+			h.log.Error("Failed to enqueue insight processing",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	h.log.Info("Created insight from upload",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("filename", upload.Name),
+		zap.Int("transcript_items", len(upload.Transcripts)),
+	)
+	c.JSON(http.StatusCreated, gin.H{
+		"data": models.CreateInsightResponse{
+			ID:      insight.ID,
+			Status:  insight.Status,
+			Message: "Insight 创建成功，正在处理中",
+		},
+	})
+}
+
+// extractSourceID extracts the source ID from a URL (e.g., YouTube video ID or tweet ID)
 func extractSourceID(sourceURL string) string {
 	// YouTube URL patterns:
 	// - https://www.youtube.com/watch?v=VIDEO_ID
@@ -231,8 +396,15 @@ func extractSourceID(sourceURL string) string {
 		`youtube\.com/embed/([a-zA-Z0-9_-]{11})`,
 		`youtube\.com/shorts/([a-zA-Z0-9_-]{11})`,
 		`youtube\.com/v/([a-zA-Z0-9_-]{11})`,
+		// Twitter/X status URLs: https://x.com/USER/status/TWEET_ID
+		`(?:twitter|x)\.com/[^/?#]+/status(?:es)?/([0-9]{1,20})`,
 	}
 	
+	// Podcast episodes: feed URL with the episode GUID
+	if _, guid := podcast.ParseEpisodeURL(sourceURL); guid != "" {
+		return podcast.SourceID(guid)
+	}
+
 	for _, pattern := range patterns {
 		re := regexp.MustCompile(pattern)
 		matches := re.FindStringSubmatch(sourceURL)
@@ -247,30 +419,8 @@ func extractSourceID(sourceURL string) string {
 // Update updates an existing insight.
 // PATCH /api/v1/insights/:id
 func (h *InsightHandler) Update(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, ok := h.ownedInsight(c)
+	if !ok {
 		return
 	}
 
@@ -287,6 +437,7 @@ func (h *InsightHandler) Update(c *gin.Context) {
 		return
 	}
 
+	titleChanged := updates.Title != nil && *updates.Title != insight.Title
 	if updates.Title != nil {
 		insight.Title = *updates.Title
 	}
@@ -303,6 +454,12 @@ func (h *InsightHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if titleChanged && h.search != nil && insight.Status == models.InsightStatusCompleted {
+		if err := h.search.IndexInsight(c.Request.Context(), insight); err != nil {
+			h.log.Warn("Failed to re-index insight for search", zap.Uint("insight_id", insight.ID), zap.Error(err))
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": insight})
 }
 
@@ -432,6 +589,7 @@ func (h *InsightHandler) CreateHighlight(c *gin.Context) {
 		EndOffset:   req.EndOffset,
 		Color:       color,
 		Note:        req.Note,
+		Version:     insight.CurrentVersion,
 	}
 
 	if err := h.repo.CreateHighlight(c.Request.Context(), highlight); err != nil {
@@ -602,13 +760,8 @@ func (h *InsightHandler) DeleteHighlight(c *gin.Context) {
 // ListChatMessages returns all chat messages for an insight.
 // GET /api/v1/insights/:id/chat
 func (h *InsightHandler) ListChatMessages(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, ok := h.ownedInsight(c)
+	if !ok {
 		return
 	}
 
@@ -617,7 +770,7 @@ func (h *InsightHandler) ListChatMessages(c *gin.Context) {
 	limit, _ := strconv.Atoi(limitStr)
 	offset, _ := strconv.Atoi(offsetStr)
 
-	messages, total, err := h.repo.GetChatMessagesByInsightIDPaginated(c.Request.Context(), uint(insightID), limit, offset)
+	messages, total, err := h.repo.GetChatMessagesByInsightIDPaginated(c.Request.Context(), insight.ID, limit, offset)
 	if err != nil {
 		h.log.Error("Failed to get chat messages", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -635,12 +788,10 @@ func (h *InsightHandler) ListChatMessages(c *gin.Context) {
 	})
 }
 
-// CreateChatMessage creates a new chat message (user message).
-// POST /api/v1/insights/:id/chat
-// Note: AI responses will be handled separately via streaming in a future issue
-func (h *InsightHandler) CreateChatMessage(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
+// ListHighlightChatMessages returns the conversation anchored to a highlight.
+// GET /api/v1/insights/:id/highlights/:highlightId/chat
+func (h *InsightHandler) ListHighlightChatMessages(c *gin.Context) {
+	insightID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":      "无效的 Insight ID",
@@ -648,20 +799,141 @@ func (h *InsightHandler) CreateChatMessage(c *gin.Context) {
 		})
 		return
 	}
-
-	var req models.ChatRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	highlightID, err := strconv.ParseUint(c.Param("highlightId"), 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      err.Error(),
+			"error":      "无效的 Highlight ID",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
+	highlight, err := h.repo.GetHighlightByID(c.Request.Context(), uint(highlightID))
+	if err != nil || highlight.InsightID != uint(insightID) {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "高亮不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to get highlight", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取高亮失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// Verify user ownership
 	userID := middleware.MustGetUserID(c)
+	insight, err := h.repo.GetByID(c.Request.Context(), highlight.InsightID)
+	if err != nil || insight.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限查看此高亮",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
 
-	// Verify insight exists and user owns it
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(insightID))
+	messages, err := h.repo.GetChatMessagesByHighlightID(c.Request.Context(), highlight.ID)
+	if err != nil {
+		h.log.Error("Failed to get highlight chat messages", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取对话历史失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"highlight": highlight,
+			"messages":  messages,
+		},
+	})
+}
+
+// ClearChatHistory clears all chat messages for an insight.
+// DELETE /api/v1/insights/:id/chat
+func (h *InsightHandler) ClearChatHistory(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteChatMessagesByInsightID(c.Request.Context(), insight.ID); err != nil {
+		h.log.Error("Failed to clear chat history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "清空对话历史失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "对话历史已清空"})
+}
+
+// Process manually triggers reprocessing of an insight.
+// POST /api/v1/insights/:id/process
+func (h *InsightHandler) Process(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+
+	// Reset status to pending
+	insight.Status = models.InsightStatusPending
+	insight.ErrorMessage = ""
+	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
+		h.log.Error("Failed to update insight status", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "更新 Insight 状态失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// Queue reprocessing if processor is available
+	if h.processor != nil {
+		if err := h.processor.EnqueueInsight(c.Request.Context(), insight.ID); err != nil {
+			h.log.Error("Failed to enqueue insight reprocessing",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":      "启动重新处理失败",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Info("Queued manual insight reprocessing", zap.Uint("insight_id", insight.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"id":      insight.ID,
+			"status":  insight.Status,
+			"message": "重新处理已启动",
+		},
+	})
+}
+
+// ListTranscripts returns the transcript tracks stored for an insight.
+// GET /api/v1/insights/:id/transcripts
+func (h *InsightHandler) ListTranscripts(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的 Insight ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -681,43 +953,44 @@ func (h *InsightHandler) CreateChatMessage(c *gin.Context) {
 	// Verify user ownership
 	if insight.UserID != userID {
 		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限操作此 Insight",
+			"error":      "无权限访问此 Insight",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	message := &models.ChatMessage{
-		InsightID:   uint(insightID),
-		UserID:      userID,
-		Role:        "user",
-		Content:     req.Message,
-		HighlightID: req.HighlightID,
-	}
-
-	if err := h.repo.CreateChatMessage(c.Request.Context(), message); err != nil {
-		h.log.Error("Failed to create chat message", zap.Error(err))
+	stored, err := h.repo.GetTranscriptsByInsightID(c.Request.Context(), insight.ID)
+	if err != nil {
+		h.log.Error("Failed to get transcript tracks", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "创建消息失败",
+			"error":      "获取字幕列表失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": models.ChatResponse{
-			ID:      message.ID,
-			Role:    message.Role,
-			Content: message.Content,
-		},
-	})
+	tracks := make([]models.InsightTranscriptTrack, 0, len(stored))
+	for _, t := range stored {
+		var items []models.TranscriptItem
+		_ = json.Unmarshal(t.Items, &items)
+		tracks = append(tracks, models.InsightTranscriptTrack{
+			Language: t.Language,
+			Kind:     t.Kind,
+			Name:     t.Name,
+			Segments: len(items),
+			Active:   t.Language == insight.TranscriptLanguage && t.Kind == insight.TranscriptKind,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tracks})
 }
 
-// ClearChatHistory clears all chat messages for an insight.
-// DELETE /api/v1/insights/:id/chat
-func (h *InsightHandler) ClearChatHistory(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
+// SwitchTranscript changes the transcript track an insight shows.
+// PUT /api/v1/insights/:id/transcript-language
+func (h *InsightHandler) SwitchTranscript(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":      "无效的 Insight ID",
@@ -726,21 +999,77 @@ func (h *InsightHandler) ClearChatHistory(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.DeleteChatMessagesByInsightID(c.Request.Context(), uint(insightID)); err != nil {
-		h.log.Error("Failed to clear chat history", zap.Error(err))
+	var req models.SwitchTranscriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "Insight 不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to get insight", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "清空对话历史失败",
+			"error":      "获取 Insight 失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "对话历史已清空"})
+	// Verify user ownership
+	if insight.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限修改此 Insight",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if h.processor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":      "字幕切换服务不可用",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.processor.SwitchTranscript(c.Request.Context(), insight, req.Language, req.Kind); err != nil {
+		if errors.Is(err, services.ErrTranscriptTrackNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "该语言的字幕不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to switch transcript",
+			zap.Uint("insight_id", insight.ID),
+			zap.String("language", req.Language),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "切换字幕失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.convertToDetailResponse(insight)})
 }
 
-// Process manually triggers reprocessing of an insight.
-// POST /api/v1/insights/:id/process
-func (h *InsightHandler) Process(c *gin.Context) {
+// ExportSubtitles downloads an insight's transcript, with its translation,
+// as a subtitle file.
+// GET /api/v1/insights/:id/subtitles?format=srt|vtt|ass&layout=stacked|original|translated
+func (h *InsightHandler) ExportSubtitles(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -751,7 +1080,15 @@ func (h *InsightHandler) Process(c *gin.Context) {
 		return
 	}
 
-	// Get the insight
+	opts, err := subtitles.ParseExportOptions(c.Query("format"), c.Query("layout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
 	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -769,32 +1106,36 @@ func (h *InsightHandler) Process(c *gin.Context) {
 		return
 	}
 
-	// Reset status to pending
-	insight.Status = models.InsightStatusPending
-	insight.ErrorMessage = ""
-	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
-		h.log.Error("Failed to update insight status", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "更新 Insight 状态失败",
+	// Verify user ownership
+	if insight.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限访问此 Insight",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	// Trigger async reprocessing if processor is available
-	if h.processor != nil {
-		// Use background context for async processing since request context may be cancelled
-		go h.processor.ProcessInsightAsync(context.Background(), insight.ID)
-		h.log.Info("Triggered manual insight reprocessing", zap.Uint("insight_id", insight.ID))
+	var items []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &items); err != nil {
+			h.log.Warn("Failed to parse transcripts", zap.Error(err), zap.Uint("insight_id", insight.ID))
+		}
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":      "该 Insight 没有字幕",
+			"request_id": c.GetString("request_id"),
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"id":      insight.ID,
-			"status":  insight.Status,
-			"message": "重新处理已启动",
-		},
-	})
+	if err := serveSubtitles(c, fmt.Sprintf("insight-%d", insight.ID), services.TranscriptItemCues(items), opts); err != nil {
+		h.log.Error("Failed to export subtitles", zap.Error(err), zap.Uint("insight_id", insight.ID))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "导出字幕失败",
+			"request_id": c.GetString("request_id"),
+		})
+	}
 }
 
 // ShareInsight creates or updates a share configuration for an insight.
@@ -1067,13 +1408,11 @@ func (h *InsightHandler) GetShared(c *gin.Context) {
 	}
 
 	if shareConfig.IncludeKeyPoints {
-		var keyPoints []string
-		if len(insight.KeyPoints) > 0 {
-			if err := json.Unmarshal(insight.KeyPoints, &keyPoints); err != nil {
-				h.log.Warn("Failed to unmarshal key_points", zap.Error(err))
-			} else {
-				response.Content.KeyPoints = keyPoints
-			}
+		keyPoints, err := models.ParseKeyPoints(insight.KeyPoints)
+		if err != nil {
+			h.log.Warn("Failed to unmarshal key_points", zap.Error(err))
+		} else if len(keyPoints) > 0 {
+			response.Content.KeyPoints = models.KeyPointTexts(keyPoints)
 		}
 	}
 
@@ -1100,12 +1439,10 @@ func (h *InsightHandler) generateShareToken() (string, error) {
 // convertToDetailResponse converts an Insight model to InsightDetailResponse.
 func (h *InsightHandler) convertToDetailResponse(insight *models.Insight) *models.InsightDetailResponse {
 	// Parse key_points from JSON
-	var keyPoints []string
-	if len(insight.KeyPoints) > 0 {
-		if err := json.Unmarshal(insight.KeyPoints, &keyPoints); err != nil {
-			h.log.Warn("Failed to unmarshal key_points", zap.Error(err))
-			keyPoints = []string{}
-		}
+	keyPoints, err := models.ParseKeyPoints(insight.KeyPoints)
+	if err != nil {
+		h.log.Warn("Failed to unmarshal key_points", zap.Error(err))
+		keyPoints = []models.InsightKeyPoint{}
 	}
 
 	// Parse transcripts from JSON
@@ -1117,23 +1454,35 @@ func (h *InsightHandler) convertToDetailResponse(insight *models.Insight) *model
 		}
 	}
 
+	chapters := insight.Chapters
+	if chapters == nil {
+		chapters = []models.InsightChapter{}
+	}
+
 	return &models.InsightDetailResponse{
-		ID:           insight.ID,
-		SourceType:   insight.SourceType,
-		SourceURL:    insight.SourceURL,
-		SourceID:     insight.SourceID,
-		Title:        insight.Title,
-		Author:       insight.Author,
-		ThumbnailURL: insight.ThumbnailURL,
-		Duration:     insight.Duration,
-		PublishedAt:  insight.PublishedAt,
-		Summary:      insight.Summary,
-		KeyPoints:    keyPoints,
-		RawContent:   insight.RawContent,
-		TransContent: insight.TransContent,
-		Transcripts:  transcripts,
-		Status:       insight.Status,
-		Highlights:   insight.Highlights,
-		CreatedAt:    insight.CreatedAt,
+		ID:                 insight.ID,
+		SourceType:         insight.SourceType,
+		SourceURL:          insight.SourceURL,
+		SourceID:           insight.SourceID,
+		Title:              insight.Title,
+		Author:             insight.Author,
+		ThumbnailURL:       insight.ThumbnailURL,
+		Duration:           insight.Duration,
+		PublishedAt:        insight.PublishedAt,
+		Summary:            insight.Summary,
+		KeyPoints:          models.KeyPointTexts(keyPoints),
+		KeyPointDetails:    keyPoints,
+		RawContent:         insight.RawContent,
+		TransContent:       insight.TransContent,
+		Transcripts:        transcripts,
+		TranscriptLanguage: insight.TranscriptLanguage,
+		TranscriptKind:     insight.TranscriptKind,
+		SourceMeta:         json.RawMessage(insight.SourceMeta),
+		Status:             insight.Status,
+		CurrentVersion:     insight.CurrentVersion,
+		VersionPinned:      insight.VersionPinned,
+		Highlights:         insight.Highlights,
+		Chapters:           chapters,
+		CreatedAt:          insight.CreatedAt,
 	}
 }
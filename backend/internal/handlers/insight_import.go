@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+const (
+	// maxBatchItems caps the insights a single import can create.
+	maxBatchItems = 200
+	// batchListLimit caps the batches returned by ListBatches.
+	batchListLimit = 50
+)
+
+// importCandidate is an item found in an import source, before duplicates
+// are skipped.
+type importCandidate struct {
+	sourceURL    string
+	sourceID     string
+	sourceType   models.SourceType // empty = detected when processed
+	title        string
+	thumbnailURL string
+	unavailable  bool
+	invalid      bool
+}
+
+// SetYouTubeAPIService sets the service used to list the videos of playlists
+// and channels for imports.
+func (h *InsightHandler) SetYouTubeAPIService(api *services.YouTubeAPIService) {
+	h.youtubeAPI = api
+}
+
+// Import creates a batch of insights from a YouTube playlist, the uploads of
+// a YouTube channel or a list of URLs. Items the user already has an insight
+// for are skipped; every other item gets a queued insight.
+// POST /api/v1/insights/import
+func (h *InsightHandler) Import(c *gin.Context) {
+	var req models.ImportInsightsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	sources := 0
+	for _, given := range []bool{req.PlaylistID != "", req.ChannelID != "", len(req.URLs) > 0} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "请提供 playlist_id、channel_id 或 urls 中的一项",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	userID := middleware.MustGetUserID(c)
+	if req.TargetLang == "" {
+		req.TargetLang = "zh"
+	}
+	limit := maxBatchItems
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+
+	batch := &models.InsightBatch{
+		UserID:     userID,
+		TargetLang: req.TargetLang,
+	}
+	var candidates []importCandidate
+	switch {
+	case req.PlaylistID != "":
+		batch.SourceKind = models.BatchSourcePlaylist
+		batch.SourceRef = strings.TrimSpace(req.PlaylistID)
+		title, items, ok := h.playlistCandidates(c, batch.SourceRef, limit)
+		if !ok {
+			return
+		}
+		batch.Title, candidates = title, items
+
+	case req.ChannelID != "":
+		batch.SourceKind = models.BatchSourceChannel
+		batch.SourceRef = strings.TrimSpace(req.ChannelID)
+		if h.youtubeAPI == nil {
+			h.youtubeAPIError(c, services.ErrYouTubeAPINotConfigured)
+			return
+		}
+		uploads, title, err := h.youtubeAPI.GetChannelUploads(c.Request.Context(), batch.SourceRef)
+		if err != nil {
+			h.youtubeAPIError(c, err)
+			return
+		}
+		_, items, ok := h.playlistCandidates(c, uploads, limit)
+		if !ok {
+			return
+		}
+		batch.Title, candidates = title, items
+
+	default:
+		batch.SourceKind = models.BatchSourceURLs
+		batch.Title = fmt.Sprintf("%d 个链接", len(req.URLs))
+		for _, rawURL := range req.URLs {
+			if len(candidates) == limit {
+				break
+			}
+			rawURL = strings.TrimSpace(rawURL)
+			parsed, err := url.Parse(rawURL)
+			candidates = append(candidates, importCandidate{
+				sourceURL: rawURL,
+				sourceID:  extractSourceID(rawURL),
+				invalid:   err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https"),
+			})
+		}
+	}
+	batch.Requested = len(candidates)
+
+	insights, skipped, err := h.newBatchInsights(c, userID, req.TargetLang, candidates)
+	if err != nil {
+		h.log.Error("Failed to check for duplicate insights", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "创建导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	if batch.Skipped, err = json.Marshal(skipped); err != nil {
+		h.log.Error("Failed to encode skipped import items", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "创建导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.repo.CreateBatch(c.Request.Context(), batch, insights); err != nil {
+		h.log.Error("Failed to create insight batch", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "创建导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// A failed enqueue leaves the insight pending; it is recovered on next startup
+	if h.processor != nil {
+		for _, insight := range insights {
+			if err := h.processor.EnqueueInsight(c.Request.Context(), insight.ID); err != nil {
+				h.log.Error("Failed to enqueue insight processing",
+					zap.Uint("insight_id", insight.ID),
+					zap.Uint("batch_id", batch.ID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	h.log.Info("Created insight batch",
+		zap.Uint("batch_id", batch.ID),
+		zap.String("source_kind", string(batch.SourceKind)),
+		zap.String("source_ref", batch.SourceRef),
+		zap.Int("requested", batch.Requested),
+		zap.Int("created", len(insights)),
+		zap.Int("skipped", len(skipped)),
+	)
+
+	items := make([]models.InsightBatchItem, len(insights))
+	counts := make(map[models.InsightStatus]int)
+	for i, insight := range insights {
+		items[i] = batchItem(insight)
+		counts[insight.Status]++
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": batchResponse(batch, counts, items)})
+}
+
+// ListBatches returns the user's import batches with their progress.
+// GET /api/v1/insights/batches
+func (h *InsightHandler) ListBatches(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+
+	batches, err := h.repo.ListBatches(c.Request.Context(), userID, batchListLimit)
+	if err != nil {
+		h.log.Error("Failed to list insight batches", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	ids := make([]uint, len(batches))
+	for i, batch := range batches {
+		ids[i] = batch.ID
+	}
+	counts := map[uint]map[models.InsightStatus]int{}
+	if len(ids) > 0 {
+		if counts, err = h.repo.CountBatchStatuses(c.Request.Context(), ids); err != nil {
+			h.log.Error("Failed to count insight batch progress", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":      "获取导入任务失败",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+	}
+
+	responses := make([]models.InsightBatchResponse, len(batches))
+	for i := range batches {
+		responses[i] = batchResponse(&batches[i], counts[batches[i].ID], nil)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": responses})
+}
+
+// GetBatch returns an import batch with its progress and insights.
+// GET /api/v1/insights/batches/:batchId
+func (h *InsightHandler) GetBatch(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+	id, err := strconv.ParseUint(c.Param("batchId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的导入任务 ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	batch, err := h.repo.GetBatch(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "导入任务不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to get insight batch", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	if batch.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限访问此导入任务",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	insights, err := h.repo.GetBatchInsights(c.Request.Context(), batch.ID)
+	if err != nil {
+		h.log.Error("Failed to get insight batch items", zap.Uint("batch_id", batch.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取导入任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	items := make([]models.InsightBatchItem, len(insights))
+	counts := make(map[models.InsightStatus]int)
+	for i := range insights {
+		items[i] = batchItem(&insights[i])
+		counts[insights[i].Status]++
+	}
+	c.JSON(http.StatusOK, gin.H{"data": batchResponse(batch, counts, items)})
+}
+
+// playlistCandidates lists the videos of a playlist, writing an error
+// response if it cannot.
+func (h *InsightHandler) playlistCandidates(c *gin.Context, playlistID string, limit int) (string, []importCandidate, bool) {
+	if h.youtubeAPI == nil {
+		h.youtubeAPIError(c, services.ErrYouTubeAPINotConfigured)
+		return "", nil, false
+	}
+	playlist, err := h.youtubeAPI.ListPlaylistVideos(c.Request.Context(), playlistID, limit)
+	if err != nil {
+		h.youtubeAPIError(c, err)
+		return "", nil, false
+	}
+
+	candidates := make([]importCandidate, len(playlist.Items))
+	for i, item := range playlist.Items {
+		candidates[i] = importCandidate{
+			sourceURL:    "https://www.youtube.com/watch?v=" + item.VideoID,
+			sourceID:     item.VideoID,
+			sourceType:   models.SourceTypeYouTube,
+			title:        item.Title,
+			thumbnailURL: item.Thumbnail,
+			unavailable:  item.Unavailable,
+		}
+	}
+	return playlist.Title, candidates, true
+}
+
+// newBatchInsights builds the insights of a batch, skipping unavailable
+// videos, unrecognised URLs and items the user already has an insight for
+// or that appear twice.
+func (h *InsightHandler) newBatchInsights(c *gin.Context, userID uint, targetLang string, candidates []importCandidate) ([]*models.Insight, []models.InsightBatchSkip, error) {
+	insights := make([]*models.Insight, 0, len(candidates))
+	skipped := make([]models.InsightBatchSkip, 0)
+	seen := make(map[string]bool, len(candidates))
+
+	for _, candidate := range candidates {
+		skip := models.InsightBatchSkip{SourceURL: candidate.sourceURL, Title: candidate.title}
+		if candidate.unavailable {
+			skip.Reason = models.BatchSkipUnavailable
+			skipped = append(skipped, skip)
+			continue
+		}
+		if candidate.invalid {
+			skip.Reason = models.BatchSkipInvalid
+			skipped = append(skipped, skip)
+			continue
+		}
+		key := candidate.sourceID
+		if key == "" {
+			key = candidate.sourceURL
+		}
+		if seen[key] {
+			skip.Reason = models.BatchSkipDuplicate
+			skipped = append(skipped, skip)
+			continue
+		}
+		seen[key] = true
+
+		var existing *models.Insight
+		var err error
+		if candidate.sourceID != "" {
+			existing, err = h.repo.GetBySourceID(c.Request.Context(), candidate.sourceID, userID)
+		} else {
+			existing, err = h.repo.GetBySourceURL(c.Request.Context(), candidate.sourceURL, userID)
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		if existing != nil {
+			existingID := existing.ID
+			skip.Reason = models.BatchSkipDuplicate
+			skip.InsightID = &existingID
+			skipped = append(skipped, skip)
+			continue
+		}
+
+		insights = append(insights, &models.Insight{
+			UserID:       userID,
+			SourceType:   candidate.sourceType,
+			SourceURL:    candidate.sourceURL,
+			SourceID:     candidate.sourceID,
+			Title:        candidate.title,
+			ThumbnailURL: candidate.thumbnailURL,
+			TargetLang:   targetLang,
+			Status:       models.InsightStatusPending,
+		})
+	}
+	return insights, skipped, nil
+}
+
+// youtubeAPIError writes the response for a failed playlist or channel lookup.
+func (h *InsightHandler) youtubeAPIError(c *gin.Context, err error) {
+	status, msg := http.StatusInternalServerError, "获取 YouTube 视频列表失败"
+	switch {
+	case errors.Is(err, services.ErrYouTubeAPINotConfigured):
+		status, msg = http.StatusServiceUnavailable, "未配置 YouTube Data API，无法导入播放列表或频道"
+	case isUnauthorizedError(err):
+		status, msg = http.StatusForbidden, "播放列表不公开，无法导入"
+	case isQuotaError(err):
+		status, msg = http.StatusServiceUnavailable, "YouTube API 配额已用尽，请稍后再试"
+	case isNotFoundError(err):
+		status, msg = http.StatusNotFound, "播放列表或频道不存在"
+	default:
+		h.log.Error("Failed to list YouTube videos for import", zap.Error(err))
+	}
+	c.JSON(status, gin.H{
+		"error":      msg,
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// batchItem describes an insight of a batch.
+func batchItem(insight *models.Insight) models.InsightBatchItem {
+	return models.InsightBatchItem{
+		ID:           insight.ID,
+		SourceURL:    insight.SourceURL,
+		Title:        insight.Title,
+		ThumbnailURL: insight.ThumbnailURL,
+		Status:       insight.Status,
+		ErrorMessage: insight.ErrorMessage,
+	}
+}
+
+// batchResponse describes a batch with the progress given by the status
+// counts of its insights.
+func batchResponse(batch *models.InsightBatch, counts map[models.InsightStatus]int, items []models.InsightBatchItem) models.InsightBatchResponse {
+	progress := models.InsightBatchProgress{
+		Queued:     counts[models.InsightStatusPending],
+		Processing: counts[models.InsightStatusProcessing],
+		Completed:  counts[models.InsightStatusCompleted],
+		Failed:     counts[models.InsightStatusFailed],
+	}
+	progress.Total = progress.Queued + progress.Processing + progress.Completed + progress.Failed
+	progress.Done = progress.Queued == 0 && progress.Processing == 0
+
+	skipped := []models.InsightBatchSkip{}
+	if len(batch.Skipped) > 0 {
+		// Unreadable skip lists only lose detail, not progress
+		_ = json.Unmarshal(batch.Skipped, &skipped)
+	}
+
+	return models.InsightBatchResponse{
+		ID:         batch.ID,
+		SourceKind: batch.SourceKind,
+		SourceRef:  batch.SourceRef,
+		Title:      batch.Title,
+		TargetLang: batch.TargetLang,
+		Requested:  batch.Requested,
+		Progress:   progress,
+		Skipped:    skipped,
+		Items:      items,
+		CreatedAt:  batch.CreatedAt,
+	}
+}
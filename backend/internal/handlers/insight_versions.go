@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+// --- Version endpoints ---
+
+// ListVersions returns the processing runs of an insight, newest first.
+// GET /api/v1/insights/:id/versions
+func (h *InsightHandler) ListVersions(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.repo.ListVersions(c.Request.Context(), insight.ID)
+	if err != nil {
+		h.log.Error("Failed to list insight versions", zap.Uint("insight_id", insight.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取版本列表失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	items := make([]models.InsightVersionListItem, len(versions))
+	for i, v := range versions {
+		items[i] = models.InsightVersionListItem{
+			Version:            v.Version,
+			ProcessedAt:        v.ProcessedAt,
+			TranscriptProvider: v.TranscriptProvider,
+			LLMProvider:        v.LLMProvider,
+			Model:              v.Model,
+			Title:              v.Title,
+			TranscriptLanguage: v.TranscriptLanguage,
+			TranscriptKind:     v.TranscriptKind,
+			Current:            v.Version == insight.CurrentVersion,
+			Pinned:             v.Version == insight.CurrentVersion && insight.VersionPinned,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// GetVersion returns one version of an insight with its artifacts.
+// GET /api/v1/insights/:id/versions/:version
+func (h *InsightHandler) GetVersion(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	v, ok := h.loadVersion(c, insight.ID, version)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": v})
+}
+
+// DiffVersions compares a version with an earlier one, by default the
+// version before it.
+// GET /api/v1/insights/:id/versions/:version/diff?from=1
+func (h *InsightHandler) DiffVersions(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	from := version - 1
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := strconv.Atoi(fromStr)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "无效的对比版本",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		from = parsed
+	}
+	if from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "第一个版本没有可对比的早期版本，请指定 from",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	fromVersion, ok := h.loadVersion(c, insight.ID, from)
+	if !ok {
+		return
+	}
+	toVersion, ok := h.loadVersion(c, insight.ID, version)
+	if !ok {
+		return
+	}
+
+	diff, err := services.DiffInsightVersions(fromVersion, toVersion)
+	if err != nil {
+		h.log.Error("Failed to diff insight versions",
+			zap.Uint("insight_id", insight.ID),
+			zap.Int("from", from),
+			zap.Int("to", version),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "版本对比失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// RestoreVersion rolls an insight back (or forward) to a stored version.
+// POST /api/v1/insights/:id/versions/:version/restore
+func (h *InsightHandler) RestoreVersion(c *gin.Context) {
+	h.restoreVersion(c, false)
+}
+
+// PinVersion makes a version current and keeps it current when the insight
+// is reprocessed; new runs are still stored as versions.
+// POST /api/v1/insights/:id/versions/:version/pin
+func (h *InsightHandler) PinVersion(c *gin.Context) {
+	h.restoreVersion(c, true)
+}
+
+// UnpinVersion lets the next processing run become current again.
+// DELETE /api/v1/insights/:id/versions/pin
+func (h *InsightHandler) UnpinVersion(c *gin.Context) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+
+	insight.VersionPinned = false
+	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
+		h.log.Error("Failed to unpin insight version", zap.Uint("insight_id", insight.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "取消固定版本失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"current_version": insight.CurrentVersion,
+			"version_pinned":  insight.VersionPinned,
+		},
+	})
+}
+
+// restoreVersion makes a version current, optionally pinning it, and returns
+// the insight as it now reads.
+func (h *InsightHandler) restoreVersion(c *gin.Context, pin bool) {
+	insight, ok := h.ownedInsight(c)
+	if !ok {
+		return
+	}
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	if insight.Status == models.InsightStatusPending || insight.Status == models.InsightStatusProcessing {
+		// The running job would replace the restored version when it finishes
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Insight 正在处理中，请稍后再试",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if h.processor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":      "版本服务不可用",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.processor.RestoreVersion(c.Request.Context(), insight, version, pin); err != nil {
+		if errors.Is(err, services.ErrInsightVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "版本不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to restore insight version",
+			zap.Uint("insight_id", insight.ID),
+			zap.Int("version", version),
+			zap.Bool("pin", pin),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "恢复版本失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	// Reload so the response carries the restored version's highlights
+	restored, err := h.repo.GetByIDWithRelations(c.Request.Context(), insight.ID)
+	if err != nil {
+		h.log.Error("Failed to reload insight", zap.Uint("insight_id", insight.ID), zap.Error(err))
+		restored = insight
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.convertToDetailResponse(restored)})
+}
+
+// ownedInsight loads the insight named by the :id parameter and checks that
+// the current user owns it, writing an error response if not.
+func (h *InsightHandler) ownedInsight(c *gin.Context) (*models.Insight, bool) {
+	userID := middleware.MustGetUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的 Insight ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "Insight 不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		h.log.Error("Failed to get insight", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取 Insight 失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	if insight.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限访问此 Insight",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+	return insight, true
+}
+
+// loadVersion loads a version of an insight, writing an error response if
+// it cannot.
+func (h *InsightHandler) loadVersion(c *gin.Context, insightID uint, version int) (*models.InsightVersion, bool) {
+	v, err := h.repo.GetVersion(c.Request.Context(), insightID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "版本不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		h.log.Error("Failed to get insight version",
+			zap.Uint("insight_id", insightID),
+			zap.Int("version", version),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取版本失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+	return v, true
+}
+
+// versionParam parses the :version parameter, writing an error response if
+// it is invalid.
+func versionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的版本号",
+			"request_id": c.GetString("request_id"),
+		})
+		return 0, false
+	}
+	return version, true
+}
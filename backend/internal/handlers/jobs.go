@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// JobHandler handles admin HTTP requests for the background job queues.
+type JobHandler struct {
+	repo *repository.JobRepository
+	log  *zap.Logger
+}
+
+// NewJobHandler creates a new JobHandler.
+func NewJobHandler(repo *repository.JobRepository, log *zap.Logger) *JobHandler {
+	return &JobHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Stats returns queue depth and status counts for every queue.
+// GET /api/v1/admin/jobs
+func (h *JobHandler) Stats(c *gin.Context) {
+	stats, err := h.repo.Stats(c.Request.Context())
+	if err != nil {
+		h.log.Error("Failed to get job stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取任务队列状态失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// ListDead returns dead-lettered jobs, optionally filtered by queue.
+// GET /api/v1/admin/jobs/dead?queue=insight&limit=50
+func (h *JobHandler) ListDead(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	deadJobs, err := h.repo.ListByStatus(c.Request.Context(), models.JobStatusDead, c.Query("queue"), limit)
+	if err != nil {
+		h.log.Error("Failed to list dead jobs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取失败任务列表失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deadJobs})
+}
+
+// Retry moves a dead job back to its queue.
+// POST /api/v1/admin/jobs/:id/retry
+func (h *JobHandler) Retry(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的任务 ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.repo.Requeue(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "任务不存在或不处于失败状态",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrJobActiveDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "已有相同的任务在排队或运行中",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to requeue job", zap.Error(err), zap.Uint64("job_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "重试任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	h.log.Info("Dead job requeued", zap.Uint64("job_id", id))
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"id":     id,
+			"status": models.JobStatusQueued,
+		},
+	})
+}
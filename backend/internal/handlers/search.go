@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/services"
+)
+
+// SearchHandler handles library search HTTP requests.
+type SearchHandler struct {
+	searchService *services.SearchService
+	log           *zap.Logger
+}
+
+// NewSearchHandler creates a new SearchHandler.
+func NewSearchHandler(searchService *services.SearchService, log *zap.Logger) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		log:           log,
+	}
+}
+
+// Search finds where the current user's insights mention the query, ranked,
+// with transcript timestamps and highlighted snippets.
+// GET /api/v1/search?q=...&limit=20&offset=0&per_insight=3
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	perInsight, _ := strconv.Atoi(c.DefaultQuery("per_insight", "3"))
+
+	result, err := h.searchService.Search(c.Request.Context(), userID, c.Query("q"), services.SearchOptions{
+		Limit:      limit,
+		Offset:     offset,
+		PerInsight: perInsight,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrEmptySearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "搜索关键词不能为空",
+				"request_id": c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error("Failed to search insights", zap.Error(err), zap.Uint("user_id", userID))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "搜索失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
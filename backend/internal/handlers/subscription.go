@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+	"vibe-backend/internal/services"
+	"vibe-backend/internal/ytfeed"
+)
+
+const (
+	// subscriptionVideoLimit caps the uploads returned by ListVideos.
+	subscriptionVideoLimit = 100
+	// maxPushBody bounds hub notifications.
+	maxPushBody = 1 << 20
+)
+
+// SubscriptionHandler handles YouTube channel subscriptions and the WebSub
+// callback that announces their uploads.
+type SubscriptionHandler struct {
+	repo    *repository.SubscriptionRepository
+	service *services.SubscriptionService
+	log     *zap.Logger
+}
+
+// NewSubscriptionHandler creates a new SubscriptionHandler.
+func NewSubscriptionHandler(repo *repository.SubscriptionRepository, service *services.SubscriptionService, log *zap.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		repo:    repo,
+		service: service,
+		log:     log,
+	}
+}
+
+// List returns the user's channel subscriptions.
+// GET /api/v1/subscriptions
+func (h *SubscriptionHandler) List(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+
+	subs, err := h.repo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to list channel subscriptions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取频道订阅失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": subs})
+}
+
+// Create subscribes the user to a channel, given by channel ID, @handle or
+// channel URL. Uploads from then on become insights in the user's default
+// target language.
+// POST /api/v1/subscriptions
+func (h *SubscriptionHandler) Create(c *gin.Context) {
+	var req models.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	userID := middleware.MustGetUserID(c)
+	sub, err := h.service.Subscribe(c.Request.Context(), userID, &req)
+	if err != nil {
+		status, msg := http.StatusInternalServerError, "订阅频道失败"
+		switch {
+		case errors.Is(err, ytfeed.ErrInvalidChannel):
+			status, msg = http.StatusBadRequest, "无法识别的频道，请提供频道 ID、@handle 或频道链接"
+		case errors.Is(err, ytfeed.ErrChannelNotFound):
+			status, msg = http.StatusNotFound, "频道不存在"
+		case errors.Is(err, services.ErrAlreadySubscribed):
+			status, msg = http.StatusConflict, "已订阅该频道"
+		default:
+			h.log.Error("Failed to subscribe to channel",
+				zap.String("channel", req.Channel),
+				zap.Error(err),
+			)
+		}
+		c.JSON(status, gin.H{
+			"error":      msg,
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": sub})
+}
+
+// Update changes a subscription's filters or pauses it.
+// PATCH /api/v1/subscriptions/:id
+func (h *SubscriptionHandler) Update(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.service.Update(c.Request.Context(), sub, &req); err != nil {
+		h.log.Error("Failed to update channel subscription", zap.Uint("subscription_id", sub.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "更新频道订阅失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sub})
+}
+
+// Delete unsubscribes the user from a channel. Insights already created are
+// kept.
+// DELETE /api/v1/subscriptions/:id
+func (h *SubscriptionHandler) Delete(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unsubscribe(c.Request.Context(), sub); err != nil {
+		h.log.Error("Failed to delete channel subscription", zap.Uint("subscription_id", sub.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "取消订阅失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已取消订阅"})
+}
+
+// ListVideos returns the uploads a subscription has seen and what was done
+// with each: an insight created, filtered out, or skipped as a duplicate.
+// GET /api/v1/subscriptions/:id/videos
+func (h *SubscriptionHandler) ListVideos(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	videos, err := h.repo.ListVideos(c.Request.Context(), sub.ID, subscriptionVideoLimit)
+	if err != nil {
+		h.log.Error("Failed to list subscription videos", zap.Uint("subscription_id", sub.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取订阅视频失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": videos})
+}
+
+// VerifyPush answers the WebSub hub's verification of a subscription change
+// by echoing its challenge.
+// GET /api/v1/websub/youtube/:channelId
+func (h *SubscriptionHandler) VerifyPush(c *gin.Context) {
+	channelID := c.Param("channelId")
+	lease, _ := strconv.Atoi(c.Query("hub.lease_seconds"))
+
+	ok, err := h.service.ConfirmPush(c.Request.Context(), channelID, c.Query("hub.mode"), c.Query("hub.topic"), time.Duration(lease)*time.Second)
+	if err != nil {
+		h.log.Error("Failed to confirm hub subscription", zap.String("channel_id", channelID), zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// ReceivePush accepts a WebSub notification of a channel's uploads. The hub
+// gets a 2xx even for notifications that are ignored, as the spec asks.
+// POST /api/v1/websub/youtube/:channelId
+func (h *SubscriptionHandler) ReceivePush(c *gin.Context) {
+	channelID := c.Param("channelId")
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPushBody))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.HandlePush(c.Request.Context(), channelID, body, c.GetHeader("X-Hub-Signature")); err != nil {
+		h.log.Error("Failed to handle hub notification", zap.String("channel_id", channelID), zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadSubscription loads the subscription named by the :id parameter,
+// writing an error response if it is missing or not the user's.
+func (h *SubscriptionHandler) loadSubscription(c *gin.Context) (*models.ChannelSubscription, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的订阅 ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	sub, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "订阅不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		h.log.Error("Failed to get channel subscription", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取频道订阅失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+	if sub.UserID != middleware.MustGetUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "无权限访问此订阅",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
+	}
+	return sub, true
+}
@@ -28,7 +28,9 @@ func NewTranscriptHandler(transcriptService *services.TranscriptService, log *za
 // POST /api/v1/transcript
 func (h *TranscriptHandler) GetTranscript(c *gin.Context) {
 	var req struct {
-		Input string `json:"input" binding:"required"`
+		Input     string   `json:"input" binding:"required"`
+		Languages []string `json:"languages"` // caption languages, most preferred first
+		Refresh   bool     `json:"refresh"`   // bypass the transcript cache
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,7 +42,10 @@ func (h *TranscriptHandler) GetTranscript(c *gin.Context) {
 	}
 
 	// Get transcript
-	response, err := h.transcriptService.GetTranscript(c.Request.Context(), req.Input)
+	response, err := h.transcriptService.GetTranscript(c.Request.Context(), req.Input, services.TranscriptFetchOptions{
+		Languages: h.transcriptService.PreferLanguages(req.Languages...),
+		Refresh:   req.Refresh,
+	})
 	if err != nil {
 		h.log.Error("Failed to get transcript",
 			zap.Error(err),
@@ -56,3 +61,10 @@ func (h *TranscriptHandler) GetTranscript(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ProviderStats returns success and latency counters for each transcript
+// provider, in the order they are tried.
+// GET /api/v1/admin/transcript-providers
+func (h *TranscriptHandler) ProviderStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.transcriptService.ProviderStats()})
+}
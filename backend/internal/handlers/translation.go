@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
+	"vibe-backend/internal/subtitles"
 )
 
 // TranslationHandler handles translation endpoints.
@@ -78,7 +82,11 @@ func (h *TranslationHandler) Translate(c *gin.Context) {
 			}
 		}
 
-		c.JSON(http.StatusBadRequest, models.TranslateResponse{
+		status := http.StatusBadRequest
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, models.TranslateResponse{
 			Status:  "error",
 			Message: err.Error(),
 		})
@@ -201,3 +209,71 @@ func (h *TranslationHandler) GetTranslation(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ExportSubtitles downloads a translation's dual subtitles as a subtitle file.
+// GET /api/translate/:id/subtitles?format=srt|vtt|ass&layout=stacked|original|translated
+func (h *TranslationHandler) ExportSubtitles(c *gin.Context) {
+	var translationID uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &translationID); err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslateResponse{
+			Status:  "error",
+			Message: "Invalid translation ID",
+		})
+		return
+	}
+
+	opts, err := subtitles.ParseExportOptions(c.Query("format"), c.Query("layout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslateResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	translation, err := h.translationRepo.GetByID(c.Request.Context(), translationID)
+	if err != nil {
+		h.log.Error("Failed to get translation",
+			zap.Error(err),
+			zap.Uint("id", translationID),
+		)
+		c.JSON(http.StatusNotFound, models.TranslateResponse{
+			Status:  "error",
+			Message: "Translation not found",
+		})
+		return
+	}
+
+	cues := services.DualSubtitleCues(translation.DualSubtitles)
+	if len(cues) == 0 {
+		c.JSON(http.StatusNotFound, models.TranslateResponse{
+			Status:  "error",
+			Message: "Translation has no timed subtitles",
+		})
+		return
+	}
+
+	if err := serveSubtitles(c, fmt.Sprintf("translation-%d", translation.ID), cues, opts); err != nil {
+		h.log.Error("Failed to export subtitles",
+			zap.Error(err),
+			zap.Uint("id", translationID),
+		)
+		c.JSON(http.StatusInternalServerError, models.TranslateResponse{
+			Status:  "error",
+			Message: "Failed to export subtitles",
+		})
+	}
+}
+
+// serveSubtitles writes cues as a downloadable subtitle file named
+// <name>.<format>. Nothing is written if it returns an error.
+func serveSubtitles(c *gin.Context, name string, cues []subtitles.BilingualCue, opts subtitles.ExportOptions) error {
+	var buf bytes.Buffer
+	if err := subtitles.Export(&buf, cues, opts); err != nil {
+		return err
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, opts.Format))
+	c.Data(http.StatusOK, opts.Format.ContentType(), buf.Bytes())
+	return nil
+}
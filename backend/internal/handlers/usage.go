@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/services"
+)
+
+// UsageHandler handles LLM usage HTTP requests.
+type UsageHandler struct {
+	usageService *services.UsageService
+	log          *zap.Logger
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(usageService *services.UsageService, log *zap.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+		log:          log,
+	}
+}
+
+// Get returns the current user's token and cost usage with daily and monthly rollups.
+// GET /api/v1/usage?days=30
+func (h *UsageHandler) Get(c *gin.Context) {
+	userID := middleware.MustGetUserID(c)
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "days 必须是 1 到 366 之间的整数",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	usage, err := h.usageService.GetUsage(c.Request.Context(), userID, days)
+	if err != nil {
+		h.log.Error("Failed to get usage", zap.Error(err), zap.Uint("user_id", userID))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取用量统计失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
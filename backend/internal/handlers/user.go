@@ -103,12 +103,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	// Return user info and API key
 	c.JSON(http.StatusCreated, models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-		},
+		User:   userResponse(user),
 		APIKey: user.APIKey,
 	})
 }
@@ -169,12 +164,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	// Return user info and API key
 	c.JSON(http.StatusOK, models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-		},
+		User:   userResponse(user),
 		APIKey: user.APIKey,
 	})
 }
@@ -199,12 +189,65 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	})
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// UpdateProfile handles PATCH /api/v1/auth/profile - update name and default target language
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Invalid profile update request",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to get user profile",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to update user profile.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.TargetLang != nil {
+		user.TargetLang = *req.TargetLang
+	}
+
+	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
+		h.log.Error("Failed to update user profile",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to update user profile.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, userResponse(user))
 }
 
 // RegenerateAPIKey handles POST /api/v1/auth/regenerate-key - regenerate API key
@@ -236,3 +279,14 @@ func (h *UserHandler) RegenerateAPIKey(c *gin.Context) {
 		"api_key": apiKey,
 	})
 }
+
+// userResponse builds the API representation of a user.
+func userResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		TargetLang: user.TargetLang,
+		CreatedAt:  user.CreatedAt,
+	}
+}
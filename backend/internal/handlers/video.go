@@ -15,6 +15,8 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
@@ -24,6 +26,7 @@ import (
 type VideoHandler struct {
 	repo           *repository.VideoRepository
 	youtubeService *services.YouTubeService
+	queue          *jobs.Manager
 	log            *zap.Logger
 }
 
@@ -36,6 +39,11 @@ func NewVideoHandler(repo *repository.VideoRepository, youtubeService *services.
 	}
 }
 
+// SetJobQueue sets the job queue used to run analyses in the background.
+func (h *VideoHandler) SetJobQueue(queue *jobs.Manager) {
+	h.queue = queue
+}
+
 // GetMetadata fetches video metadata and AI analysis directly using Gemini.
 // POST /api/v1/videos/metadata
 // Request body: {"url": "https://youtube.com/watch?v=..."} or {"videoId": "..."}
@@ -84,10 +92,17 @@ func (h *VideoHandler) GetMetadata(c *gin.Context) {
 			zap.String("video_id", videoID),
 		)
 		// Check if error is due to missing API key
-		if strings.Contains(err.Error(), "OPENROUTER_API_KEY") {
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code":    models.ErrAIBudgetExceeded,
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, llm.ErrNotConfigured) {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    "API_KEY_MISSING",
-				"message": "OPENROUTER_API_KEY 环境变量未配置，请设置该环境变量以使用视频分析功能",
+				"message": "LLM API 密钥未配置，请设置 LLM_API_KEY 或 OPENROUTER_API_KEY 以使用视频分析功能",
 			})
 			return
 		}
@@ -181,127 +196,18 @@ func (h *VideoHandler) AnalyzeVideo(c *gin.Context) {
 		return
 	}
 
-	// Call Gemini directly and save result (even if error, save raw response)
-	go func() {
-		ctx := context.Background()
-		response, err := h.youtubeService.CallGeminiDirect(ctx, videoURL)
-
-		// Get analysis record
-		analysisRecord, err2 := h.repo.GetAnalysisByJobID(ctx, jobID)
-		if err2 != nil {
-			h.log.Error("Failed to get analysis record", zap.Error(err2))
-			return
-		}
-
-		// Even if Gemini returns error, save the response as completed
-		// User said: "大模型如果返回错误，也是正确的"
-		if err != nil {
-			h.log.Warn("Gemini returned error, but saving as completed",
-				zap.Error(err),
-				zap.String("video_url", videoURL),
-				zap.String("job_id", jobID),
-			)
-			// Save error message as transcription
-			analysisRecord.Status = "completed"
-			analysisRecord.Summary = err.Error()
-			if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-				h.log.Error("Failed to update analysis", zap.Error(err))
-				return
-			}
-			// Save error as a single transcription entry
-			transcriptions := []models.Transcription{
-				{
-					AnalysisID: analysisRecord.ID,
-					Text:       fmt.Sprintf("Error: %v", err),
-					Timestamp:  "00:00",
-					Seconds:    0,
-					OrderIndex: 0,
-				},
-			}
-			h.repo.CreateTranscriptions(ctx, transcriptions)
-			return
-		}
-
-		// Parse response to extract transcription
-		var result struct {
-			Transcription []struct {
-				Text      string `json:"text"`
-				Timestamp string `json:"timestamp"`
-				Seconds   int    `json:"seconds"`
-			} `json:"transcription"`
-		}
-
-		// Clean response before parsing
-		cleanedResponse := strings.TrimSpace(response)
-		if strings.HasPrefix(cleanedResponse, "```json") {
-			cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		} else if strings.HasPrefix(cleanedResponse, "```") {
-			cleanedResponse = strings.TrimPrefix(cleanedResponse, "```")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		}
-		if strings.HasSuffix(cleanedResponse, "```") {
-			cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		}
-		if !strings.HasPrefix(cleanedResponse, "{") {
-			startIdx := strings.Index(cleanedResponse, "{")
-			endIdx := strings.LastIndex(cleanedResponse, "}")
-			if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
-				cleanedResponse = cleanedResponse[startIdx : endIdx+1]
-			}
-		}
-
-		// Even if parsing fails, save raw response as completed
-		if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
-			h.log.Warn("Failed to parse Gemini response, but saving raw response as completed",
-				zap.Error(err),
-				zap.String("job_id", jobID),
-				zap.String("raw_response", response),
-			)
-			// Save raw response as transcription
-			analysisRecord.Status = "completed"
-			analysisRecord.Summary = response // Save raw response
-			if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-				h.log.Error("Failed to update analysis", zap.Error(err))
-				return
-			}
-			// Save raw response as a single transcription entry
-			transcriptions := []models.Transcription{
-				{
-					AnalysisID: analysisRecord.ID,
-					Text:       response,
-					Timestamp:  "00:00",
-					Seconds:    0,
-					OrderIndex: 0,
-				},
-			}
-			h.repo.CreateTranscriptions(ctx, transcriptions)
-			return
-		}
-
-		// Update analysis status to completed
-		analysisRecord.Status = "completed"
-		if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-			h.log.Error("Failed to update analysis", zap.Error(err))
-			return
-		}
-
-		// Save transcriptions
-		transcriptions := make([]models.Transcription, len(result.Transcription))
-		for i, tr := range result.Transcription {
-			transcriptions[i] = models.Transcription{
-				AnalysisID: analysisRecord.ID,
-				Text:       tr.Text,
-				Timestamp:  tr.Timestamp,
-				Seconds:    tr.Seconds,
-				OrderIndex: i,
-			}
-		}
-		if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
-			h.log.Error("Failed to save transcriptions", zap.Error(err))
-		}
-	}()
+	// Queue the analysis; the worker saves the result (even if error, save raw response)
+	if err := h.enqueueAnalysis(c.Request.Context(), jobID, videoURL); err != nil {
+		h.log.Error("Failed to enqueue video analysis",
+			zap.Error(err),
+			zap.String("job_id", jobID),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "ANALYSIS_FAILED",
+			"message": "无法创建解析任务",
+		})
+		return
+	}
 
 	// Return jobId immediately for frontend compatibility
 	// #region agent log
@@ -338,6 +244,182 @@ func (h *VideoHandler) AnalyzeVideo(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// HandleAnalysisJob is the jobs.Handler for the video analysis queue. It
+// calls Gemini directly and saves the result (even if error, save raw response).
+func (h *VideoHandler) HandleAnalysisJob(ctx context.Context, job *models.Job) error {
+	var payload jobs.VideoAnalysisPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	jobID, videoURL := payload.JobID, payload.VideoURL
+
+	// Get analysis record
+	analysisRecord, err := h.repo.GetAnalysisByJobID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted before the job ran; nothing left to do
+			return nil
+		}
+		return fmt.Errorf("failed to get analysis record: %w", err)
+	}
+
+	response, err := h.youtubeService.CallGeminiDirect(ctx, videoURL)
+
+	// Transient provider failures are retried by the queue before giving up
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	if err != nil && llm.IsRetryable(err) && jobs.WillRetry(job, err) {
+		return err
+	}
+
+	// Even if Gemini returns error, save the response as completed
+	// User said: "大模型如果返回错误，也是正确的"
+	if err != nil {
+		h.log.Warn("Gemini returned error, but saving as completed",
+			zap.Error(err),
+			zap.String("video_url", videoURL),
+			zap.String("job_id", jobID),
+		)
+		// Save error message as transcription
+		analysisRecord.Status = "completed"
+		analysisRecord.Summary = err.Error()
+		if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
+			return fmt.Errorf("failed to update analysis: %w", err)
+		}
+		// Save error as a single transcription entry
+		transcriptions := []models.Transcription{
+			{
+				AnalysisID: analysisRecord.ID,
+				Text:       fmt.Sprintf("Error: %v", err),
+				Timestamp:  "00:00",
+				Seconds:    0,
+				OrderIndex: 0,
+			},
+		}
+		if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
+			h.log.Error("Failed to save transcriptions", zap.Error(err))
+		}
+		return nil
+	}
+
+	// Parse response to extract transcription
+	var result struct {
+		Transcription []struct {
+			Text      string `json:"text"`
+			Timestamp string `json:"timestamp"`
+			Seconds   int    `json:"seconds"`
+		} `json:"transcription"`
+	}
+
+	// Clean response before parsing
+	cleanedResponse := strings.TrimSpace(response)
+	if strings.HasPrefix(cleanedResponse, "```json") {
+		cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
+		cleanedResponse = strings.TrimSpace(cleanedResponse)
+	} else if strings.HasPrefix(cleanedResponse, "```") {
+		cleanedResponse = strings.TrimPrefix(cleanedResponse, "```")
+		cleanedResponse = strings.TrimSpace(cleanedResponse)
+	}
+	if strings.HasSuffix(cleanedResponse, "```") {
+		cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
+		cleanedResponse = strings.TrimSpace(cleanedResponse)
+	}
+	if !strings.HasPrefix(cleanedResponse, "{") {
+		startIdx := strings.Index(cleanedResponse, "{")
+		endIdx := strings.LastIndex(cleanedResponse, "}")
+		if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
+			cleanedResponse = cleanedResponse[startIdx : endIdx+1]
+		}
+	}
+
+	// Even if parsing fails, save raw response as completed
+	if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
+		h.log.Warn("Failed to parse Gemini response, but saving raw response as completed",
+			zap.Error(err),
+			zap.String("job_id", jobID),
+			zap.String("raw_response", response),
+		)
+		// Save raw response as transcription
+		analysisRecord.Status = "completed"
+		analysisRecord.Summary = response // Save raw response
+		if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
+			return fmt.Errorf("failed to update analysis: %w", err)
+		}
+		// Save raw response as a single transcription entry
+		transcriptions := []models.Transcription{
+			{
+				AnalysisID: analysisRecord.ID,
+				Text:       response,
+				Timestamp:  "00:00",
+				Seconds:    0,
+				OrderIndex: 0,
+			},
+		}
+		if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
+			h.log.Error("Failed to save transcriptions", zap.Error(err))
+		}
+		return nil
+	}
+
+	// Update analysis status to completed
+	analysisRecord.Status = "completed"
+	if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
+		return fmt.Errorf("failed to update analysis: %w", err)
+	}
+
+	// Save transcriptions
+	transcriptions := make([]models.Transcription, len(result.Transcription))
+	for i, tr := range result.Transcription {
+		transcriptions[i] = models.Transcription{
+			AnalysisID: analysisRecord.ID,
+			Text:       tr.Text,
+			Timestamp:  tr.Timestamp,
+			Seconds:    tr.Seconds,
+			OrderIndex: i,
+		}
+	}
+	if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
+		h.log.Error("Failed to save transcriptions", zap.Error(err))
+	}
+	return nil
+}
+
+// RecoverStale enqueues analyses still marked processing, e.g. ones started
+// before the job queue existed. Analyses with an active job are skipped.
+func (h *VideoHandler) RecoverStale(ctx context.Context) error {
+	analyses, err := h.repo.GetAnalysesByStatus(ctx, "processing", 500)
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished analyses: %w", err)
+	}
+
+	for _, analysis := range analyses {
+		if analysis.VideoID == "" {
+			continue
+		}
+		videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", analysis.VideoID)
+		if err := h.enqueueAnalysis(ctx, analysis.JobID, videoURL); err != nil {
+			h.log.Error("Failed to re-enqueue video analysis",
+				zap.String("job_id", analysis.JobID),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// enqueueAnalysis schedules a video analysis job.
+func (h *VideoHandler) enqueueAnalysis(ctx context.Context, jobID, videoURL string) error {
+	if h.queue == nil {
+		return fmt.Errorf("job queue not configured")
+	}
+	_, err := h.queue.Enqueue(ctx, jobs.QueueVideoAnalysis, jobs.VideoAnalysisPayload{
+		JobID:    jobID,
+		VideoURL: videoURL,
+	}, jobs.EnqueueOptions{DedupeKey: jobs.VideoAnalysisKey(jobID)})
+	return err
+}
+
 // processAnalysis performs the actual video analysis asynchronously.
 func (h *VideoHandler) processAnalysis(ctx context.Context, analysisID uint, videoID, targetLanguage string) {
 	h.log.Info("Starting video analysis",
@@ -20,21 +20,21 @@ import (
 
 // YouTubeAPIHandler handles YouTube API endpoints.
 type YouTubeAPIHandler struct {
-	youtubeAPI     *services.YouTubeAPIService
-	youtubeService *services.YouTubeService
-	oauthService   *services.OAuthService
-	userRepo       repository.UserRepository
-	log            *zap.Logger
+	youtubeAPI        *services.YouTubeAPIService
+	transcriptService *services.TranscriptService
+	oauthService      *services.OAuthService
+	userRepo          repository.UserRepository
+	log               *zap.Logger
 }
 
 // NewYouTubeAPIHandler creates a new YouTubeAPIHandler.
-func NewYouTubeAPIHandler(youtubeAPI *services.YouTubeAPIService, youtubeService *services.YouTubeService, oauthService *services.OAuthService, userRepo *repository.UserRepository, log *zap.Logger) *YouTubeAPIHandler {
+func NewYouTubeAPIHandler(youtubeAPI *services.YouTubeAPIService, transcriptService *services.TranscriptService, oauthService *services.OAuthService, userRepo *repository.UserRepository, log *zap.Logger) *YouTubeAPIHandler {
 	return &YouTubeAPIHandler{
-		youtubeAPI:     youtubeAPI,
-		youtubeService: youtubeService,
-		oauthService:   oauthService,
-		userRepo:       *userRepo,
-		log:            log,
+		youtubeAPI:        youtubeAPI,
+		transcriptService: transcriptService,
+		oauthService:      oauthService,
+		userRepo:          *userRepo,
+		log:               log,
 	}
 }
 
@@ -345,7 +345,7 @@ func (h *YouTubeAPIHandler) GetCaptions(c *gin.Context) {
 	logDebug("youtube_api.go:278", "GetCaptions entry", map[string]interface{}{
 		"videoId": c.Query("videoId"),
 		"hasAuthHeader": c.GetHeader("Authorization") != "",
-		"youtubeServiceNil": h.youtubeService == nil,
+		"transcriptServiceNil": h.transcriptService == nil,
 		"youtubeAPINil": h.youtubeAPI == nil,
 		"method": c.Request.Method,
 		"path": c.Request.URL.Path,
@@ -440,7 +440,7 @@ func (h *YouTubeAPIHandler) GetCaptions(c *gin.Context) {
 		// #region agent log
 		logDebug("youtube_api.go:369", "Checking fallback availability", map[string]interface{}{
 			"videoId": videoID,
-			"youtubeServiceNil": h.youtubeService == nil,
+			"transcriptServiceNil": h.transcriptService == nil,
 			"hasError": err != nil,
 			"error": func() string {
 				if err != nil {
@@ -451,20 +451,19 @@ func (h *YouTubeAPIHandler) GetCaptions(c *gin.Context) {
 		}, "A")
 		// #endregion
 
-		if h.youtubeService != nil {
+		if h.transcriptService != nil {
 			// #region agent log
 			logDebug("youtube_api.go:315", "Starting fallback method", map[string]interface{}{
 				"videoId": videoID,
 			}, "C")
 			// #endregion
 
-			// Try structured transcript first (new format)
-			h.log.Info("Attempting structured transcript extraction",
+			h.log.Info("Attempting transcript extraction via provider chain",
 				zap.String("video_id", videoID),
 			)
-			
-			structuredResponse, structuredErr := h.youtubeService.FetchYouTubeTranscriptStructured(c.Request.Context(), videoID)
-			if structuredErr == nil && structuredResponse != nil {
+
+			structuredResponse, structuredErr := h.transcriptService.GetStructuredTranscript(c.Request.Context(), videoID)
+			if structuredErr == nil {
 				h.log.Info("Successfully fetched structured transcript",
 					zap.String("video_id", videoID),
 					zap.Int("language_count", len(structuredResponse.LanguageCode)),
@@ -473,106 +472,13 @@ func (h *YouTubeAPIHandler) GetCaptions(c *gin.Context) {
 				return
 			}
 
-			// Fallback to old method
-			h.log.Info("Structured method failed, trying old transcript extraction",
-				zap.String("video_id", videoID),
-				zap.Error(structuredErr),
-			)
-			
-			transcript, fallbackErr := h.youtubeService.FetchYouTubeTranscript(c.Request.Context(), videoID)
-			
-			// #region agent log
-			logDebug("youtube_api.go:398", "Fallback method result", map[string]interface{}{
-				"videoId": videoID,
-				"hasError": fallbackErr != nil,
-				"error": func() string {
-					if fallbackErr != nil {
-						return fallbackErr.Error()
-					}
-					return ""
-				}(),
-				"transcriptLength": len(transcript),
-				"transcriptEmpty": transcript == "",
-			}, "C")
-			// #endregion
-
-			// If parsing failed, return error response
-			if fallbackErr != nil {
-				errStr := fallbackErr.Error()
-				
-				// #region agent log
-				logDebug("youtube_api.go:420", "Checking error type", map[string]interface{}{
-					"videoId": videoID,
-					"error": errStr,
-					"containsParseError": strings.Contains(errStr, "字幕内容解析失败"),
-					"containsFetchError": strings.Contains(errStr, "字幕获取失败"),
-				}, "C")
-				// #endregion
-				
-				if strings.Contains(errStr, "字幕内容解析失败") || strings.Contains(errStr, "字幕获取失败") {
-					// We found the URL but parsing failed - return error
-					h.log.Warn("Found captions but parsing failed",
-						zap.String("video_id", videoID),
-						zap.Error(fallbackErr),
-					)
-					c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-						Code:    models.ErrorNoCaptions,
-						Message: "字幕解析失败。该视频有字幕，但无法通过当前方法解析。请尝试使用其他方法获取字幕。",
-					})
-					return
-				}
-			}
-
-			if fallbackErr == nil && transcript != "" {
-				// Successfully fetched transcript via web scraping
-				// Create a response with a virtual caption track AND the actual transcript
-				fallbackResponse := &models.YouTubeCaptionsResponse{
-					Captions: []models.YouTubeCaption{
-						{
-							ID:       "fallback-transcript",
-							Language: "auto",
-							Name:     "自动生成字幕（网页抓取）",
-						},
-					},
-					Transcript: transcript, // 返回实际的字幕内容
-				}
-				h.log.Info("Successfully fetched captions via fallback method",
-					zap.String("video_id", videoID),
-					zap.Int("transcript_length", len(transcript)),
-				)
-				// #region agent log
-				logDebug("youtube_api.go:445", "Fallback success, returning 200", map[string]interface{}{
-					"videoId": videoID,
-				}, "C")
-				// #endregion
-				c.JSON(http.StatusOK, fallbackResponse)
-				return
-			}
-
-			// If parsing failed completely, return error
-			if fallbackErr != nil {
-				errStr := fallbackErr.Error()
-				// If error contains "字幕内容解析失败" or "字幕获取失败", return error
-				if strings.Contains(errStr, "字幕内容解析失败") || strings.Contains(errStr, "字幕获取失败") {
-					h.log.Warn("Found captions URL but parsing failed",
-						zap.String("video_id", videoID),
-						zap.String("error", errStr),
-					)
-					c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-						Code:    models.ErrorNoCaptions,
-						Message: "字幕解析失败。该视频有字幕，但无法通过当前方法解析。请尝试使用其他方法获取字幕。",
-					})
-					return
-				}
-			}
-			
 			h.log.Warn("Fallback method also failed",
-				zap.Error(fallbackErr),
+				zap.Error(structuredErr),
 				zap.String("video_id", videoID),
 			)
 		} else {
 			// #region agent log
-			logDebug("youtube_api.go:343", "Fallback skipped - youtubeService is nil", map[string]interface{}{
+			logDebug("youtube_api.go:343", "Fallback skipped - transcriptService is nil", map[string]interface{}{
 				"videoId": videoID,
 			}, "A")
 			// #endregion
@@ -658,7 +564,7 @@ func isNotFoundError(err error) bool {
 		return false
 	}
 	errStr := err.Error()
-	return contains(errStr, "VIDEO_NOT_FOUND") || contains(errStr, "PLAYLIST_NOT_FOUND") || contains(errStr, "not found")
+	return contains(errStr, "VIDEO_NOT_FOUND") || contains(errStr, "PLAYLIST_NOT_FOUND") || contains(errStr, "CHANNEL_NOT_FOUND") || contains(errStr, "not found")
 }
 
 func isUnauthorizedError(err error) bool {
@@ -0,0 +1,96 @@
+// Package jobs runs durable background work on top of the Postgres jobs table.
+//
+// Jobs are leased rather than locked: a worker claims a job for a lease
+// duration and extends the lease while the handler runs. If the process
+// dies, the lease expires and another worker picks the job up again. Failed
+// jobs are retried with exponential backoff and moved to the dead-letter
+// state once they run out of attempts.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"vibe-backend/internal/models"
+)
+
+// Queue names.
+const (
+	QueueInsight       = "insight"
+	QueueVideoAnalysis = "video_analysis"
+	QueueSubscription  = "subscription"
+)
+
+// Handler processes a single job. Returning nil completes the job; returning
+// an error schedules a retry unless the error is Permanent or the job has
+// used all of its attempts.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered without further retries.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// WillRetry reports whether a job that just failed with err will run again.
+func WillRetry(job *models.Job, err error) bool {
+	return !IsPermanent(err) && job.Attempts < job.MaxAttempts
+}
+
+// Decode unmarshals a job's payload into v.
+func Decode(job *models.Job, v interface{}) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid payload for job %d: %w", job.ID, err))
+	}
+	return nil
+}
+
+// InsightPayload is the payload of QueueInsight jobs.
+type InsightPayload struct {
+	InsightID uint `json:"insight_id"`
+}
+
+// VideoAnalysisPayload is the payload of QueueVideoAnalysis jobs.
+type VideoAnalysisPayload struct {
+	JobID    string `json:"job_id"`
+	VideoURL string `json:"video_url"`
+}
+
+// SubscriptionPayload is the payload of QueueSubscription jobs, which poll a
+// subscribed channel for new uploads.
+type SubscriptionPayload struct {
+	ChannelID string `json:"channel_id"`
+}
+
+// InsightKey returns the dedupe key for an insight processing job.
+func InsightKey(insightID uint) string {
+	return fmt.Sprintf("insight:%d", insightID)
+}
+
+// VideoAnalysisKey returns the dedupe key for a video analysis job.
+func VideoAnalysisKey(jobID string) string {
+	return "video_analysis:" + jobID
+}
+
+// SubscriptionKey returns the dedupe key for a channel poll job.
+func SubscriptionKey(channelID string) string {
+	return "subscription:" + channelID
+}
@@ -0,0 +1,423 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// QueueOptions configures the workers of one queue.
+type QueueOptions struct {
+	// Workers is the number of concurrent workers. Defaults to 1.
+	Workers int
+	// MaxAttempts is the default attempt budget for new jobs. Defaults to 5.
+	MaxAttempts int
+	// Lease is how long a claimed job is reserved before another worker may
+	// take it over. It is extended while the handler runs. Defaults to 5m.
+	Lease time.Duration
+	// PollInterval is how often idle workers check for new jobs. Defaults to 2s.
+	PollInterval time.Duration
+	// Backoff is the delay before the first retry, doubled on each
+	// subsequent attempt and capped at MaxBackoff. Defaults to 30s.
+	Backoff time.Duration
+	// MaxBackoff caps the retry delay. Defaults to 1h.
+	MaxBackoff time.Duration
+}
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	return o
+}
+
+// EnqueueOptions tunes a single Enqueue call.
+type EnqueueOptions struct {
+	// DedupeKey skips the enqueue if an active job with the same key exists.
+	DedupeKey string
+	// RunAt delays the job. Zero means now.
+	RunAt time.Time
+	// MaxAttempts overrides the queue default.
+	MaxAttempts int
+}
+
+// completedRetention is how long completed jobs are kept for inspection.
+const completedRetention = 7 * 24 * time.Hour
+
+type queue struct {
+	name    string
+	handler Handler
+	opts    QueueOptions
+	wake    chan struct{}
+}
+
+// Manager owns the worker pools for all registered queues.
+type Manager struct {
+	repo     *repository.JobRepository
+	workerID string
+	log      *zap.Logger
+
+	mu      sync.RWMutex
+	queues  map[string]*queue
+	onStart []func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a new Manager.
+func NewManager(repo *repository.JobRepository, log *zap.Logger) *Manager {
+	host, _ := os.Hostname()
+	return &Manager{
+		repo:     repo,
+		workerID: fmt.Sprintf("%s-%d-%04d", host, os.Getpid(), rand.Intn(10000)),
+		queues:   make(map[string]*queue),
+		log:      log,
+	}
+}
+
+// Register installs the handler for a queue. It must be called before Start.
+func (m *Manager) Register(name string, handler Handler, opts QueueOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queues[name] = &queue{
+		name:    name,
+		handler: handler,
+		opts:    opts.withDefaults(),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// OnStart registers a hook run once when the manager starts, before workers
+// begin polling. Hooks are used to recover work interrupted by a crash.
+func (m *Manager) OnStart(hook func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStart = append(m.onStart, hook)
+}
+
+// Enqueue adds a job to a queue. It returns the created job, or nil if an
+// active job with the same dedupe key already exists.
+func (m *Manager) Enqueue(ctx context.Context, queueName string, payload interface{}, opts EnqueueOptions) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	m.mu.RLock()
+	q := m.queues[queueName]
+	m.mu.RUnlock()
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+		if q != nil {
+			maxAttempts = q.opts.MaxAttempts
+		}
+	}
+
+	job := &models.Job{
+		Queue:       queueName,
+		Payload:     datatypes.JSON(data),
+		Status:      models.JobStatusQueued,
+		RunAt:       opts.RunAt,
+		MaxAttempts: maxAttempts,
+	}
+	if opts.DedupeKey != "" {
+		key := opts.DedupeKey
+		job.DedupeKey = &key
+	}
+
+	created, err := m.repo.Enqueue(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if !created {
+		m.log.Debug("Job already queued, skipping",
+			zap.String("queue", queueName),
+			zap.String("dedupe_key", opts.DedupeKey),
+		)
+		return nil, nil
+	}
+
+	m.log.Info("Job enqueued",
+		zap.String("queue", queueName),
+		zap.Uint("job_id", job.ID),
+	)
+
+	// Wake an idle local worker so the job starts without waiting for a poll.
+	if q != nil {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return job, nil
+}
+
+// Start runs the startup hooks and launches the worker pools.
+func (m *Manager) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.mu.RLock()
+	hooks := append([]func(context.Context) error(nil), m.onStart...)
+	queues := make([]*queue, 0, len(m.queues))
+	for _, q := range m.queues {
+		queues = append(queues, q)
+	}
+	m.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			m.log.Error("Job manager startup hook failed", zap.Error(err))
+		}
+	}
+
+	m.wg.Add(1)
+	go m.janitor(ctx)
+
+	for _, q := range queues {
+		for i := 0; i < q.opts.Workers; i++ {
+			m.wg.Add(1)
+			go m.work(ctx, q)
+		}
+		m.log.Info("Job workers started",
+			zap.String("queue", q.name),
+			zap.Int("workers", q.opts.Workers),
+			zap.String("worker_id", m.workerID),
+		)
+	}
+}
+
+// Stop cancels in-flight jobs (returning them to the queue) and waits for
+// workers to exit or ctx to expire.
+func (m *Manager) Stop(ctx context.Context) error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.log.Info("Job workers stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns queue depth per queue.
+func (m *Manager) Stats(ctx context.Context) ([]models.QueueStats, error) {
+	return m.repo.Stats(ctx)
+}
+
+// work is the main loop of a single worker.
+func (m *Manager) work(ctx context.Context, q *queue) {
+	defer m.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := m.repo.Claim(ctx, q.name, m.workerID, q.opts.Lease)
+		if err != nil && ctx.Err() == nil {
+			m.log.Error("Failed to claim job", zap.String("queue", q.name), zap.Error(err))
+		}
+		if job != nil {
+			m.run(ctx, q, job)
+			continue
+		}
+
+		// Idle: wait for a wake-up or the next poll.
+		timer := time.NewTimer(q.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// run executes one job with lease heartbeats and records the outcome.
+func (m *Manager) run(ctx context.Context, q *queue, job *models.Job) {
+	log := m.log.With(
+		zap.String("queue", q.name),
+		zap.Uint("job_id", job.ID),
+		zap.Int("attempt", job.Attempts),
+	)
+	log.Info("Job started")
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	var leaseLost bool
+	go func() {
+		defer close(heartbeatDone)
+		leaseLost = m.heartbeat(jobCtx, cancel, q, job, log)
+	}()
+
+	err := m.invoke(jobCtx, q, job)
+	cancel()
+	<-heartbeatDone
+
+	if leaseLost {
+		// Another worker owns the job now; its run decides the outcome.
+		log.Warn("Job lease lost, outcome discarded", zap.Error(err))
+		return
+	}
+
+	// Bookkeeping must succeed even when the manager is shutting down.
+	bg := context.WithoutCancel(ctx)
+
+	switch {
+	case err == nil:
+		if err := m.repo.Complete(bg, job.ID, m.workerID); err != nil {
+			logOutcomeFailure(log, "Failed to mark job completed", err)
+			return
+		}
+		log.Info("Job completed")
+
+	case ctx.Err() != nil:
+		// Interrupted by shutdown: hand the job back without using an attempt.
+		if err := m.repo.Release(bg, job.ID, m.workerID); err != nil {
+			logOutcomeFailure(log, "Failed to release interrupted job", err)
+			return
+		}
+		log.Info("Job released for another worker")
+
+	case WillRetry(job, err):
+		delay := backoff(q.opts, job.Attempts)
+		if err := m.repo.Retry(bg, job.ID, m.workerID, err.Error(), time.Now().Add(delay)); err != nil {
+			logOutcomeFailure(log, "Failed to schedule job retry", err)
+			return
+		}
+		log.Warn("Job failed, will retry",
+			zap.Error(err),
+			zap.Duration("retry_in", delay),
+			zap.Int("max_attempts", job.MaxAttempts),
+		)
+
+	default:
+		if err := m.repo.Bury(bg, job.ID, m.workerID, err.Error()); err != nil {
+			logOutcomeFailure(log, "Failed to dead-letter job", err)
+			return
+		}
+		log.Error("Job moved to dead-letter queue",
+			zap.Error(err),
+			zap.Bool("permanent", IsPermanent(err)),
+		)
+	}
+}
+
+// invoke calls the handler, converting panics into errors.
+func (m *Manager) invoke(ctx context.Context, q *queue, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panic: %v", r)
+		}
+	}()
+	return q.handler(ctx, job)
+}
+
+// logOutcomeFailure logs a failed outcome update. A lost lease is a
+// warning: the job expired while it ran and another worker took it over.
+func logOutcomeFailure(log *zap.Logger, msg string, err error) {
+	if errors.Is(err, repository.ErrJobLeaseLost) {
+		log.Warn(msg, zap.Error(err))
+		return
+	}
+	log.Error(msg, zap.Error(err))
+}
+
+// heartbeat extends the job lease until ctx is done. If the lease is lost
+// it cancels the job, so the handler stops instead of running alongside the
+// worker that claimed the job next, and reports true.
+func (m *Manager) heartbeat(ctx context.Context, cancel context.CancelFunc, q *queue, job *models.Job, log *zap.Logger) bool {
+	ticker := time.NewTicker(q.opts.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			err := m.repo.ExtendLease(ctx, job.ID, m.workerID, q.opts.Lease)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			if errors.Is(err, repository.ErrJobLeaseLost) {
+				log.Warn("Job lease lost, cancelling job")
+				cancel()
+				return true
+			}
+			log.Warn("Failed to extend job lease", zap.Error(err))
+		}
+	}
+}
+
+// janitor periodically deletes old completed jobs.
+func (m *Manager) janitor(ctx context.Context) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		deleted, err := m.repo.DeleteCompletedBefore(ctx, time.Now().Add(-completedRetention))
+		if err != nil && ctx.Err() == nil {
+			m.log.Warn("Failed to clean up completed jobs", zap.Error(err))
+		} else if deleted > 0 {
+			m.log.Info("Cleaned up completed jobs", zap.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff returns the retry delay after the given attempt, with jitter.
+func backoff(opts QueueOptions, attempt int) time.Duration {
+	delay := opts.Backoff
+	for i := 1; i < attempt && delay < opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > opts.MaxBackoff {
+		delay = opts.MaxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
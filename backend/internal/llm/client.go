@@ -0,0 +1,344 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ClientOptions tunes a Client.
+type ClientOptions struct {
+	// Model is used when a Request does not set one.
+	Model string
+	// Timeout bounds each non-streaming attempt. Defaults to 120s.
+	Timeout time.Duration
+	// StreamIdleTimeout bounds the wait for a stream to start and for each
+	// chunk after that; a stream that goes quiet for longer is cancelled
+	// and ends with an ErrTimeout chunk. Defaults to 30s.
+	StreamIdleTimeout time.Duration
+	// MaxRetries is the number of retries after the first attempt for
+	// retryable errors (rate limits, timeouts, 5xx). Defaults to 0.
+	MaxRetries int
+	// Backoff is the initial retry delay, doubled on each retry. Defaults to 500ms.
+	Backoff time.Duration
+}
+
+// Client wraps a Provider with default model, timeouts and retries.
+type Client struct {
+	provider    Provider
+	model       string
+	timeout     time.Duration
+	idleTimeout time.Duration
+	maxRetries  int
+	backoff     time.Duration
+	meter       Meter
+	log         *zap.Logger
+}
+
+const maxBackoff = 8 * time.Second
+
+// NewClient creates a new Client.
+func NewClient(provider Provider, opts ClientOptions, log *zap.Logger) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 120 * time.Second
+	}
+	if opts.StreamIdleTimeout <= 0 {
+		opts.StreamIdleTimeout = 30 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 500 * time.Millisecond
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	return &Client{
+		provider:    provider,
+		model:       opts.Model,
+		timeout:     opts.Timeout,
+		idleTimeout: opts.StreamIdleTimeout,
+		maxRetries:  opts.MaxRetries,
+		backoff:     opts.Backoff,
+		log:         log,
+	}
+}
+
+// SetMeter installs a Meter used for budget checks and usage recording.
+func (c *Client) SetMeter(m Meter) {
+	c.meter = m
+}
+
+// Model returns the default model name.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// ProviderName returns the name of the underlying provider.
+func (c *Client) ProviderName() string {
+	return c.provider.Name()
+}
+
+// Complete performs a non-streaming completion with retries.
+func (c *Client) Complete(ctx context.Context, req Request) (*Response, error) {
+	req = c.prepare(req)
+	if err := c.allow(ctx); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt, lastErr); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		resp, err := c.provider.Complete(attemptCtx, req)
+		cancel()
+		if err == nil {
+			if strings.TrimSpace(resp.Content) == "" {
+				err = &Error{Kind: ErrEmptyResponse, Provider: c.provider.Name()}
+			} else {
+				c.record(ctx, req, resp.Model, resp.Usage, resp.Content)
+				return resp, nil
+			}
+		}
+
+		lastErr = err
+		if !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	c.logFailure(lastErr, req)
+	return nil, lastErr
+}
+
+// CompleteText is a convenience wrapper that sends a single user prompt.
+func (c *Client) CompleteText(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, Request{Messages: []Message{User(prompt)}})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// Stream starts a streaming completion. Connection failures are retried;
+// once the first chunk has been produced the stream is not restarted. A
+// stream that produces nothing for the idle timeout is cancelled.
+func (c *Client) Stream(ctx context.Context, req Request) (<-chan Chunk, error) {
+	req = c.prepare(req)
+	if err := c.allow(ctx); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt, lastErr); err != nil {
+				return nil, err
+			}
+		}
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		idle := newIdleTimer(c.idleTimeout, cancel)
+		ch, err := c.provider.Stream(streamCtx, req)
+		if err == nil {
+			return c.forwardStream(ctx, req, ch, idle, cancel), nil
+		}
+		idle.stop()
+		cancel()
+		if idle.fired() && ctx.Err() == nil {
+			err = c.idleError()
+		}
+
+		lastErr = err
+		if !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	c.logFailure(lastErr, req)
+	return nil, lastErr
+}
+
+// forwardStream forwards chunks from in, cancelling the stream when idle
+// fires, and records usage once the stream ends. The idle timer only runs
+// while waiting for the provider, not while the caller reads a chunk.
+func (c *Client) forwardStream(ctx context.Context, req Request, in <-chan Chunk, idle *idleTimer, cancel context.CancelFunc) <-chan Chunk {
+	out := make(chan Chunk, cap(in))
+	go func() {
+		defer close(out)
+		defer cancel()
+		var content strings.Builder
+		var usage Usage
+		var model string
+		for chunk := range in {
+			idle.stop()
+			content.WriteString(chunk.Content)
+			if chunk.Usage != nil {
+				usage = *chunk.Usage
+			}
+			if chunk.Model != "" {
+				model = chunk.Model
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep draining so partial usage is still recorded.
+			}
+			idle.reset()
+		}
+		idle.stop()
+
+		if idle.fired() && ctx.Err() == nil {
+			c.log.Warn("LLM stream stalled, cancelled",
+				zap.String("provider", c.provider.Name()),
+				zap.String("model", req.Model),
+				zap.Duration("idle_timeout", c.idleTimeout),
+			)
+			select {
+			case out <- Chunk{Err: c.idleError()}:
+			case <-ctx.Done():
+			}
+		}
+		if content.Len() > 0 {
+			c.record(context.WithoutCancel(ctx), req, model, usage, content.String())
+		}
+	}()
+	return out
+}
+
+// idleError is the error a stalled stream ends with.
+func (c *Client) idleError() error {
+	return &Error{
+		Kind:     ErrTimeout,
+		Provider: c.provider.Name(),
+		Message:  fmt.Sprintf("no data received for %s", c.idleTimeout),
+	}
+}
+
+// idleTimer cancels a stream when it is not stopped or reset in time.
+type idleTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+	expired atomic.Bool
+}
+
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.expired.Store(true)
+		cancel()
+	})
+	return t
+}
+
+func (t *idleTimer) stop() {
+	t.timer.Stop()
+}
+
+// reset restarts the countdown unless the timer has already fired.
+func (t *idleTimer) reset() {
+	if !t.expired.Load() {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// fired reports whether the timer cancelled the stream.
+func (t *idleTimer) fired() bool {
+	return t.expired.Load()
+}
+
+// allow consults the meter before a call.
+func (c *Client) allow(ctx context.Context) error {
+	if c.meter == nil {
+		return nil
+	}
+	return c.meter.Allow(ctx, TagsFromContext(ctx))
+}
+
+// record reports usage to the meter, estimating it when the provider did not.
+func (c *Client) record(ctx context.Context, req Request, model string, usage Usage, completion string) {
+	if c.meter == nil {
+		return
+	}
+	if model == "" {
+		model = req.Model
+	}
+	estimated := false
+	if usage.TotalTokens == 0 && usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		usage = EstimateUsage(req, completion)
+		estimated = true
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	c.meter.Record(ctx, UsageRecord{
+		Tags:      TagsFromContext(ctx),
+		Provider:  c.provider.Name(),
+		Model:     model,
+		Usage:     usage,
+		Estimated: estimated,
+	})
+}
+
+func (c *Client) prepare(req Request) Request {
+	if req.Model == "" {
+		req.Model = c.model
+	}
+	return req
+}
+
+// wait sleeps for the backoff of the given attempt, honouring cancellation.
+func (c *Client) wait(ctx context.Context, attempt int, lastErr error) error {
+	delay := c.backoff << (attempt - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	// Add up to 20% jitter so concurrent callers don't retry in lockstep.
+	delay += time.Duration(rand.Int63n(int64(delay)/5 + 1))
+
+	c.log.Warn("LLM request failed, retrying",
+		zap.String("provider", c.provider.Name()),
+		zap.Int("attempt", attempt),
+		zap.Duration("delay", delay),
+		zap.Error(lastErr),
+	)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) logFailure(err error, req Request) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrBudgetExceeded) {
+		return
+	}
+	if errors.Is(err, ErrAuth) {
+		c.log.Error("❌ LLM API 认证失败 - API密钥无效",
+			zap.String("provider", c.provider.Name()),
+			zap.String("model", req.Model),
+			zap.String("error_type", "AUTHENTICATION_FAILED"),
+			zap.String("解决方案", "请检查 LLM_API_KEY / OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
+			zap.Error(err),
+		)
+		return
+	}
+	c.log.Error("LLM request failed",
+		zap.String("provider", c.provider.Name()),
+		zap.String("model", req.Model),
+		zap.Error(err),
+	)
+}
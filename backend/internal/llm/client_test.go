@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestClient(provider Provider, idle time.Duration) *Client {
+	return NewClient(provider, ClientOptions{
+		Model:             "test-model",
+		StreamIdleTimeout: idle,
+		MaxRetries:        2,
+		Backoff:           time.Millisecond,
+	}, zap.NewNop())
+}
+
+// collect reads a stream to its end, sleeping delay after each chunk, and
+// returns its text and the error of its last chunk.
+func collect(t *testing.T, ch <-chan Chunk, delay time.Duration) (string, error) {
+	t.Helper()
+	var text strings.Builder
+	var err error
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				return text.String(), err
+			}
+			text.WriteString(chunk.Content)
+			if chunk.Err != nil {
+				err = chunk.Err
+			}
+			time.Sleep(delay)
+		case <-timeout:
+			t.Fatal("stream did not end")
+		}
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	unavailable := &Error{Kind: ErrUnavailable, Provider: ProviderFake}
+	tests := []struct {
+		name  string
+		setup func(f *Fake)
+		// delay is how long the reader takes over each chunk
+		delay        time.Duration
+		wantText     string
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			name:         "complete stream",
+			wantText:     "one two three",
+			wantRequests: 1,
+		},
+		{
+			name:         "slow reader does not trip the idle timer",
+			delay:        100 * time.Millisecond,
+			wantText:     "one two three",
+			wantRequests: 1,
+		},
+		{
+			name:         "stalled stream ends with a timeout",
+			setup:        func(f *Fake) { f.FailStreamAfter(1, nil) },
+			wantText:     "one ",
+			wantErr:      ErrTimeout,
+			wantRequests: 1,
+		},
+		{
+			name:         "stalled before the first chunk",
+			setup:        func(f *Fake) { f.FailStreamAfter(0, nil) },
+			wantErr:      ErrTimeout,
+			wantRequests: 1,
+		},
+		{
+			name:         "not restarted after the first chunk",
+			setup:        func(f *Fake) { f.FailStreamAfter(2, unavailable) },
+			wantText:     "one two ",
+			wantErr:      ErrUnavailable,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFake("one two three")
+			if tt.setup != nil {
+				tt.setup(fake)
+			}
+			client := newTestClient(fake, 50*time.Millisecond)
+
+			ch, err := client.Stream(context.Background(), Request{Messages: []Message{User("hi")}})
+			if err != nil {
+				t.Fatalf("Stream() error: %v", err)
+			}
+			text, err := collect(t, ch, tt.delay)
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("stream error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("stream error = %v, want %v", err, tt.wantErr)
+			}
+			if got := len(fake.Requests()); got != tt.wantRequests {
+				t.Errorf("provider called %d times, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClientStreamRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			name:         "retryable errors are retried",
+			errs:         []error{&Error{Kind: ErrUnavailable}, &Error{Kind: ErrRateLimited}},
+			wantRequests: 3,
+		},
+		{
+			name:         "retries run out",
+			errs:         []error{&Error{Kind: ErrTimeout}, &Error{Kind: ErrTimeout}, &Error{Kind: ErrTimeout}},
+			wantErr:      ErrTimeout,
+			wantRequests: 3,
+		},
+		{
+			name:         "other errors are not retried",
+			errs:         []error{&Error{Kind: ErrBadRequest}},
+			wantErr:      ErrBadRequest,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFake("one two three")
+			fake.FailNext(tt.errs...)
+			client := newTestClient(fake, time.Second)
+
+			ch, err := client.Stream(context.Background(), Request{Messages: []Message{User("hi")}})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Stream() error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Stream() error: %v", err)
+				}
+				if text, err := collect(t, ch, 0); text != "one two three" || err != nil {
+					t.Errorf("stream = %q, %v; want full text", text, err)
+				}
+			}
+			if got := len(fake.Requests()); got != tt.wantRequests {
+				t.Errorf("provider called %d times, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
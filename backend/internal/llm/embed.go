@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// embedBatchSize is the number of texts sent per embeddings request.
+const embedBatchSize = 64
+
+// Embedder turns texts into vectors for semantic retrieval.
+type Embedder interface {
+	// Name identifies the embedder in logs.
+	Name() string
+	// Model is the embedding model; vectors from different models are not comparable.
+	Model() string
+	// Embed returns one vector per input text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderConfig selects and configures an embedder.
+type EmbedderConfig struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	Model    string
+	Timeout  time.Duration
+}
+
+// NewEmbedder builds an Embedder from configuration. It returns nil when no
+// provider is configured, in which case retrieval falls back to keyword search.
+func NewEmbedder(cfg EmbedderConfig) (Embedder, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "":
+		return nil, nil
+	case ProviderOpenAI, ProviderOpenRouter:
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("llm: EMBEDDING_MODEL is required for embedding provider %q", cfg.Provider)
+		}
+		var provider *OpenAICompatible
+		if strings.ToLower(cfg.Provider) == ProviderOpenRouter {
+			provider = NewOpenRouter(cfg.APIKey, cfg.BaseURL, cfg.Timeout)
+		} else {
+			if cfg.BaseURL == "" {
+				return nil, fmt.Errorf("llm: EMBEDDING_BASE_URL is required for embedding provider %q", ProviderOpenAI)
+			}
+			provider = NewOpenAICompatible(ProviderOpenAI, cfg.BaseURL, cfg.APIKey, cfg.Timeout)
+		}
+		return &httpEmbedder{provider: provider, model: cfg.Model}, nil
+	case ProviderFake:
+		return NewFakeEmbedder(64), nil
+	default:
+		return nil, fmt.Errorf("llm: unknown embedding provider %q", cfg.Provider)
+	}
+}
+
+// httpEmbedder calls an OpenAI-compatible /embeddings endpoint.
+type httpEmbedder struct {
+	provider *OpenAICompatible
+	model    string
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *apiError `json:"error"`
+}
+
+// Name implements Embedder.
+func (e *httpEmbedder) Name() string {
+	return e.provider.Name()
+}
+
+// Model implements Embedder.
+func (e *httpEmbedder) Model() string {
+	return e.model
+}
+
+// Embed implements Embedder.
+func (e *httpEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := e.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *httpEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	name := e.provider.Name()
+	resp, err := e.provider.post(ctx, "/embeddings", embeddingRequest{Model: e.model, Input: texts}, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errorFromTransport(ctx, name, err)
+	}
+
+	var result embeddingResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Provider: name, Message: fmt.Sprintf("failed to parse embeddings response: %v", err)}
+	}
+	if result.Error != nil {
+		return nil, &Error{Kind: ErrUnavailable, Provider: name, Message: result.Error.Message}
+	}
+	if len(result.Data) != len(texts) {
+		return nil, &Error{Kind: ErrEmptyResponse, Provider: name, Message: fmt.Sprintf("expected %d embeddings, got %d", len(texts), len(result.Data))}
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, &Error{Kind: ErrUnavailable, Provider: name, Message: fmt.Sprintf("embedding index %d out of range", d.Index)}
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// FakeEmbedder is a deterministic embedder for tests and offline development.
+// It hashes words into a fixed number of buckets, so texts sharing words get
+// similar vectors.
+type FakeEmbedder struct {
+	dims int
+}
+
+// NewFakeEmbedder creates a fake embedder producing vectors of dims dimensions.
+func NewFakeEmbedder(dims int) *FakeEmbedder {
+	return &FakeEmbedder{dims: dims}
+}
+
+// Name implements Embedder.
+func (f *FakeEmbedder) Name() string {
+	return ProviderFake
+}
+
+// Model implements Embedder.
+func (f *FakeEmbedder) Model() string {
+	return fmt.Sprintf("fake-hash-%d", f.dims)
+}
+
+// Embed implements Embedder.
+func (f *FakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, f.dims)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			v[h.Sum32()%uint32(f.dims)]++
+		}
+		var norm float64
+		for _, x := range v {
+			norm += float64(x * x)
+		}
+		if norm > 0 {
+			scale := float32(1 / math.Sqrt(norm))
+			for j := range v {
+				v[j] *= scale
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
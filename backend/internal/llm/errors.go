@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Error kinds. Use errors.Is to check which kind an error belongs to.
+var (
+	ErrNotConfigured = errors.New("llm provider not configured")
+	ErrAuth          = errors.New("llm authentication failed")
+	ErrRateLimited   = errors.New("llm rate limited")
+	ErrQuota         = errors.New("llm quota exceeded")
+	ErrTimeout       = errors.New("llm request timed out")
+	ErrUnavailable   = errors.New("llm provider unavailable")
+	ErrBadRequest    = errors.New("llm bad request")
+	ErrEmptyResponse = errors.New("llm returned empty response")
+	// ErrBudgetExceeded is returned by a Meter when the caller has used up
+	// its AI budget for the current period.
+	ErrBudgetExceeded = errors.New("ai budget exceeded")
+)
+
+// Error is returned by providers and the Client.
+type Error struct {
+	Kind       error
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: %s (status %d): %s", e.Provider, e.Kind, e.StatusCode, e.Message)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s: %s", e.Provider, e.Kind, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Provider, e.Kind)
+}
+
+// Unwrap exposes the error kind to errors.Is.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// IsRetryable reports whether a failed call may succeed if repeated.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrTimeout) ||
+		errors.Is(err, ErrUnavailable)
+}
+
+// errorFromStatus classifies an HTTP error response.
+func errorFromStatus(provider string, status int, body string) *Error {
+	kind := ErrUnavailable
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		kind = ErrAuth
+	case status == http.StatusPaymentRequired:
+		kind = ErrQuota
+	case status == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		kind = ErrTimeout
+	case status >= 400 && status < 500:
+		kind = ErrBadRequest
+	}
+	return &Error{Kind: kind, Provider: provider, StatusCode: status, Message: body}
+}
+
+// errorFromTransport classifies a transport-level failure.
+func errorFromTransport(ctx context.Context, provider string, err error) error {
+	if ctx.Err() == context.Canceled {
+		return ctx.Err()
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &Error{Kind: ErrTimeout, Provider: provider, Message: err.Error()}
+	}
+	return &Error{Kind: ErrUnavailable, Provider: provider, Message: err.Error()}
+}
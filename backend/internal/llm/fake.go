@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Fake is a deterministic provider for tests and offline development.
+// It returns queued responses in order (repeating the last one), or echoes
+// the last user message when nothing is queued.
+type Fake struct {
+	mu        sync.Mutex
+	responses []string
+	next      int
+	err       error
+	failNext  []error
+	requests  []Request
+
+	// Streams send streamLimit chunks (-1 for all), then streamErr, or stall
+	// until cancelled if it is nil.
+	streamLimit int
+	streamErr   error
+}
+
+// NewFake creates a fake provider that replies with the given responses.
+func NewFake(responses ...string) *Fake {
+	return &Fake{responses: responses, streamLimit: -1}
+}
+
+// Name implements Provider.
+func (f *Fake) Name() string {
+	return ProviderFake
+}
+
+// SetError makes every subsequent call fail with err (nil to clear).
+func (f *Fake) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// FailNext makes the next calls fail with errs, one call per error, before
+// calls succeed again.
+func (f *Fake) FailNext(errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext = append(f.failNext, errs...)
+}
+
+// FailStreamAfter makes every subsequent stream fail after sending n
+// chunks: with a final chunk carrying err, or, if err is nil, by stalling
+// until the stream is cancelled.
+func (f *Fake) FailStreamAfter(n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.streamLimit = n
+	f.streamErr = err
+}
+
+// Requests returns the requests received so far.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
+
+// Complete implements Provider.
+func (f *Fake) Complete(ctx context.Context, req Request) (*Response, error) {
+	content, err := f.reply(req)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Content:      content,
+		Model:        req.Model,
+		FinishReason: "stop",
+		Usage:        EstimateUsage(req, content),
+	}, nil
+}
+
+// Stream implements Provider. The reply is emitted word by word.
+func (f *Fake) Stream(ctx context.Context, req Request) (<-chan Chunk, error) {
+	content, err := f.reply(req)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	limit, streamErr := f.streamLimit, f.streamErr
+	f.mu.Unlock()
+
+	ch := make(chan Chunk, 16)
+	go func() {
+		defer close(ch)
+		for i, word := range strings.SplitAfter(content, " ") {
+			if i == limit {
+				if streamErr == nil {
+					<-ctx.Done()
+					return
+				}
+				select {
+				case ch <- Chunk{Err: streamErr}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			select {
+			case ch <- Chunk{Content: word}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		usage := EstimateUsage(req, content)
+		select {
+		case ch <- Chunk{Usage: &usage, Model: req.Model}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch, nil
+}
+
+func (f *Fake) reply(req Request) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return "", f.err
+	}
+	if len(f.failNext) > 0 {
+		err := f.failNext[0]
+		f.failNext = f.failNext[1:]
+		return "", err
+	}
+	if len(f.responses) > 0 {
+		content := f.responses[f.next]
+		if f.next < len(f.responses)-1 {
+			f.next++
+		}
+		return content, nil
+	}
+
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			return "fake reply: " + req.Messages[i].Content, nil
+		}
+	}
+	return "fake reply", nil
+}
@@ -0,0 +1,167 @@
+// Package llm provides a provider-agnostic client for chat completion models.
+//
+// All AI features (chat, translation, video analysis) go through a single
+// Client so that retries, timeouts and error classification behave the same
+// everywhere, and so the backing model can be switched between OpenRouter,
+// any OpenAI-compatible server (llama.cpp, Ollama, vLLM) or a deterministic
+// fake without touching the services.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Supported provider names for Config.Provider.
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	ProviderFake       = "fake"
+)
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// System returns a system message.
+func System(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+// User returns a user message.
+func User(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// Assistant returns an assistant message.
+func Assistant(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// Request is a chat completion request.
+type Request struct {
+	// Model overrides the client's default model when set.
+	Model       string
+	Messages    []Message
+	Temperature *float64
+	MaxTokens   int
+}
+
+// Temperature returns a pointer to t, for use in Request.Temperature.
+func Temperature(t float64) *float64 {
+	return &t
+}
+
+// Usage reports token consumption for a completion.
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost,omitempty"`
+}
+
+// Response is a non-streaming chat completion result.
+type Response struct {
+	Content      string
+	Model        string
+	FinishReason string
+	Usage        Usage
+}
+
+// Chunk is a single streaming event. Content carries incremental text;
+// the final chunk may carry Usage. Model is the model that served the
+// request, when the provider reports it. Err is set when the stream fails
+// mid-way, after which the channel is closed.
+type Chunk struct {
+	Content string
+	Usage   *Usage
+	Model   string
+	Err     error
+}
+
+// Provider is implemented by every model backend.
+type Provider interface {
+	// Name identifies the provider in logs and errors.
+	Name() string
+	// Complete performs a single non-streaming completion.
+	Complete(ctx context.Context, req Request) (*Response, error)
+	// Stream starts a streaming completion. Errors that occur before the
+	// first byte is received are returned directly so they can be retried.
+	Stream(ctx context.Context, req Request) (<-chan Chunk, error)
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	Provider   string
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Timeout    time.Duration
+	MaxRetries int
+
+	// StreamIdleTimeout bounds the wait for each chunk of a stream
+	StreamIdleTimeout time.Duration
+}
+
+// New builds a Client from configuration.
+func New(cfg Config, log *zap.Logger) (*Client, error) {
+	var provider Provider
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderOpenRouter:
+		provider = NewOpenRouter(cfg.APIKey, cfg.BaseURL, cfg.Timeout)
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("llm: LLM_BASE_URL is required for provider %q", ProviderOpenAI)
+		}
+		provider = NewOpenAICompatible(ProviderOpenAI, cfg.BaseURL, cfg.APIKey, cfg.Timeout)
+	case ProviderFake:
+		provider = NewFake()
+	default:
+		return nil, fmt.Errorf("llm: unknown provider %q", cfg.Provider)
+	}
+
+	if cfg.APIKey == "" && provider.Name() == ProviderOpenRouter {
+		log.Warn("⚠️  LLM API 密钥未设置",
+			zap.String("provider", provider.Name()),
+			zap.String("环境变量", "LLM_API_KEY / OPENROUTER_API_KEY"),
+			zap.String("影响功能", "聊天、翻译、视频分析等AI功能将无法使用"),
+		)
+	} else {
+		log.Info("✅ LLM 服务已初始化",
+			zap.String("provider", provider.Name()),
+			zap.String("model", cfg.Model),
+			zap.String("api_key", MaskAPIKey(cfg.APIKey)),
+		)
+	}
+
+	return NewClient(provider, ClientOptions{
+		Model:             cfg.Model,
+		Timeout:           cfg.Timeout,
+		StreamIdleTimeout: cfg.StreamIdleTimeout,
+		MaxRetries:        cfg.MaxRetries,
+	}, log), nil
+}
+
+// MaskAPIKey returns a masked version of an API key for logging.
+func MaskAPIKey(key string) string {
+	if key == "" {
+		return "<未设置>"
+	}
+	if len(key) <= 14 {
+		return "***"
+	}
+	return key[:10] + "..." + key[len(key)-4:]
+}
@@ -0,0 +1,304 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const openRouterBaseURL = "https://openrouter.ai/api/v1"
+
+// OpenAICompatible talks to any server implementing the OpenAI
+// /chat/completions API, including OpenRouter, llama.cpp and Ollama.
+type OpenAICompatible struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	requireKey bool
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewOpenAICompatible creates a provider for an OpenAI-compatible base URL
+// (e.g. http://localhost:11434/v1). The API key is optional.
+func NewOpenAICompatible(name, baseURL, apiKey string, timeout time.Duration) *OpenAICompatible {
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+	return &OpenAICompatible{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		headers: map[string]string{},
+		httpClient: &http.Client{
+			// No overall Timeout: streams may legitimately run longer than
+			// a single request. Non-streaming calls are bounded by the
+			// Client via the context deadline.
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: timeout,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+	}
+}
+
+// NewOpenRouter creates a provider for OpenRouter. baseURL may be empty.
+func NewOpenRouter(apiKey, baseURL string, timeout time.Duration) *OpenAICompatible {
+	if baseURL == "" {
+		baseURL = openRouterBaseURL
+	}
+	p := NewOpenAICompatible(ProviderOpenRouter, baseURL, apiKey, timeout)
+	p.requireKey = true
+	p.headers["HTTP-Referer"] = "https://github.com/your-repo/vibe-engineering-playbook"
+	p.headers["X-Title"] = "VIBE Engineering Playbook"
+	return p
+}
+
+// Name implements Provider.
+func (p *OpenAICompatible) Name() string {
+	return p.name
+}
+
+type chatCompletionRequest struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+	// Usage asks OpenRouter to include token counts and cost in the response.
+	Usage *usageOptions `json:"usage,omitempty"`
+}
+
+type usageOptions struct {
+	Include bool `json:"include"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type apiError struct {
+	Message string          `json:"message"`
+	Type    string          `json:"type"`
+	Code    json.RawMessage `json:"code"`
+}
+
+// apiErrorKinds maps the error codes and types that OpenAI-compatible
+// servers put in an error object to error kinds.
+var apiErrorKinds = map[string]error{
+	"invalid_api_key":       ErrAuth,
+	"authentication_error":  ErrAuth,
+	"permission_error":      ErrAuth,
+	"insufficient_quota":    ErrQuota,
+	"rate_limit_exceeded":   ErrRateLimited,
+	"rate_limit_error":      ErrRateLimited,
+	"timeout":               ErrTimeout,
+	"server_error":          ErrUnavailable,
+	"api_error":             ErrUnavailable,
+	"overloaded_error":      ErrUnavailable,
+	"service_unavailable":   ErrUnavailable,
+	"invalid_request_error": ErrBadRequest,
+	"model_not_found":       ErrBadRequest,
+}
+
+// errorFromAPI classifies an error object sent in place of a completion,
+// often with status 200. A numeric code is an HTTP status, as OpenRouter
+// sends; otherwise the code or type is looked up in apiErrorKinds. Anything
+// unrecognised is a bad request, so a deterministic failure such as an
+// unknown model is not retried.
+func errorFromAPI(provider string, apiErr *apiError) *Error {
+	var status int
+	if err := json.Unmarshal(apiErr.Code, &status); err == nil && status > 0 {
+		return errorFromStatus(provider, status, apiErr.Message)
+	}
+
+	var code string
+	_ = json.Unmarshal(apiErr.Code, &code)
+	kind := ErrBadRequest
+	for _, key := range []string{code, apiErr.Type} {
+		if k, ok := apiErrorKinds[key]; ok {
+			kind = k
+			break
+		}
+	}
+	return &Error{Kind: kind, Provider: provider, Message: apiErr.Message}
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage    `json:"usage"`
+	Error *apiError `json:"error"`
+}
+
+// Complete implements Provider.
+func (p *OpenAICompatible) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := p.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errorFromTransport(ctx, p.name, err)
+	}
+
+	var result chatCompletionResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Provider: p.name, Message: fmt.Sprintf("failed to parse response: %v", err)}
+	}
+	if result.Error != nil {
+		return nil, errorFromAPI(p.name, result.Error)
+	}
+	if len(result.Choices) == 0 {
+		return nil, &Error{Kind: ErrEmptyResponse, Provider: p.name}
+	}
+
+	out := &Response{
+		Content: result.Choices[0].Message.Content,
+		Model:   result.Model,
+	}
+	if result.Choices[0].FinishReason != nil {
+		out.FinishReason = *result.Choices[0].FinishReason
+	}
+	if result.Usage != nil {
+		out.Usage = *result.Usage
+	}
+	return out, nil
+}
+
+// Stream implements Provider.
+func (p *OpenAICompatible) Stream(ctx context.Context, req Request) (<-chan Chunk, error) {
+	resp, err := p.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Chunk, 64)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		send := func(c Chunk) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" || strings.HasPrefix(line, ":") || !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				return
+			}
+
+			var event chatCompletionResponse
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+			if event.Error != nil {
+				send(Chunk{Err: errorFromAPI(p.name, event.Error)})
+				return
+			}
+			if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+				if !send(Chunk{Content: event.Choices[0].Delta.Content, Model: event.Model}) {
+					return
+				}
+			}
+			if event.Usage != nil {
+				if !send(Chunk{Usage: event.Usage, Model: event.Model}) {
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			send(Chunk{Err: errorFromTransport(ctx, p.name, err)})
+		}
+	}()
+
+	return ch, nil
+}
+
+// do sends a chat completion request and returns the response when the status is 200.
+func (p *OpenAICompatible) do(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	payload := chatCompletionRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if stream {
+		payload.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if p.name == ProviderOpenRouter {
+		payload.Usage = &usageOptions{Include: true}
+	}
+
+	return p.post(ctx, "/chat/completions", payload, stream)
+}
+
+// post sends a JSON request to path and returns the response when the status is 200.
+func (p *OpenAICompatible) post(ctx context.Context, path string, payload interface{}, stream bool) (*http.Response, error) {
+	if p.requireKey && p.apiKey == "" {
+		return nil, &Error{Kind: ErrNotConfigured, Provider: p.name, Message: "API key is not set (LLM_API_KEY / OPENROUTER_API_KEY)"}
+	}
+
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, &Error{Kind: ErrBadRequest, Provider: p.name, Message: fmt.Sprintf("failed to marshal request: %v", err)}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, &Error{Kind: ErrBadRequest, Provider: p.name, Message: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for k, v := range p.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, errorFromTransport(ctx, p.name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, errorFromStatus(p.name, resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenAICompatibleCompleteErrorBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantKind  error
+		retryable bool
+	}{
+		{
+			name:     "invalid model",
+			body:     `{"error":{"message":"The model 'nope' does not exist","type":"invalid_request_error","code":"model_not_found"}}`,
+			wantKind: ErrBadRequest,
+		},
+		{
+			name:     "unrecognised error",
+			body:     `{"error":{"message":"something odd"}}`,
+			wantKind: ErrBadRequest,
+		},
+		{
+			name:      "rate limited",
+			body:      `{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`,
+			wantKind:  ErrRateLimited,
+			retryable: true,
+		},
+		{
+			name:      "numeric upstream status",
+			body:      `{"error":{"message":"Provider returned error","code":502}}`,
+			wantKind:  ErrUnavailable,
+			retryable: true,
+		},
+		{
+			name:     "numeric auth status",
+			body:     `{"error":{"message":"No auth credentials found","code":401}}`,
+			wantKind: ErrAuth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			provider := NewOpenAICompatible(ProviderOpenAI, srv.URL, "key", 5*time.Second)
+			_, err := provider.Complete(context.Background(), Request{Model: "m", Messages: []Message{User("hi")}})
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("Complete() error = %v, want %v", err, tt.wantKind)
+			}
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token accounting is approximate: providers use different tokenizers and
+// none is available offline, so counts are estimated from per-family ratios
+// measured on mixed English/Chinese text. Estimates err on the high side so
+// prompts built against them stay within the real context window.
+
+// messageOverheadTokens covers the role and separators around each message.
+const messageOverheadTokens = 4
+
+// defaultContextWindow is used for models missing from modelWindows.
+const defaultContextWindow = 8192
+
+// tokenProfile describes how a model family's tokenizer splits text.
+type tokenProfile struct {
+	charsPerToken float64 // latin characters per token
+	tokensPerCJK  float64 // tokens per CJK character
+}
+
+var defaultTokenProfile = tokenProfile{charsPerToken: 3.5, tokensPerCJK: 1.5}
+
+// tokenProfiles maps a model name fragment to its tokenizer profile. The
+// first matching fragment wins.
+var tokenProfiles = []struct {
+	match   string
+	profile tokenProfile
+}{
+	{"gpt-4o", tokenProfile{charsPerToken: 4, tokensPerCJK: 1}},
+	{"gpt-4.1", tokenProfile{charsPerToken: 4, tokensPerCJK: 1}},
+	{"gpt", tokenProfile{charsPerToken: 4, tokensPerCJK: 1.5}},
+	{"gemini", tokenProfile{charsPerToken: 4, tokensPerCJK: 1}},
+	{"claude", tokenProfile{charsPerToken: 3.5, tokensPerCJK: 1.5}},
+	{"qwen", tokenProfile{charsPerToken: 4, tokensPerCJK: 0.8}},
+	{"deepseek", tokenProfile{charsPerToken: 4, tokensPerCJK: 0.8}},
+	{"llama", tokenProfile{charsPerToken: 3.5, tokensPerCJK: 1.5}},
+}
+
+// modelWindows maps a model name fragment to its context window in tokens.
+// The first matching fragment wins, so more specific names come first.
+var modelWindows = []struct {
+	match  string
+	tokens int
+}{
+	{"gemini", 1_000_000},
+	{"gpt-4.1", 1_000_000},
+	{"gpt-4o", 128_000},
+	{"gpt-4-turbo", 128_000},
+	{"gpt-3.5", 16_385},
+	{"gpt-4", 8_192},
+	{"claude", 200_000},
+	{"deepseek", 64_000},
+	{"qwen", 32_768},
+	{"mistral", 32_768},
+	{"llama-3.1", 128_000},
+	{"llama-3", 8_192},
+}
+
+// ContextWindow returns the context window of model in tokens.
+func ContextWindow(model string) int {
+	name := strings.ToLower(model)
+	for _, w := range modelWindows {
+		if strings.Contains(name, w.match) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// CountTokens estimates the number of tokens text uses with model.
+func CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	p := profileFor(model)
+
+	latin, cjk := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			latin++
+		}
+	}
+	return int(float64(latin)/p.charsPerToken+float64(cjk)*p.tokensPerCJK) + 1
+}
+
+// CountMessageTokens estimates the prompt tokens of messages with model.
+func CountMessageTokens(model string, messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += CountTokens(model, m.Content) + messageOverheadTokens
+	}
+	return total
+}
+
+func profileFor(model string) tokenProfile {
+	name := strings.ToLower(model)
+	for _, p := range tokenProfiles {
+		if strings.Contains(name, p.match) {
+			return p.profile
+		}
+	}
+	return defaultTokenProfile
+}
@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Features identify which product area made an LLM call, for accounting.
+const (
+	FeatureChat           = "chat"
+	FeatureEntities       = "entities"
+	FeatureTranslate      = "translate"
+	FeatureDetectLanguage = "detect_language"
+	FeatureVideoMetadata  = "video_metadata"
+	FeatureVideoAnalysis  = "video_analysis"
+	FeatureSummary        = "summary"
+	FeatureChapters       = "chapters"
+	FeatureChatMemory     = "chat_memory"
+	FeaturePunctuation    = "punctuation"
+)
+
+// Tags attribute an LLM call to a user, insight and feature.
+type Tags struct {
+	UserID    uint
+	InsightID uint
+	Feature   string
+}
+
+type tagsKey struct{}
+
+// WithTags returns a context carrying t merged over any tags already present.
+// Zero-valued fields in t do not overwrite existing values.
+func WithTags(ctx context.Context, t Tags) context.Context {
+	merged := TagsFromContext(ctx)
+	if t.UserID != 0 {
+		merged.UserID = t.UserID
+	}
+	if t.InsightID != 0 {
+		merged.InsightID = t.InsightID
+	}
+	if t.Feature != "" {
+		merged.Feature = t.Feature
+	}
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// WithFeature is shorthand for WithTags(ctx, Tags{Feature: feature}).
+func WithFeature(ctx context.Context, feature string) context.Context {
+	return WithTags(ctx, Tags{Feature: feature})
+}
+
+// TagsFromContext returns the tags attached to ctx.
+func TagsFromContext(ctx context.Context) Tags {
+	t, _ := ctx.Value(tagsKey{}).(Tags)
+	return t
+}
+
+// UsageRecord describes one completed LLM call.
+type UsageRecord struct {
+	Tags
+	Provider string
+	Model    string
+	Usage    Usage
+	// Estimated is true when token counts were approximated locally
+	// because the provider did not report usage.
+	Estimated bool
+}
+
+// Meter enforces budgets and records usage. Allow is called before every
+// request; returning an error aborts the call. Record is called after every
+// successful call.
+type Meter interface {
+	Allow(ctx context.Context, tags Tags) error
+	Record(ctx context.Context, rec UsageRecord)
+}
+
+// Price is the cost in USD per million tokens.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Pricing maps model names to prices. The "*" entry, if present, is used
+// for models without an explicit price.
+type Pricing map[string]Price
+
+// ParsePricing parses "model=prompt:completion,..." (USD per million tokens).
+func ParsePricing(s string) (Pricing, error) {
+	pricing := Pricing{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, prices, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pricing entry %q", entry)
+		}
+		promptStr, completionStr, ok := strings.Cut(prices, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid pricing entry %q", entry)
+		}
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price in %q: %w", entry, err)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price in %q: %w", entry, err)
+		}
+		pricing[strings.TrimSpace(model)] = Price{Prompt: prompt, Completion: completion}
+	}
+	return pricing, nil
+}
+
+// Cost estimates the USD cost of usage for model.
+func (p Pricing) Cost(model string, u Usage) float64 {
+	price, ok := p[model]
+	if !ok {
+		price, ok = p["*"]
+		if !ok {
+			return 0
+		}
+	}
+	return (float64(u.PromptTokens)*price.Prompt + float64(u.CompletionTokens)*price.Completion) / 1e6
+}
+
+// EstimateTokens roughly approximates the token count of text: about four
+// bytes per token for Latin text and one token per CJK character.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return ascii/4 + other + 1
+}
+
+// EstimateUsage approximates usage for a request and its completion.
+func EstimateUsage(req Request, completion string) Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += EstimateTokens(m.Content) + 4
+	}
+	out := EstimateTokens(completion)
+	return Usage{PromptTokens: prompt, CompletionTokens: out, TotalTokens: prompt + out}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 )
@@ -76,6 +77,8 @@ func Auth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc
 		// Set user information in context
 		c.Set(UserIDKey, user.ID)
 		c.Set(UserKey, user)
+		// Attribute any LLM calls made while serving this request to the user
+		c.Request = c.Request.WithContext(llm.WithTags(c.Request.Context(), llm.Tags{UserID: user.ID}))
 
 		log.Debug("User authenticated",
 			zap.String("request_id", requestID),
@@ -109,6 +112,7 @@ func OptionalAuth(userRepo *repository.UserRepository, log *zap.Logger) gin.Hand
 		if err == nil {
 			c.Set(UserIDKey, user.ID)
 			c.Set(UserKey, user)
+			c.Request = c.Request.WithContext(llm.WithTags(c.Request.Context(), llm.Tags{UserID: user.ID}))
 		}
 
 		c.Next()
@@ -144,3 +148,35 @@ func MustGetUserID(c *gin.Context) uint {
 	}
 	return userID
 }
+
+// RequireAdmin returns a Gin middleware that only allows users whose email is
+// in adminEmails. It must run after Auth.
+func RequireAdmin(adminEmails []string, log *zap.Logger) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			allowed[email] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetString(RequestIDKey)
+
+		user, ok := GetUser(c)
+		if !ok || !allowed[strings.ToLower(user.Email)] {
+			log.Warn("Admin access denied",
+				zap.String("request_id", requestID),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Code:      models.ErrForbidden,
+				Message:   "Admin access required.",
+				RequestID: requestID,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 // Entity represents a detected entity in the content.
 type Entity struct {
@@ -16,14 +21,52 @@ type Suggestion struct {
 	Prompt string `json:"prompt"` // suggested prompt
 }
 
+// CitationType identifies what a chat citation points to.
+type CitationType string
+
+const (
+	CitationTypeTranscript CitationType = "transcript"
+	CitationTypeHighlight  CitationType = "highlight"
+)
+
+// ChatCitation links part of an assistant answer to a transcript position or
+// to one of the user's highlights.
+type ChatCitation struct {
+	Type        CitationType `json:"type"`
+	Seconds     int          `json:"seconds"`                // transcript position in seconds
+	Timestamp   string       `json:"timestamp,omitempty"`    // e.g., "12:34"
+	Quote       string       `json:"quote"`                  // cited transcript or highlight text
+	HighlightID *uint        `json:"highlight_id,omitempty"` // set for highlight citations
+}
+
+// ParseCitations decodes ChatMessage.Citations.
+func ParseCitations(data datatypes.JSON) ([]ChatCitation, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var citations []ChatCitation
+	if err := json.Unmarshal(data, &citations); err != nil {
+		return nil, err
+	}
+	return citations, nil
+}
+
+// Stream event types, sent as the SSE event name.
+const (
+	ChatEventMessage   = "message"
+	ChatEventCitations = "citations"
+	ChatEventError     = "error"
+)
+
 // Request/Response DTOs
 
 // ChatMessageResponse represents a single message in the response.
 type ChatMessageResponse struct {
-	ID        uint      `json:"id"`
-	Role      string    `json:"role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint           `json:"id"`
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Citations []ChatCitation `json:"citations,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 // ChatHistoryResponse represents the chat history response.
@@ -33,10 +76,12 @@ type ChatHistoryResponse struct {
 
 // ChatStreamEvent represents a streaming chat event.
 type ChatStreamEvent struct {
-	Role      string `json:"role"`
-	Content   string `json:"content"`
-	Done      bool   `json:"done"`
-	MessageID *uint  `json:"message_id,omitempty"`
+	Type      string         `json:"type"` // ChatEventMessage, ChatEventCitations or ChatEventError
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Citations []ChatCitation `json:"citations,omitempty"`
+	Done      bool           `json:"done"`
+	MessageID *uint          `json:"message_id,omitempty"`
 }
 
 // AnalyzeEntitiesResponse represents the entity analysis response.
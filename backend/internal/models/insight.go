@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/datatypes"
@@ -14,8 +15,14 @@ const (
 	SourceTypeYouTube SourceType = "youtube"
 	SourceTypeTwitter SourceType = "twitter"
 	SourceTypePodcast SourceType = "podcast"
+	SourceTypeArticle SourceType = "article" // web page such as a blog post or newsletter
+	SourceTypeUpload  SourceType = "upload"  // transcript or text file uploaded by the user
 )
 
+// UploadSourcePrefix starts the SourceURL of uploaded insights, followed by
+// the file name.
+const UploadSourcePrefix = "upload://"
+
 // InsightStatus represents the processing status of an insight.
 type InsightStatus string
 
@@ -35,6 +42,7 @@ type Insight struct {
 	SourceType SourceType `json:"source_type" gorm:"type:varchar(20);not null"`
 	SourceURL  string     `json:"source_url" gorm:"type:varchar(2000);not null"`
 	SourceID   string     `json:"source_id" gorm:"type:varchar(100);index"` // video_id, tweet_id, etc.
+	BatchID    *uint      `json:"batch_id,omitempty" gorm:"index"`          // InsightBatch the insight was imported with
 
 	// Content metadata
 	Title        string     `json:"title" gorm:"type:varchar(500)"`
@@ -53,12 +61,25 @@ type Insight struct {
 	TransContent string `json:"trans_content" gorm:"type:text"` // Translated content
 
 	// Transcripts with timestamps (for video/audio)
-	Transcripts datatypes.JSON `json:"transcripts" gorm:"type:jsonb"` // Array of {timestamp, seconds, text}
+	Transcripts        datatypes.JSON `json:"transcripts" gorm:"type:jsonb"`               // Array of {timestamp, seconds, text}
+	TranscriptLanguage string         `json:"transcript_language" gorm:"type:varchar(20)"` // caption track shown in Transcripts
+	TranscriptKind     CaptionKind    `json:"transcript_kind" gorm:"type:varchar(10)"`     // manual | auto
+
+	// Source-specific details, e.g. the tweets of a thread with their media
+	SourceMeta datatypes.JSON `json:"source_meta,omitempty" gorm:"type:jsonb"`
 
 	// Processing status
 	Status       InsightStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	ErrorMessage string        `json:"error_message,omitempty" gorm:"type:text"`
 
+	// Versioning: every processing run is stored as an InsightVersion
+	CurrentVersion int  `json:"current_version" gorm:"default:0"`    // 0 = processed before versioning
+	VersionPinned  bool `json:"version_pinned" gorm:"default:false"` // reprocessing keeps CurrentVersion current
+
+	// When the search documents were last built. Set only by the search
+	// repository, so saving a stale copy of the insight cannot clear it.
+	SearchIndexedAt *time.Time `json:"-" gorm:"<-:false"`
+
 	// Sharing
 	ShareToken    *string    `json:"share_token,omitempty" gorm:"type:varchar(64);uniqueIndex"`
 	SharePassword string     `json:"-" gorm:"type:varchar(255)"`             // bcrypt hash, never exposed in JSON
@@ -67,8 +88,9 @@ type Insight struct {
 	SharedAt      *time.Time `json:"shared_at,omitempty"`
 
 	// Associations
-	Highlights   []Highlight   `json:"highlights,omitempty" gorm:"foreignKey:InsightID"`
-	ChatMessages []ChatMessage `json:"chat_messages,omitempty" gorm:"foreignKey:InsightID"`
+	Highlights   []Highlight      `json:"highlights,omitempty" gorm:"foreignKey:InsightID"`
+	Chapters     []InsightChapter `json:"chapters,omitempty" gorm:"foreignKey:InsightID"`
+	ChatMessages []ChatMessage    `json:"chat_messages,omitempty" gorm:"foreignKey:InsightID"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -96,6 +118,46 @@ type TranscriptItem struct {
 	TranslatedText string `json:"translated_text,omitempty"` // translated text (if available)
 }
 
+// InsightKeyPoint represents an AI generated key point anchored to the
+// transcript position it came from.
+type InsightKeyPoint struct {
+	Text      string `json:"text"`
+	Timestamp string `json:"timestamp,omitempty"` // e.g., "05:12"
+	Seconds   int    `json:"seconds"`             // time in seconds
+}
+
+// ParseKeyPoints decodes Insight.KeyPoints. Older insights stored key points
+// as a plain string array; those are returned with Seconds set to 0.
+func ParseKeyPoints(data datatypes.JSON) ([]InsightKeyPoint, error) {
+	if len(data) == 0 {
+		return []InsightKeyPoint{}, nil
+	}
+
+	var keyPoints []InsightKeyPoint
+	if err := json.Unmarshal(data, &keyPoints); err == nil {
+		return keyPoints, nil
+	}
+
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return nil, err
+	}
+	keyPoints = make([]InsightKeyPoint, len(texts))
+	for i, text := range texts {
+		keyPoints[i] = InsightKeyPoint{Text: text}
+	}
+	return keyPoints, nil
+}
+
+// KeyPointTexts returns the text of each key point.
+func KeyPointTexts(keyPoints []InsightKeyPoint) []string {
+	texts := make([]string, len(keyPoints))
+	for i, kp := range keyPoints {
+		texts[i] = kp.Text
+	}
+	return texts
+}
+
 // Highlight represents a user-created highlight/annotation on content.
 type Highlight struct {
 	ID        uint `json:"id" gorm:"primaryKey"`
@@ -107,6 +169,7 @@ type Highlight struct {
 	EndOffset   int    `json:"end_offset" gorm:"not null"`                        // End position in content
 	Color       string `json:"color" gorm:"type:varchar(20);default:'yellow'"`    // Highlight color
 	Note        string `json:"note,omitempty" gorm:"type:text"`                   // User's note on the highlight
+	Version     int    `json:"version" gorm:"default:0"`                          // Insight version the offsets refer to
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -117,6 +180,182 @@ func (Highlight) TableName() string {
 	return "highlights"
 }
 
+// ChapterSource describes where an insight chapter came from.
+type ChapterSource string
+
+const (
+	ChapterSourceDescription ChapterSource = "description" // creator timestamps in the video description
+	ChapterSourceGenerated   ChapterSource = "generated"   // LLM detected topic shifts in the transcript
+)
+
+// InsightChapter represents a titled section of an insight's content.
+type InsightChapter struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"index;not null"`
+
+	Title        string        `json:"title" gorm:"type:varchar(500);not null"`
+	Summary      string        `json:"summary" gorm:"type:text"`
+	Timestamp    string        `json:"timestamp" gorm:"type:varchar(20)"` // e.g., "05:12"
+	StartSeconds int           `json:"start_seconds" gorm:"not null"`
+	EndSeconds   int           `json:"end_seconds" gorm:"not null"`
+	Source       ChapterSource `json:"source" gorm:"type:varchar(20);not null"`
+	OrderIndex   int           `json:"order_index"` // for maintaining order
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for InsightChapter model.
+func (InsightChapter) TableName() string {
+	return "insight_chapters"
+}
+
+// TranscriptChunk is a window of an insight's transcript indexed for
+// retrieval-grounded chat.
+type TranscriptChunk struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"index;not null"`
+
+	ChunkIndex     int            `json:"chunk_index" gorm:"not null"`
+	StartSeconds   int            `json:"start_seconds" gorm:"not null"`
+	EndSeconds     int            `json:"end_seconds" gorm:"not null"`
+	Text           string         `json:"text" gorm:"type:text;not null"`
+	Embedding      datatypes.JSON `json:"-" gorm:"type:jsonb"`        // []float32, empty without an embedder
+	EmbeddingModel string         `json:"-" gorm:"type:varchar(100)"` // model that produced Embedding
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for TranscriptChunk model.
+func (TranscriptChunk) TableName() string {
+	return "transcript_chunks"
+}
+
+// InsightTranscript is one caption track of an insight's video, stored so
+// the insight can switch transcript language without fetching again.
+type InsightTranscript struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"not null;uniqueIndex:idx_insight_transcripts_insight_lang_kind"`
+
+	Language   string         `json:"language" gorm:"type:varchar(20);not null;uniqueIndex:idx_insight_transcripts_insight_lang_kind"`
+	Kind       CaptionKind    `json:"kind" gorm:"type:varchar(10);not null;uniqueIndex:idx_insight_transcripts_insight_lang_kind"`
+	Name       string         `json:"name" gorm:"type:varchar(255)"`
+	Items      datatypes.JSON `json:"items" gorm:"type:jsonb"` // []TranscriptItem without translations
+	OrderIndex int            `json:"order_index"`             // preference order, 0 = most preferred
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for InsightTranscript model.
+func (InsightTranscript) TableName() string {
+	return "insight_transcripts"
+}
+
+// InsightTranscriptTrack describes an available transcript track of an insight.
+type InsightTranscriptTrack struct {
+	Language string      `json:"language"`
+	Kind     CaptionKind `json:"kind"`
+	Name     string      `json:"name"`
+	Segments int         `json:"segments"`
+	Active   bool        `json:"active"` // currently shown in Insight.Transcripts
+}
+
+// SwitchTranscriptRequest selects the transcript track an insight shows.
+type SwitchTranscriptRequest struct {
+	Language string      `json:"language" binding:"required,max=20"`
+	Kind     CaptionKind `json:"kind" binding:"omitempty,oneof=manual auto"` // empty picks manual if available
+}
+
+// InsightVersion is the artifact set produced by one processing run of an
+// insight. Reprocessing adds a version instead of overwriting the last one.
+type InsightVersion struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"not null;uniqueIndex:idx_insight_versions_insight_version"`
+	Version   int  `json:"version" gorm:"not null;uniqueIndex:idx_insight_versions_insight_version"` // 1, 2, ... per insight
+
+	// Run metadata
+	ProcessedAt        time.Time `json:"processed_at"`
+	TranscriptProvider string    `json:"transcript_provider" gorm:"type:varchar(50)"` // e.g., innertube, stt
+	LLMProvider        string    `json:"llm_provider" gorm:"type:varchar(50)"`
+	Model              string    `json:"model" gorm:"type:varchar(100)"`
+
+	// Artifacts, as in Insight
+	Title              string         `json:"title" gorm:"type:varchar(500)"`
+	Author             string         `json:"author" gorm:"type:varchar(255)"`
+	ThumbnailURL       string         `json:"thumbnail_url" gorm:"type:varchar(1000)"`
+	Duration           int            `json:"duration"`
+	Summary            string         `json:"summary" gorm:"type:text"`
+	KeyPoints          datatypes.JSON `json:"key_points" gorm:"type:jsonb"`
+	TargetLang         string         `json:"target_lang" gorm:"type:varchar(10)"`
+	RawContent         string         `json:"raw_content" gorm:"type:text"`
+	TransContent       string         `json:"trans_content" gorm:"type:text"`
+	Transcripts        datatypes.JSON `json:"transcripts" gorm:"type:jsonb"`
+	TranscriptLanguage string         `json:"transcript_language" gorm:"type:varchar(20)"`
+	TranscriptKind     CaptionKind    `json:"transcript_kind" gorm:"type:varchar(10)"`
+	Chapters           datatypes.JSON `json:"chapters" gorm:"type:jsonb"` // []InsightChapter
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for InsightVersion model.
+func (InsightVersion) TableName() string {
+	return "insight_versions"
+}
+
+// InsightVersionListItem describes a version without its artifacts.
+type InsightVersionListItem struct {
+	Version            int         `json:"version"`
+	ProcessedAt        time.Time   `json:"processed_at"`
+	TranscriptProvider string      `json:"transcript_provider"`
+	LLMProvider        string      `json:"llm_provider"`
+	Model              string      `json:"model"`
+	Title              string      `json:"title"`
+	TranscriptLanguage string      `json:"transcript_language"`
+	TranscriptKind     CaptionKind `json:"transcript_kind"`
+	Current            bool        `json:"current"`
+	Pinned             bool        `json:"pinned"`
+}
+
+// InsightFieldChange is a text field that differs between two versions.
+type InsightFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// InsightListChange lists the entries added and removed between two versions.
+type InsightListChange struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// TranscriptLineChange is a transcript item inserted or deleted between two versions.
+type TranscriptLineChange struct {
+	Op             string `json:"op"` // insert | delete
+	Timestamp      string `json:"timestamp"`
+	Seconds        int    `json:"seconds"`
+	Text           string `json:"text"`
+	TranslatedText string `json:"translated_text,omitempty"`
+}
+
+// TranscriptDiff summarizes how the transcript changed between two versions.
+type TranscriptDiff struct {
+	Unchanged int                    `json:"unchanged"`
+	Inserted  int                    `json:"inserted"`
+	Deleted   int                    `json:"deleted"`
+	Changes   []TranscriptLineChange `json:"changes"`
+	Truncated bool                   `json:"truncated"` // Changes holds only the first changes
+}
+
+// InsightVersionDiff compares the artifacts of two versions of an insight.
+type InsightVersionDiff struct {
+	From       int                  `json:"from"`
+	To         int                  `json:"to"`
+	Fields     []InsightFieldChange `json:"fields"`
+	KeyPoints  InsightListChange    `json:"key_points"`
+	Chapters   InsightListChange    `json:"chapters"` // by title
+	Transcript TranscriptDiff       `json:"transcript"`
+}
+
 // ChatMessage represents a message in AI conversation about an insight.
 type ChatMessage struct {
 	ID        uint `json:"id" gorm:"primaryKey"`
@@ -129,6 +368,9 @@ type ChatMessage struct {
 	// Optional: link to a specific highlight
 	HighlightID *uint `json:"highlight_id,omitempty" gorm:"index"`
 
+	// Sources cited by an assistant answer, as an array of ChatCitation
+	Citations datatypes.JSON `json:"citations,omitempty" gorm:"type:jsonb"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -137,12 +379,44 @@ func (ChatMessage) TableName() string {
 	return "chat_messages"
 }
 
+// ChatMemory is the rolling summary of an insight's older chat turns, which
+// stands in for them once they no longer fit in the model's context.
+type ChatMemory struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"uniqueIndex;not null"`
+
+	Summary          string `json:"summary" gorm:"type:text;not null"`
+	CoveredMessageID uint   `json:"covered_message_id" gorm:"not null"` // last chat message folded into Summary
+	CoveredMessages  int    `json:"covered_messages" gorm:"not null"`   // number of messages folded in so far
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ChatMemory model.
+func (ChatMemory) TableName() string {
+	return "chat_memories"
+}
+
 // Request/Response DTOs
 
 // CreateInsightRequest represents the request to create a new insight.
 type CreateInsightRequest struct {
 	SourceURL  string `json:"source_url" binding:"required,url"`
 	TargetLang string `json:"target_lang" binding:"omitempty,min=2,max=10"`
+	// SourceType overrides detection from the URL, e.g. "podcast" for an
+	// episode page on a show's own website
+	SourceType SourceType `json:"source_type" binding:"omitempty,oneof=youtube twitter podcast article"`
+	// EpisodeGUID picks an episode when SourceURL is a podcast RSS feed
+	EpisodeGUID string `json:"episode_guid" binding:"omitempty,max=2000"`
+}
+
+// CreateUploadInsightRequest holds the form fields sent with an uploaded
+// transcript or text file.
+type CreateUploadInsightRequest struct {
+	Title      string `form:"title" binding:"max=500"`                      // defaults to the first Markdown heading or the file name
+	Language   string `form:"language" binding:"omitempty,max=20"`          // language of the file, e.g. "en"
+	TargetLang string `form:"target_lang" binding:"omitempty,min=2,max=10"` // defaults to "zh"
 }
 
 // CreateInsightResponse represents the response after creating an insight.
@@ -173,23 +447,31 @@ type InsightListResponse struct {
 
 // InsightDetailResponse represents the full insight detail response.
 type InsightDetailResponse struct {
-	ID           uint             `json:"id"`
-	SourceType   SourceType       `json:"source_type"`
-	SourceURL    string           `json:"source_url"`
-	SourceID     string           `json:"source_id"`
-	Title        string           `json:"title"`
-	Author       string           `json:"author"`
-	ThumbnailURL string           `json:"thumbnail_url"`
-	Duration     int              `json:"duration"`
-	PublishedAt  *time.Time       `json:"published_at,omitempty"`
-	Summary      string           `json:"summary"`
-	KeyPoints    []string         `json:"key_points"`
-	RawContent   string           `json:"raw_content,omitempty"`
-	TransContent string           `json:"trans_content,omitempty"`
-	Transcripts  []TranscriptItem `json:"transcripts,omitempty"`
-	Status       InsightStatus    `json:"status"`
-	Highlights   []Highlight      `json:"highlights,omitempty"`
-	CreatedAt    time.Time        `json:"created_at"`
+	ID                 uint              `json:"id"`
+	SourceType         SourceType        `json:"source_type"`
+	SourceURL          string            `json:"source_url"`
+	SourceID           string            `json:"source_id"`
+	Title              string            `json:"title"`
+	Author             string            `json:"author"`
+	ThumbnailURL       string            `json:"thumbnail_url"`
+	Duration           int               `json:"duration"`
+	PublishedAt        *time.Time        `json:"published_at,omitempty"`
+	Summary            string            `json:"summary"`
+	KeyPoints          []string          `json:"key_points"`
+	// KeyPointDetails carries the transcript position of each key point
+	KeyPointDetails    []InsightKeyPoint `json:"key_point_details"`
+	RawContent         string            `json:"raw_content,omitempty"`
+	TransContent       string            `json:"trans_content,omitempty"`
+	Transcripts        []TranscriptItem  `json:"transcripts,omitempty"`
+	TranscriptLanguage string            `json:"transcript_language,omitempty"`
+	TranscriptKind     CaptionKind       `json:"transcript_kind,omitempty"`
+	SourceMeta         json.RawMessage   `json:"source_meta,omitempty"`
+	Status             InsightStatus     `json:"status"`
+	CurrentVersion     int               `json:"current_version"`
+	VersionPinned      bool              `json:"version_pinned"`
+	Highlights         []Highlight       `json:"highlights,omitempty"`
+	Chapters           []InsightChapter  `json:"chapters"`
+	CreatedAt          time.Time         `json:"created_at"`
 }
 
 // CreateHighlightRequest represents the request to create a highlight.
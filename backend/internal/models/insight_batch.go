@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BatchSourceKind identifies what an import batch was created from.
+type BatchSourceKind string
+
+const (
+	BatchSourcePlaylist BatchSourceKind = "playlist" // YouTube playlist
+	BatchSourceChannel  BatchSourceKind = "channel"  // uploads of a YouTube channel
+	BatchSourceURLs     BatchSourceKind = "urls"     // list of source URLs
+)
+
+// Reasons an import skips an item.
+const (
+	BatchSkipDuplicate   = "duplicate"   // the user already has an insight for it
+	BatchSkipUnavailable = "unavailable" // private or deleted video
+	BatchSkipInvalid     = "invalid"     // URL not recognised
+)
+
+// InsightBatch records one bulk import. Its insights point back to it with
+// Insight.BatchID; progress is counted from their statuses.
+type InsightBatch struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	UserID     uint            `json:"user_id" gorm:"index;not null"`
+	SourceKind BatchSourceKind `json:"source_kind" gorm:"type:varchar(20);not null"`
+	SourceRef  string          `json:"source_ref" gorm:"type:varchar(255)"` // playlist or channel ID
+	Title      string          `json:"title" gorm:"type:varchar(500)"`
+	TargetLang string          `json:"target_lang" gorm:"type:varchar(10)"`
+	Requested  int             `json:"requested"`                 // items found in the source
+	Skipped    datatypes.JSON  `json:"skipped" gorm:"type:jsonb"` // []InsightBatchSkip
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for InsightBatch model.
+func (InsightBatch) TableName() string {
+	return "insight_batches"
+}
+
+// InsightBatchSkip is an item of a batch that did not get a new insight.
+type InsightBatchSkip struct {
+	SourceURL string `json:"source_url"`
+	Title     string `json:"title,omitempty"`
+	Reason    string `json:"reason"`
+	InsightID *uint  `json:"insight_id,omitempty"` // the existing insight, for duplicates
+}
+
+// ImportInsightsRequest asks for a bulk import. Exactly one of PlaylistID,
+// ChannelID and URLs is given.
+type ImportInsightsRequest struct {
+	PlaylistID string   `json:"playlist_id" binding:"omitempty,max=100"`
+	ChannelID  string   `json:"channel_id" binding:"omitempty,max=100"`
+	URLs       []string `json:"urls" binding:"omitempty,max=200,dive,url,max=2000"`
+	TargetLang string   `json:"target_lang" binding:"omitempty,min=2,max=10"`
+	// Limit caps the number of videos taken from a playlist or channel,
+	// newest first for channels
+	Limit int `json:"limit" binding:"omitempty,min=1"`
+}
+
+// InsightBatchProgress counts a batch's insights by status.
+type InsightBatchProgress struct {
+	Total      int  `json:"total"`
+	Queued     int  `json:"queued"`
+	Processing int  `json:"processing"`
+	Completed  int  `json:"completed"`
+	Failed     int  `json:"failed"`
+	Done       bool `json:"done"` // nothing left queued or processing
+}
+
+// InsightBatchItem is one insight of a batch.
+type InsightBatchItem struct {
+	ID           uint          `json:"id"`
+	SourceURL    string        `json:"source_url"`
+	Title        string        `json:"title"`
+	ThumbnailURL string        `json:"thumbnail_url"`
+	Status       InsightStatus `json:"status"`
+	ErrorMessage string        `json:"error_message,omitempty"`
+}
+
+// InsightBatchResponse describes a batch with its progress.
+type InsightBatchResponse struct {
+	ID         uint                 `json:"id"`
+	SourceKind BatchSourceKind      `json:"source_kind"`
+	SourceRef  string               `json:"source_ref,omitempty"`
+	Title      string               `json:"title"`
+	TargetLang string               `json:"target_lang"`
+	Requested  int                  `json:"requested"`
+	Progress   InsightBatchProgress `json:"progress"`
+	Skipped    []InsightBatchSkip   `json:"skipped"`
+	Items      []InsightBatchItem   `json:"items,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
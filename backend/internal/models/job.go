@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// JobStatus represents the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusDead      JobStatus = "dead"
+)
+
+// Job is a durable unit of background work. Workers lease jobs by setting
+// LockedBy/LockedUntil; a job whose lease expires is picked up again.
+type Job struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Queue       string         `json:"queue" gorm:"type:varchar(50);not null;index:idx_jobs_claim,priority:1"`
+	Payload     datatypes.JSON `json:"payload" gorm:"type:jsonb"`
+	Status      JobStatus      `json:"status" gorm:"type:varchar(20);not null;default:'queued';index:idx_jobs_claim,priority:2"`
+	RunAt       time.Time      `json:"run_at" gorm:"not null;index:idx_jobs_claim,priority:3"`
+	Attempts    int            `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int            `json:"max_attempts" gorm:"not null;default:5"`
+	// DedupeKey prevents enqueueing the same work twice while a job is active.
+	DedupeKey   *string    `json:"dedupe_key,omitempty" gorm:"type:varchar(100);uniqueIndex:idx_jobs_dedupe_active,where:status IN ('queued'\\,'running')"`
+	LockedBy    string     `json:"locked_by,omitempty" gorm:"type:varchar(100)"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Job model.
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// QueueStats summarizes one queue for the admin view.
+type QueueStats struct {
+	Queue            string  `json:"queue"`
+	Queued           int64   `json:"queued"`
+	Running          int64   `json:"running"`
+	Completed        int64   `json:"completed"`
+	Dead             int64   `json:"dead"`
+	OldestQueuedSecs float64 `json:"oldest_queued_secs"`
+}
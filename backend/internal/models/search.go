@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// SearchDocumentKind identifies which part of an insight a search document indexes.
+type SearchDocumentKind string
+
+const (
+	SearchKindTitle       SearchDocumentKind = "title"
+	SearchKindSummary     SearchDocumentKind = "summary"
+	SearchKindContent     SearchDocumentKind = "content"     // raw content of insights without a transcript
+	SearchKindSegment     SearchDocumentKind = "segment"     // one transcript item
+	SearchKindTranslation SearchDocumentKind = "translation" // translated text of one transcript item
+)
+
+// Weight returns the Postgres full-text weight of the kind, so title matches
+// rank above summary matches, which rank above transcript matches.
+func (k SearchDocumentKind) Weight() string {
+	switch k {
+	case SearchKindTitle:
+		return "A"
+	case SearchKindSummary:
+		return "B"
+	case SearchKindSegment, SearchKindTranslation:
+		return "C"
+	default:
+		return "D"
+	}
+}
+
+// SearchDocument is one full-text indexed piece of an insight: its title,
+// summary, raw content or a single transcript item.
+type SearchDocument struct {
+	ID           uint               `json:"id" gorm:"primaryKey"`
+	InsightID    uint               `json:"insight_id" gorm:"index;not null"`
+	UserID       uint               `json:"user_id" gorm:"index;not null"`
+	Kind         SearchDocumentKind `json:"kind" gorm:"type:varchar(20);not null"`
+	SegmentIndex int                `json:"segment_index"` // position in Insight.Transcripts, 0 for other kinds
+	Seconds      int                `json:"seconds"`
+	Text         string             `json:"text" gorm:"type:text;not null"`
+	Config       string             `json:"config" gorm:"type:varchar(32);not null"` // Postgres text search configuration
+	SearchVector string             `json:"-" gorm:"type:tsvector;index:idx_search_documents_vector,type:gin"`
+	CreatedAt    time.Time          `json:"created_at"`
+
+	// Terms is the text the vector is built from: Text with CJK runs split
+	// into bigrams, since Postgres has no built-in Chinese parser.
+	Terms string `json:"-" gorm:"-"`
+}
+
+// TableName returns the table name for SearchDocument model.
+func (SearchDocument) TableName() string {
+	return "search_documents"
+}
+
+// SearchHit is a matching part of an insight.
+type SearchHit struct {
+	Kind      SearchDocumentKind `json:"kind"`
+	Text      string             `json:"text"`
+	Snippet   string             `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+	Seconds   int                `json:"seconds"`
+	Timestamp string             `json:"timestamp,omitempty"` // e.g., "05:12", transcript hits only
+	Score     float64            `json:"score"`
+}
+
+// SearchResult is an insight matching a search, with its best hits.
+type SearchResult struct {
+	Insight InsightListItem `json:"insight"`
+	Score   float64         `json:"score"`
+	Matches int64           `json:"matches"` // all matching parts, not only those in Hits
+	Hits    []SearchHit     `json:"hits"`
+}
+
+// SearchResponse is the response of a library search.
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	Total   int64          `json:"total"` // matching insights
+}
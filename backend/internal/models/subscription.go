@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// SubscriptionVideoOutcome records what a subscription did with an upload.
+type SubscriptionVideoOutcome string
+
+const (
+	SubscriptionVideoCreated   SubscriptionVideoOutcome = "created"   // an insight was created
+	SubscriptionVideoFiltered  SubscriptionVideoOutcome = "filtered"  // rejected by the subscription's filters
+	SubscriptionVideoDuplicate SubscriptionVideoOutcome = "duplicate" // the user already had an insight for it
+	SubscriptionVideoExisting  SubscriptionVideoOutcome = "existing"  // uploaded before the subscription
+)
+
+// Reasons a subscription filters out an upload.
+const (
+	SubscriptionFilterKeywords = "keywords"  // no keyword in title or description
+	SubscriptionFilterDuration = "too_short" // shorter than MinDurationSeconds
+	SubscriptionFilterShorts   = "short"     // a Short, with SkipShorts set
+)
+
+// ChannelFeed is a YouTube channel followed by at least one subscription.
+// It holds the polling and WebSub state shared by its subscribers.
+type ChannelFeed struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	ChannelID     string     `json:"channel_id" gorm:"type:varchar(30);uniqueIndex;not null"`
+	Title         string     `json:"title" gorm:"type:varchar(255)"`
+	LastPolledAt  *time.Time `json:"last_polled_at"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	PushSecret    string     `json:"-" gorm:"type:varchar(64)"` // HMAC key of hub notifications
+	PushExpiresAt *time.Time `json:"push_expires_at"`           // end of the confirmed hub lease
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for ChannelFeed model.
+func (ChannelFeed) TableName() string {
+	return "channel_feeds"
+}
+
+// ChannelSubscription makes new uploads of a channel into insights for a
+// user, in the user's default target language, if they pass its filters.
+type ChannelSubscription struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"uniqueIndex:idx_channel_subscriptions_user_channel;not null"`
+	ChannelID    string `json:"channel_id" gorm:"type:varchar(30);uniqueIndex:idx_channel_subscriptions_user_channel;index;not null"`
+	ChannelTitle string `json:"channel_title" gorm:"type:varchar(255)"`
+	Handle       string `json:"handle,omitempty" gorm:"type:varchar(100)"` // @handle the user subscribed with
+
+	// Filters. Keywords match title or description, case-insensitively; an
+	// upload needs one of them. Zero values disable a filter.
+	Keywords           datatypes.JSON `json:"keywords" gorm:"type:jsonb"` // []string
+	MinDurationSeconds int            `json:"min_duration_seconds"`
+	SkipShorts         bool           `json:"skip_shorts"`
+
+	Paused    bool      `json:"paused"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ChannelSubscription model.
+func (ChannelSubscription) TableName() string {
+	return "channel_subscriptions"
+}
+
+// SubscriptionVideo is an upload a subscription has seen. Its unique key
+// makes each upload count once, however many polls or notifications
+// report it.
+type SubscriptionVideo struct {
+	ID             uint                     `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint                     `json:"subscription_id" gorm:"uniqueIndex:idx_subscription_videos_subscription_video;not null"`
+	VideoID        string                   `json:"video_id" gorm:"type:varchar(20);uniqueIndex:idx_subscription_videos_subscription_video;not null"`
+	Title          string                   `json:"title" gorm:"type:varchar(500)"`
+	PublishedAt    *time.Time               `json:"published_at"`
+	Outcome        SubscriptionVideoOutcome `json:"outcome" gorm:"type:varchar(20);not null"`
+	Reason         string                   `json:"reason,omitempty" gorm:"type:varchar(50)"` // filter that rejected it
+	InsightID      *uint                    `json:"insight_id,omitempty"`                     // created or duplicate insight
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+// TableName returns the table name for SubscriptionVideo model.
+func (SubscriptionVideo) TableName() string {
+	return "subscription_videos"
+}
+
+// CreateSubscriptionRequest subscribes to a channel.
+type CreateSubscriptionRequest struct {
+	// Channel is a channel ID, an @handle or a channel URL
+	Channel            string   `json:"channel" binding:"required,max=200"`
+	Keywords           []string `json:"keywords" binding:"omitempty,max=20,dive,min=1,max=100"`
+	MinDurationSeconds int      `json:"min_duration_seconds" binding:"omitempty,min=0"`
+	SkipShorts         bool     `json:"skip_shorts"`
+}
+
+// UpdateSubscriptionRequest changes a subscription's filters or pauses it.
+type UpdateSubscriptionRequest struct {
+	Keywords           *[]string `json:"keywords" binding:"omitempty,max=20,dive,min=1,max=100"`
+	MinDurationSeconds *int      `json:"min_duration_seconds" binding:"omitempty,min=0"`
+	SkipShorts         *bool     `json:"skip_shorts"`
+	Paused             *bool     `json:"paused"`
+}
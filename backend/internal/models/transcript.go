@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CaptionKind distinguishes creator-uploaded captions from speech recognition.
+type CaptionKind string
+
+const (
+	CaptionKindManual CaptionKind = "manual"
+	CaptionKindAuto   CaptionKind = "auto"
+)
+
+// CaptionSegment is one timed line of a caption track. Every transcript
+// provider returns segments in this form.
+type CaptionSegment struct {
+	StartMs int    `json:"start_ms"`
+	EndMs   int    `json:"end_ms"`
+	Text    string `json:"text"`
+}
+
+// CaptionTrack is the captions of a video in one language.
+type CaptionTrack struct {
+	Language string           `json:"language"`
+	Name     string           `json:"name"`
+	Kind     CaptionKind      `json:"kind"`
+	Segments []CaptionSegment `json:"segments"`
+	// RawSegments holds the captions as fetched once Segments has been
+	// regrouped into sentences; nil while Segments is still as fetched.
+	RawSegments []CaptionSegment `json:"raw_segments,omitempty"`
+}
+
+// VideoTranscript is a video's caption tracks together with the metadata the
+// provider returned alongside them.
+type VideoTranscript struct {
+	VideoID     string         `json:"video_id"`
+	Title       string         `json:"title"`
+	Author      string         `json:"author"`
+	ChannelID   string         `json:"channel_id"`
+	Description string         `json:"description"`
+	Duration    int            `json:"duration"` // seconds
+	Provider    string         `json:"provider"` // name of the provider that fetched it
+	Tracks      []CaptionTrack `json:"tracks"`
+	FetchedAt   time.Time      `json:"fetched_at"` // when the provider fetched it
+	CacheHit    bool           `json:"cache_hit"`  // served from the transcript cache
+}
+
+// PrimaryTrack returns the track used when a caller needs a single
+// transcript. Tracks are ordered by the caller's language preference, so
+// this is the first track.
+func (t *VideoTranscript) PrimaryTrack() *CaptionTrack {
+	if len(t.Tracks) > 0 {
+		return &t.Tracks[0]
+	}
+	return nil
+}
+
+// Track returns the track with the given language and kind, or nil. An empty
+// kind matches either kind, preferring the earlier track.
+func (t *VideoTranscript) Track(language string, kind CaptionKind) *CaptionTrack {
+	for i := range t.Tracks {
+		if t.Tracks[i].Language == language && (kind == "" || t.Tracks[i].Kind == kind) {
+			return &t.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// TranscriptCache is a video's fetched transcript stored for reuse across
+// users and pipelines. Tracks are stored once per language and kind.
+type TranscriptCache struct {
+	ID          uint                   `json:"id" gorm:"primaryKey"`
+	VideoID     string                 `json:"video_id" gorm:"type:varchar(20);uniqueIndex;not null"`
+	Title       string                 `json:"title" gorm:"type:varchar(500)"`
+	Author      string                 `json:"author" gorm:"type:varchar(255)"`
+	ChannelID   string                 `json:"channel_id" gorm:"type:varchar(100)"`
+	Description string                 `json:"description" gorm:"type:text"`
+	Duration    int                    `json:"duration"`
+	Provider    string                 `json:"provider" gorm:"type:varchar(50)"`
+	FetchedAt   time.Time              `json:"fetched_at" gorm:"not null"`
+	Tracks      []TranscriptCacheTrack `json:"tracks" gorm:"foreignKey:VideoID;references:VideoID"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// TableName specifies the table name for TranscriptCache.
+func (TranscriptCache) TableName() string {
+	return "transcript_cache"
+}
+
+// TranscriptCacheTrack is one stored caption track of a cached transcript.
+type TranscriptCacheTrack struct {
+	ID       uint           `json:"id" gorm:"primaryKey"`
+	VideoID  string         `json:"video_id" gorm:"type:varchar(20);not null;uniqueIndex:idx_transcript_cache_tracks_video_lang_kind"`
+	Language string         `json:"language" gorm:"type:varchar(20);not null;uniqueIndex:idx_transcript_cache_tracks_video_lang_kind"`
+	Kind     CaptionKind    `json:"kind" gorm:"type:varchar(10);not null;uniqueIndex:idx_transcript_cache_tracks_video_lang_kind"`
+	Name     string         `json:"name" gorm:"type:varchar(255)"`
+	Position int            `json:"position"`                   // order the provider returned the track in
+	Segments datatypes.JSON `json:"segments" gorm:"type:jsonb"` // []CaptionSegment
+	// RawSegments is the track as fetched when Segments holds sentences
+	RawSegments datatypes.JSON `json:"raw_segments" gorm:"type:jsonb"` // []CaptionSegment, NULL if not segmented
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name for TranscriptCacheTrack.
+func (TranscriptCacheTrack) TableName() string {
+	return "transcript_cache_tracks"
+}
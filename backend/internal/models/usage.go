@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ErrAIBudgetExceeded is returned when a user's monthly AI budget is used up.
+const ErrAIBudgetExceeded ErrorCode = "AI_BUDGET_EXCEEDED"
+
+// LLMUsage records a single LLM call for token and cost accounting.
+type LLMUsage struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	UserID           *uint     `json:"user_id,omitempty" gorm:"index:idx_llm_usage_user_created,priority:1"`
+	InsightID        *uint     `json:"insight_id,omitempty" gorm:"index"`
+	Feature          string    `json:"feature" gorm:"type:varchar(50);not null;index"`
+	Provider         string    `json:"provider" gorm:"type:varchar(50);not null"`
+	Model            string    `json:"model" gorm:"type:varchar(200);not null"`
+	PromptTokens     int       `json:"prompt_tokens" gorm:"not null;default:0"`
+	CompletionTokens int       `json:"completion_tokens" gorm:"not null;default:0"`
+	TotalTokens      int       `json:"total_tokens" gorm:"not null;default:0"`
+	CostUSD          float64   `json:"cost_usd" gorm:"type:numeric(12,6);not null;default:0"`
+	Estimated        bool      `json:"estimated" gorm:"not null;default:false"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index:idx_llm_usage_user_created,priority:2"`
+}
+
+// TableName returns the table name for LLMUsage model.
+func (LLMUsage) TableName() string {
+	return "llm_usage"
+}
+
+// UsageRollup aggregates usage over a period or feature.
+type UsageRollup struct {
+	Period           string  `json:"period,omitempty"`
+	Feature          string  `json:"feature,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// BudgetStatus describes a user's monthly AI budget. Zero limits mean unlimited.
+type BudgetStatus struct {
+	LimitUSD     float64   `json:"limit_usd"`
+	UsedUSD      float64   `json:"used_usd"`
+	RemainingUSD float64   `json:"remaining_usd"`
+	TokenLimit   int64     `json:"token_limit"`
+	TokensUsed   int64     `json:"tokens_used"`
+	Exceeded     bool      `json:"exceeded"`
+	ResetsAt     time.Time `json:"resets_at"`
+}
+
+// UsageResponse represents the API response for GET /api/v1/usage.
+type UsageResponse struct {
+	Daily     []UsageRollup `json:"daily"`
+	Monthly   []UsageRollup `json:"monthly"`
+	ByFeature []UsageRollup `json:"by_feature"`
+	Budget    BudgetStatus  `json:"budget"`
+}
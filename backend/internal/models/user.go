@@ -14,6 +14,10 @@ type User struct {
 	Name     string `json:"name" gorm:"type:varchar(255)"`
 	APIKey   string `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // API key for authentication
 
+	// TargetLang is the default target language of insights created on the
+	// user's behalf, such as by channel subscriptions
+	TargetLang string `json:"target_lang" gorm:"type:varchar(10);default:'zh'"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -26,10 +30,11 @@ func (User) TableName() string {
 
 // UserResponse represents the user data returned in API responses.
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint      `json:"id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	TargetLang string    `json:"target_lang"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // RegisterRequest represents the user registration request.
@@ -45,6 +50,12 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest represents the profile update request.
+type UpdateProfileRequest struct {
+	Name       *string `json:"name" binding:"omitempty,min=1"`
+	TargetLang *string `json:"target_lang" binding:"omitempty,min=2,max=10"`
+}
+
 // AuthResponse represents the response after successful login/registration.
 type AuthResponse struct {
 	User   UserResponse `json:"user"`
@@ -31,6 +31,7 @@ type YouTubePlaylistRequest struct {
 
 // YouTubePlaylistResponse represents the playlist response.
 type YouTubePlaylistResponse struct {
+	Title    string                `json:"title,omitempty"`
 	Items    []YouTubePlaylistItem `json:"items"`
 	CacheHit bool                  `json:"cacheHit"`
 }
@@ -40,6 +41,8 @@ type YouTubePlaylistItem struct {
 	VideoID   string `json:"videoId"`
 	Title     string `json:"title"`
 	Thumbnail string `json:"thumbnail"`
+	// Unavailable marks private and deleted videos left in the playlist
+	Unavailable bool `json:"unavailable,omitempty"`
 }
 
 // YouTubeCaptionsRequest represents the request for video captions.
@@ -86,6 +89,13 @@ type YouTubeVideoInfo struct {
 	Genre       string                 `json:"genre"`
 	Author      string                 `json:"author"`
 	ChannelID   string                 `json:"channel_id"`
+	Chapters    []YouTubeChapter       `json:"chapters,omitempty"` // creator chapters parsed from the description
+}
+
+// YouTubeChapter represents a creator chapter marker from a video description.
+type YouTubeChapter struct {
+	Title   string `json:"title"`
+	Seconds int    `json:"seconds"`
 }
 
 // YouTubeThumbnailURLs represents thumbnail URLs.
@@ -0,0 +1,140 @@
+// Package netguard builds HTTP clients for fetching URLs that users supply.
+// They only connect to public addresses, so such a URL cannot reach the
+// server's own network: loopback, private ranges, link-local addresses such
+// as the cloud metadata endpoint at 169.254.169.254, and other reserved
+// ranges. Every redirect hop is checked again.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+)
+
+// maxRedirects matches the net/http default.
+const maxRedirects = 10
+
+// ErrBlockedAddress is returned for URLs that lead to a non-public address.
+var ErrBlockedAddress = errors.New("destination address is not allowed")
+
+// reservedPrefixes are special-purpose ranges that the netip predicates do
+// not cover.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can embed any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, which can embed any IPv4 address
+}
+
+// transport is shared by every guarded client so they share connections.
+var transport = newTransport()
+
+// NewClient returns an HTTP client that only connects to public addresses.
+// timeout bounds each request; zero means no limit.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+		Timeout:       timeout,
+	}
+}
+
+// IsPublic reports whether ip is a publicly routable unicast address.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL resolves a URL's host and returns ErrBlockedAddress unless the
+// URL is http(s) and every address of the host is public.
+func CheckURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrBlockedAddress, u.Scheme)
+	}
+	_, err := resolve(ctx, u.Hostname())
+	return err
+}
+
+// newTransport clones the default transport with a dialer that only
+// connects to public addresses. Proxies are disabled, as a proxy would
+// connect on the client's behalf without the check.
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		// Dial the addresses that were checked rather than the name, which
+		// could resolve differently a second time
+		var dialErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+		}
+		return nil, dialErr
+	}
+	return t
+}
+
+// resolve looks up a host, failing if any of its addresses is not public.
+func resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !IsPublic(ip) {
+			return nil, fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+		}
+		return []netip.Addr{ip}, nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, ip := range ips {
+		if !IsPublic(ip) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, ip.Unmap())
+		}
+	}
+	return ips, nil
+}
+
+// checkRedirect checks each redirect hop before it is followed.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return CheckURL(req.Context(), req.URL)
+}
@@ -0,0 +1,324 @@
+package podcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vibe-backend/internal/subtitles"
+)
+
+// Namespace of the iTunes tags; they win over plain RSS tags of the same
+// name because they hold names instead of e-mail addresses.
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// Feed is a parsed podcast feed.
+type Feed struct {
+	URL      string
+	Title    string
+	Author   string
+	ImageURL string
+	Language string
+	Link     string
+	Episodes []Episode
+}
+
+// Elements are matched by local name, so e.g. <title> and <itunes:title> land
+// in the same slice and pickText chooses between them.
+type rssDocument struct {
+	Channel struct {
+		Titles   []rssText  `xml:"title"`
+		Authors  []rssText  `xml:"author"`
+		Links    []rssText  `xml:"link"`
+		Images   []rssImage `xml:"image"`
+		Language string     `xml:"language"`
+		Items    []rssItem  `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Titles      []rssText  `xml:"title"`
+	Authors     []rssText  `xml:"author"`
+	Links       []rssText  `xml:"link"`
+	Images      []rssImage `xml:"image"`
+	GUID        string     `xml:"guid"`
+	PubDate     string     `xml:"pubDate"`
+	Description string     `xml:"description"`
+	Summary     string     `xml:"summary"`
+	Encoded     string     `xml:"encoded"`
+	Duration    string     `xml:"duration"`
+	Enclosure   *struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Transcripts []struct {
+		URL      string `xml:"url,attr"`
+		Type     string `xml:"type,attr"`
+		Language string `xml:"language,attr"`
+		Rel      string `xml:"rel,attr"`
+	} `xml:"transcript"`
+	// <podcast:chapters url type> or <psc:chapters><psc:chapter start title>
+	Chapters []struct {
+		URL   string `xml:"url,attr"`
+		Items []struct {
+			Start string `xml:"start,attr"`
+			Title string `xml:"title,attr"`
+		} `xml:"chapter"`
+	} `xml:"chapters"`
+}
+
+type rssText struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+	Href    string `xml:"href,attr"`
+}
+
+type rssImage struct {
+	Href string `xml:"href,attr"` // <itunes:image href>
+	URL  string `xml:"url"`       // <image><url>
+}
+
+// FetchFeed downloads and parses a podcast feed.
+func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*Feed, error) {
+	data, _, err := c.get(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFeed(feedURL, data)
+}
+
+// ParseFeed parses an RSS podcast feed. feedURL is recorded in the episodes.
+func ParseFeed(feedURL string, data []byte) (*Feed, error) {
+	if !isFeed(data) {
+		return nil, ErrNotPodcast
+	}
+
+	var doc rssDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse podcast feed: %w", err)
+	}
+
+	ch := &doc.Channel
+	feed := &Feed{
+		URL:      feedURL,
+		Title:    pickText(ch.Titles, ""),
+		Author:   pickText(ch.Authors, itunesNamespace),
+		ImageURL: pickImage(ch.Images),
+		Language: strings.TrimSpace(ch.Language),
+		Link:     pickLink(ch.Links),
+	}
+
+	for i := range ch.Items {
+		item := &ch.Items[i]
+		episode := Episode{
+			FeedURL:     feedURL,
+			FeedTitle:   feed.Title,
+			GUID:        strings.TrimSpace(item.GUID),
+			Title:       pickText(item.Titles, ""),
+			Author:      pickText(item.Authors, itunesNamespace),
+			Description: plainText(firstNonEmpty(item.Encoded, item.Description, item.Summary)),
+			Link:        pickLink(item.Links),
+			ImageURL:    pickImage(item.Images),
+			Language:    feed.Language,
+			PublishedAt: parseDate(item.PubDate),
+			Duration:    parseDuration(item.Duration),
+		}
+		if episode.Author == "" {
+			episode.Author = feed.Author
+		}
+		if episode.ImageURL == "" {
+			episode.ImageURL = feed.ImageURL
+		}
+		if e := item.Enclosure; e != nil {
+			length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+			episode.Enclosure = Enclosure{URL: strings.TrimSpace(e.URL), Type: e.Type, Length: length}
+		}
+		if episode.GUID == "" {
+			// Feeds without GUIDs are identified by their audio file
+			episode.GUID = episode.Enclosure.URL
+		}
+		for _, t := range item.Transcripts {
+			if t.URL != "" {
+				episode.Transcripts = append(episode.Transcripts, Transcript{
+					URL:      strings.TrimSpace(t.URL),
+					Type:     strings.ToLower(strings.TrimSpace(t.Type)),
+					Language: t.Language,
+					Rel:      t.Rel,
+				})
+			}
+		}
+		for _, chapters := range item.Chapters {
+			if chapters.URL != "" && episode.ChaptersURL == "" {
+				episode.ChaptersURL = strings.TrimSpace(chapters.URL)
+			}
+			for _, c := range chapters.Items {
+				start, ok := parseOffset(c.Start)
+				if ok && strings.TrimSpace(c.Title) != "" {
+					episode.Chapters = append(episode.Chapters, Chapter{Title: strings.TrimSpace(c.Title), Start: start})
+				}
+			}
+		}
+		feed.Episodes = append(feed.Episodes, episode)
+	}
+
+	return feed, nil
+}
+
+// Episode returns the episode with the given GUID, or nil.
+func (f *Feed) Episode(guid string) *Episode {
+	guid = strings.TrimSpace(guid)
+	for i := range f.Episodes {
+		if f.Episodes[i].GUID == guid {
+			return &f.Episodes[i]
+		}
+	}
+	return nil
+}
+
+// isFeed reports whether data looks like an RSS document rather than e.g.
+// an HTML page.
+func isFeed(data []byte) bool {
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	return bytes.Contains(head, []byte("<rss")) || bytes.Contains(head, []byte("<channel"))
+}
+
+// charsetReader accepts the Latin-1 declarations some feed generators emit
+// for what is in practice UTF-8 or ASCII.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii", "ascii", "iso-8859-1", "latin1", "windows-1252":
+		return input, nil
+	}
+	return nil, fmt.Errorf("unsupported feed charset %q", charset)
+}
+
+// pickText returns the text of the first element in the preferred namespace,
+// or else the first non-empty one.
+func pickText(values []rssText, preferred string) string {
+	fallback := ""
+	for _, v := range values {
+		text := strings.TrimSpace(v.Text)
+		if text == "" {
+			continue
+		}
+		if v.XMLName.Space == preferred {
+			return text
+		}
+		if fallback == "" {
+			fallback = text
+		}
+	}
+	return fallback
+}
+
+// pickLink returns the first RSS <link>, skipping <atom:link> elements that
+// only carry an href.
+func pickLink(values []rssText) string {
+	for _, v := range values {
+		if text := strings.TrimSpace(v.Text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// pickImage prefers <itunes:image>, which is square artwork.
+func pickImage(images []rssImage) string {
+	for _, img := range images {
+		if img.Href != "" {
+			return strings.TrimSpace(img.Href)
+		}
+	}
+	for _, img := range images {
+		if img.URL != "" {
+			return strings.TrimSpace(img.URL)
+		}
+	}
+	return ""
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+}
+
+// parseDate parses an RSS pubDate; it returns the zero time if no layout
+// matches.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration parses <itunes:duration>: seconds, "MM:SS" or "HH:MM:SS".
+func parseDuration(s string) int {
+	d, _ := parseOffset(s)
+	return int(d / time.Second)
+}
+
+// parseOffset parses a time into an episode given in seconds or as
+// "MM:SS(.mmm)" or "HH:MM:SS(.mmm)".
+func parseOffset(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if !strings.Contains(s, ":") {
+		seconds, err := strconv.ParseFloat(s, 64)
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return subtitles.ParseTimestamp(s)
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6])>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+	blankRun  = regexp.MustCompile(`\n\s*\n\s*`)
+)
+
+// plainText turns HTML show notes into plain text with paragraph breaks.
+func plainText(s string) string {
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
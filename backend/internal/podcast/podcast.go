@@ -0,0 +1,227 @@
+// Package podcast reads podcast RSS feeds and the Podcasting 2.0 tags that
+// come with an episode: its audio enclosure, published transcripts and
+// chapters.
+//
+// An episode is addressed either by a feed URL plus the episode's GUID, which
+// EpisodeURL packs into a single URL, or by the URL of a page about the
+// episode (the show's website or Apple Podcasts), from which Resolve finds
+// the feed.
+package podcast
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vibe-backend/internal/netguard"
+)
+
+const (
+	defaultLookupURL = "https://itunes.apple.com/lookup"
+	userAgent        = "Mozilla/5.0 (compatible; vibe-backend podcast reader)"
+
+	// maxFeedSize bounds feeds, pages, transcripts and chapter files.
+	maxFeedSize = 32 << 20
+	// maxAudioSize bounds downloaded episode audio.
+	maxAudioSize = 2 << 30
+
+	// guidFragment carries the episode GUID in EpisodeURL.
+	guidFragment = "guid="
+)
+
+var (
+	// ErrNotPodcast is returned for URLs that lead to no podcast feed.
+	ErrNotPodcast = errors.New("no podcast feed found")
+	// ErrEpisodeNotFound is returned when the feed has no matching episode.
+	ErrEpisodeNotFound = errors.New("podcast episode not found in feed")
+	// ErrNoTranscript is returned when none of an episode's transcript tags
+	// could be read.
+	ErrNoTranscript = errors.New("podcast episode has no readable transcript")
+)
+
+// Enclosure is the episode's media file.
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"` // bytes, as declared by the feed
+}
+
+// Transcript is a <podcast:transcript> tag.
+type Transcript struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Language string `json:"language,omitempty"`
+	Rel      string `json:"rel,omitempty"`
+}
+
+// Chapter starts at Start into the episode.
+type Chapter struct {
+	Title string        `json:"title"`
+	Start time.Duration `json:"start"`
+}
+
+// Episode is one item of a feed with the feed details it inherits.
+type Episode struct {
+	FeedURL     string    `json:"feed_url"`
+	FeedTitle   string    `json:"feed_title"`
+	GUID        string    `json:"guid"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"` // plain text show notes
+	Link        string    `json:"link,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	Duration    int       `json:"duration"` // seconds, 0 if unknown
+	Enclosure   Enclosure `json:"enclosure"`
+
+	Transcripts []Transcript `json:"transcripts,omitempty"`
+	// ChaptersURL is the <podcast:chapters> JSON file; Chapters holds
+	// chapters embedded as Podlove Simple Chapters.
+	ChaptersURL string    `json:"chapters_url,omitempty"`
+	Chapters    []Chapter `json:"chapters,omitempty"`
+}
+
+// Config configures a Client.
+type Config struct {
+	// Timeout bounds each request except audio downloads, which are only
+	// bounded by the caller's context.
+	Timeout time.Duration
+	// LookupURL is the Apple Podcasts lookup endpoint (empty = public).
+	LookupURL string
+}
+
+// Client fetches feeds and the files they link to. It only connects to
+// public addresses, as feeds and the URLs in them come from users.
+type Client struct {
+	httpClient     *http.Client
+	downloadClient *http.Client
+	lookupURL      string
+}
+
+// NewClient creates a Client.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.LookupURL == "" {
+		cfg.LookupURL = defaultLookupURL
+	}
+	return &Client{
+		httpClient:     netguard.NewClient(cfg.Timeout),
+		downloadClient: netguard.NewClient(0),
+		lookupURL:      cfg.LookupURL,
+	}
+}
+
+// EpisodeURL addresses the episode with the given GUID in a feed. The GUID
+// travels in the URL fragment, so the URL still opens the feed.
+func EpisodeURL(feedURL, guid string) string {
+	if i := strings.IndexByte(feedURL, '#'); i >= 0 {
+		feedURL = feedURL[:i]
+	}
+	return feedURL + "#" + guidFragment + url.QueryEscape(guid)
+}
+
+// ParseEpisodeURL splits a URL made by EpisodeURL. guid is empty for any
+// other URL.
+func ParseEpisodeURL(rawURL string) (feedURL, guid string) {
+	feedURL, fragment, found := strings.Cut(rawURL, "#")
+	if !found || !strings.HasPrefix(fragment, guidFragment) {
+		return rawURL, ""
+	}
+	guid, err := url.QueryUnescape(strings.TrimPrefix(fragment, guidFragment))
+	if err != nil {
+		return rawURL, ""
+	}
+	return feedURL, guid
+}
+
+// SourceID derives a short, stable ID for an episode from its GUID: the GUID
+// itself when it fits in 100 bytes, its SHA-256 otherwise.
+func SourceID(guid string) string {
+	if len(guid) <= 100 {
+		return guid
+	}
+	sum := sha256.Sum256([]byte(guid))
+	return hex.EncodeToString(sum[:])
+}
+
+// DownloadAudio saves the episode's enclosure in dir and returns its path.
+func (c *Client) DownloadAudio(ctx context.Context, episode *Episode, dir string) (string, error) {
+	if episode.Enclosure.URL == "" {
+		return "", fmt.Errorf("episode has no audio enclosure")
+	}
+	resp, err := c.do(ctx, c.downloadClient, episode.Enclosure.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	ext := path.Ext(resp.Request.URL.Path)
+	if ext == "" || len(ext) > 5 {
+		ext = ".audio"
+	}
+	file, err := os.Create(filepath.Join(dir, "episode"+ext))
+	if err != nil {
+		return "", fmt.Errorf("failed to create audio file: %w", err)
+	}
+	defer file.Close()
+
+	n, err := io.Copy(file, io.LimitReader(resp.Body, maxAudioSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download episode audio: %w", err)
+	}
+	if n > maxAudioSize {
+		return "", fmt.Errorf("episode audio is larger than %d MB", maxAudioSize>>20)
+	}
+	return file.Name(), nil
+}
+
+// get fetches a small document such as a feed or a transcript.
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, string, error) {
+	resp, err := c.do(ctx, c.httpClient, rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, "", fmt.Errorf("%s is larger than %d MB", rawURL, maxFeedSize>>20)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// do sends a GET request and checks the response status. The caller closes
+// the body.
+func (c *Client) do(ctx context.Context, httpClient *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,188 @@
+package podcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrEpisodeRequired is returned for a bare feed URL, which names a show but
+// not one of its episodes.
+var ErrEpisodeRequired = errors.New("podcast feed URL needs an episode GUID")
+
+var (
+	linkTag      = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	attrPattern  = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+	appleShowID  = regexp.MustCompile(`/id(\d+)`)
+	feedMimeType = "application/rss+xml"
+)
+
+// Resolve finds the episode a URL refers to: a URL made by EpisodeURL, an
+// Apple Podcasts episode link, or an episode page that advertises its feed
+// with <link rel="alternate">.
+func (c *Client) Resolve(ctx context.Context, rawURL string) (*Episode, error) {
+	if feedURL, guid := ParseEpisodeURL(rawURL); guid != "" {
+		feed, err := c.FetchFeed(ctx, feedURL)
+		if err != nil {
+			return nil, err
+		}
+		if episode := feed.Episode(guid); episode != nil {
+			return episode, nil
+		}
+		return nil, ErrEpisodeNotFound
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil, ErrNotPodcast
+	}
+	if strings.EqualFold(parsed.Hostname(), "podcasts.apple.com") {
+		return c.resolveApple(ctx, parsed)
+	}
+	return c.resolvePage(ctx, parsed)
+}
+
+// resolveApple looks an Apple Podcasts episode up in the iTunes directory,
+// which knows the show's feed and the episode's GUID.
+func (c *Client) resolveApple(ctx context.Context, page *url.URL) (*Episode, error) {
+	showID := appleShowID.FindStringSubmatch(page.Path)
+	trackID, err := strconv.ParseInt(page.Query().Get("i"), 10, 64)
+	if showID == nil || err != nil {
+		return nil, ErrEpisodeNotFound
+	}
+
+	query := url.Values{}
+	query.Set("id", showID[1])
+	query.Set("entity", "podcastEpisode")
+	query.Set("limit", "300")
+	data, _, err := c.get(ctx, c.lookupURL+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var lookup struct {
+		Results []struct {
+			FeedURL     string `json:"feedUrl"`
+			TrackID     int64  `json:"trackId"`
+			Kind        string `json:"kind"`
+			EpisodeGUID string `json:"episodeGuid"`
+			EpisodeURL  string `json:"episodeUrl"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &lookup); err != nil {
+		return nil, fmt.Errorf("failed to parse podcast lookup: %w", err)
+	}
+
+	var feedURL, guid, audioURL string
+	for _, r := range lookup.Results {
+		if r.FeedURL != "" && feedURL == "" {
+			feedURL = r.FeedURL
+		}
+		if r.Kind == "podcast-episode" && r.TrackID == trackID {
+			guid, audioURL = r.EpisodeGUID, r.EpisodeURL
+		}
+	}
+	if feedURL == "" {
+		return nil, ErrNotPodcast
+	}
+	if guid == "" && audioURL == "" {
+		return nil, ErrEpisodeNotFound
+	}
+
+	feed, err := c.FetchFeed(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	if episode := feed.Episode(guid); guid != "" && episode != nil {
+		return episode, nil
+	}
+	for i := range feed.Episodes {
+		if audioURL != "" && sameURL(feed.Episodes[i].Enclosure.URL, audioURL) {
+			return &feed.Episodes[i], nil
+		}
+	}
+	return nil, ErrEpisodeNotFound
+}
+
+// resolvePage reads an episode page, follows the feeds it links to and picks
+// the episode whose link, GUID or audio file matches the page.
+func (c *Client) resolvePage(ctx context.Context, page *url.URL) (*Episode, error) {
+	data, _, err := c.get(ctx, page.String())
+	if err != nil {
+		return nil, err
+	}
+	if isFeed(data) {
+		return nil, ErrEpisodeRequired
+	}
+
+	feeds := feedLinks(page, data)
+	if len(feeds) == 0 {
+		return nil, ErrNotPodcast
+	}
+
+	var lastErr error = ErrEpisodeNotFound
+	for _, feedURL := range feeds {
+		feed, err := c.FetchFeed(ctx, feedURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for i := range feed.Episodes {
+			episode := &feed.Episodes[i]
+			if sameURL(episode.Link, page.String()) || sameURL(episode.GUID, page.String()) {
+				return episode, nil
+			}
+		}
+		// Many pages embed a player pointing at the episode's audio file
+		for i := range feed.Episodes {
+			episode := &feed.Episodes[i]
+			if enclosure := episode.Enclosure.URL; enclosure != "" && bytes.Contains(data, []byte(enclosure)) {
+				return episode, nil
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+// feedLinks returns the RSS feeds a page advertises, as absolute URLs.
+func feedLinks(page *url.URL, data []byte) []string {
+	var feeds []string
+	for _, tag := range linkTag.FindAll(data, -1) {
+		attrs := map[string]string{}
+		for _, m := range attrPattern.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(m[1]))] = html.UnescapeString(strings.Trim(string(m[2]), `"'`))
+		}
+		if !strings.Contains(strings.ToLower(attrs["rel"]), "alternate") ||
+			!strings.EqualFold(attrs["type"], feedMimeType) || attrs["href"] == "" {
+			continue
+		}
+		href, err := page.Parse(attrs["href"])
+		if err != nil {
+			continue
+		}
+		feeds = append(feeds, href.String())
+	}
+	return feeds
+}
+
+// sameURL compares two URLs ignoring scheme, "www.", query, fragment and a
+// trailing slash.
+func sameURL(a, b string) bool {
+	normalize := func(s string) string {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil || u.Host == "" {
+			return ""
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+		return host + strings.TrimSuffix(u.Path, "/")
+	}
+	na := normalize(a)
+	return na != "" && na == normalize(b)
+}
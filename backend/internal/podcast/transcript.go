@@ -0,0 +1,168 @@
+package podcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"vibe-backend/internal/subtitles"
+)
+
+// jsonCueMaxDuration bounds cues merged from word-level JSON transcripts.
+const jsonCueMaxDuration = 20 * time.Second
+
+// transcriptFormats maps transcript MIME types to formats, in order of
+// preference. HTML and plain-text transcripts carry no timing and are not
+// used.
+var transcriptFormats = []struct {
+	types  []string
+	format subtitles.Format
+}{
+	{[]string{"text/vtt"}, subtitles.FormatVTT},
+	{[]string{"application/x-subrip", "application/srt", "text/srt"}, subtitles.FormatSRT},
+	{[]string{"application/json"}, ""}, // Podcasting 2.0 JSON
+}
+
+// FetchTranscript reads the episode's preferred timed transcript. It tries
+// each supported <podcast:transcript> tag in turn and returns the cues and
+// the tag they came from, or ErrNoTranscript.
+func (c *Client) FetchTranscript(ctx context.Context, episode *Episode) ([]subtitles.Cue, *Transcript, error) {
+	var lastErr error = ErrNoTranscript
+	for _, f := range transcriptFormats {
+		for i := range episode.Transcripts {
+			t := &episode.Transcripts[i]
+			if !slices.Contains(f.types, mimeType(t.Type)) {
+				continue
+			}
+			cues, err := c.fetchTranscript(ctx, t, f.format)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				lastErr = fmt.Errorf("%w: %s: %v", ErrNoTranscript, t.URL, err)
+				continue
+			}
+			return cues, t, nil
+		}
+	}
+	return nil, nil, lastErr
+}
+
+func (c *Client) fetchTranscript(ctx context.Context, t *Transcript, format subtitles.Format) ([]subtitles.Cue, error) {
+	data, _, err := c.get(ctx, t.URL)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		return parseJSONTranscript(data)
+	}
+	return subtitles.Parse(data, format)
+}
+
+// parseJSONTranscript reads the Podcasting 2.0 JSON transcript format. Its
+// segments are often single words, so consecutive segments of one speaker
+// are merged into sentence-sized cues.
+func parseJSONTranscript(data []byte) ([]subtitles.Cue, error) {
+	var doc struct {
+		Segments []struct {
+			Speaker   string  `json:"speaker"`
+			StartTime float64 `json:"startTime"` // seconds
+			EndTime   float64 `json:"endTime"`
+			Body      string  `json:"body"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON transcript: %w", err)
+	}
+
+	var cues []subtitles.Cue
+	var words []string
+	var cue subtitles.Cue
+	speaker := ""
+	flush := func() {
+		if len(words) > 0 {
+			cue.Text = strings.Join(words, " ")
+			cues = append(cues, cue)
+		}
+		words = nil
+	}
+	for _, seg := range doc.Segments {
+		body := strings.Join(strings.Fields(seg.Body), " ")
+		if body == "" {
+			continue
+		}
+		start := time.Duration(seg.StartTime * float64(time.Second))
+		end := time.Duration(seg.EndTime * float64(time.Second))
+		if len(words) > 0 && (seg.Speaker != speaker || start-cue.Start > jsonCueMaxDuration) {
+			flush()
+		}
+		if len(words) == 0 {
+			cue = subtitles.Cue{Start: start}
+			speaker = seg.Speaker
+		}
+		words = append(words, body)
+		cue.End = max(end, start)
+		if strings.HasSuffix(body, ".") || strings.HasSuffix(body, "?") || strings.HasSuffix(body, "!") ||
+			strings.HasSuffix(body, "。") || strings.HasSuffix(body, "？") || strings.HasSuffix(body, "！") {
+			flush()
+		}
+	}
+	flush()
+
+	if len(cues) == 0 {
+		return nil, subtitles.ErrNoCues
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	return cues, nil
+}
+
+// FetchChapters returns the episode's chapters from its <podcast:chapters>
+// file, or the Podlove chapters embedded in the feed. Chapters marked as
+// hidden from the table of contents are skipped.
+func (c *Client) FetchChapters(ctx context.Context, episode *Episode) ([]Chapter, error) {
+	if episode.ChaptersURL == "" {
+		return episode.Chapters, nil
+	}
+
+	data, _, err := c.get(ctx, episode.ChaptersURL)
+	if err != nil {
+		return episode.Chapters, err
+	}
+	var doc struct {
+		Chapters []struct {
+			StartTime float64 `json:"startTime"` // seconds
+			Title     string  `json:"title"`
+			TOC       *bool   `json:"toc"`
+		} `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return episode.Chapters, fmt.Errorf("invalid chapters file: %w", err)
+	}
+
+	var chapters []Chapter
+	for _, ch := range doc.Chapters {
+		title := strings.TrimSpace(ch.Title)
+		if title == "" || (ch.TOC != nil && !*ch.TOC) {
+			continue
+		}
+		chapters = append(chapters, Chapter{
+			Title: title,
+			Start: time.Duration(ch.StartTime * float64(time.Second)),
+		})
+	}
+	if len(chapters) == 0 {
+		return episode.Chapters, nil
+	}
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	return chapters, nil
+}
+
+// mimeType strips parameters such as "; charset=utf-8".
+func mimeType(t string) string {
+	t, _, _ = strings.Cut(t, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"vibe-backend/internal/models"
 )
 
@@ -43,10 +45,43 @@ func (r *ChatRepository) GetMessageByID(ctx context.Context, id uint) (*models.C
 	return &message, nil
 }
 
-// DeleteMessagesByAnalysisID deletes all chat messages for an analysis.
+// DeleteMessage deletes a single chat message.
+func (r *ChatRepository) DeleteMessage(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.ChatMessage{}, id).Error
+}
+
+// DeleteMessagesByAnalysisID deletes all chat messages for an analysis, along
+// with the rolling summary built from them.
 // Note: This method now uses InsightID instead of AnalysisID for compatibility with the InsightFlow system.
 func (r *ChatRepository) DeleteMessagesByAnalysisID(ctx context.Context, analysisID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", analysisID).Delete(&models.ChatMemory{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("insight_id = ?", analysisID).Delete(&models.ChatMessage{}).Error
+	})
+}
+
+// GetMemory returns the rolling chat summary for an insight, or nil if none
+// has been written yet.
+func (r *ChatRepository) GetMemory(ctx context.Context, insightID uint) (*models.ChatMemory, error) {
+	var memory models.ChatMemory
+	err := r.db.WithContext(ctx).Where("insight_id = ?", insightID).First(&memory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// SaveMemory creates or updates the rolling chat summary for an insight.
+func (r *ChatRepository) SaveMemory(ctx context.Context, memory *models.ChatMemory) error {
 	return r.db.WithContext(ctx).
-		Where("insight_id = ?", analysisID).
-		Delete(&models.ChatMessage{}).Error
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "insight_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"summary", "covered_message_id", "covered_messages", "updated_at"}),
+		}).
+		Create(memory).Error
 }
@@ -8,6 +8,10 @@ import (
 	"vibe-backend/internal/models"
 )
 
+// currentVersionHighlights limits highlights to those made on the insight's
+// current version; highlights of other versions come back on rollback.
+const currentVersionHighlights = "version = (SELECT current_version FROM insights WHERE insights.id = highlights.insight_id)"
+
 // InsightRepository handles database operations for insights.
 type InsightRepository struct {
 	db *gorm.DB
@@ -33,12 +37,15 @@ func (r *InsightRepository) GetByID(ctx context.Context, id uint) (*models.Insig
 	return &insight, nil
 }
 
-// GetByIDWithRelations returns an insight by ID with highlights preloaded.
+// GetByIDWithRelations returns an insight by ID with highlights and chapters preloaded.
 func (r *InsightRepository) GetByIDWithRelations(ctx context.Context, id uint) (*models.Insight, error) {
 	var insight models.Insight
 	err := r.db.WithContext(ctx).
 		Preload("Highlights", func(db *gorm.DB) *gorm.DB {
-			return db.Order("start_offset ASC")
+			return db.Where(currentVersionHighlights).Order("start_offset ASC")
+		}).
+		Preload("Chapters", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_index ASC")
 		}).
 		First(&insight, id).Error
 	if err != nil {
@@ -47,6 +54,19 @@ func (r *InsightRepository) GetByIDWithRelations(ctx context.Context, id uint) (
 	return &insight, nil
 }
 
+// GetByIDs returns the list fields of the given insights, in no particular order.
+func (r *InsightRepository) GetByIDs(ctx context.Context, ids []uint) ([]models.Insight, error) {
+	var insights []models.Insight
+	if len(ids) == 0 {
+		return insights, nil
+	}
+	err := r.db.WithContext(ctx).
+		Select("id", "source_type", "title", "author", "thumbnail_url", "status", "created_at").
+		Where("id IN ?", ids).
+		Find(&insights).Error
+	return insights, err
+}
+
 // GetByUserID returns insights for a user, optionally filtered by status.
 func (r *InsightRepository) GetByUserID(ctx context.Context, userID uint, status *models.InsightStatus, limit, offset int) ([]models.Insight, int64, error) {
 	var insights []models.Insight
@@ -162,7 +182,7 @@ func (r *InsightRepository) GetByShareToken(ctx context.Context, token string) (
 	err := r.db.WithContext(ctx).
 		Where("share_token = ? AND is_public = ?", token, true).
 		Preload("Highlights", func(db *gorm.DB) *gorm.DB {
-			return db.Order("start_offset ASC")
+			return db.Where(currentVersionHighlights).Order("start_offset ASC")
 		}).
 		First(&insight).Error
 	if err != nil {
@@ -171,6 +191,19 @@ func (r *InsightRepository) GetByShareToken(ctx context.Context, token string) (
 	return &insight, nil
 }
 
+// GetByStatuses returns insights in any of the given statuses, oldest first.
+// Used on startup to recover work interrupted by a crash or deploy.
+func (r *InsightRepository) GetByStatuses(ctx context.Context, statuses []models.InsightStatus, limit int) ([]models.Insight, error) {
+	var insights []models.Insight
+	err := r.db.WithContext(ctx).
+		Select("id", "user_id", "status", "created_at").
+		Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&insights).Error
+	return insights, err
+}
+
 // Update updates an insight record.
 func (r *InsightRepository) Update(ctx context.Context, insight *models.Insight) error {
 	return r.db.WithContext(ctx).Save(insight).Error
@@ -198,6 +231,24 @@ func (r *InsightRepository) Delete(ctx context.Context, id uint) error {
 		if err := tx.Where("insight_id = ?", id).Delete(&models.ChatMessage{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.ChatMemory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.InsightChapter{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.TranscriptChunk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.InsightTranscript{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.SearchDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.InsightVersion{}).Error; err != nil {
+			return err
+		}
 		// Soft delete the insight
 		return tx.Delete(&models.Insight{}, id).Error
 	})
@@ -210,11 +261,12 @@ func (r *InsightRepository) CreateHighlight(ctx context.Context, highlight *mode
 	return r.db.WithContext(ctx).Create(highlight).Error
 }
 
-// GetHighlightsByInsightID returns all highlights for an insight.
+// GetHighlightsByInsightID returns the highlights of an insight's current version.
 func (r *InsightRepository) GetHighlightsByInsightID(ctx context.Context, insightID uint) ([]models.Highlight, error) {
 	var highlights []models.Highlight
 	err := r.db.WithContext(ctx).
 		Where("insight_id = ?", insightID).
+		Where(currentVersionHighlights).
 		Order("start_offset ASC").
 		Find(&highlights).Error
 	return highlights, err
@@ -257,6 +309,16 @@ func (r *InsightRepository) GetChatMessagesByInsightID(ctx context.Context, insi
 	return messages, err
 }
 
+// GetChatMessagesByHighlightID returns all chat messages anchored to a highlight.
+func (r *InsightRepository) GetChatMessagesByHighlightID(ctx context.Context, highlightID uint) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.db.WithContext(ctx).
+		Where("highlight_id = ?", highlightID).
+		Order("created_at ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
 // GetChatMessagesByInsightIDPaginated returns paginated chat messages for an insight.
 func (r *InsightRepository) GetChatMessagesByInsightIDPaginated(ctx context.Context, insightID uint, limit, offset int) ([]models.ChatMessage, int64, error) {
 	var messages []models.ChatMessage
@@ -277,7 +339,256 @@ func (r *InsightRepository) GetChatMessagesByInsightIDPaginated(ctx context.Cont
 	return messages, total, err
 }
 
-// DeleteChatMessagesByInsightID deletes all chat messages for an insight.
+// DeleteChatMessagesByInsightID deletes all chat messages for an insight,
+// along with the rolling summary built from them.
 func (r *InsightRepository) DeleteChatMessagesByInsightID(ctx context.Context, insightID uint) error {
-	return r.db.WithContext(ctx).Where("insight_id = ?", insightID).Delete(&models.ChatMessage{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.ChatMemory{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("insight_id = ?", insightID).Delete(&models.ChatMessage{}).Error
+	})
+}
+
+// --- Chapter operations ---
+
+// ReplaceChapters replaces all chapters of an insight.
+func (r *InsightRepository) ReplaceChapters(ctx context.Context, insightID uint, chapters []models.InsightChapter) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.InsightChapter{}).Error; err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			return nil
+		}
+		for i := range chapters {
+			chapters[i].ID = 0
+			chapters[i].InsightID = insightID
+			chapters[i].OrderIndex = i
+		}
+		return tx.Create(&chapters).Error
+	})
+}
+
+// GetChaptersByInsightID returns all chapters for an insight in order.
+func (r *InsightRepository) GetChaptersByInsightID(ctx context.Context, insightID uint) ([]models.InsightChapter, error) {
+	var chapters []models.InsightChapter
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ?", insightID).
+		Order("order_index ASC").
+		Find(&chapters).Error
+	return chapters, err
+}
+
+// --- Transcript chunk operations ---
+
+// ReplaceTranscriptChunks replaces the retrieval chunks of an insight.
+func (r *InsightRepository) ReplaceTranscriptChunks(ctx context.Context, insightID uint, chunks []models.TranscriptChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.TranscriptChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		for i := range chunks {
+			chunks[i].ID = 0
+			chunks[i].InsightID = insightID
+			chunks[i].ChunkIndex = i
+		}
+		return tx.CreateInBatches(&chunks, 200).Error
+	})
+}
+
+// GetTranscriptChunksByInsightID returns the retrieval chunks of an insight in order.
+func (r *InsightRepository) GetTranscriptChunksByInsightID(ctx context.Context, insightID uint) ([]models.TranscriptChunk, error) {
+	var chunks []models.TranscriptChunk
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ?", insightID).
+		Order("chunk_index ASC").
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// --- Transcript track operations ---
+
+// ReplaceTranscripts replaces the stored caption tracks of an insight.
+func (r *InsightRepository) ReplaceTranscripts(ctx context.Context, insightID uint, tracks []models.InsightTranscript) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.InsightTranscript{}).Error; err != nil {
+			return err
+		}
+		if len(tracks) == 0 {
+			return nil
+		}
+		for i := range tracks {
+			tracks[i].ID = 0
+			tracks[i].InsightID = insightID
+			tracks[i].OrderIndex = i
+		}
+		return tx.Create(&tracks).Error
+	})
+}
+
+// GetTranscriptsByInsightID returns the stored caption tracks of an insight
+// in preference order.
+func (r *InsightRepository) GetTranscriptsByInsightID(ctx context.Context, insightID uint) ([]models.InsightTranscript, error) {
+	var tracks []models.InsightTranscript
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ?", insightID).
+		Order("order_index ASC").
+		Find(&tracks).Error
+	return tracks, err
+}
+
+// --- Version operations ---
+
+// CreateVersion stores a processing run of an insight as its next version
+// and sets version.Version.
+func (r *InsightRepository) CreateVersion(ctx context.Context, version *models.InsightVersion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.InsightVersion{}).
+			Select("COALESCE(MAX(version), 0)").
+			Where("insight_id = ?", version.InsightID).
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.ID = 0
+		version.Version = latest + 1
+		return tx.Create(version).Error
+	})
+}
+
+// AdoptLegacyVersion makes version the current version of an insight
+// processed before versioning, moving its highlights to that version.
+func (r *InsightRepository) AdoptLegacyVersion(ctx context.Context, insightID uint, version int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Highlight{}).
+			Where("insight_id = ? AND version = 0", insightID).
+			Update("version", version).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Insight{}).
+			Where("id = ?", insightID).
+			Update("current_version", version).Error
+	})
+}
+
+// UpdateVersionTranscript copies the insight's transcript into its current
+// version, after the insight switched transcript track.
+func (r *InsightRepository) UpdateVersionTranscript(ctx context.Context, insight *models.Insight) error {
+	return r.db.WithContext(ctx).
+		Model(&models.InsightVersion{}).
+		Where("insight_id = ? AND version = ?", insight.ID, insight.CurrentVersion).
+		Updates(map[string]interface{}{
+			"transcripts":         insight.Transcripts,
+			"raw_content":         insight.RawContent,
+			"transcript_language": insight.TranscriptLanguage,
+			"transcript_kind":     insight.TranscriptKind,
+		}).Error
+}
+
+// GetVersion returns one version of an insight with its artifacts.
+func (r *InsightRepository) GetVersion(ctx context.Context, insightID uint, version int) (*models.InsightVersion, error) {
+	var v models.InsightVersion
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ? AND version = ?", insightID, version).
+		First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVersions returns the versions of an insight, newest first, without
+// their artifacts.
+func (r *InsightRepository) ListVersions(ctx context.Context, insightID uint) ([]models.InsightVersion, error) {
+	var versions []models.InsightVersion
+	err := r.db.WithContext(ctx).
+		Select("id", "insight_id", "version", "processed_at", "transcript_provider", "llm_provider", "model",
+			"title", "transcript_language", "transcript_kind", "created_at").
+		Where("insight_id = ?", insightID).
+		Order("version DESC").
+		Find(&versions).Error
+	return versions, err
+}
+
+// --- Batch operations ---
+
+// CreateBatch stores an import batch together with its insights, which get
+// their BatchID set.
+func (r *InsightRepository) CreateBatch(ctx context.Context, batch *models.InsightBatch, insights []*models.Insight) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for _, insight := range insights {
+			insight.BatchID = &batch.ID
+			if err := tx.Create(insight).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetBatch returns an import batch by ID.
+func (r *InsightRepository) GetBatch(ctx context.Context, id uint) (*models.InsightBatch, error) {
+	var batch models.InsightBatch
+	err := r.db.WithContext(ctx).First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches returns a user's import batches, newest first.
+func (r *InsightRepository) ListBatches(ctx context.Context, userID uint, limit int) ([]models.InsightBatch, error) {
+	var batches []models.InsightBatch
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&batches).Error
+	return batches, err
+}
+
+// GetBatchInsights returns the insights of a batch in import order, without
+// their content.
+func (r *InsightRepository) GetBatchInsights(ctx context.Context, batchID uint) ([]models.Insight, error) {
+	var insights []models.Insight
+	err := r.db.WithContext(ctx).
+		Select("id", "source_url", "title", "thumbnail_url", "status", "error_message").
+		Where("batch_id = ?", batchID).
+		Order("id ASC").
+		Find(&insights).Error
+	return insights, err
+}
+
+// CountBatchStatuses counts the insights of each batch by status.
+func (r *InsightRepository) CountBatchStatuses(ctx context.Context, batchIDs []uint) (map[uint]map[models.InsightStatus]int, error) {
+	var rows []struct {
+		BatchID uint
+		Status  models.InsightStatus
+		Count   int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.Insight{}).
+		Select("batch_id, status, COUNT(*) AS count").
+		Where("batch_id IN ?", batchIDs).
+		Group("batch_id, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]map[models.InsightStatus]int, len(batchIDs))
+	for _, row := range rows {
+		if counts[row.BatchID] == nil {
+			counts[row.BatchID] = make(map[models.InsightStatus]int)
+		}
+		counts[row.BatchID][row.Status] = row.Count
+	}
+	return counts, nil
 }
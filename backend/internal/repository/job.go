@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vibe-backend/internal/models"
+)
+
+// ErrJobLeaseLost is returned when a worker updates a job it no longer
+// holds: its lease expired and the job was claimed again, or it was moved
+// out of the running state.
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+// ErrJobActiveDuplicate is returned by Requeue when a queued or running job
+// with the same dedupe key already exists.
+var ErrJobActiveDuplicate = errors.New("an active job with the same dedupe key exists")
+
+// JobRepository handles database operations for background jobs.
+type JobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new JobRepository.
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Enqueue inserts a job. If the job has a DedupeKey and an active job with
+// the same key already exists, nothing is inserted and created is false.
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) (created bool, err error) {
+	if job.Status == "" {
+		job.Status = models.JobStatusQueued
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "dedupe_key"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status IN ('queued','running')"}}},
+			DoNothing:   true,
+		}).
+		Create(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Claim leases the next runnable job on a queue. Runnable jobs are queued
+// jobs whose run_at has passed, and running jobs whose lease has expired
+// (their worker died) with attempts left. Expired jobs that used their last
+// attempt are moved to the dead-letter state instead, so a job that keeps
+// killing its worker is not retried forever. Returns nil when the queue is
+// empty.
+func (r *JobRepository) Claim(ctx context.Context, queue, workerID string, lease time.Duration) (*models.Job, error) {
+	err := r.db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("queue = ? AND status = ? AND locked_until < NOW() AND attempts >= max_attempts", queue, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusDead,
+			"locked_by":    "",
+			"locked_until": nil,
+			"last_error":   "job lease expired on its last attempt",
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.Job
+	result := r.db.WithContext(ctx).Raw(`
+		UPDATE jobs SET
+			status = ?,
+			locked_by = ?,
+			locked_until = NOW() + make_interval(secs => ?),
+			attempts = attempts + 1,
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE queue = ?
+			  AND ((status = ? AND run_at <= NOW()) OR (status = ? AND locked_until < NOW() AND attempts < max_attempts))
+			ORDER BY run_at ASC, id ASC
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		models.JobStatusRunning, workerID, lease.Seconds(),
+		queue, models.JobStatusQueued, models.JobStatusRunning,
+	).Scan(&job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// ExtendLease pushes out the lease of a job still held by workerID.
+func (r *JobRepository) ExtendLease(ctx context.Context, id uint, workerID string, lease time.Duration) error {
+	return r.updateLeased(ctx, id, workerID, map[string]interface{}{
+		"locked_until": time.Now().Add(lease),
+	})
+}
+
+// Complete marks a job held by workerID as completed.
+func (r *JobRepository) Complete(ctx context.Context, id uint, workerID string) error {
+	now := time.Now()
+	return r.updateLeased(ctx, id, workerID, map[string]interface{}{
+		"status":       models.JobStatusCompleted,
+		"locked_by":    "",
+		"locked_until": nil,
+		"completed_at": &now,
+		"last_error":   "",
+	})
+}
+
+// Retry puts a failed job held by workerID back on the queue to run at runAt.
+func (r *JobRepository) Retry(ctx context.Context, id uint, workerID, errMsg string, runAt time.Time) error {
+	return r.updateLeased(ctx, id, workerID, map[string]interface{}{
+		"status":       models.JobStatusQueued,
+		"locked_by":    "",
+		"locked_until": nil,
+		"run_at":       runAt,
+		"last_error":   errMsg,
+	})
+}
+
+// Release returns an interrupted job held by workerID to the queue without
+// counting the attempt.
+func (r *JobRepository) Release(ctx context.Context, id uint, workerID string) error {
+	return r.updateLeased(ctx, id, workerID, map[string]interface{}{
+		"status":       models.JobStatusQueued,
+		"locked_by":    "",
+		"locked_until": nil,
+		"run_at":       time.Now(),
+		"attempts":     gorm.Expr("GREATEST(attempts - 1, 0)"),
+	})
+}
+
+// Bury moves a job held by workerID to the dead-letter state.
+func (r *JobRepository) Bury(ctx context.Context, id uint, workerID, errMsg string) error {
+	return r.updateLeased(ctx, id, workerID, map[string]interface{}{
+		"status":       models.JobStatusDead,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   errMsg,
+	})
+}
+
+// updateLeased updates a job only while workerID still holds its lease, so
+// a worker whose lease expired cannot overwrite the run of the worker that
+// claimed the job after it. It returns ErrJobLeaseLost otherwise.
+func (r *JobRepository) updateLeased(ctx context.Context, id uint, workerID string, updates map[string]interface{}) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("id = ? AND locked_by = ? AND status = ?", id, workerID, models.JobStatusRunning).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// Requeue moves a dead job back to the queue with a fresh attempt budget.
+// It returns ErrJobActiveDuplicate, leaving the job dead, if an active job
+// with the same dedupe key exists, as the two would do the same work.
+func (r *JobRepository) Requeue(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, models.JobStatusDead).
+		Where(`dedupe_key IS NULL OR NOT EXISTS (
+			SELECT 1 FROM jobs active
+			WHERE active.dedupe_key = jobs.dedupe_key AND active.status IN ('queued','running'))`).
+		Updates(map[string]interface{}{
+			"status":   models.JobStatusQueued,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		err := r.db.WithContext(ctx).
+			Model(&models.Job{}).
+			Where("id = ? AND status = ?", id, models.JobStatusDead).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrJobActiveDuplicate
+		}
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Stats returns per-queue counts by status and the age of the oldest runnable job.
+func (r *JobRepository) Stats(ctx context.Context) ([]models.QueueStats, error) {
+	var stats []models.QueueStats
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT queue,
+			COUNT(*) FILTER (WHERE status = 'queued') AS queued,
+			COUNT(*) FILTER (WHERE status = 'running') AS running,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status = 'dead') AS dead,
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(run_at) FILTER (WHERE status = 'queued' AND run_at <= NOW())), 0) AS oldest_queued_secs
+		FROM jobs
+		GROUP BY queue
+		ORDER BY queue`).Scan(&stats).Error
+	return stats, err
+}
+
+// ListByStatus returns the most recently updated jobs with a given status,
+// optionally filtered by queue.
+func (r *JobRepository) ListByStatus(ctx context.Context, status models.JobStatus, queue string, limit int) ([]models.Job, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var jobs []models.Job
+	query := r.db.WithContext(ctx).Where("status = ?", status)
+	if queue != "" {
+		query = query.Where("queue = ?", queue)
+	}
+	err := query.Order("updated_at DESC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// DeleteCompletedBefore removes completed jobs older than the given time.
+func (r *JobRepository) DeleteCompletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND completed_at < ?", models.JobStatusCompleted, before).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// searchHeadlineOptions configures ts_headline. Matches are wrapped in the
+// STX and ETX control characters, which the service swaps for <mark> tags
+// after HTML-escaping the snippet.
+const searchHeadlineOptions = "StartSel=\x02, StopSel=\x03, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// SearchRepository handles the full-text search index of insights.
+type SearchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository creates a new SearchRepository.
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// SearchQuery is a full-text query. Text is parsed with websearch_to_tsquery
+// under every configuration and the results are OR-ed, so stemmed and
+// unstemmed documents both match.
+type SearchQuery struct {
+	Text    string
+	Configs []string
+}
+
+// expr returns the query as a tsquery SQL expression and its arguments.
+func (q SearchQuery) expr() (string, []interface{}) {
+	parts := make([]string, len(q.Configs))
+	args := make([]interface{}, 0, 2*len(q.Configs))
+	for i, config := range q.Configs {
+		parts[i] = "websearch_to_tsquery(?::regconfig, ?)"
+		args = append(args, config, q.Text)
+	}
+	return "(" + strings.Join(parts, " || ") + ")", args
+}
+
+// SearchRank is the relevance of one insight to a query.
+type SearchRank struct {
+	InsightID uint
+	Score     float64
+	Matches   int64
+}
+
+// SearchMatch is a matching document with its rank and highlighted headline.
+type SearchMatch struct {
+	InsightID    uint
+	Kind         models.SearchDocumentKind
+	SegmentIndex int
+	Seconds      int
+	Text         string
+	Rank         float64
+	Headline     string
+}
+
+// ReplaceDocuments replaces the search documents of an insight and marks it
+// indexed, even when it has no documents. Vectors are built by Postgres from
+// each document's Terms and configuration.
+func (r *SearchRepository) ReplaceDocuments(ctx context.Context, insightID uint, docs []models.SearchDocument) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.SearchDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE insights SET search_indexed_at = NOW() WHERE id = ?", insightID).Error; err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		rows := make([]map[string]interface{}, len(docs))
+		for i, doc := range docs {
+			rows[i] = map[string]interface{}{
+				"insight_id":    insightID,
+				"user_id":       doc.UserID,
+				"kind":          doc.Kind,
+				"segment_index": doc.SegmentIndex,
+				"seconds":       doc.Seconds,
+				"text":          doc.Text,
+				"config":        doc.Config,
+				"search_vector": gorm.Expr("setweight(to_tsvector(?::regconfig, ?), ?)", doc.Config, doc.Terms, doc.Kind.Weight()),
+				"created_at":    gorm.Expr("NOW()"),
+			}
+		}
+		return tx.Model(&models.SearchDocument{}).CreateInBatches(rows, 200).Error
+	})
+}
+
+// SearchInsights ranks a user's insights by their best matching document and
+// returns one page of them with the total number of matching insights.
+func (r *SearchRepository) SearchInsights(ctx context.Context, userID uint, query SearchQuery, limit, offset int) ([]SearchRank, int64, error) {
+	expr, args := query.expr()
+	from := `FROM search_documents d
+		JOIN insights i ON i.id = d.insight_id AND i.deleted_at IS NULL
+		CROSS JOIN (SELECT ` + expr + ` AS query) q
+		WHERE d.user_id = ? AND d.search_vector @@ q.query`
+	args = append(args, userID)
+
+	var total int64
+	if err := r.db.WithContext(ctx).Raw(`SELECT COUNT(DISTINCT d.insight_id) `+from, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	var ranks []SearchRank
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT d.insight_id,
+			MAX(ts_rank_cd(d.search_vector, q.query, 1)) AS score,
+			COUNT(*) AS matches
+		`+from+`
+		GROUP BY d.insight_id
+		ORDER BY score DESC, d.insight_id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...).Scan(&ranks).Error
+	return ranks, total, err
+}
+
+// SearchMatches returns up to perInsight best matching documents of each
+// insight, with ts_headline snippets.
+func (r *SearchRepository) SearchMatches(ctx context.Context, insightIDs []uint, query SearchQuery, perInsight int) ([]SearchMatch, error) {
+	if len(insightIDs) == 0 {
+		return nil, nil
+	}
+	expr, args := query.expr()
+	args = append(args, insightIDs, searchHeadlineOptions, perInsight)
+
+	var matches []SearchMatch
+	err := r.db.WithContext(ctx).Raw(`
+		WITH q AS (SELECT `+expr+` AS query),
+		ranked AS (
+			SELECT d.insight_id, d.kind, d.segment_index, d.seconds, d.text, d.config,
+				ts_rank_cd(d.search_vector, q.query, 1) AS rank,
+				ROW_NUMBER() OVER (
+					PARTITION BY d.insight_id
+					ORDER BY ts_rank_cd(d.search_vector, q.query, 1) DESC, d.segment_index ASC
+				) AS position
+			FROM search_documents d, q
+			WHERE d.insight_id IN ? AND d.search_vector @@ q.query
+		)
+		SELECT ranked.insight_id, ranked.kind, ranked.segment_index, ranked.seconds, ranked.text, ranked.rank,
+			ts_headline(ranked.config::regconfig, ranked.text, q.query, ?) AS headline
+		FROM ranked, q
+		WHERE ranked.position <= ?
+		ORDER BY ranked.insight_id, ranked.position`, args...).Scan(&matches).Error
+	return matches, err
+}
+
+// GetUnindexedInsightIDs returns completed insights after afterID that have
+// never been indexed, in ID order.
+func (r *SearchRepository) GetUnindexedInsightIDs(ctx context.Context, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&models.Insight{}).
+		Where("id > ? AND status = ?", afterID, models.InsightStatusCompleted).
+		Where("search_indexed_at IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
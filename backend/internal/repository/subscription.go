@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vibe-backend/internal/models"
+)
+
+// SubscriptionRepository handles database operations for channel
+// subscriptions and the channel feeds they follow.
+type SubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriptionRepository creates a new SubscriptionRepository.
+func NewSubscriptionRepository(db *gorm.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+// --- Subscriptions ---
+
+// Create creates a subscription along with the uploads it starts from, which
+// are recorded so that only later uploads become insights.
+func (r *SubscriptionRepository) Create(ctx context.Context, sub *models.ChannelSubscription, existing []models.SubscriptionVideo) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return nil
+		}
+		for i := range existing {
+			existing[i].SubscriptionID = sub.ID
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&existing).Error
+	})
+}
+
+// GetByID returns a subscription by ID.
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id uint) (*models.ChannelSubscription, error) {
+	var sub models.ChannelSubscription
+	err := r.db.WithContext(ctx).First(&sub, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetByChannel returns a user's subscription to a channel.
+func (r *SubscriptionRepository) GetByChannel(ctx context.Context, userID uint, channelID string) (*models.ChannelSubscription, error) {
+	var sub models.ChannelSubscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND channel_id = ?", userID, channelID).
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListByUser returns a user's subscriptions, most recent first.
+func (r *SubscriptionRepository) ListByUser(ctx context.Context, userID uint) ([]models.ChannelSubscription, error) {
+	var subs []models.ChannelSubscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&subs).Error
+	return subs, err
+}
+
+// ListActiveByChannel returns the subscriptions to a channel that are not
+// paused.
+func (r *SubscriptionRepository) ListActiveByChannel(ctx context.Context, channelID string) ([]models.ChannelSubscription, error) {
+	var subs []models.ChannelSubscription
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ? AND paused = ?", channelID, false).
+		Order("id ASC").
+		Find(&subs).Error
+	return subs, err
+}
+
+// ListActiveChannelIDs returns the channels with at least one subscription
+// that is not paused.
+func (r *SubscriptionRepository) ListActiveChannelIDs(ctx context.Context) ([]string, error) {
+	var channelIDs []string
+	err := r.db.WithContext(ctx).
+		Model(&models.ChannelSubscription{}).
+		Where("paused = ?", false).
+		Distinct().
+		Pluck("channel_id", &channelIDs).Error
+	return channelIDs, err
+}
+
+// CountByChannel counts the subscriptions to a channel, paused or not.
+func (r *SubscriptionRepository) CountByChannel(ctx context.Context, channelID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ChannelSubscription{}).
+		Where("channel_id = ?", channelID).
+		Count(&count).Error
+	return count, err
+}
+
+// Update updates a subscription record.
+func (r *SubscriptionRepository) Update(ctx context.Context, sub *models.ChannelSubscription) error {
+	return r.db.WithContext(ctx).Save(sub).Error
+}
+
+// Delete deletes a subscription and the uploads it has seen.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.SubscriptionVideo{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ChannelSubscription{}, id).Error
+	})
+}
+
+// --- Seen uploads ---
+
+// SeenVideoIDs returns which of the given videos a subscription has already
+// recorded.
+func (r *SubscriptionRepository) SeenVideoIDs(ctx context.Context, subscriptionID uint, videoIDs []string) (map[string]bool, error) {
+	var seen []string
+	err := r.db.WithContext(ctx).
+		Model(&models.SubscriptionVideo{}).
+		Where("subscription_id = ? AND video_id IN ?", subscriptionID, videoIDs).
+		Pluck("video_id", &seen).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(seen))
+	for _, id := range seen {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// RecordVideo records an upload for a subscription and, if insight is not
+// nil, creates the insight for it. It reports false without creating
+// anything when the upload was already recorded, e.g. by a concurrent poll.
+func (r *SubscriptionRepository) RecordVideo(ctx context.Context, video *models.SubscriptionVideo, insight *models.Insight) (bool, error) {
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(video)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		recorded = true
+		if insight == nil {
+			return nil
+		}
+		if err := tx.Create(insight).Error; err != nil {
+			return err
+		}
+		video.InsightID = &insight.ID
+		return tx.Model(video).Update("insight_id", insight.ID).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return recorded, nil
+}
+
+// ListVideos returns the uploads a subscription has seen, newest first.
+func (r *SubscriptionRepository) ListVideos(ctx context.Context, subscriptionID uint, limit int) ([]models.SubscriptionVideo, error) {
+	var videos []models.SubscriptionVideo
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("published_at DESC NULLS LAST, id DESC").
+		Limit(limit).
+		Find(&videos).Error
+	return videos, err
+}
+
+// --- Channel feeds ---
+
+// EnsureFeed creates the feed of a channel if it is not followed yet, and
+// returns it.
+func (r *SubscriptionRepository) EnsureFeed(ctx context.Context, channelID, title, pushSecret string) (*models.ChannelFeed, error) {
+	feed := models.ChannelFeed{ChannelID: channelID, Title: title, PushSecret: pushSecret}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetFeed(ctx, channelID)
+}
+
+// GetFeed returns the feed of a channel.
+func (r *SubscriptionRepository) GetFeed(ctx context.Context, channelID string) (*models.ChannelFeed, error) {
+	var feed models.ChannelFeed
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// UpdateFeedPolled records the outcome of polling a channel. An empty
+// pollErr clears the last error.
+func (r *SubscriptionRepository) UpdateFeedPolled(ctx context.Context, channelID, title, pollErr string) error {
+	updates := map[string]interface{}{
+		"last_polled_at": time.Now(),
+		"last_error":     pollErr,
+	}
+	if title != "" {
+		updates["title"] = title
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.ChannelFeed{}).
+		Where("channel_id = ?", channelID).
+		Updates(updates).Error
+}
+
+// UpdateFeedPush sets when a channel's hub lease expires; nil means push
+// is off.
+func (r *SubscriptionRepository) UpdateFeedPush(ctx context.Context, channelID string, expiresAt *time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ChannelFeed{}).
+		Where("channel_id = ?", channelID).
+		Update("push_expires_at", expiresAt).Error
+}
+
+// DeleteFeed stops following a channel.
+func (r *SubscriptionRepository) DeleteFeed(ctx context.Context, channelID string) error {
+	return r.db.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Delete(&models.ChannelFeed{}).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vibe-backend/internal/models"
+)
+
+// TranscriptRepository handles database operations for cached transcripts.
+type TranscriptRepository struct {
+	db *gorm.DB
+}
+
+// NewTranscriptRepository creates a new TranscriptRepository.
+func NewTranscriptRepository(db *gorm.DB) *TranscriptRepository {
+	return &TranscriptRepository{db: db}
+}
+
+// GetByVideoID returns the cached transcript of a video with its tracks, or
+// nil if the video has not been cached.
+func (r *TranscriptRepository) GetByVideoID(ctx context.Context, videoID string) (*models.TranscriptCache, error) {
+	var entry models.TranscriptCache
+	err := r.db.WithContext(ctx).
+		Preload("Tracks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("video_id = ?", videoID).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save stores a video's transcript, replacing any tracks cached before.
+func (r *TranscriptRepository) Save(ctx context.Context, entry *models.TranscriptCache) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tracks := entry.Tracks
+		entry.Tracks = nil
+		defer func() { entry.Tracks = tracks }()
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "video_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"title", "author", "channel_id", "description", "duration",
+				"provider", "fetched_at", "updated_at",
+			}),
+		}).Create(entry).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("video_id = ?", entry.VideoID).Delete(&models.TranscriptCacheTrack{}).Error; err != nil {
+			return err
+		}
+		if len(tracks) == 0 {
+			return nil
+		}
+		return tx.Create(&tracks).Error
+	})
+}
+
+// Delete removes a video's cached transcript.
+func (r *TranscriptRepository) Delete(ctx context.Context, videoID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.TranscriptCacheTrack{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("video_id = ?", videoID).Delete(&models.TranscriptCache{}).Error
+	})
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// UsageRepository handles database operations for LLM usage records.
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates a new UsageRepository.
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// Create records a single LLM call.
+func (r *UsageRepository) Create(ctx context.Context, usage *models.LLMUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+// UsageTotals holds summed cost and tokens.
+type UsageTotals struct {
+	CostUSD     float64
+	TotalTokens int64
+}
+
+// Totals sums usage since the given time. A nil userID sums across all users.
+func (r *UsageRepository) Totals(ctx context.Context, userID *uint, since time.Time) (*UsageTotals, error) {
+	var totals UsageTotals
+	query := r.db.WithContext(ctx).
+		Model(&models.LLMUsage{}).
+		Select("COALESCE(SUM(cost_usd), 0) AS cost_usd, COALESCE(SUM(total_tokens), 0) AS total_tokens").
+		Where("created_at >= ?", since)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// Rollup aggregates a user's usage into buckets ("day" or "month") since the given time.
+func (r *UsageRepository) Rollup(ctx context.Context, userID uint, bucket string, since time.Time) ([]models.UsageRollup, error) {
+	layout := "2006-01-02"
+	if bucket == "month" {
+		layout = "2006-01"
+	} else {
+		bucket = "day"
+	}
+
+	var rows []struct {
+		Bucket           time.Time
+		Requests         int64
+		PromptTokens     int64
+		CompletionTokens int64
+		TotalTokens      int64
+		CostUSD          float64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.LLMUsage{}).
+		Select(`date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket,
+			COUNT(*) AS requests,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(cost_usd), 0) AS cost_usd`, bucket).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Group("bucket").
+		Order("bucket ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := make([]models.UsageRollup, len(rows))
+	for i, row := range rows {
+		rollups[i] = models.UsageRollup{
+			Period:           row.Bucket.Format(layout),
+			Requests:         row.Requests,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			TotalTokens:      row.TotalTokens,
+			CostUSD:          row.CostUSD,
+		}
+	}
+	return rollups, nil
+}
+
+// RollupByFeature aggregates a user's usage per feature since the given time.
+func (r *UsageRepository) RollupByFeature(ctx context.Context, userID uint, since time.Time) ([]models.UsageRollup, error) {
+	var rollups []models.UsageRollup
+	err := r.db.WithContext(ctx).
+		Model(&models.LLMUsage{}).
+		Select(`feature,
+			COUNT(*) AS requests,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(cost_usd), 0) AS cost_usd`).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Group("feature").
+		Order("cost_usd DESC").
+		Scan(&rollups).Error
+	return rollups, err
+}
@@ -60,6 +60,17 @@ func (r *VideoRepository) UpdateAnalysis(ctx context.Context, analysis *models.V
 	return r.db.WithContext(ctx).Save(analysis).Error
 }
 
+// GetAnalysesByStatus returns analyses with the given status, oldest first.
+func (r *VideoRepository) GetAnalysesByStatus(ctx context.Context, status string, limit int) ([]models.VideoAnalysis, error) {
+	var analyses []models.VideoAnalysis
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&analyses).Error
+	return analyses, err
+}
+
 // GetHistoryByUserID returns all video analyses for a user.
 func (r *VideoRepository) GetHistoryByUserID(ctx context.Context, userID uint, limit int) ([]models.VideoAnalysis, error) {
 	var analyses []models.VideoAnalysis
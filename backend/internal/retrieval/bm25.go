@@ -0,0 +1,106 @@
+package retrieval
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters (standard Okapi defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Index is an in-memory BM25 index over a fixed set of documents.
+type Index struct {
+	termFreqs []map[string]int
+	docLens   []int
+	avgDocLen float64
+	docFreq   map[string]int
+}
+
+// NewIndex builds a BM25 index. Documents are identified by their position.
+func NewIndex(docs []string) *Index {
+	idx := &Index{
+		termFreqs: make([]map[string]int, len(docs)),
+		docLens:   make([]int, len(docs)),
+		docFreq:   make(map[string]int),
+	}
+
+	total := 0
+	for i, doc := range docs {
+		terms := Tokenize(doc)
+		tf := make(map[string]int, len(terms))
+		for _, t := range terms {
+			tf[t]++
+		}
+		for t := range tf {
+			idx.docFreq[t]++
+		}
+		idx.termFreqs[i] = tf
+		idx.docLens[i] = len(terms)
+		total += len(terms)
+	}
+	if len(docs) > 0 {
+		idx.avgDocLen = float64(total) / float64(len(docs))
+	}
+	return idx
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+	return len(idx.docLens)
+}
+
+// Search returns up to k documents matching query, best first. Documents
+// sharing no terms with the query are not returned.
+func (idx *Index) Search(query string, k int) []Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 || idx.Len() == 0 {
+		return nil
+	}
+
+	// Repeated query terms count once
+	seen := make(map[string]bool, len(terms))
+	n := float64(idx.Len())
+	var hits []Hit
+	scores := make([]float64, idx.Len())
+	for _, t := range terms {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		df := float64(idx.docFreq[t])
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, tf := range idx.termFreqs {
+			f := float64(tf[t])
+			if f == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(idx.docLens[i])/idx.avgDocLen
+			scores[i] += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+	}
+
+	for i, score := range scores {
+		if score > 0 {
+			hits = append(hits, Hit{Doc: i, Score: score})
+		}
+	}
+	return topK(hits, k)
+}
+
+// topK sorts hits by descending score and keeps the first k.
+func topK(hits []Hit, k int) []Hit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
@@ -0,0 +1,73 @@
+// Package retrieval ranks transcript chunks against a question using a local
+// BM25 index, optionally fused with embedding similarity.
+package retrieval
+
+import (
+	"math"
+	"sort"
+)
+
+// rrfK dampens the influence of top ranks in reciprocal rank fusion.
+const rrfK = 60
+
+// Hit is a ranked document.
+type Hit struct {
+	Doc   int
+	Score float64
+}
+
+// VectorSearch ranks documents by cosine similarity to query. Documents
+// without a vector (nil or mismatched length) are skipped.
+func VectorSearch(query []float32, vectors [][]float32, k int) []Hit {
+	var hits []Hit
+	for i, v := range vectors {
+		if len(v) == 0 || len(v) != len(query) {
+			continue
+		}
+		if score := Cosine(query, v); score > 0 {
+			hits = append(hits, Hit{Doc: i, Score: score})
+		}
+	}
+	return topK(hits, k)
+}
+
+// Fuse combines ranked lists with reciprocal rank fusion, which needs no
+// score normalization between BM25 and cosine similarity.
+func Fuse(k int, lists ...[]Hit) []Hit {
+	scores := make(map[int]float64)
+	for _, list := range lists {
+		for rank, hit := range list {
+			scores[hit.Doc] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, Hit{Doc: doc, Score: score})
+	}
+	// Break ties by document order so results are deterministic
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc < hits[j].Doc
+	})
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// Cosine returns the cosine similarity of two equal-length vectors.
+func Cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
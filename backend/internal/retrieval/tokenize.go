@@ -0,0 +1,72 @@
+package retrieval
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are common English words that carry no retrieval signal.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "do": true, "for": true, "from": true,
+	"he": true, "her": true, "his": true, "i": true, "if": true, "in": true,
+	"is": true, "it": true, "its": true, "me": true, "my": true, "of": true,
+	"on": true, "or": true, "our": true, "she": true, "so": true, "that": true,
+	"the": true, "their": true, "them": true, "then": true, "there": true,
+	"they": true, "this": true, "to": true, "was": true, "we": true,
+	"were": true, "what": true, "when": true, "which": true, "who": true,
+	"will": true, "with": true, "you": true, "your": true,
+}
+
+// Tokenize splits text into lowercase terms for BM25. Words in space
+// separated scripts become one term each; runs of CJK characters, which have
+// no spaces, become single characters plus overlapping bigrams.
+func Tokenize(text string) []string {
+	var terms []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			w := string(word)
+			if !stopwords[w] {
+				terms = append(terms, w)
+			}
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		for i, r := range cjk {
+			terms = append(terms, string(r))
+			if i+1 < len(cjk) {
+				terms = append(terms, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+// isCJK reports whether r belongs to a script written without spaces.
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
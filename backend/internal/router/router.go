@@ -3,17 +3,25 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"vibe-backend/internal/article"
 	"vibe-backend/internal/cache"
 	"vibe-backend/internal/config"
 	"vibe-backend/internal/database"
 	"vibe-backend/internal/handlers"
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/podcast"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
+	"vibe-backend/internal/stt"
+	"vibe-backend/internal/twitter"
+	"vibe-backend/internal/ytfeed"
 )
 
 // New creates and configures a new Gin router.
-func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, log *zap.Logger) *gin.Engine {
+// Background work is registered on jobManager, which the caller starts and stops.
+func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, jobManager *jobs.Manager, log *zap.Logger) *gin.Engine {
 	// Set Gin mode based on environment
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -45,23 +53,91 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 	pomodoroRepo := repository.NewPomodoroRepository(db.DB)
 	pomodoroHandler := handlers.NewPomodoroHandler(pomodoroRepo)
 	parserService := services.NewParserService(log)
+	tweetFetcher, err := twitter.New(twitter.Config{
+		Backend:        cfg.TwitterFetcher,
+		SyndicationURL: cfg.TwitterSyndicationURL,
+		TimelineURL:    cfg.TwitterTimelineURL,
+		OEmbedURL:      cfg.TwitterOEmbedURL,
+		Timeout:        cfg.TwitterTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid Twitter configuration", zap.Error(err))
+	}
+	parserService.SetTweetFetcher(tweetFetcher)
 	parseHandler := handlers.NewParseHandler(parserService, log)
 
 	// Analysis handlers
 	analysisRepo := repository.NewAnalysisRepository(db.DB)
 	analysisHandler := handlers.NewAnalysisHandler(analysisRepo, log)
 
+	// Shared LLM client used by all AI features
+	llmClient, err := llm.New(llm.Config{
+		Provider:          cfg.LLMProvider,
+		BaseURL:           cfg.LLMBaseURL,
+		APIKey:            cfg.LLMKey(),
+		Model:             cfg.LLMModelName(),
+		Timeout:           cfg.LLMTimeout,
+		StreamIdleTimeout: cfg.LLMStreamIdleTimeout,
+		MaxRetries:        cfg.LLMMaxRetries,
+	}, log)
+	if err != nil {
+		log.Fatal("Invalid LLM configuration", zap.Error(err))
+	}
+
+	// LLM usage accounting and budgets
+	llmPricing, err := llm.ParsePricing(cfg.LLMPricing)
+	if err != nil {
+		log.Fatal("Invalid LLM_PRICING", zap.Error(err))
+	}
+	usageRepo := repository.NewUsageRepository(db.DB)
+	usageService := services.NewUsageService(usageRepo, llmPricing, services.UsageBudgets{
+		UserMonthlyUSD:    cfg.AIUserMonthlyBudgetUSD,
+		UserMonthlyTokens: cfg.AIUserMonthlyTokenBudget,
+		GlobalMonthlyUSD:  cfg.AIGlobalMonthlyBudgetUSD,
+	}, log)
+	llmClient.SetMeter(usageService)
+	usageHandler := handlers.NewUsageHandler(usageService, log)
+
+	// Transcript service (ordered provider chain for YouTube captions)
+	transcriber, err := stt.New(stt.Config{
+		Backend:   cfg.STTBackend,
+		Binary:    cfg.STTWhisperBinary,
+		ModelPath: cfg.STTWhisperModel,
+		BaseURL:   cfg.STTBaseURL,
+		APIKey:    cfg.STTKey(),
+		Model:     cfg.STTModel,
+		Timeout:   cfg.STTTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid speech-to-text configuration", zap.Error(err))
+	}
+	transcriptProviders, err := services.NewTranscriptProviders(cfg.TranscriptProviders, services.TranscriptProviderOptions{
+		Languages:        cfg.TranscriptLanguages,
+		Transcriber:      transcriber,
+		STTChunkDuration: cfg.STTChunkDuration,
+		STTMaxDuration:   cfg.STTMaxDuration,
+	}, log)
+	if err != nil {
+		log.Fatal("Invalid TRANSCRIPT_PROVIDERS", zap.Error(err))
+	}
+	transcriptService := services.NewTranscriptService(transcriptProviders, cfg.TranscriptLanguages, log)
+	transcriptService.SetStore(services.NewTranscriptStore(repository.NewTranscriptRepository(db.DB), cache, cfg.TranscriptCacheTTL, log))
+	if cfg.TranscriptSentences {
+		var punctuationClient *llm.Client
+		if cfg.TranscriptLLMPunctuation {
+			punctuationClient = llmClient
+		}
+		transcriptService.SetSegmenter(services.NewSentenceSegmenter(punctuationClient, log))
+	}
+
 	// YouTube video analysis handlers
 	videoRepo := repository.NewVideoRepository(db.DB)
-	youtubeService := services.NewYouTubeService(cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
+	youtubeService := services.NewYouTubeService(llmClient, transcriptService, log)
 	videoHandler := handlers.NewVideoHandler(videoRepo, youtubeService, log)
 
-	// Transcript service (yt-dlp based subtitle extraction)
-	transcriptService := services.NewTranscriptService(log)
-
 	// Translation service and handlers
 	translationRepo := repository.NewTranslationRepository(db.DB)
-	translationService := services.NewTranslationService(cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
+	translationService := services.NewTranslationService(llmClient, log)
 	translationHandler := handlers.NewTranslationHandler(translationRepo, translationService, transcriptService, log)
 
 	// User authentication handlers
@@ -70,20 +146,96 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 
 	// InsightFlow handlers
 	insightRepo := repository.NewInsightRepository(db.DB)
-	insightProcessor := services.NewInsightProcessor(insightRepo, youtubeService, log)
+	insightProcessor := services.NewInsightProcessor(insightRepo, youtubeService, transcriptService, log)
 	insightProcessor.SetTranslationService(translationService) // Inject translation service
+	insightProcessor.SetSummaryService(services.NewSummaryService(llmClient, log))
+	insightProcessor.SetChapterService(services.NewChapterService(llmClient, log))
+	insightProcessor.SetTweetFetcher(tweetFetcher)
+	podcastOptions := services.PodcastOptions{
+		STTChunkDuration: cfg.STTChunkDuration,
+		STTMaxDuration:   cfg.STTMaxDuration,
+	}
+	if cfg.PodcastSTT {
+		podcastOptions.Transcriber = transcriber
+	}
+	insightProcessor.SetPodcastClient(podcast.NewClient(podcast.Config{Timeout: cfg.PodcastTimeout}), podcastOptions)
+	insightProcessor.SetArticleClient(article.NewClient(article.Config{Timeout: cfg.ArticleTimeout}))
+
+	// Transcript retrieval for grounded chat
+	embedder, err := llm.NewEmbedder(llm.EmbedderConfig{
+		Provider: cfg.EmbeddingProvider,
+		BaseURL:  cfg.EmbeddingBaseURL,
+		APIKey:   cfg.EmbeddingKey(),
+		Model:    cfg.EmbeddingModel,
+		Timeout:  cfg.LLMTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid embedding configuration", zap.Error(err))
+	}
+	retrievalService := services.NewRetrievalService(insightRepo, embedder, cfg.RAGTopK, log)
+	insightProcessor.SetRetrievalService(retrievalService)
+
+	// Full-text search across a user's library
+	searchService := services.NewSearchService(repository.NewSearchRepository(db.DB), insightRepo, log)
+	insightProcessor.SetSearchService(searchService)
+	searchHandler := handlers.NewSearchHandler(searchService, log)
+
 	insightHandler := handlers.NewInsightHandler(insightRepo, insightProcessor, log)
+	insightHandler.SetSearchService(searchService)
+
+	// YouTube channel subscriptions
+	subscriptionRepo := repository.NewSubscriptionRepository(db.DB)
+	feedClient := ytfeed.NewClient(ytfeed.Config{
+		Timeout: cfg.SubscriptionTimeout,
+		HubURL:  cfg.SubscriptionHubURL,
+	})
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo, insightRepo, userRepo, feedClient, youtubeService, insightProcessor, services.SubscriptionOptions{
+		PollInterval:    cfg.SubscriptionPollInterval,
+		PushCallbackURL: cfg.SubscriptionPushCallbackURL,
+		PushLease:       cfg.SubscriptionPushLease,
+	}, log)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionRepo, subscriptionService, log)
+
+	// Background job queues
+	jobRepo := repository.NewJobRepository(db.DB)
+	jobHandler := handlers.NewJobHandler(jobRepo, log)
+	if jobManager != nil {
+		jobOpts := jobs.QueueOptions{
+			MaxAttempts:  cfg.JobMaxAttempts,
+			Lease:        cfg.JobLease,
+			PollInterval: cfg.JobPollInterval,
+			Backoff:      cfg.JobRetryBackoff,
+		}
+		insightOpts, videoOpts, subscriptionOpts := jobOpts, jobOpts, jobOpts
+		insightOpts.Workers = cfg.JobInsightWorkers
+		videoOpts.Workers = cfg.JobVideoWorkers
+		subscriptionOpts.Workers = 1
+
+		jobManager.Register(jobs.QueueInsight, insightProcessor.HandleJob, insightOpts)
+		jobManager.Register(jobs.QueueVideoAnalysis, videoHandler.HandleAnalysisJob, videoOpts)
+		jobManager.Register(jobs.QueueSubscription, subscriptionService.HandleJob, subscriptionOpts)
+		jobManager.OnStart(insightProcessor.RecoverStale)
+		jobManager.OnStart(videoHandler.RecoverStale)
+		jobManager.OnStart(searchService.StartBackfill)
+		jobManager.OnStart(subscriptionService.Start)
+		insightProcessor.SetJobQueue(jobManager)
+		videoHandler.SetJobQueue(jobManager)
+		subscriptionService.SetJobQueue(jobManager)
+	}
 
 	// YouTube Data API v3 handlers (OAuth + API endpoints)
 	oauthService := services.NewOAuthService(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL, log)
 	youtubeAPIService := services.NewYouTubeAPIService(cfg.YouTubeAPIKey, cache, oauthService, log)
-	youtubeAPIHandler := handlers.NewYouTubeAPIHandler(youtubeAPIService, youtubeService, oauthService, userRepo, log)
+	insightHandler.SetYouTubeAPIService(youtubeAPIService)
+	youtubeAPIHandler := handlers.NewYouTubeAPIHandler(youtubeAPIService, transcriptService, oauthService, userRepo, log)
 
 	transcriptHandler := handlers.NewTranscriptHandler(transcriptService, log)
 
 	// Chat handlers
 	chatRepo := repository.NewChatRepository(db.DB)
-	chatService := services.NewChatService(chatRepo, videoRepo, insightRepo, cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
+	chatService := services.NewChatService(chatRepo, videoRepo, insightRepo, llmClient, log)
+	chatService.SetRetrievalService(retrievalService)
+	chatService.SetContextWindow(cfg.ChatContextWindow)
 	chatHandler := handlers.NewChatHandler(chatService, log)
 
 	// Image compression handler (no database required)
@@ -104,6 +256,7 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 			authProtected.Use(middleware.Auth(userRepo, log))
 			{
 				authProtected.GET("/profile", userHandler.GetProfile)
+				authProtected.PATCH("/profile", userHandler.UpdateProfile)
 				authProtected.POST("/regenerate-key", userHandler.RegenerateAPIKey)
 			}
 		}
@@ -172,12 +325,13 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 				system.GET("/quota", youtubeAPIHandler.GetQuota)
 			}
 
-			// Transcript extraction endpoint (yt-dlp based)
+			// Transcript extraction endpoint (provider chain)
 			v1.POST("/transcript", transcriptHandler.GetTranscript)
 
 			// Translation routes
 			v1.POST("/translate", translationHandler.Translate)
 			v1.GET("/translate/:id", translationHandler.GetTranslation)
+			v1.GET("/translate/:id/subtitles", translationHandler.ExportSubtitles)
 
 			// InsightFlow routes (protected by authentication)
 			insights := v1.Group("/insights")
@@ -185,10 +339,25 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 			{
 				insights.GET("", insightHandler.List)
 				insights.POST("", insightHandler.Create)
+				insights.POST("/upload", insightHandler.CreateFromUpload)
+				insights.POST("/import", insightHandler.Import)
+				insights.GET("/batches", insightHandler.ListBatches)
+				insights.GET("/batches/:batchId", insightHandler.GetBatch)
 				insights.GET("/:id", insightHandler.Get)
 				insights.PATCH("/:id", insightHandler.Update)
 				insights.DELETE("/:id", insightHandler.Delete)
 				insights.POST("/:id/process", insightHandler.Process)
+				insights.GET("/:id/transcripts", insightHandler.ListTranscripts)
+				insights.PUT("/:id/transcript-language", insightHandler.SwitchTranscript)
+				insights.GET("/:id/subtitles", insightHandler.ExportSubtitles)
+
+				// Version routes
+				insights.GET("/:id/versions", insightHandler.ListVersions)
+				insights.GET("/:id/versions/:version", insightHandler.GetVersion)
+				insights.GET("/:id/versions/:version/diff", insightHandler.DiffVersions)
+				insights.POST("/:id/versions/:version/restore", insightHandler.RestoreVersion)
+				insights.POST("/:id/versions/:version/pin", insightHandler.PinVersion)
+				insights.DELETE("/:id/versions/pin", insightHandler.UnpinVersion)
 
 				// Share routes
 				insights.POST("/:id/share", insightHandler.ShareInsight)
@@ -199,16 +368,57 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 				insights.POST("/:id/highlights", insightHandler.CreateHighlight)
 				insights.PATCH("/:id/highlights/:highlightId", insightHandler.UpdateHighlight)
 				insights.DELETE("/:id/highlights/:highlightId", insightHandler.DeleteHighlight)
+				insights.GET("/:id/highlights/:highlightId/chat", insightHandler.ListHighlightChatMessages)
 
-				// Chat routes (InsightHandler)
+				// Chat routes (history via InsightHandler, streaming answers via ChatHandler)
 				insights.GET("/:id/chat", insightHandler.ListChatMessages)
-				insights.POST("/:id/chat", insightHandler.CreateChatMessage)
+				insights.POST("/:id/chat", chatHandler.Chat)
+				insights.POST("/:id/chat/regenerate", chatHandler.Regenerate)
 				insights.DELETE("/:id/chat", insightHandler.ClearChatHistory)
 
 				// Entity analysis route (ChatHandler)
 				insights.POST("/:id/analyze-entities", chatHandler.AnalyzeEntities)
 			}
 
+			// YouTube channel subscriptions (protected by authentication)
+			subscriptions := v1.Group("/subscriptions")
+			subscriptions.Use(middleware.Auth(userRepo, log))
+			{
+				subscriptions.GET("", subscriptionHandler.List)
+				subscriptions.POST("", subscriptionHandler.Create)
+				subscriptions.PATCH("/:id", subscriptionHandler.Update)
+				subscriptions.DELETE("/:id", subscriptionHandler.Delete)
+				subscriptions.GET("/:id/videos", subscriptionHandler.ListVideos)
+			}
+
+			// WebSub callback for subscribed channels (public; notifications are signed)
+			v1.GET("/websub/youtube/:channelId", subscriptionHandler.VerifyPush)
+			v1.POST("/websub/youtube/:channelId", subscriptionHandler.ReceivePush)
+
+			// Library search (protected by authentication)
+			search := v1.Group("/search")
+			search.Use(middleware.Auth(userRepo, log))
+			{
+				search.GET("", searchHandler.Search)
+			}
+
+			// LLM usage and budget (protected by authentication)
+			usage := v1.Group("/usage")
+			usage.Use(middleware.Auth(userRepo, log))
+			{
+				usage.GET("", usageHandler.Get)
+			}
+
+			// Job queue admin (authentication + ADMIN_EMAILS)
+			admin := v1.Group("/admin")
+			admin.Use(middleware.Auth(userRepo, log), middleware.RequireAdmin(cfg.AdminEmails, log))
+			{
+				admin.GET("/jobs", jobHandler.Stats)
+				admin.GET("/jobs/dead", jobHandler.ListDead)
+				admin.POST("/jobs/:id/retry", jobHandler.Retry)
+				admin.GET("/transcript-providers", transcriptHandler.ProviderStats)
+			}
+
 			// Shared insight (public access, with rate limiting to prevent brute-force)
 			v1.GET("/shared/:token", middleware.ShareAccessRateLimit(), insightHandler.GetShared)
 		}
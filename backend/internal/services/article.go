@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/article"
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/netguard"
+)
+
+// TranscriptProviderArticle names transcripts made from the paragraphs of a
+// web article.
+const TranscriptProviderArticle = "article"
+
+// SetArticleClient sets the client used to fetch web articles.
+func (p *InsightProcessor) SetArticleClient(client *article.Client) {
+	p.articleClient = client
+}
+
+// processArticleInsight extracts the main content of a web page. Its
+// paragraphs become the insight's transcript, positioned by estimated
+// reading time, so highlights, chat citations and translation address them
+// the way they address lines of a video transcript.
+func (p *InsightProcessor) processArticleInsight(ctx context.Context, insight *models.Insight) error {
+	p.log.Info("Processing article insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("source_url", insight.SourceURL),
+	)
+
+	if p.articleClient == nil {
+		return jobs.Permanent(fmt.Errorf("未配置网页文章抓取"))
+	}
+
+	page, err := p.articleClient.Fetch(ctx, insight.SourceURL)
+	if err != nil {
+		var statusErr *article.StatusError
+		switch {
+		case errors.As(err, &statusErr) && statusErr.Permanent():
+			return jobs.Permanent(fmt.Errorf("无法访问该网页 (HTTP %d)", statusErr.StatusCode))
+		case errors.Is(err, article.ErrNotHTML):
+			return jobs.Permanent(fmt.Errorf("该链接不是网页: %v", err))
+		case errors.Is(err, article.ErrNoContent):
+			return jobs.Permanent(fmt.Errorf("未能从网页中提取正文"))
+		case errors.Is(err, netguard.ErrBlockedAddress):
+			return jobs.Permanent(fmt.Errorf("不允许访问该地址"))
+		}
+		return fmt.Errorf("获取网页失败: %w", err)
+	}
+
+	insight.Title = page.Title
+	if insight.Title == "" {
+		insight.Title = page.URL
+	}
+	insight.Author = page.Author
+	if insight.Author == "" {
+		insight.Author = page.SiteName
+	}
+	insight.ThumbnailURL = page.LeadImageURL
+	insight.PublishedAt = page.PublishedAt
+	insight.Duration = int(page.ReadingTime / time.Second)
+	meta, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("failed to encode article: %w", err)
+	}
+	insight.SourceMeta = meta
+
+	items, creatorChapters := articleItems(page)
+	transcripts, err := p.convertTranscriptsToInsightFormat(ctx, items, insight.TargetLang)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("网页正文为空: %v", err))
+	}
+	insight.Transcripts = transcripts
+	insight.TranscriptLanguage = page.Language
+	insight.TranscriptKind = models.CaptionKindManual
+	insight.RawContent = page.Text()
+	if n := len(items); n > 0 && items[n-1].Seconds > insight.Duration {
+		insight.Duration = items[n-1].Seconds
+	}
+
+	return p.completeInsight(ctx, insight, creatorChapters, TranscriptProviderArticle)
+}
+
+// articleItems turns an article's paragraphs into transcript items, one per
+// paragraph at its reading position, and its headings into chapters when
+// there are enough of them to outline the article.
+func articleItems(page *article.Article) ([]models.TranscriptItem, []models.YouTubeChapter) {
+	items := make([]models.TranscriptItem, 0, len(page.Paragraphs))
+	var chapters []models.YouTubeChapter
+	seconds := -1
+	for _, para := range page.Paragraphs {
+		// Positions must be distinct to address a single paragraph
+		seconds = max(seconds+1, int(para.Start/time.Second))
+		items = append(items, models.TranscriptItem{
+			Timestamp: formatDuration(seconds),
+			Seconds:   seconds,
+			Text:      para.Text,
+		})
+		if para.Level > 0 {
+			chapters = append(chapters, models.YouTubeChapter{Title: para.Text, Seconds: seconds})
+		}
+	}
+	if len(chapters) < 2 {
+		chapters = nil
+	}
+	return items, chapters
+}
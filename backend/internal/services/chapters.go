@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+const (
+	// chapterExcerptChars bounds the transcript excerpt sent per chapter when
+	// summarizing creator chapters.
+	chapterExcerptChars = 3000
+	// minChapterSeconds is the shortest generated chapter kept as its own entry.
+	minChapterSeconds = 60
+)
+
+// ChapterService builds insight chapters, either from creator timestamps in
+// the video description or by asking the LLM to find topic shifts in the
+// transcript.
+type ChapterService struct {
+	llmClient *llm.Client
+	log       *zap.Logger
+}
+
+// NewChapterService creates a new ChapterService.
+func NewChapterService(llmClient *llm.Client, log *zap.Logger) *ChapterService {
+	return &ChapterService{
+		llmClient: llmClient,
+		log:       log,
+	}
+}
+
+// ChapterInput is the content to build chapters for.
+type ChapterInput struct {
+	Title       string
+	Transcripts []models.TranscriptItem
+	Creator     []models.YouTubeChapter // chapters parsed from the description, if any
+	Duration    int                     // content length in seconds, 0 if unknown
+	TargetLang  string                  // language of generated titles and summaries
+}
+
+// chapterResponse is the JSON shape the model is asked to return.
+type chapterResponse struct {
+	Chapters []struct {
+		Index        int     `json:"index"`
+		Title        string  `json:"title"`
+		StartSeconds float64 `json:"start_seconds"`
+		Summary      string  `json:"summary"`
+	} `json:"chapters"`
+}
+
+// Generate returns the chapters for the content, or nil if there is neither
+// a creator chapter list nor a transcript to work from.
+func (s *ChapterService) Generate(ctx context.Context, in ChapterInput) ([]models.InsightChapter, error) {
+	ctx = llm.WithFeature(ctx, llm.FeatureChapters)
+
+	end := in.Duration
+	if n := len(in.Transcripts); n > 0 && in.Transcripts[n-1].Seconds > end {
+		end = in.Transcripts[n-1].Seconds
+	}
+
+	if len(in.Creator) > 0 {
+		chapters := make([]models.InsightChapter, len(in.Creator))
+		for i, c := range in.Creator {
+			chapters[i] = models.InsightChapter{
+				Title:        c.Title,
+				StartSeconds: c.Seconds,
+				Source:       models.ChapterSourceDescription,
+			}
+		}
+		chapters = finalizeChapters(chapters, end)
+
+		if len(in.Transcripts) > 0 {
+			if err := s.summarizeChapters(ctx, in, chapters); err != nil {
+				if llm.IsRetryable(err) {
+					return nil, err
+				}
+				// Creator chapters are still useful without summaries
+				s.log.Warn("Failed to summarize creator chapters", zap.Error(err))
+			}
+		}
+		return chapters, nil
+	}
+
+	if len(in.Transcripts) == 0 {
+		return nil, nil
+	}
+
+	chapters, err := s.detectChapters(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return finalizeChapters(chapters, end), nil
+}
+
+// detectChapters asks the LLM to split each transcript chunk at topic shifts.
+func (s *ChapterService) detectChapters(ctx context.Context, in ChapterInput) ([]models.InsightChapter, error) {
+	chunks := chunkTranscripts(in.Transcripts, summaryChunkChars)
+	results := make([][]models.InsightChapter, len(chunks))
+
+	err := forEachConcurrently(len(chunks), summaryMapConcurrency, func(i int) error {
+		prompt := fmt.Sprintf(`Split the following transcript into chapters at the points where the topic changes.
+%sEach transcript line starts with the second it begins at, in square brackets.
+Chapters should usually span several minutes; do not create a chapter for every few sentences.
+%sFor each chapter give a short title (at most 8 words) and a 1-2 sentence summary, written in %s.
+"start_seconds" must be the start second of the transcript line where the chapter begins.
+Return ONLY JSON in this format, without any other text:
+{"chapters": [{"title": "...", "start_seconds": <integer seconds>, "summary": "..."}]}
+
+Transcript:
+%s`,
+			chapterContext(in.Title),
+			chunkPosition(i, len(chunks)),
+			outputLanguage(in.TargetLang),
+			chunks[i].Text,
+		)
+
+		parsed, err := s.complete(ctx, prompt)
+		if err != nil {
+			return fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		for _, c := range parsed.Chapters {
+			title := strings.TrimSpace(c.Title)
+			if title == "" {
+				continue
+			}
+			start := int(c.StartSeconds)
+			if start < chunks[i].Start || start > chunks[i].End {
+				continue
+			}
+			results[i] = append(results[i], models.InsightChapter{
+				Title:        title,
+				Summary:      strings.TrimSpace(c.Summary),
+				StartSeconds: snapToTranscript(start, in.Transcripts),
+				Source:       models.ChapterSourceGenerated,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []models.InsightChapter
+	for _, r := range results {
+		chapters = append(chapters, r...)
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters detected")
+	}
+	return mergeShortChapters(chapters), nil
+}
+
+// summarizeChapters fills in a short summary for each chapter from the
+// transcript lines it covers.
+func (s *ChapterService) summarizeChapters(ctx context.Context, in ChapterInput, chapters []models.InsightChapter) error {
+	// Render each chapter's excerpt and batch them to fit one call each
+	excerpts := make([]string, len(chapters))
+	for i, ch := range chapters {
+		var sb strings.Builder
+		for _, item := range in.Transcripts {
+			if item.Seconds < ch.StartSeconds || (item.Seconds >= ch.EndSeconds && i < len(chapters)-1) {
+				continue
+			}
+			sb.WriteString(strings.TrimSpace(item.Text))
+			sb.WriteString(" ")
+		}
+		excerpts[i] = truncateRunes(strings.TrimSpace(sb.String()), chapterExcerptChars)
+	}
+
+	var batches [][]int
+	var current []int
+	size := 0
+	for i, excerpt := range excerpts {
+		n := utf8.RuneCountInString(excerpt) + utf8.RuneCountInString(chapters[i].Title)
+		if len(current) > 0 && size+n > summaryChunkChars {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, i)
+		size += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return forEachConcurrently(len(batches), summaryMapConcurrency, func(b int) error {
+		var sections strings.Builder
+		for _, i := range batches[b] {
+			fmt.Fprintf(&sections, "\n## %d. %s\n%s\n", i+1, chapters[i].Title, excerpts[i])
+		}
+
+		prompt := fmt.Sprintf(`Below are chapters of a video with the transcript text each one covers.
+%sFor each chapter write a 1-2 sentence summary in %s.
+Return ONLY JSON in this format, without any other text:
+{"chapters": [{"index": <chapter number>, "summary": "..."}]}
+%s`, chapterContext(in.Title), outputLanguage(in.TargetLang), sections.String())
+
+		parsed, err := s.complete(ctx, prompt)
+		if err != nil {
+			return err
+		}
+		// Only accept indexes from this batch; other batches run concurrently
+		inBatch := make(map[int]bool, len(batches[b]))
+		for _, i := range batches[b] {
+			inBatch[i] = true
+		}
+		for _, c := range parsed.Chapters {
+			if i := c.Index - 1; inBatch[i] {
+				chapters[i].Summary = strings.TrimSpace(c.Summary)
+			}
+		}
+		return nil
+	})
+}
+
+// complete sends a chapter prompt and parses the JSON response.
+func (s *ChapterService) complete(ctx context.Context, prompt string) (*chapterResponse, error) {
+	resp, err := s.llmClient.Complete(ctx, llm.Request{
+		Messages:    []llm.Message{llm.User(prompt)},
+		Temperature: llm.Temperature(0.3),
+		MaxTokens:   2000,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chapter generation failed: %w", err)
+	}
+
+	var parsed chapterResponse
+	if err := json.Unmarshal([]byte(cleanJSONResponse(resp.Content)), &parsed); err != nil {
+		s.log.Warn("Failed to parse chapter response",
+			zap.Error(err),
+			zap.String("raw_response", resp.Content),
+		)
+		return nil, fmt.Errorf("failed to parse chapter response: %w", err)
+	}
+	return &parsed, nil
+}
+
+// chapterContext returns the prompt line naming the content, if known.
+func chapterContext(title string) string {
+	if title == "" {
+		return ""
+	}
+	return fmt.Sprintf("The video is titled %q.\n", title)
+}
+
+// chunkPosition tells the model which part of a long transcript it sees.
+func chunkPosition(i, n int) string {
+	if n == 1 {
+		return ""
+	}
+	return fmt.Sprintf("This is part %d of %d of the transcript; only chapter this part. Its first line continues the previous part's topic unless the topic clearly changes.\n", i+1, n)
+}
+
+// outputLanguage returns the language name to write output in.
+func outputLanguage(targetLang string) string {
+	if lang := languageName(targetLang); lang != "" {
+		return lang
+	}
+	return "the same language as the content"
+}
+
+// finalizeChapters sorts chapters, makes the first start at 0, fills in end
+// times and timestamps.
+func finalizeChapters(chapters []models.InsightChapter, end int) []models.InsightChapter {
+	if len(chapters) == 0 {
+		return chapters
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].StartSeconds < chapters[j].StartSeconds
+	})
+	chapters[0].StartSeconds = 0
+
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].EndSeconds = chapters[i+1].StartSeconds
+		} else {
+			chapters[i].EndSeconds = end
+		}
+		if chapters[i].EndSeconds < chapters[i].StartSeconds {
+			chapters[i].EndSeconds = chapters[i].StartSeconds
+		}
+		chapters[i].Timestamp = formatDuration(chapters[i].StartSeconds)
+		chapters[i].OrderIndex = i
+	}
+	return chapters
+}
+
+// mergeShortChapters drops duplicate starts and folds chapters shorter than
+// minChapterSeconds into the previous one.
+func mergeShortChapters(chapters []models.InsightChapter) []models.InsightChapter {
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].StartSeconds < chapters[j].StartSeconds
+	})
+
+	merged := make([]models.InsightChapter, 0, len(chapters))
+	for _, ch := range chapters {
+		if n := len(merged); n > 0 && ch.StartSeconds-merged[n-1].StartSeconds < minChapterSeconds {
+			continue
+		}
+		merged = append(merged, ch)
+	}
+	return merged
+}
+
+// snapToTranscript returns the start of the last transcript line beginning
+// at or before seconds.
+func snapToTranscript(seconds int, items []models.TranscriptItem) int {
+	idx := sort.Search(len(items), func(i int) bool { return items[i].Seconds > seconds }) - 1
+	if idx < 0 {
+		return 0
+	}
+	return items[idx].Seconds
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+var (
+	// "0:00 Intro", "(1:02:03) Topic", "12:34 - Topic"
+	chapterTimeFirst = regexp.MustCompile(`^[\(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\)\]]?\s*(?:[-–—:|•]\s*)?(.+)$`)
+	// "Intro - 0:00", "Topic (12:34)"
+	chapterTimeLast = regexp.MustCompile(`^(.+?)\s*(?:[-–—:|•]\s*)?[\(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\)\]]?$`)
+)
+
+// ParseDescriptionChapters extracts creator chapters from a video description.
+// Like YouTube, it only accepts lists that start at 0:00, have at least three
+// entries and are in ascending order; anything else returns nil.
+func ParseDescriptionChapters(description string) []models.YouTubeChapter {
+	var chapters []models.YouTubeChapter
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var stamp, title string
+		if m := chapterTimeFirst.FindStringSubmatch(line); m != nil {
+			stamp, title = m[1], m[2]
+		} else if m := chapterTimeLast.FindStringSubmatch(line); m != nil {
+			title, stamp = m[1], m[2]
+		} else {
+			continue
+		}
+
+		seconds, ok := parseClock(stamp)
+		title = strings.TrimSpace(title)
+		if !ok || title == "" {
+			continue
+		}
+		chapters = append(chapters, models.YouTubeChapter{Title: title, Seconds: seconds})
+	}
+
+	if len(chapters) < 3 || chapters[0].Seconds != 0 {
+		return nil
+	}
+	for i := 1; i < len(chapters); i++ {
+		if chapters[i].Seconds <= chapters[i-1].Seconds {
+			return nil
+		}
+	}
+	return chapters
+}
+
+// parseClock converts "MM:SS" or "H:MM:SS" to seconds.
+func parseClock(stamp string) (int, bool) {
+	parts := strings.Split(stamp, ":")
+	total := 0
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	return total, true
+}
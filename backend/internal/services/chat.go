@@ -1,29 +1,39 @@
 package services
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 )
 
+var (
+	// ErrChatInsightNotFound is returned when the insight being discussed does not exist.
+	ErrChatInsightNotFound = errors.New("insight not found")
+	// ErrChatForbidden is returned when the insight belongs to another user.
+	ErrChatForbidden = errors.New("insight belongs to another user")
+	// ErrChatHighlightNotFound is returned when a highlight is not part of the insight.
+	ErrChatHighlightNotFound = errors.New("highlight not found")
+	// ErrNothingToRegenerate is returned when the conversation has no question to answer again.
+	ErrNothingToRegenerate = errors.New("no question to regenerate an answer for")
+)
+
 // ChatService handles AI chat operations.
 type ChatService struct {
-	chatRepo         *repository.ChatRepository
-	videoRepo        *repository.VideoRepository
-	insightRepo      *repository.InsightRepository
-	openRouterAPIKey string
-	chatModel        string
-	httpClient       *http.Client
-	log              *zap.Logger
+	chatRepo      *repository.ChatRepository
+	videoRepo     *repository.VideoRepository
+	insightRepo   *repository.InsightRepository
+	llmClient     *llm.Client
+	retrieval     *RetrievalService
+	contextWindow int // tokens; 0 uses the model's known window
+	log           *zap.Logger
 }
 
 // NewChatService creates a new ChatService.
@@ -31,48 +41,44 @@ func NewChatService(
 	chatRepo *repository.ChatRepository,
 	videoRepo *repository.VideoRepository,
 	insightRepo *repository.InsightRepository,
-	apiKey string,
-	chatModel string,
+	llmClient *llm.Client,
 	log *zap.Logger,
 ) *ChatService {
-	if chatModel == "" {
-		chatModel = "anthropic/claude-3-5-sonnet"
+	return &ChatService{
+		chatRepo:    chatRepo,
+		videoRepo:   videoRepo,
+		insightRepo: insightRepo,
+		llmClient:   llmClient,
+		log:         log,
 	}
+}
 
-	// Validate API key on service initialization
-	if apiKey == "" {
-		log.Error("⚠️  OpenRouter API 密钥未设置",
-			zap.String("环境变量", "OPENROUTER_API_KEY"),
-			zap.String("影响功能", "聊天、实体分析等AI功能将无法使用"),
-			zap.String("解决方案", "请在 .env 文件中设置 OPENROUTER_API_KEY，访问 https://openrouter.ai/ 获取密钥"),
-		)
-	} else {
-		maskedKey := apiKey[:10] + "..." + apiKey[len(apiKey)-4:]
-		log.Info("✅ OpenRouter API 服务已初始化",
-			zap.String("model", chatModel),
-			zap.String("api_key", maskedKey),
-		)
-	}
+// SetRetrievalService sets the service used to ground answers in the transcript.
+func (s *ChatService) SetRetrievalService(svc *RetrievalService) {
+	s.retrieval = svc
+}
 
-	return &ChatService{
-		chatRepo:         chatRepo,
-		videoRepo:        videoRepo,
-		insightRepo:      insightRepo,
-		openRouterAPIKey: apiKey,
-		chatModel:        chatModel,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
-		log: log,
-	}
+// SetContextWindow overrides the context window, in tokens, that chat prompts
+// are built to fit. Zero uses the model's known window.
+func (s *ChatService) SetContextWindow(tokens int) {
+	s.contextWindow = tokens
 }
 
-// ChatStream sends a message and returns a channel for streaming responses.
-func (s *ChatService) ChatStream(ctx context.Context, insightID uint, message string, highlightID *uint) (<-chan models.ChatStreamEvent, error) {
-	// Get the insight for context
-	insight, err := s.insightRepo.GetByID(ctx, insightID)
+// ChatStream saves the user's question and returns a channel streaming the
+// assistant's answer. The insight must belong to userID.
+func (s *ChatService) ChatStream(ctx context.Context, userID, insightID uint, message string, highlightID *uint) (<-chan models.ChatStreamEvent, error) {
+	insight, err := s.loadOwnedInsight(ctx, userID, insightID)
 	if err != nil {
-		return nil, fmt.Errorf("insight not found: %w", err)
+		return nil, err
+	}
+
+	// Load the highlight the question is anchored to
+	var highlight *models.Highlight
+	if highlightID != nil {
+		highlight, err = s.insightRepo.GetHighlightByID(ctx, *highlightID)
+		if err != nil || highlight.InsightID != insightID {
+			return nil, fmt.Errorf("%w: %d", ErrChatHighlightNotFound, *highlightID)
+		}
 	}
 
 	// Get existing chat history
@@ -85,28 +91,101 @@ func (s *ChatService) ChatStream(ctx context.Context, insightID uint, message st
 	// Save user message
 	userMessage := &models.ChatMessage{
 		InsightID:   insightID,
-		UserID:      0, // TODO: Get from context/auth
+		UserID:      userID,
 		Role:        "user",
 		Content:     message,
 		HighlightID: highlightID,
 	}
 	if err := s.chatRepo.CreateMessage(ctx, userMessage); err != nil {
-		s.log.Error("Failed to save user message", zap.Error(err))
+		return nil, fmt.Errorf("failed to save user message: %w", err)
+	}
+
+	return s.answer(ctx, userID, insight, history, message, highlight)
+}
+
+// RegenerateStream discards the latest assistant answer, if any, and streams
+// a new answer to the latest user question. The insight must belong to userID.
+func (s *ChatService) RegenerateStream(ctx context.Context, userID, insightID uint) (<-chan models.ChatStreamEvent, error) {
+	insight, err := s.loadOwnedInsight(ctx, userID, insightID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.chatRepo.GetMessagesByAnalysisID(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	// Find the latest question; everything after it is the answer being replaced
+	last := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return nil, ErrNothingToRegenerate
+	}
+	question := history[last]
+
+	for _, msg := range history[last+1:] {
+		if err := s.chatRepo.DeleteMessage(ctx, msg.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete previous answer: %w", err)
+		}
+	}
+
+	var highlight *models.Highlight
+	if question.HighlightID != nil {
+		highlight, err = s.insightRepo.GetHighlightByID(ctx, *question.HighlightID)
+		if err != nil {
+			// The highlight was deleted since; answer without it
+			highlight = nil
+		}
+	}
+
+	return s.answer(ctx, userID, insight, history[:last], question.Content, highlight)
+}
+
+// loadOwnedInsight returns the insight if it exists and belongs to userID.
+func (s *ChatService) loadOwnedInsight(ctx context.Context, userID, insightID uint) (*models.Insight, error) {
+	insight, err := s.insightRepo.GetByID(ctx, insightID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatInsightNotFound
+		}
+		return nil, fmt.Errorf("failed to get insight: %w", err)
+	}
+	if insight.UserID != userID {
+		return nil, ErrChatForbidden
 	}
+	return insight, nil
+}
 
-	// Build system prompt with context
-	systemPrompt := s.buildSystemPrompt(insight)
+// answer starts the model stream for question and returns a channel relaying
+// it. history holds the turns before question.
+func (s *ChatService) answer(ctx context.Context, userID uint, insight *models.Insight, history []models.ChatMessage, question string, highlight *models.Highlight) (<-chan models.ChatStreamEvent, error) {
+	// Collect the sources the answer may cite
+	sources := s.loadCitationSources(ctx, insight, question, highlight)
 
-	// Build messages array
-	messages := s.buildMessages(systemPrompt, history, message)
+	// Build the prompt within the model's context window
+	messages := s.buildContext(ctx, insight, sources, history, question)
+
+	// Start the model stream before returning so budget and configuration
+	// errors reach the caller instead of an empty stream.
+	ctx = llm.WithTags(ctx, llm.Tags{UserID: userID, InsightID: insight.ID, Feature: llm.FeatureChat})
+	stream, err := s.llmClient.Stream(ctx, llm.Request{Messages: messages})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start chat stream: %w", err)
+	}
 
 	// Create response channel
 	responseChan := make(chan models.ChatStreamEvent, 100)
 
-	// Start streaming in goroutine
+	// Forward the stream in a goroutine
 	go func() {
 		defer close(responseChan)
-		s.streamFromOpenRouter(ctx, messages, insightID, responseChan)
+		s.forwardStream(ctx, stream, userID, insight.ID, sources, responseChan)
 	}()
 
 	return responseChan, nil
@@ -124,10 +203,18 @@ func (s *ChatService) GetChatHistory(ctx context.Context, analysisID uint) (*mod
 	}
 
 	for i, msg := range messages {
+		citations, err := models.ParseCitations(msg.Citations)
+		if err != nil {
+			s.log.Warn("Failed to parse chat citations",
+				zap.Uint("message_id", msg.ID),
+				zap.Error(err),
+			)
+		}
 		response.Messages[i] = models.ChatMessageResponse{
 			ID:        msg.ID,
 			Role:      msg.Role,
 			Content:   msg.Content,
+			Citations: citations,
 			CreatedAt: msg.CreatedAt,
 		}
 	}
@@ -136,18 +223,14 @@ func (s *ChatService) GetChatHistory(ctx context.Context, analysisID uint) (*mod
 }
 
 // AnalyzeEntities analyzes the content and returns detected entities and suggestions.
-func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*models.AnalyzeEntitiesResponse, error) {
+func (s *ChatService) AnalyzeEntities(ctx context.Context, userID, insightID uint) (*models.AnalyzeEntitiesResponse, error) {
 	s.log.Info("Starting entity analysis",
 		zap.Uint("insight_id", insightID),
 	)
 
-	insight, err := s.insightRepo.GetByID(ctx, insightID)
+	insight, err := s.loadOwnedInsight(ctx, userID, insightID)
 	if err != nil {
-		s.log.Error("Failed to fetch insight",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("insight not found: %w", err)
+		return nil, err
 	}
 
 	s.log.Debug("Insight data retrieved",
@@ -176,22 +259,23 @@ func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*mod
 
 只返回JSON，不要其他文字。`, insight.Title, insight.Author, insight.Summary)
 
-	s.log.Debug("Calling OpenRouter API",
+	s.log.Debug("Calling LLM",
 		zap.Uint("insight_id", insightID),
-		zap.String("model", s.chatModel),
+		zap.String("model", s.llmClient.Model()),
 	)
 
-	response, err := s.callOpenRouter(ctx, prompt)
+	ctx = llm.WithTags(ctx, llm.Tags{UserID: userID, InsightID: insightID, Feature: llm.FeatureEntities})
+	response, err := s.llmClient.CompleteText(ctx, prompt)
 	if err != nil {
 		s.log.Error("Failed to analyze entities",
 			zap.Uint("insight_id", insightID),
-			zap.String("model", s.chatModel),
+			zap.String("model", s.llmClient.Model()),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("failed to call AI service: %w", err)
 	}
 
-	s.log.Debug("OpenRouter API response received",
+	s.log.Debug("LLM response received",
 		zap.Uint("insight_id", insightID),
 		zap.Int("response_length", len(response)),
 	)
@@ -232,8 +316,54 @@ func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*mod
 	return &result, nil
 }
 
-// buildSystemPrompt creates the system prompt with insight context.
-func (s *ChatService) buildSystemPrompt(insight *models.Insight) string {
+// loadCitationSources retrieves the transcript passages relevant to the
+// question, the user's highlights and, for highlight-anchored questions, the
+// transcript around the highlight. Failures are logged and the answer falls
+// back to the summary.
+func (s *ChatService) loadCitationSources(ctx context.Context, insight *models.Insight, question string, highlight *models.Highlight) citationSources {
+	var sources citationSources
+
+	if s.retrieval != nil {
+		query := question
+		if highlight != nil {
+			// "Explain this" questions carry little text of their own
+			query = question + "\n" + highlight.Text
+		}
+		passages, err := s.retrieval.Retrieve(ctx, insight, query)
+		if err != nil {
+			s.log.Warn("Failed to retrieve transcript passages",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+		sources.passages = passages
+	}
+
+	if (len(sources.passages) > 0 || highlight != nil) && len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &sources.transcripts); err != nil {
+			s.log.Warn("Failed to parse transcripts", zap.Uint("insight_id", insight.ID), zap.Error(err))
+		}
+	}
+
+	if highlight != nil {
+		sources.focus = newHighlightFocus(*highlight, sources.transcripts)
+	}
+
+	highlights, err := s.insightRepo.GetHighlightsByInsightID(ctx, insight.ID)
+	if err != nil {
+		s.log.Warn("Failed to get highlights", zap.Uint("insight_id", insight.ID), zap.Error(err))
+	}
+	sources.highlights = highlights
+
+	return sources
+}
+
+// buildSystemPrompt creates the system prompt with insight context and the
+// sources retrieved for the current question.
+func (s *ChatService) buildSystemPrompt(insight *models.Insight, sources citationSources) string {
+	if len(sources.passages) > 0 || len(sources.highlights) > 0 || sources.focus != nil {
+		return s.buildGroundedPrompt(insight, sources)
+	}
 	return fmt.Sprintf(`你是一个智能阅读助手。用户正在阅读以下内容：
 
 标题: %s
@@ -247,261 +377,188 @@ func (s *ChatService) buildSystemPrompt(insight *models.Insight) string {
 4. 支持 Markdown 格式`, insight.Title, insight.Author, insight.Summary)
 }
 
+// buildGroundedPrompt creates a system prompt that asks the model to answer
+// from the retrieved passages and highlights and to cite them.
+func (s *ChatService) buildGroundedPrompt(insight *models.Insight, sources citationSources) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`你是一个智能阅读助手。用户正在阅读以下内容：
+
+标题: %s
+作者: %s
+摘要: %s
+`, insight.Title, insight.Author, insight.Summary))
+
+	if len(sources.passages) > 0 {
+		sb.WriteString("\n以下是原文中与用户问题最相关的片段（按相关度排序，方括号内为时间范围）：\n")
+		for _, p := range sources.passages {
+			if p.StartSeconds == 0 && p.EndSeconds == 0 {
+				// Raw content without timing
+				sb.WriteString(fmt.Sprintf("- %s\n", p.Text))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", formatChunkRange(p), p.Text))
+		}
+	}
+
+	if len(sources.highlights) > 0 {
+		sb.WriteString("\n以下是用户的高亮（方括号内为编号）：\n")
+		sb.WriteString(formatHighlightsForPrompt(sources.highlights))
+	}
+
+	if f := sources.focus; f != nil {
+		sb.WriteString(fmt.Sprintf("\n用户正在针对高亮 [H%d] 提问，问题中的“这段”“这里”等均指该高亮。\n高亮原文: %s\n", f.highlight.ID, f.highlight.Text))
+		if f.highlight.Note != "" {
+			sb.WriteString(fmt.Sprintf("用户笔记: %s\n", f.highlight.Note))
+		}
+		if len(f.window) > 0 {
+			sb.WriteString("高亮前后的原文：\n")
+			for _, item := range f.window {
+				sb.WriteString(fmt.Sprintf("[%s] %s\n", formatDuration(item.Seconds), strings.TrimSpace(item.Text)))
+			}
+		}
+	}
+
+	sb.WriteString(`
+请基于以上内容回答用户的问题。回答时：
+1. 优先依据原文片段作答，引用原文时在句末标注对应时间，如 [05:12]
+2. 引用用户的高亮时标注其编号，如 [H3]
+3. 如果以上内容中没有相关信息，可以结合摘要或你的知识回答，但需说明
+4. 不要编造原文中不存在的时间或内容
+5. 保持回答简洁、有洞察力
+6. 支持 Markdown 格式`)
+	return sb.String()
+}
+
 // buildMessages constructs the messages array for the API call.
-func (s *ChatService) buildMessages(systemPrompt string, history []models.ChatMessage, newMessage string) []map[string]string {
-	messages := make([]map[string]string, 0, len(history)+2)
+func (s *ChatService) buildMessages(systemPrompt string, history []models.ChatMessage, newMessage string) []llm.Message {
+	messages := make([]llm.Message, 0, len(history)+2)
 
 	// Add system message
-	messages = append(messages, map[string]string{
-		"role":    "system",
-		"content": systemPrompt,
-	})
-
-	// Add history (limit to last 10 messages to control context size)
-	historyStart := 0
-	if len(history) > 10 {
-		historyStart = len(history) - 10
-	}
-	for _, msg := range history[historyStart:] {
-		messages = append(messages, map[string]string{
-			"role":    msg.Role,
-			"content": msg.Content,
+	messages = append(messages, llm.System(systemPrompt))
+
+	// Add history (already trimmed to the token budget by buildContext)
+	for _, msg := range history {
+		messages = append(messages, llm.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
 		})
 	}
 
 	// Add new user message
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": newMessage,
-	})
+	messages = append(messages, llm.User(newMessage))
 
 	return messages
 }
 
-// streamFromOpenRouter handles the SSE streaming from OpenRouter.
-func (s *ChatService) streamFromOpenRouter(ctx context.Context, messages []map[string]string, insightID uint, responseChan chan<- models.ChatStreamEvent) {
-	const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
-
-	requestBody := map[string]interface{}{
-		"model":    s.chatModel,
-		"messages": messages,
-		"stream":   true,
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		s.log.Error("Failed to marshal request", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
+// forwardStream relays the model response into responseChan and saves the
+// assistant message. If the client goes away (ctx is cancelled) the model
+// stream stops and whatever was generated so far is still saved, so the
+// question keeps its answer and can be regenerated.
+func (s *ChatService) forwardStream(ctx context.Context, stream <-chan llm.Chunk, userID, insightID uint, sources citationSources, responseChan chan<- models.ChatStreamEvent) {
+	// send delivers an event unless the client has gone away
+	send := func(event models.ChatStreamEvent) {
+		select {
+		case responseChan <- event:
+		case <-ctx.Done():
+		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		s.log.Error("Failed to create request", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
+	var fullContent strings.Builder
+	var streamErr error
+	for chunk := range stream {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+			break
+		}
+		if chunk.Content != "" {
+			fullContent.WriteString(chunk.Content)
+			send(models.ChatStreamEvent{
+				Type:    models.ChatEventMessage,
+				Role:    "assistant",
+				Content: chunk.Content,
+				Done:    false,
+			})
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openRouterAPIKey)
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("HTTP-Referer", "https://github.com/your-repo/vibe-engineering-playbook")
-	req.Header.Set("X-Title", "VIBE Engineering Playbook")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.log.Error("Failed to call OpenRouter API", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var errorBody bytes.Buffer
-		errorBody.ReadFrom(resp.Body)
-		errorBodyStr := errorBody.String()
-		
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效 (流式请求)",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
-				zap.String("api_key_prefix", s.maskAPIKey()),
+	disconnected := ctx.Err() != nil
+	if streamErr != nil {
+		if disconnected {
+			s.log.Info("Chat client disconnected",
 				zap.Uint("insight_id", insightID),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
+				zap.Int("partial_length", fullContent.Len()),
 			)
 		} else {
-			s.log.Error("OpenRouter streaming API error",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
+			s.log.Error("Chat stream interrupted",
 				zap.Uint("insight_id", insightID),
+				zap.Error(streamErr),
 			)
+			send(models.ChatStreamEvent{
+				Type:    models.ChatEventError,
+				Role:    "assistant",
+				Content: "AI 回复中断，请重新生成",
+			})
 		}
-		
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
 	}
 
-	// Read SSE stream
-	var fullContent strings.Builder
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, ":") {
-			continue
-		}
-
-		// Parse SSE data
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-
-			// Check for stream end
-			if data == "[DONE]" {
-				break
-			}
-
-			// Parse chunk
-			var chunk struct {
-				Choices []struct {
-					Delta struct {
-						Content string `json:"content"`
-					} `json:"delta"`
-					FinishReason *string `json:"finish_reason"`
-				} `json:"choices"`
-			}
-
-			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-				continue
-			}
-
-			if len(chunk.Choices) > 0 {
-				content := chunk.Choices[0].Delta.Content
-				if content != "" {
-					fullContent.WriteString(content)
-					responseChan <- models.ChatStreamEvent{
-						Role:    "assistant",
-						Content: content,
-						Done:    false,
-					}
-				}
-			}
-		}
-	}
+	// Persist even after a disconnect
+	saveCtx := context.WithoutCancel(ctx)
 
 	// Save assistant message
 	if fullContent.Len() > 0 {
 		assistantMessage := &models.ChatMessage{
 			InsightID: insightID,
-			UserID:    0, // TODO: Get from context/auth
+			UserID:    userID,
 			Role:      "assistant",
 			Content:   fullContent.String(),
 		}
-		if err := s.chatRepo.CreateMessage(ctx, assistantMessage); err != nil {
+		if sources.focus != nil {
+			// Keep the answer in the highlight's conversation
+			highlightID := sources.focus.highlight.ID
+			assistantMessage.HighlightID = &highlightID
+		}
+
+		// Resolve citations and send them before the final event
+		citations := extractCitations(assistantMessage.Content, sources)
+		if len(citations) > 0 {
+			data, err := json.Marshal(citations)
+			if err != nil {
+				s.log.Error("Failed to marshal chat citations", zap.Error(err))
+			} else {
+				assistantMessage.Citations = data
+			}
+			send(models.ChatStreamEvent{
+				Type:      models.ChatEventCitations,
+				Role:      "assistant",
+				Citations: citations,
+			})
+		}
+
+		if err := s.chatRepo.CreateMessage(saveCtx, assistantMessage); err != nil {
 			s.log.Error("Failed to save assistant message", zap.Error(err))
 		}
 
 		// Send final event with message ID
-		responseChan <- models.ChatStreamEvent{
+		send(models.ChatStreamEvent{
+			Type:      models.ChatEventMessage,
 			Role:      "assistant",
 			Content:   "",
 			Done:      true,
 			MessageID: &assistantMessage.ID,
-		}
+		})
 	} else {
-		responseChan <- models.ChatStreamEvent{Done: true}
+		send(models.ChatStreamEvent{Type: models.ChatEventMessage, Done: true})
 	}
 }
 
-// callOpenRouter makes a non-streaming call to OpenRouter.
-func (s *ChatService) callOpenRouter(ctx context.Context, prompt string) (string, error) {
-	const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
-
-	requestBody := map[string]interface{}{
-		"model": s.chatModel,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openRouterAPIKey)
-	req.Header.Set("HTTP-Referer", "https://github.com/your-repo/vibe-engineering-playbook")
-	req.Header.Set("X-Title", "VIBE Engineering Playbook")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call OpenRouter API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		var errorBody bytes.Buffer
-		errorBody.ReadFrom(resp.Body)
-		errorBodyStr := errorBody.String()
-		
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
-				zap.String("api_key_prefix", s.maskAPIKey()),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
-			)
-			return "", fmt.Errorf("OpenRouter API 认证失败（401）: %s - 请检查 OPENROUTER_API_KEY 是否有效", errorBodyStr)
-		}
-		
-		s.log.Error("OpenRouter API returned error",
-			zap.Int("status_code", resp.StatusCode),
-			zap.String("response_body", errorBodyStr),
-		)
-		return "", fmt.Errorf("OpenRouter API error: status %d, body: %s", resp.StatusCode, errorBodyStr)
-	}
-
-	var response struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-			Code    string `json:"code"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Check for API-level errors
-	if response.Error != nil {
-		return "", fmt.Errorf("OpenRouter API error: %s (code: %s)", response.Error.Message, response.Error.Code)
-	}
-
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no response from API")
-	}
-
-	return response.Choices[0].Message.Content, nil
-}
-
 // cleanJSONResponse removes markdown code blocks from JSON response.
 func (s *ChatService) cleanJSONResponse(response string) string {
+	return cleanJSONResponse(response)
+}
+
+// cleanJSONResponse removes markdown code blocks and surrounding text from an
+// LLM JSON response.
+func cleanJSONResponse(response string) string {
 	cleaned := strings.TrimSpace(response)
 
 	// Remove markdown code blocks
@@ -534,14 +591,3 @@ func (s *ChatService) cleanJSONResponse(response string) string {
 
 	return cleaned
 }
-
-// maskAPIKey returns a masked version of the API key for logging (shows only first 10 chars).
-func (s *ChatService) maskAPIKey() string {
-	if s.openRouterAPIKey == "" {
-		return "<未设置>"
-	}
-	if len(s.openRouterAPIKey) <= 10 {
-		return "***"
-	}
-	return s.openRouterAPIKey[:10] + "..." + s.openRouterAPIKey[len(s.openRouterAPIKey)-4:]
-}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+const (
+	// chatReplyReserveTokens is kept free in the context window for the answer.
+	chatReplyReserveTokens = 2048
+	// chatHistoryMaxTokens caps verbatim history even on very large windows,
+	// so long sessions do not resend the whole conversation every turn.
+	chatHistoryMaxTokens = 6000
+	// chatMemoryMaxRunes caps the length of the rolling summary.
+	chatMemoryMaxRunes = 1500
+	// chatMemoryFoldTokens caps the older turns folded into the summary per call.
+	chatMemoryFoldTokens = 8000
+)
+
+// buildContext assembles the messages for one chat turn within the model's
+// context window. The system prompt is trimmed to at most half of the budget,
+// recent turns are kept verbatim and older turns are replaced by the
+// persisted rolling summary, which is updated when turns fall out of view.
+func (s *ChatService) buildContext(ctx context.Context, insight *models.Insight, sources citationSources, history []models.ChatMessage, newMessage string) []llm.Message {
+	model := s.llmClient.Model()
+	window := s.contextWindow
+	if window <= 0 {
+		window = llm.ContextWindow(model)
+	}
+	budget := window - chatReplyReserveTokens - llm.CountMessageTokens(model, []llm.Message{llm.User(newMessage)})
+
+	systemPrompt := s.fitSystemPrompt(insight, sources, budget/2)
+	budget -= llm.CountMessageTokens(model, []llm.Message{llm.System(systemPrompt)})
+
+	memory, err := s.chatRepo.GetMemory(ctx, insight.ID)
+	if err != nil {
+		s.log.Warn("Failed to load chat memory", zap.Uint("insight_id", insight.ID), zap.Error(err))
+	}
+
+	// Only turns after the summary's last folded message are still pending
+	pending := history
+	if memory != nil {
+		pending = messagesAfter(history, memory.CoveredMessageID)
+	}
+
+	// Leave room for the summary at its maximum length
+	historyBudget := budget - llm.CountTokens(model, strings.Repeat("字", chatMemoryMaxRunes))
+	if historyBudget > chatHistoryMaxTokens {
+		historyBudget = chatHistoryMaxTokens
+	}
+	keep := recentMessages(model, pending, historyBudget)
+	if overflow := pending[:len(pending)-keep]; len(overflow) > 0 {
+		updated, err := s.foldIntoMemory(ctx, insight, memory, overflow)
+		if err != nil {
+			// The older turns are dropped from this prompt but will be
+			// folded in on the next turn
+			s.log.Warn("Failed to update chat memory",
+				zap.Uint("insight_id", insight.ID),
+				zap.Int("messages", len(overflow)),
+				zap.Error(err),
+			)
+		} else {
+			memory = updated
+		}
+	}
+
+	if memory != nil && memory.Summary != "" {
+		systemPrompt += fmt.Sprintf("\n\n此前对话摘要（较早的 %d 条消息已压缩）：\n%s", memory.CoveredMessages, memory.Summary)
+	}
+	return s.buildMessages(systemPrompt, pending[len(pending)-keep:], newMessage)
+}
+
+// fitSystemPrompt builds the system prompt, dropping the least relevant
+// passages, then highlights, then the highlight window until it fits in
+// maxTokens. As a last resort the prompt is truncated.
+func (s *ChatService) fitSystemPrompt(insight *models.Insight, sources citationSources, maxTokens int) string {
+	model := s.llmClient.Model()
+	for {
+		prompt := s.buildSystemPrompt(insight, sources)
+		if llm.CountTokens(model, prompt) <= maxTokens {
+			return prompt
+		}
+
+		switch {
+		case len(sources.passages) > 0:
+			sources.passages = sources.passages[:len(sources.passages)-1]
+		case len(sources.highlights) > 0:
+			sources.highlights = sources.highlights[:len(sources.highlights)-1]
+		case sources.focus != nil && len(sources.focus.window) > 0:
+			// Copy so the caller's focus keeps its full window for citations
+			focus := *sources.focus
+			focus.window = nil
+			sources.focus = &focus
+		default:
+			return truncateToTokens(model, prompt, maxTokens)
+		}
+	}
+}
+
+// recentMessages returns how many messages at the end of history fit in
+// maxTokens. A user turn is never kept without the answer that follows it.
+func recentMessages(model string, history []models.ChatMessage, maxTokens int) int {
+	used, keep := 0, 0
+	for i := len(history) - 1; i >= 0; i-- {
+		used += llm.CountMessageTokens(model, []llm.Message{{Role: history[i].Role, Content: history[i].Content}})
+		if used > maxTokens {
+			break
+		}
+		keep++
+	}
+	// Don't start the verbatim history with an orphaned assistant answer
+	for keep > 0 && history[len(history)-keep].Role == "assistant" {
+		keep--
+	}
+	return keep
+}
+
+// foldIntoMemory summarizes older turns into the insight's rolling summary and
+// persists it. Turns are folded in batches so one call never exceeds
+// chatMemoryFoldTokens of conversation.
+func (s *ChatService) foldIntoMemory(ctx context.Context, insight *models.Insight, memory *models.ChatMemory, turns []models.ChatMessage) (*models.ChatMemory, error) {
+	model := s.llmClient.Model()
+	next := &models.ChatMemory{InsightID: insight.ID}
+	if memory != nil {
+		*next = *memory
+	}
+
+	ctx = llm.WithTags(ctx, llm.Tags{InsightID: insight.ID, Feature: llm.FeatureChatMemory})
+	for start := 0; start < len(turns); {
+		var sb strings.Builder
+		end, used := start, 0
+		for end < len(turns) {
+			line := fmt.Sprintf("%s: %s\n", chatRoleLabel(turns[end].Role), turns[end].Content)
+			n := llm.CountTokens(model, line)
+			if end > start && used+n > chatMemoryFoldTokens {
+				break
+			}
+			sb.WriteString(line)
+			used += n
+			end++
+		}
+
+		summary, err := s.summarizeTurns(ctx, insight, next.Summary, sb.String())
+		if err != nil {
+			return nil, err
+		}
+		next.Summary = summary
+		next.CoveredMessageID = turns[end-1].ID
+		next.CoveredMessages += end - start
+		start = end
+	}
+
+	if err := s.chatRepo.SaveMemory(ctx, next); err != nil {
+		return nil, fmt.Errorf("failed to save chat memory: %w", err)
+	}
+	return next, nil
+}
+
+// summarizeTurns merges new conversation turns into an existing summary.
+func (s *ChatService) summarizeTurns(ctx context.Context, insight *models.Insight, previous, turns string) (string, error) {
+	if previous == "" {
+		previous = "（无）"
+	}
+	prompt := fmt.Sprintf(`以下是用户与助手围绕《%s》的对话记录。请将“已有摘要”与“新增对话”合并为一份更新后的摘要。
+
+要求：
+1. 保留用户关心的问题、得出的结论、用户表达的偏好和尚未解决的疑问
+2. 保留对话中提到的时间点（如 [05:12]）和高亮编号（如 [H3]）
+3. 不超过 %d 字，使用与对话相同的语言
+4. 只输出摘要本身，不要其他文字
+
+已有摘要：
+%s
+
+新增对话：
+%s`, insight.Title, chatMemoryMaxRunes, previous, turns)
+
+	resp, err := s.llmClient.Complete(ctx, llm.Request{
+		Messages:    []llm.Message{llm.User(prompt)},
+		Temperature: llm.Temperature(0.2),
+	})
+	if err != nil {
+		return "", err
+	}
+	return truncateRunes(strings.TrimSpace(resp.Content), chatMemoryMaxRunes), nil
+}
+
+// messagesAfter returns the messages with an ID greater than id. History is
+// ordered by creation time, which follows ID order.
+func messagesAfter(history []models.ChatMessage, id uint) []models.ChatMessage {
+	for i, msg := range history {
+		if msg.ID > id {
+			return history[i:]
+		}
+	}
+	return nil
+}
+
+// chatRoleLabel names a message role in summarization prompts.
+func chatRoleLabel(role string) string {
+	if role == "assistant" {
+		return "助手"
+	}
+	return "用户"
+}
+
+// truncateToTokens cuts text so it uses at most maxTokens with model.
+func truncateToTokens(model, text string, maxTokens int) string {
+	runes := []rune(text)
+	for len(runes) > 0 && llm.CountTokens(model, string(runes)) > maxTokens {
+		// Shrink proportionally, with a floor so the loop always progresses
+		cut := len(runes) * maxTokens / llm.CountTokens(model, string(runes))
+		if cut >= len(runes) {
+			cut = len(runes) - 1
+		}
+		runes = runes[:cut]
+	}
+	return string(runes)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vibe-backend/internal/models"
+)
+
+const (
+	// citationQuoteChars caps the length of a quoted citation in runes.
+	citationQuoteChars = 200
+	// chatPromptHighlights caps the number of highlights offered to the model.
+	chatPromptHighlights = 20
+)
+
+var (
+	// "[12:34]", "[1:02:03]", "[12:34-13:00]"; only the start is cited
+	citationTimestamp = regexp.MustCompile(`\[((?:\d{1,2}:)?\d{1,2}:\d{2})(?:\s*[-–~]\s*(?:\d{1,2}:)?\d{1,2}:\d{2})?\]`)
+	// "[H12]"
+	citationHighlight = regexp.MustCompile(`\[H(\d+)\]`)
+)
+
+// citationSources is the material a chat answer was allowed to cite.
+type citationSources struct {
+	passages    []models.TranscriptChunk
+	transcripts []models.TranscriptItem
+	highlights  []models.Highlight
+	focus       *highlightFocus // set for highlight-anchored questions
+}
+
+// extractCitations finds the timestamps and highlight markers in an answer and
+// resolves them against the sources given to the model. Markers that do not
+// match a source are dropped so hallucinated times never become links.
+func extractCitations(answer string, sources citationSources) []models.ChatCitation {
+	var citations []models.ChatCitation
+	seen := make(map[string]bool)
+
+	for _, m := range citationTimestamp.FindAllStringSubmatch(answer, -1) {
+		seconds, ok := parseClock(m[1])
+		if !ok || !sources.coversSeconds(seconds) {
+			continue
+		}
+		key := "t" + strconv.Itoa(seconds)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		citations = append(citations, models.ChatCitation{
+			Type:      models.CitationTypeTranscript,
+			Seconds:   seconds,
+			Timestamp: formatDuration(seconds),
+			Quote:     truncateRunes(sources.quoteAt(seconds), citationQuoteChars),
+		})
+	}
+
+	for _, m := range citationHighlight.FindAllStringSubmatch(answer, -1) {
+		id, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		key := "h" + m[1]
+		if seen[key] {
+			continue
+		}
+		for _, h := range sources.highlights {
+			if h.ID != uint(id) {
+				continue
+			}
+			seen[key] = true
+			highlightID := h.ID
+			citations = append(citations, models.ChatCitation{
+				Type:        models.CitationTypeHighlight,
+				Quote:       truncateRunes(h.Text, citationQuoteChars),
+				HighlightID: &highlightID,
+			})
+			break
+		}
+	}
+
+	return citations
+}
+
+// coversSeconds reports whether seconds falls inside a retrieved passage or
+// the transcript around the focused highlight.
+func (cs citationSources) coversSeconds(seconds int) bool {
+	if cs.focus.covers(seconds) {
+		return true
+	}
+	for _, p := range cs.passages {
+		if seconds >= p.StartSeconds && seconds <= p.EndSeconds {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteAt returns the transcript line playing at seconds, falling back to the
+// passage containing it.
+func (cs citationSources) quoteAt(seconds int) string {
+	if len(cs.transcripts) > 0 {
+		i := sort.Search(len(cs.transcripts), func(i int) bool {
+			return cs.transcripts[i].Seconds > seconds
+		})
+		if i > 0 {
+			return strings.TrimSpace(cs.transcripts[i-1].Text)
+		}
+	}
+	for _, p := range cs.passages {
+		if seconds >= p.StartSeconds && seconds <= p.EndSeconds {
+			return p.Text
+		}
+	}
+	return ""
+}
+
+// formatHighlightsForPrompt lists highlights with the marker the model should
+// use to cite them.
+func formatHighlightsForPrompt(highlights []models.Highlight) string {
+	var sb strings.Builder
+	for i, h := range highlights {
+		if i == chatPromptHighlights {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- [H%d] %s", h.ID, truncateRunes(h.Text, citationQuoteChars)))
+		if h.Note != "" {
+			sb.WriteString(fmt.Sprintf("（笔记: %s）", truncateRunes(h.Note, citationQuoteChars)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"vibe-backend/internal/models"
+)
+
+const (
+	// highlightWindowSeconds is how much transcript before and after a
+	// highlight is given to the model as context.
+	highlightWindowSeconds = 60
+	// highlightMatchRunes is how many leading runes of a highlight are used to
+	// find it in the transcript.
+	highlightMatchRunes = 24
+	// highlightMinLineRunes keeps short lines like "yes" from matching any
+	// highlight that happens to contain them.
+	highlightMinLineRunes = 8
+)
+
+// highlightFocus is the highlight a question is anchored to, with the
+// transcript surrounding it.
+type highlightFocus struct {
+	highlight models.Highlight
+	window    []models.TranscriptItem
+}
+
+// newHighlightFocus locates a highlight in the transcript and collects the
+// lines within highlightWindowSeconds of it. The window is empty when the
+// insight has no timed transcript.
+func newHighlightFocus(h models.Highlight, transcripts []models.TranscriptItem) *highlightFocus {
+	focus := &highlightFocus{highlight: h}
+	if len(transcripts) == 0 {
+		return focus
+	}
+
+	first, last := locateHighlight(h, transcripts)
+	from := transcripts[first].Seconds - highlightWindowSeconds
+	to := transcripts[last].Seconds + highlightWindowSeconds
+	for _, item := range transcripts {
+		if item.Seconds >= from && item.Seconds <= to {
+			focus.window = append(focus.window, item)
+		}
+	}
+	return focus
+}
+
+// covers reports whether seconds falls inside the focus window.
+func (f *highlightFocus) covers(seconds int) bool {
+	if f == nil || len(f.window) == 0 {
+		return false
+	}
+	return seconds >= f.window[0].Seconds && seconds <= f.window[len(f.window)-1].Seconds
+}
+
+// locateHighlight returns the indexes of the first and last transcript lines
+// spanned by a highlight. It matches the highlight text against the original
+// and translated lines, falling back to the highlight's character offsets.
+func locateHighlight(h models.Highlight, transcripts []models.TranscriptItem) (int, int) {
+	needle := normalizeForMatch(h.Text)
+	if needle != "" {
+		head := needle
+		if utf8.RuneCountInString(head) > highlightMatchRunes {
+			head = string([]rune(head)[:highlightMatchRunes])
+		}
+
+		for i, item := range transcripts {
+			for _, text := range []string{item.Text, item.TranslatedText} {
+				line := normalizeForMatch(text)
+				if line == "" {
+					continue
+				}
+				if strings.Contains(line, head) || lineInHighlight(needle, line) {
+					return i, spanEnd(i, needle, transcripts)
+				}
+			}
+		}
+	}
+
+	// Offsets are positions in the displayed content; map them onto the
+	// transcript by cumulative line length
+	first := offsetToLine(h.StartOffset, transcripts)
+	last := offsetToLine(h.EndOffset, transcripts)
+	if last < first {
+		last = first
+	}
+	return first, last
+}
+
+// spanEnd extends a match starting at line i over the following lines whose
+// text is also part of the highlight.
+func spanEnd(i int, needle string, transcripts []models.TranscriptItem) int {
+	end := i
+	for j := i + 1; j < len(transcripts); j++ {
+		if lineInHighlight(needle, normalizeForMatch(transcripts[j].Text)) ||
+			lineInHighlight(needle, normalizeForMatch(transcripts[j].TranslatedText)) {
+			end = j
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// lineInHighlight reports whether a normalized transcript line is part of the
+// normalized highlight text.
+func lineInHighlight(needle, line string) bool {
+	return utf8.RuneCountInString(line) >= highlightMinLineRunes && strings.Contains(needle, line)
+}
+
+// offsetToLine returns the transcript line containing a character offset into
+// the newline-joined transcript text.
+func offsetToLine(offset int, transcripts []models.TranscriptItem) int {
+	pos := 0
+	for i, item := range transcripts {
+		pos += utf8.RuneCountInString(item.Text) + 1
+		if offset < pos {
+			return i
+		}
+	}
+	return len(transcripts) - 1
+}
+
+// normalizeForMatch lowercases text and drops whitespace and punctuation so
+// highlights match transcript lines regardless of formatting.
+func normalizeForMatch(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
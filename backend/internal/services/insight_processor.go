@@ -3,20 +3,42 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"vibe-backend/internal/article"
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
+	"vibe-backend/internal/podcast"
 	"vibe-backend/internal/repository"
+	"vibe-backend/internal/twitter"
 )
 
-// InsightProcessor handles async processing of insights.
+// ErrTranscriptTrackNotFound is returned when an insight has no stored
+// transcript track in the requested language.
+var ErrTranscriptTrackNotFound = errors.New("transcript track not found")
+
+// InsightProcessor handles background processing of insights.
 type InsightProcessor struct {
 	repo               *repository.InsightRepository
 	youtubeService     *YouTubeService
+	transcriptService  *TranscriptService
 	translationService *TranslationService
+	summaryService     *SummaryService
+	chapterService     *ChapterService
+	retrievalService   *RetrievalService
+	searchService      *SearchService
+	tweetFetcher       twitter.Fetcher
+	podcastClient      *podcast.Client
+	podcastOptions     PodcastOptions
+	articleClient      *article.Client
+	queue              *jobs.Manager
 	log                *zap.Logger
 }
 
@@ -24,13 +46,15 @@ type InsightProcessor struct {
 func NewInsightProcessor(
 	repo *repository.InsightRepository,
 	youtubeService *YouTubeService,
+	transcriptService *TranscriptService,
 	log *zap.Logger,
 ) *InsightProcessor {
 	// Note: translationService will be nil for now, needs to be injected
 	return &InsightProcessor{
-		repo:           repo,
-		youtubeService: youtubeService,
-		log:            log,
+		repo:              repo,
+		youtubeService:    youtubeService,
+		transcriptService: transcriptService,
+		log:               log,
 	}
 }
 
@@ -39,44 +63,154 @@ func (p *InsightProcessor) SetTranslationService(svc *TranslationService) {
 	p.translationService = svc
 }
 
-// ProcessInsightAsync starts async processing of an insight.
-// This should be called in a goroutine.
-func (p *InsightProcessor) ProcessInsightAsync(ctx context.Context, insightID uint) {
-	p.log.Info("Starting async insight processing", zap.Uint("insight_id", insightID))
+// SetSummaryService sets the service used to generate summaries and key points.
+func (p *InsightProcessor) SetSummaryService(svc *SummaryService) {
+	p.summaryService = svc
+}
+
+// SetChapterService sets the service used to build chapters.
+func (p *InsightProcessor) SetChapterService(svc *ChapterService) {
+	p.chapterService = svc
+}
+
+// SetRetrievalService sets the service used to index transcripts for chat.
+func (p *InsightProcessor) SetRetrievalService(svc *RetrievalService) {
+	p.retrievalService = svc
+}
+
+// SetSearchService sets the service used to index insights for library search.
+func (p *InsightProcessor) SetSearchService(svc *SearchService) {
+	p.searchService = svc
+}
+
+// SetTweetFetcher sets the fetcher used to read Twitter/X threads.
+func (p *InsightProcessor) SetTweetFetcher(f twitter.Fetcher) {
+	p.tweetFetcher = f
+}
+
+// SetJobQueue sets the job queue used to schedule processing.
+func (p *InsightProcessor) SetJobQueue(queue *jobs.Manager) {
+	p.queue = queue
+}
+
+// EnqueueInsight schedules an insight for background processing. Enqueueing
+// an insight that already has an active job is a no-op.
+func (p *InsightProcessor) EnqueueInsight(ctx context.Context, insightID uint) error {
+	if p.queue == nil {
+		return fmt.Errorf("job queue not configured")
+	}
+	_, err := p.queue.Enqueue(ctx, jobs.QueueInsight, jobs.InsightPayload{InsightID: insightID}, jobs.EnqueueOptions{
+		DedupeKey: jobs.InsightKey(insightID),
+	})
+	return err
+}
+
+// HandleJob is the jobs.Handler for the insight queue.
+func (p *InsightProcessor) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload jobs.InsightPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	err := p.ProcessInsight(ctx, payload.InsightID)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	if jobs.WillRetry(job, err) {
+		// Keep the insight visible as pending while the job waits for its retry
+		msg := fmt.Sprintf("处理失败，将自动重试 (%d/%d): %v", job.Attempts, job.MaxAttempts, err)
+		if updateErr := p.repo.UpdateStatus(ctx, payload.InsightID, models.InsightStatusPending, msg); updateErr != nil {
+			p.log.Error("Failed to update insight status to pending",
+				zap.Uint("insight_id", payload.InsightID),
+				zap.Error(updateErr),
+			)
+		}
+	} else {
+		p.handleProcessingError(ctx, payload.InsightID, err.Error())
+	}
+	return err
+}
+
+// RecoverStale re-enqueues insights left pending or processing, e.g. by a
+// restart before the job queue existed or by a lost enqueue.
+func (p *InsightProcessor) RecoverStale(ctx context.Context) error {
+	insights, err := p.repo.GetByStatuses(ctx, []models.InsightStatus{
+		models.InsightStatusPending,
+		models.InsightStatusProcessing,
+	}, 500)
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished insights: %w", err)
+	}
+
+	for _, insight := range insights {
+		if err := p.EnqueueInsight(ctx, insight.ID); err != nil {
+			p.log.Error("Failed to re-enqueue insight",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	if len(insights) > 0 {
+		p.log.Info("Recovered unfinished insights", zap.Int("count", len(insights)))
+	}
+	return nil
+}
+
+// ProcessInsight fetches and processes an insight's source content.
+// Errors that retrying cannot fix are wrapped with jobs.Permanent.
+func (p *InsightProcessor) ProcessInsight(ctx context.Context, insightID uint) error {
+	p.log.Info("Starting insight processing", zap.Uint("insight_id", insightID))
 
 	// Get the insight
 	insight, err := p.repo.GetByID(ctx, insightID)
 	if err != nil {
-		p.log.Error("Failed to get insight for processing",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted before the job ran; nothing left to do
+			p.log.Info("Insight no longer exists, skipping", zap.Uint("insight_id", insightID))
+			return nil
+		}
+		return fmt.Errorf("failed to get insight: %w", err)
+	}
+
+	// Attribute LLM usage during processing to the insight owner
+	ctx = llm.WithTags(ctx, llm.Tags{UserID: insight.UserID, InsightID: insightID})
+
+	// Keep results from before versioning, since this run replaces them
+	if err := p.adoptLegacyVersion(ctx, insight); err != nil {
+		return fmt.Errorf("failed to store previous results: %w", err)
 	}
 
 	// Update status to processing
 	if err := p.repo.UpdateStatus(ctx, insightID, models.InsightStatusProcessing, ""); err != nil {
-		p.log.Error("Failed to update insight status to processing",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return
+		return fmt.Errorf("failed to update insight status to processing: %w", err)
 	}
 
-	// Detect source type and process accordingly
-	sourceType, err := p.detectSourceType(insight.SourceURL)
-	if err != nil {
-		p.handleProcessingError(ctx, insightID, fmt.Sprintf("无法识别来源类型: %v", err))
-		return
+	// Detect source type and process accordingly; a type chosen when the
+	// insight was created is kept
+	sourceType := insight.SourceType
+	if sourceType == "" {
+		sourceType, err = p.detectSourceType(insight.SourceURL)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("无法识别来源类型: %v", err))
+		}
 	}
 
 	insight.SourceType = sourceType
 
 	switch sourceType {
 	case models.SourceTypeYouTube:
-		p.processYouTubeInsight(ctx, insight)
+		return p.processYouTubeInsight(ctx, insight)
+	case models.SourceTypeUpload:
+		return p.processUploadInsight(ctx, insight)
+	case models.SourceTypeTwitter:
+		return p.processTwitterInsight(ctx, insight)
+	case models.SourceTypePodcast:
+		return p.processPodcastInsight(ctx, insight)
+	case models.SourceTypeArticle:
+		return p.processArticleInsight(ctx, insight)
 	default:
-		p.handleProcessingError(ctx, insightID, fmt.Sprintf("暂不支持的来源类型: %s", sourceType))
+		return jobs.Permanent(fmt.Errorf("暂不支持的来源类型: %s", sourceType))
 	}
 }
 
@@ -84,13 +218,30 @@ func (p *InsightProcessor) ProcessInsightAsync(ctx context.Context, insightID ui
 func (p *InsightProcessor) detectSourceType(sourceURL string) (models.SourceType, error) {
 	lowerURL := strings.ToLower(sourceURL)
 
+	// Uploaded files
+	if strings.HasPrefix(lowerURL, models.UploadSourcePrefix) {
+		return models.SourceTypeUpload, nil
+	}
+
+	// Podcast episodes addressed by feed URL and GUID
+	if _, guid := podcast.ParseEpisodeURL(sourceURL); guid != "" {
+		return models.SourceTypePodcast, nil
+	}
+
 	// YouTube patterns
 	if strings.Contains(lowerURL, "youtube.com") || strings.Contains(lowerURL, "youtu.be") {
 		return models.SourceTypeYouTube, nil
 	}
 
-	// Twitter/X patterns
-	if strings.Contains(lowerURL, "twitter.com") || strings.Contains(lowerURL, "x.com") {
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("无法从 URL 识别来源类型: %s", sourceURL)
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www."), "mobile.")
+
+	// Twitter/X patterns; matched on the host so that e.g. "netflix.com"
+	// is not taken for x.com
+	if host == "twitter.com" || host == "x.com" {
 		return models.SourceTypeTwitter, nil
 	}
 
@@ -101,11 +252,12 @@ func (p *InsightProcessor) detectSourceType(sourceURL string) (models.SourceType
 		return models.SourceTypePodcast, nil
 	}
 
-	return "", fmt.Errorf("无法从 URL 识别来源类型: %s", sourceURL)
+	// Any other web page is read as an article
+	return models.SourceTypeArticle, nil
 }
 
 // processYouTubeInsight processes a YouTube video insight.
-func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *models.Insight) {
+func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *models.Insight) error {
 	p.log.Info("Processing YouTube insight",
 		zap.Uint("insight_id", insight.ID),
 		zap.String("source_url", insight.SourceURL),
@@ -114,8 +266,7 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 	// Extract video ID
 	videoID, err := p.youtubeService.ExtractVideoID(insight.SourceURL)
 	if err != nil {
-		p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("无效的 YouTube URL: %v", err))
-		return
+		return jobs.Permanent(fmt.Errorf("无效的 YouTube URL: %v", err))
 	}
 
 	insight.SourceID = videoID
@@ -142,8 +293,7 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 			// Method 3: Try Gemini/OpenRouter (last resort, requires valid API key)
 			metadata, err = p.youtubeService.GetVideoMetadata(ctx, insight.SourceURL)
 			if err != nil {
-				p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("无法获取视频元数据: 所有方法都失败了。YouTube API: 未配置或失败, yt-dlp: %v, OpenRouter API: %v", err, err))
-				return
+				return fmt.Errorf("无法获取视频元数据: 所有方法都失败了。YouTube API: 未配置或失败, yt-dlp: %v, OpenRouter API: %v", err, err)
 			}
 		}
 	}
@@ -154,17 +304,30 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 	insight.ThumbnailURL = metadata.ThumbnailURL
 	insight.Duration = metadata.Duration
 
-	// Fetch structured transcripts
-	transcriptResponse, err := p.youtubeService.FetchYouTubeTranscriptStructured(ctx, videoID)
+	// Fetch transcripts
+	var transcriptProvider string
+	transcript, err := p.transcriptService.Fetch(ctx, videoID, TranscriptFetchOptions{})
 	if err != nil {
-		p.log.Warn("Failed to fetch structured transcripts",
+		p.log.Warn("Failed to fetch transcripts",
 			zap.String("video_id", videoID),
 			zap.Error(err),
 		)
 		// Transcripts are optional, continue processing
 	} else {
-		// Convert transcripts to the format expected by Insight model
-		transcripts, err := p.convertTranscriptsToInsightFormat(transcriptResponse, insight.TargetLang)
+		transcriptProvider = transcript.Provider
+
+		// Keep every track so the insight can switch language later
+		if err := p.storeTranscriptTracks(ctx, insight.ID, transcript); err != nil {
+			p.log.Warn("Failed to store transcript tracks",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+
+		// Convert the preferred track to the format expected by Insight model
+		track := transcript.PrimaryTrack()
+		items := captionTrackItems(track)
+		transcripts, err := p.convertTranscriptsToInsightFormat(ctx, items, insight.TargetLang)
 		if err != nil {
 			p.log.Warn("Failed to convert transcripts",
 				zap.String("video_id", videoID),
@@ -172,74 +335,295 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 			)
 		} else {
 			insight.Transcripts = transcripts
+			insight.TranscriptLanguage = track.Language
+			insight.TranscriptKind = track.Kind
 
 			// Also store raw content (combined transcript text)
-			insight.RawContent = p.extractRawContentFromTranscripts(transcriptResponse)
+			insight.RawContent = p.extractRawContentFromTranscripts(items)
 		}
 	}
 
-	// Update insight with all collected data
-	insight.Status = models.InsightStatusCompleted
+	// Build chapters from creator timestamps, or from transcript topic shifts
+	creatorChapters := ParseDescriptionChapters(metadata.Description)
+	if len(creatorChapters) == 0 && transcript != nil {
+		creatorChapters = ParseDescriptionChapters(transcript.Description)
+	}
+	return p.completeInsight(ctx, insight, creatorChapters, transcriptProvider)
+}
 
-	if err := p.repo.Update(ctx, insight); err != nil {
-		p.log.Error("Failed to update insight after processing",
+// completeInsight runs the source-independent steps once an insight's content
+// is collected: summary, chapters, versioning and indexing. It then saves the
+// insight as completed.
+func (p *InsightProcessor) completeInsight(ctx context.Context, insight *models.Insight, creatorChapters []models.YouTubeChapter, transcriptProvider string) error {
+	// Generate summary and key points from the collected content
+	if err := p.summarizeInsight(ctx, insight); err != nil {
+		if llm.IsRetryable(err) {
+			return err
+		}
+		// Summary is optional, keep the transcript and metadata
+		p.log.Warn("Failed to generate insight summary",
+			zap.Uint("insight_id", insight.ID),
+			zap.Error(err),
+		)
+	}
+
+	if err := p.buildChapters(ctx, insight, creatorChapters); err != nil {
+		if llm.IsRetryable(err) {
+			return err
+		}
+		// Chapters are optional, keep the rest of the result
+		p.log.Warn("Failed to build insight chapters",
 			zap.Uint("insight_id", insight.ID),
 			zap.Error(err),
 		)
-		p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("保存处理结果失败: %v", err))
-		return
 	}
 
-	p.log.Info("Successfully processed YouTube insight",
+	// Keep this run as a new version; a pinned version stays current
+	if err := p.recordVersion(ctx, insight, transcriptProvider); err != nil {
+		return fmt.Errorf("保存处理版本失败: %w", err)
+	}
+
+	// Index the current version for chat retrieval and library search. Chat
+	// indexes lazily on first question and search backfills on startup if
+	// this fails.
+	p.reindexInsight(ctx, insight)
+
+	// Update insight with all collected data
+	insight.Status = models.InsightStatusCompleted
+
+	if err := p.repo.Update(ctx, insight); err != nil {
+		return fmt.Errorf("保存处理结果失败: %w", err)
+	}
+
+	p.log.Info("Successfully processed insight",
 		zap.Uint("insight_id", insight.ID),
+		zap.String("source_type", string(insight.SourceType)),
 		zap.String("title", insight.Title),
 		zap.Int("duration", insight.Duration),
 	)
+	return nil
 }
 
-// convertTranscriptsToInsightFormat converts YouTube transcripts to the Insight model format.
-// It also translates the transcripts to the target language if translation service is available.
-func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.YouTubeTranscriptResponse, targetLang string) ([]byte, error) {
-	// Convert to TranscriptItem array format expected by the Insight model
-	var transcriptItems []models.TranscriptItem
-
-	// Find the best available transcript (prefer default > auto > custom)
-	for _, langData := range response.Transcripts {
-		var segments []models.TranscriptSegment
-
-		if len(langData.Default) > 0 {
-			segments = langData.Default
-		} else if len(langData.Auto) > 0 {
-			segments = langData.Auto
-		} else if len(langData.Custom) > 0 {
-			segments = langData.Custom
+// summarizeInsight fills in Summary and KeyPoints using map-reduce
+// summarization over the transcripts (or raw content if there are none).
+func (p *InsightProcessor) summarizeInsight(ctx context.Context, insight *models.Insight) error {
+	if p.summaryService == nil {
+		return nil
+	}
+
+	var transcripts []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &transcripts); err != nil {
+			return fmt.Errorf("failed to parse transcripts: %w", err)
 		}
+	}
+	if len(transcripts) == 0 && strings.TrimSpace(insight.RawContent) == "" {
+		p.log.Info("No content to summarize", zap.Uint("insight_id", insight.ID))
+		return nil
+	}
 
-		if len(segments) == 0 {
-			continue
+	result, err := p.summaryService.Summarize(ctx, SummaryInput{
+		Title:       insight.Title,
+		Author:      insight.Author,
+		Transcripts: transcripts,
+		RawContent:  insight.RawContent,
+		TargetLang:  insight.TargetLang,
+	})
+	if err != nil {
+		return err
+	}
+
+	keyPoints, err := json.Marshal(result.KeyPoints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key points: %w", err)
+	}
+	insight.Summary = result.Summary
+	insight.KeyPoints = keyPoints
+
+	p.log.Info("Generated insight summary",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("summary_length", len(result.Summary)),
+		zap.Int("key_points", len(result.KeyPoints)),
+	)
+	return nil
+}
+
+// buildChapters generates and stores the insight's chapters.
+func (p *InsightProcessor) buildChapters(ctx context.Context, insight *models.Insight, creator []models.YouTubeChapter) error {
+	if p.chapterService == nil {
+		return nil
+	}
+
+	var transcripts []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &transcripts); err != nil {
+			return fmt.Errorf("failed to parse transcripts: %w", err)
 		}
+	}
 
-		// Convert segments to TranscriptItem format
-		for _, seg := range segments {
-			// Parse start time to seconds
-			seconds := parseTimestampToSeconds(seg.Start)
-
-			// Format timestamp as MM:SS
-			mins := int(seconds) / 60
-			secs := int(seconds) % 60
-			timestamp := fmt.Sprintf("%02d:%02d", mins, secs)
-
-			transcriptItems = append(transcriptItems, models.TranscriptItem{
-				Timestamp: timestamp,
-				Seconds:   int(seconds),
-				Text:      seg.Text,
-			})
+	chapters, err := p.chapterService.Generate(ctx, ChapterInput{
+		Title:       insight.Title,
+		Transcripts: transcripts,
+		Creator:     creator,
+		Duration:    insight.Duration,
+		TargetLang:  insight.TargetLang,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := p.repo.ReplaceChapters(ctx, insight.ID, chapters); err != nil {
+		return fmt.Errorf("failed to save chapters: %w", err)
+	}
+
+	p.log.Info("Built insight chapters",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("chapters", len(chapters)),
+		zap.Bool("from_description", len(creator) > 0),
+	)
+	return nil
+}
+
+// storeTranscriptTracks saves every caption track of a video for an insight,
+// in preference order.
+func (p *InsightProcessor) storeTranscriptTracks(ctx context.Context, insightID uint, transcript *models.VideoTranscript) error {
+	tracks := make([]models.InsightTranscript, 0, len(transcript.Tracks))
+	for i := range transcript.Tracks {
+		track := &transcript.Tracks[i]
+		items, err := json.Marshal(captionTrackItems(track))
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s transcript: %w", track.Language, err)
+		}
+		tracks = append(tracks, models.InsightTranscript{
+			Language: track.Language,
+			Kind:     track.Kind,
+			Name:     track.Name,
+			Items:    items,
+		})
+	}
+	return p.repo.ReplaceTranscripts(ctx, insightID, tracks)
+}
+
+// SwitchTranscript replaces the transcript an insight shows with another of
+// its stored tracks, translated to the insight's target language, and
+// re-indexes it for chat. An empty kind picks the manual track if there is
+// one. Summary and chapters are left as they are.
+func (p *InsightProcessor) SwitchTranscript(ctx context.Context, insight *models.Insight, language string, kind models.CaptionKind) error {
+	stored, err := p.repo.GetTranscriptsByInsightID(ctx, insight.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load transcript tracks: %w", err)
+	}
+
+	var selected *models.InsightTranscript
+	for i := range stored {
+		// Stored manual-first within a language, see orderTracks
+		if strings.EqualFold(stored[i].Language, language) && (kind == "" || stored[i].Kind == kind) {
+			selected = &stored[i]
+			break
 		}
+	}
+	if selected == nil {
+		return ErrTranscriptTrackNotFound
+	}
 
-		// Only use the first language found
-		break
+	var items []models.TranscriptItem
+	if err := json.Unmarshal(selected.Items, &items); err != nil {
+		return fmt.Errorf("failed to parse %s transcript: %w", selected.Language, err)
 	}
 
+	// Only the preferred track of a video is regrouped into sentences when
+	// the insight is processed; the others are stored as fetched until first
+	// shown
+	if insight.SourceType == models.SourceTypeYouTube {
+		track, err := p.transcriptService.SegmentedTrack(ctx, insight.SourceID, selected.Language, selected.Kind)
+		if err == nil {
+			items = captionTrackItems(track)
+		} else if ctx.Err() == nil {
+			p.log.Warn("Failed to segment transcript track, using captions as stored",
+				zap.Uint("insight_id", insight.ID),
+				zap.String("language", selected.Language),
+				zap.Error(err),
+			)
+		}
+	}
+
+	transcripts, err := p.convertTranscriptsToInsightFormat(ctx, items, insight.TargetLang)
+	if err != nil {
+		return err
+	}
+	insight.Transcripts = transcripts
+	insight.TranscriptLanguage = selected.Language
+	insight.TranscriptKind = selected.Kind
+	insight.RawContent = p.extractRawContentFromTranscripts(items)
+
+	if err := p.repo.Update(ctx, insight); err != nil {
+		return fmt.Errorf("保存字幕失败: %w", err)
+	}
+	if insight.CurrentVersion > 0 {
+		if err := p.repo.UpdateVersionTranscript(ctx, insight); err != nil {
+			p.log.Warn("Failed to update current version transcript",
+				zap.Uint("insight_id", insight.ID),
+				zap.Int("version", insight.CurrentVersion),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// Chat retrieval and search should quote the transcript the user now sees
+	p.reindexInsight(ctx, insight)
+	return nil
+}
+
+// reindexInsight indexes the insight's transcript for chat retrieval and
+// library search. Failures are logged, not returned.
+func (p *InsightProcessor) reindexInsight(ctx context.Context, insight *models.Insight) {
+	if p.retrievalService != nil {
+		if err := p.retrievalService.IndexInsight(ctx, insight); err != nil {
+			p.log.Warn("Failed to index insight transcript",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	if p.searchService != nil {
+		if err := p.searchService.IndexInsight(ctx, insight); err != nil {
+			p.log.Warn("Failed to index insight for search",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// captionTrackItems converts a caption track to the TranscriptItem array
+// format expected by the Insight model, without translations.
+func captionTrackItems(track *models.CaptionTrack) []models.TranscriptItem {
+	if track == nil {
+		return nil
+	}
+
+	items := make([]models.TranscriptItem, 0, len(track.Segments))
+	for _, seg := range track.Segments {
+		seconds := seg.StartMs / 1000
+
+		// Format timestamp as MM:SS
+		timestamp := fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+
+		items = append(items, models.TranscriptItem{
+			Timestamp: timestamp,
+			Seconds:   seconds,
+			Text:      seg.Text,
+		})
+	}
+	return items
+}
+
+// convertTranscriptsToInsightFormat encodes transcript items in the Insight model format.
+// It also translates the transcripts to the target language if translation service is available.
+func (p *InsightProcessor) convertTranscriptsToInsightFormat(ctx context.Context, items []models.TranscriptItem, targetLang string) ([]byte, error) {
+	// Translations are added to a copy so the caller's items stay untranslated
+	transcriptItems := append([]models.TranscriptItem(nil), items...)
+
 	if len(transcriptItems) == 0 {
 		return nil, fmt.Errorf("no transcript segments found")
 	}
@@ -260,7 +644,7 @@ func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.Yo
 		// Detect source language from first segment
 		var sourceLang string
 		if len(texts) > 0 {
-			detected, err := p.translationService.DetectLanguage(context.Background(), texts[0])
+			detected, err := p.translationService.DetectLanguage(ctx, texts[0])
 			if err != nil {
 				p.log.Warn("Failed to detect source language, skipping translation",
 					zap.Error(err),
@@ -276,7 +660,7 @@ func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.Yo
 		// Only attempt translation if we detected the source language
 		if sourceLang != "" && sourceLang != targetLang {
 			// Batch translate
-			translations, err := p.translationService.TranslateBatch(context.Background(), texts, sourceLang, targetLang)
+			translations, err := p.translationService.TranslateBatch(ctx, texts, sourceLang, targetLang)
 			if err != nil {
 				p.log.Warn("⚠️  翻译失败，字幕仍包含原文",
 					zap.Error(err),
@@ -312,29 +696,12 @@ func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.Yo
 	return json.Marshal(transcriptItems)
 }
 
-// extractRawContentFromTranscripts extracts plain text content from transcripts.
-func (p *InsightProcessor) extractRawContentFromTranscripts(response *models.YouTubeTranscriptResponse) string {
-	var textParts []string
-
-	for _, langData := range response.Transcripts {
-		var segments []models.TranscriptSegment
-
-		if len(langData.Default) > 0 {
-			segments = langData.Default
-		} else if len(langData.Auto) > 0 {
-			segments = langData.Auto
-		} else if len(langData.Custom) > 0 {
-			segments = langData.Custom
-		}
-
-		for _, seg := range segments {
-			textParts = append(textParts, seg.Text)
-		}
-
-		// Only use the first language found
-		break
+// extractRawContentFromTranscripts extracts plain text content from transcript items.
+func (p *InsightProcessor) extractRawContentFromTranscripts(items []models.TranscriptItem) string {
+	textParts := make([]string, len(items))
+	for i, item := range items {
+		textParts[i] = item.Text
 	}
-
 	return strings.Join(textParts, " ")
 }
 
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/models"
+)
+
+const (
+	// versionDiffMaxCells bounds the LCS table of a transcript diff; larger
+	// transcripts are reported as entirely replaced.
+	versionDiffMaxCells = 4000000
+	// versionDiffMaxChanges bounds the transcript lines listed in a diff.
+	versionDiffMaxChanges = 500
+)
+
+// ErrInsightVersionNotFound is returned when an insight has no such version.
+var ErrInsightVersionNotFound = errors.New("insight version not found")
+
+// adoptLegacyVersion stores the results of an insight processed before
+// versioning as version 1, so reprocessing does not lose them or the
+// highlights made on them. Insights that never completed a run are skipped:
+// what they hold was set at creation, such as the text of an upload, not
+// produced by processing.
+func (p *InsightProcessor) adoptLegacyVersion(ctx context.Context, insight *models.Insight) error {
+	if insight.CurrentVersion != 0 {
+		return nil
+	}
+	if insight.Status != models.InsightStatusCompleted && insight.Summary == "" {
+		return nil
+	}
+	if insight.Summary == "" && insight.RawContent == "" && len(insight.Transcripts) == 0 {
+		return nil
+	}
+
+	chapters, err := p.repo.GetChaptersByInsightID(ctx, insight.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load chapters: %w", err)
+	}
+	version, err := newInsightVersion(insight, chapters)
+	if err != nil {
+		return err
+	}
+	version.ProcessedAt = insight.UpdatedAt
+
+	if err := p.repo.CreateVersion(ctx, version); err != nil {
+		return fmt.Errorf("failed to save version: %w", err)
+	}
+	if err := p.repo.AdoptLegacyVersion(ctx, insight.ID, version.Version); err != nil {
+		return fmt.Errorf("failed to adopt version: %w", err)
+	}
+	insight.CurrentVersion = version.Version
+
+	p.log.Info("Stored pre-versioning insight results",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("version", version.Version),
+	)
+	return nil
+}
+
+// recordVersion stores the results of a processing run as the insight's next
+// version and makes it current, unless another version is pinned, in which
+// case the pinned version's artifacts are put back.
+func (p *InsightProcessor) recordVersion(ctx context.Context, insight *models.Insight, transcriptProvider string) error {
+	chapters, err := p.repo.GetChaptersByInsightID(ctx, insight.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load chapters: %w", err)
+	}
+	version, err := newInsightVersion(insight, chapters)
+	if err != nil {
+		return err
+	}
+	version.ProcessedAt = time.Now()
+	version.TranscriptProvider = transcriptProvider
+	if p.summaryService != nil {
+		version.LLMProvider, version.Model = p.summaryService.Model()
+	}
+
+	if err := p.repo.CreateVersion(ctx, version); err != nil {
+		return fmt.Errorf("failed to save version: %w", err)
+	}
+
+	if !insight.VersionPinned || insight.CurrentVersion == 0 {
+		insight.CurrentVersion = version.Version
+		p.log.Info("Stored insight version",
+			zap.Uint("insight_id", insight.ID),
+			zap.Int("version", version.Version),
+		)
+		return nil
+	}
+
+	pinned, err := p.repo.GetVersion(ctx, insight.ID, insight.CurrentVersion)
+	if err != nil {
+		return fmt.Errorf("failed to load pinned version %d: %w", insight.CurrentVersion, err)
+	}
+	p.log.Info("Stored insight version, keeping pinned version current",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("version", version.Version),
+		zap.Int("pinned", pinned.Version),
+	)
+	return p.applyVersion(ctx, insight, pinned)
+}
+
+// RestoreVersion makes a stored version the insight's current version,
+// restoring its artifacts, chapters and highlights, and re-indexes it. With
+// pin set the version stays current when the insight is reprocessed.
+func (p *InsightProcessor) RestoreVersion(ctx context.Context, insight *models.Insight, version int, pin bool) error {
+	v, err := p.repo.GetVersion(ctx, insight.ID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInsightVersionNotFound
+		}
+		return fmt.Errorf("failed to load version: %w", err)
+	}
+
+	if err := p.applyVersion(ctx, insight, v); err != nil {
+		return err
+	}
+	insight.VersionPinned = pin
+	if err := p.repo.Update(ctx, insight); err != nil {
+		return fmt.Errorf("保存版本失败: %w", err)
+	}
+
+	p.reindexInsight(ctx, insight)
+	return nil
+}
+
+// applyVersion copies a version's artifacts into the insight and restores
+// its chapters. The insight itself is not saved.
+func (p *InsightProcessor) applyVersion(ctx context.Context, insight *models.Insight, v *models.InsightVersion) error {
+	var chapters []models.InsightChapter
+	if len(v.Chapters) > 0 {
+		if err := json.Unmarshal(v.Chapters, &chapters); err != nil {
+			return fmt.Errorf("failed to parse version %d chapters: %w", v.Version, err)
+		}
+	}
+	if err := p.repo.ReplaceChapters(ctx, insight.ID, chapters); err != nil {
+		return fmt.Errorf("failed to restore chapters: %w", err)
+	}
+
+	insight.Title = v.Title
+	insight.Author = v.Author
+	insight.ThumbnailURL = v.ThumbnailURL
+	insight.Duration = v.Duration
+	insight.Summary = v.Summary
+	insight.KeyPoints = v.KeyPoints
+	insight.TargetLang = v.TargetLang
+	insight.RawContent = v.RawContent
+	insight.TransContent = v.TransContent
+	insight.Transcripts = v.Transcripts
+	insight.TranscriptLanguage = v.TranscriptLanguage
+	insight.TranscriptKind = v.TranscriptKind
+	insight.CurrentVersion = v.Version
+	return nil
+}
+
+// newInsightVersion snapshots the insight's current artifacts.
+func newInsightVersion(insight *models.Insight, chapters []models.InsightChapter) (*models.InsightVersion, error) {
+	chapterData, err := json.Marshal(chapters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chapters: %w", err)
+	}
+	return &models.InsightVersion{
+		InsightID:          insight.ID,
+		Title:              insight.Title,
+		Author:             insight.Author,
+		ThumbnailURL:       insight.ThumbnailURL,
+		Duration:           insight.Duration,
+		Summary:            insight.Summary,
+		KeyPoints:          insight.KeyPoints,
+		TargetLang:         insight.TargetLang,
+		RawContent:         insight.RawContent,
+		TransContent:       insight.TransContent,
+		Transcripts:        insight.Transcripts,
+		TranscriptLanguage: insight.TranscriptLanguage,
+		TranscriptKind:     insight.TranscriptKind,
+		Chapters:           chapterData,
+	}, nil
+}
+
+// DiffInsightVersions compares the artifacts of two versions of an insight.
+func DiffInsightVersions(from, to *models.InsightVersion) (*models.InsightVersionDiff, error) {
+	diff := &models.InsightVersionDiff{
+		From:   from.Version,
+		To:     to.Version,
+		Fields: []models.InsightFieldChange{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", from.Title, to.Title},
+		{"author", from.Author, to.Author},
+		{"summary", from.Summary, to.Summary},
+		{"target_lang", from.TargetLang, to.TargetLang},
+		{"transcript_language", from.TranscriptLanguage, to.TranscriptLanguage},
+		{"transcript_kind", string(from.TranscriptKind), string(to.TranscriptKind)},
+		{"trans_content", from.TransContent, to.TransContent},
+	}
+	for _, f := range fields {
+		if f.from != f.to {
+			diff.Fields = append(diff.Fields, models.InsightFieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+
+	fromKeyPoints, err := models.ParseKeyPoints(from.KeyPoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version %d key points: %w", from.Version, err)
+	}
+	toKeyPoints, err := models.ParseKeyPoints(to.KeyPoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version %d key points: %w", to.Version, err)
+	}
+	diff.KeyPoints = diffLists(models.KeyPointTexts(fromKeyPoints), models.KeyPointTexts(toKeyPoints))
+
+	fromChapters, err := versionChapterTitles(from)
+	if err != nil {
+		return nil, err
+	}
+	toChapters, err := versionChapterTitles(to)
+	if err != nil {
+		return nil, err
+	}
+	diff.Chapters = diffLists(fromChapters, toChapters)
+
+	fromItems, err := versionTranscriptItems(from)
+	if err != nil {
+		return nil, err
+	}
+	toItems, err := versionTranscriptItems(to)
+	if err != nil {
+		return nil, err
+	}
+	diff.Transcript = diffTranscripts(fromItems, toItems)
+	return diff, nil
+}
+
+// versionChapterTitles returns the chapter titles of a version in order.
+func versionChapterTitles(v *models.InsightVersion) ([]string, error) {
+	var chapters []models.InsightChapter
+	if len(v.Chapters) > 0 {
+		if err := json.Unmarshal(v.Chapters, &chapters); err != nil {
+			return nil, fmt.Errorf("failed to parse version %d chapters: %w", v.Version, err)
+		}
+	}
+	titles := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		titles[i] = chapter.Title
+	}
+	return titles, nil
+}
+
+// versionTranscriptItems returns the transcript of a version. Versions of
+// insights without a transcript compare their raw content by paragraph.
+func versionTranscriptItems(v *models.InsightVersion) ([]models.TranscriptItem, error) {
+	var items []models.TranscriptItem
+	if len(v.Transcripts) > 0 {
+		if err := json.Unmarshal(v.Transcripts, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse version %d transcripts: %w", v.Version, err)
+		}
+	}
+	if len(items) > 0 {
+		return items, nil
+	}
+	for _, line := range splitParagraphs(v.RawContent) {
+		items = append(items, models.TranscriptItem{Text: line})
+	}
+	return items, nil
+}
+
+// diffLists returns the entries only in to (added) and only in from (removed).
+func diffLists(from, to []string) models.InsightListChange {
+	change := models.InsightListChange{Added: []string{}, Removed: []string{}}
+	inFrom := make(map[string]int, len(from))
+	for _, s := range from {
+		inFrom[s]++
+	}
+	for _, s := range to {
+		if inFrom[s] > 0 {
+			inFrom[s]--
+			continue
+		}
+		change.Added = append(change.Added, s)
+	}
+	for _, s := range from {
+		if inFrom[s] > 0 {
+			inFrom[s]--
+			change.Removed = append(change.Removed, s)
+		}
+	}
+	return change
+}
+
+// diffTranscripts aligns two transcripts by their longest common
+// subsequence of items, comparing original and translated text.
+func diffTranscripts(from, to []models.TranscriptItem) models.TranscriptDiff {
+	same := func(a, b models.TranscriptItem) bool {
+		return a.Text == b.Text && a.TranslatedText == b.TranslatedText
+	}
+
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && same(from[prefix], to[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix &&
+		same(from[len(from)-1-suffix], to[len(to)-1-suffix]) {
+		suffix++
+	}
+	a := from[prefix : len(from)-suffix]
+	b := to[prefix : len(to)-suffix]
+
+	diff := models.TranscriptDiff{Unchanged: prefix + suffix, Changes: []models.TranscriptLineChange{}}
+	add := func(op string, item models.TranscriptItem) {
+		if op == "insert" {
+			diff.Inserted++
+		} else {
+			diff.Deleted++
+		}
+		if len(diff.Changes) >= versionDiffMaxChanges {
+			diff.Truncated = true
+			return
+		}
+		diff.Changes = append(diff.Changes, models.TranscriptLineChange{
+			Op:             op,
+			Timestamp:      item.Timestamp,
+			Seconds:        item.Seconds,
+			Text:           item.Text,
+			TranslatedText: item.TranslatedText,
+		})
+	}
+
+	n, m := len(a), len(b)
+	if n*m > versionDiffMaxCells {
+		for _, item := range a {
+			add("delete", item)
+		}
+		for _, item := range b {
+			add("insert", item)
+		}
+		return diff
+	}
+
+	// lcs[i*(m+1)+j] is the LCS length of a[i:] and b[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case same(a[i], b[j]):
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j]
+			default:
+				lcs[i*(m+1)+j] = lcs[i*(m+1)+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case same(a[i], b[j]):
+			diff.Unchanged++
+			i++
+			j++
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			add("delete", a[i])
+			i++
+		default:
+			add("insert", b[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		add("delete", a[i])
+	}
+	for ; j < m; j++ {
+		add("insert", b[j])
+	}
+	return diff
+}
+
+// splitParagraphs splits text into its non-empty lines.
+func splitParagraphs(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
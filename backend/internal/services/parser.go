@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"vibe-backend/internal/models"
+	"vibe-backend/internal/twitter"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -23,7 +24,8 @@ var (
 
 // ParserService handles URL parsing and content extraction.
 type ParserService struct {
-	log *zap.Logger
+	tweetFetcher twitter.Fetcher
+	log          *zap.Logger
 }
 
 // NewParserService creates a new ParserService.
@@ -31,6 +33,12 @@ func NewParserService(log *zap.Logger) *ParserService {
 	return &ParserService{log: log}
 }
 
+// SetTweetFetcher sets the fetcher used to read tweets. Without one, Twitter
+// links are parsed into mock data.
+func (s *ParserService) SetTweetFetcher(f twitter.Fetcher) {
+	s.tweetFetcher = f
+}
+
 // Parse parses a URL and extracts metadata.
 func (s *ParserService) Parse(ctx context.Context, rawURL string) (*models.ParsedContent, error) {
 	// Validate and detect source
@@ -124,6 +132,10 @@ func (s *ParserService) parseYouTube(ctx context.Context, rawURL string) (*model
 
 // parseTwitter extracts metadata from a Twitter/X URL.
 func (s *ParserService) parseTwitter(ctx context.Context, rawURL string) (*models.ParsedContent, error) {
+	if s.tweetFetcher != nil {
+		return s.fetchTwitter(ctx, rawURL)
+	}
+
 	// TODO: Implement Twitter API integration or web scraping
 	// For now, return mock data for demonstration purposes
 
@@ -152,6 +164,34 @@ func (s *ParserService) parseTwitter(ctx context.Context, rawURL string) (*model
 	return content, nil
 }
 
+// fetchTwitter reads a single tweet with the configured fetcher.
+func (s *ParserService) fetchTwitter(ctx context.Context, rawURL string) (*models.ParsedContent, error) {
+	tweetID, err := twitter.ParseTweetURL(rawURL)
+	if err != nil {
+		return nil, ErrParsingFailed
+	}
+
+	tweet, err := s.tweetFetcher.Tweet(ctx, tweetID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParsingFailed, err)
+	}
+
+	thread := &twitter.Thread{Tweets: []twitter.Tweet{*tweet}}
+	content := &models.ParsedContent{
+		Source:       models.SourceTwitter,
+		Title:        thread.Title(maxTweetTitleRunes),
+		Author:       tweet.Author(),
+		Summary:      tweet.Text,
+		ThumbnailURL: thread.Thumbnail(),
+	}
+	if !tweet.CreatedAt.IsZero() {
+		publishedAt := tweet.CreatedAt
+		content.PublishedAt = &publishedAt
+	}
+
+	return content, nil
+}
+
 // extractYouTubeVideoID extracts the video ID from a YouTube URL.
 func (s *ParserService) extractYouTubeVideoID(rawURL string) (string, error) {
 	parsedURL, err := url.Parse(rawURL)
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/netguard"
+	"vibe-backend/internal/podcast"
+	"vibe-backend/internal/stt"
+	"vibe-backend/internal/subtitles"
+)
+
+// TranscriptProviderPodcast names transcripts published with a podcast
+// episode in its <podcast:transcript> tag.
+const TranscriptProviderPodcast = "podcast"
+
+// PodcastOptions configures podcast processing.
+type PodcastOptions struct {
+	// Transcriber transcribes episodes without a transcript tag; nil leaves
+	// them with their show notes only.
+	Transcriber      stt.Transcriber
+	STTChunkDuration time.Duration
+	STTMaxDuration   time.Duration // 0 = no limit
+}
+
+// SetPodcastClient sets the client used to read podcast feeds.
+func (p *InsightProcessor) SetPodcastClient(client *podcast.Client, opts PodcastOptions) {
+	p.podcastClient = client
+	p.podcastOptions = opts
+}
+
+// processPodcastInsight resolves a podcast episode from its feed and builds
+// the insight from the episode's published transcript, or from speech
+// recognition of its audio when there is none.
+func (p *InsightProcessor) processPodcastInsight(ctx context.Context, insight *models.Insight) error {
+	p.log.Info("Processing podcast insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("source_url", insight.SourceURL),
+	)
+
+	if p.podcastClient == nil {
+		return jobs.Permanent(fmt.Errorf("未配置播客抓取"))
+	}
+
+	episode, err := p.podcastClient.Resolve(ctx, insight.SourceURL)
+	if err != nil {
+		switch {
+		case errors.Is(err, podcast.ErrEpisodeRequired):
+			return jobs.Permanent(fmt.Errorf("请同时提供 RSS 地址和单集 GUID"))
+		case errors.Is(err, podcast.ErrNotPodcast):
+			return jobs.Permanent(fmt.Errorf("无法从该链接找到播客 RSS"))
+		case errors.Is(err, podcast.ErrEpisodeNotFound):
+			return jobs.Permanent(fmt.Errorf("RSS 中找不到该单集"))
+		case errors.Is(err, netguard.ErrBlockedAddress):
+			return jobs.Permanent(fmt.Errorf("不允许访问该地址"))
+		}
+		return fmt.Errorf("获取播客信息失败: %w", err)
+	}
+
+	insight.SourceID = podcast.SourceID(episode.GUID)
+	insight.Title = episode.Title
+	insight.Author = episode.Author
+	if insight.Author == "" {
+		insight.Author = episode.FeedTitle
+	}
+	insight.ThumbnailURL = episode.ImageURL
+	insight.Duration = episode.Duration
+	if !episode.PublishedAt.IsZero() {
+		publishedAt := episode.PublishedAt
+		insight.PublishedAt = &publishedAt
+	}
+	meta, err := json.Marshal(episode)
+	if err != nil {
+		return fmt.Errorf("failed to encode podcast episode: %w", err)
+	}
+	insight.SourceMeta = meta
+
+	track, transcriptProvider, err := p.podcastTranscript(ctx, episode)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Transcripts are optional, the show notes still make an insight
+		p.log.Warn("Failed to get podcast transcript",
+			zap.Uint("insight_id", insight.ID),
+			zap.String("episode_guid", episode.GUID),
+			zap.Error(err),
+		)
+	}
+
+	if track != nil {
+		if err := p.storeTranscriptTracks(ctx, insight.ID, &models.VideoTranscript{Tracks: []models.CaptionTrack{*track}}); err != nil {
+			p.log.Warn("Failed to store transcript tracks",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+		items := captionTrackItems(track)
+		transcripts, err := p.convertTranscriptsToInsightFormat(ctx, items, insight.TargetLang)
+		if err != nil {
+			p.log.Warn("Failed to convert transcripts",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		} else {
+			insight.Transcripts = transcripts
+			insight.TranscriptLanguage = track.Language
+			insight.TranscriptKind = track.Kind
+			insight.RawContent = p.extractRawContentFromTranscripts(items)
+			if n := len(items); insight.Duration == 0 && n > 0 {
+				insight.Duration = items[n-1].Seconds
+			}
+		}
+	}
+	if insight.RawContent == "" {
+		if strings.TrimSpace(episode.Description) == "" {
+			return jobs.Permanent(fmt.Errorf("该单集没有文字稿，也没有节目简介"))
+		}
+		insight.RawContent = episode.Description
+		insight.TransContent = p.translateContent(ctx, insight.RawContent, insight.TargetLang)
+	}
+
+	chapters, err := p.podcastClient.FetchChapters(ctx, episode)
+	if err != nil {
+		p.log.Warn("Failed to get podcast chapters",
+			zap.Uint("insight_id", insight.ID),
+			zap.String("chapters_url", episode.ChaptersURL),
+			zap.Error(err),
+		)
+	}
+	creatorChapters := make([]models.YouTubeChapter, 0, len(chapters))
+	for _, ch := range chapters {
+		creatorChapters = append(creatorChapters, models.YouTubeChapter{
+			Title:   ch.Title,
+			Seconds: int(ch.Start / time.Second),
+		})
+	}
+
+	return p.completeInsight(ctx, insight, creatorChapters, transcriptProvider)
+}
+
+// podcastTranscript returns the episode's published transcript, or
+// transcribes its audio when it has none. It returns a nil track when
+// neither is available.
+func (p *InsightProcessor) podcastTranscript(ctx context.Context, episode *podcast.Episode) (*models.CaptionTrack, string, error) {
+	cues, tag, err := p.podcastClient.FetchTranscript(ctx, episode)
+	if err == nil {
+		language := tag.Language
+		if language == "" {
+			language = episode.Language
+		}
+		track := podcastTrack(cues, language, models.CaptionKindManual, "Podcast transcript")
+		track.Segments = p.transcriptService.Resegment(ctx, track.Segments)
+		return track, TranscriptProviderPodcast, nil
+	}
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
+	if len(episode.Transcripts) > 0 {
+		p.log.Warn("Failed to read podcast transcript tag, falling back to speech recognition",
+			zap.String("episode_guid", episode.GUID),
+			zap.Error(err),
+		)
+	}
+
+	opts := p.podcastOptions
+	if opts.Transcriber == nil {
+		return nil, "", nil
+	}
+	if opts.STTMaxDuration > 0 && time.Duration(episode.Duration)*time.Second > opts.STTMaxDuration {
+		return nil, "", fmt.Errorf("episode is %s long, speech-to-text is limited to %s",
+			formatDuration(episode.Duration), opts.STTMaxDuration)
+	}
+
+	dir, err := os.MkdirTemp("", "podcast-stt-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create audio directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	audio, err := p.podcastClient.DownloadAudio(ctx, episode, dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	started := time.Now()
+	result, err := stt.TranscribeFile(ctx, opts.Transcriber, audio, baseLanguageTag(episode.Language), opts.STTChunkDuration)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s transcription failed: %w", opts.Transcriber.Name(), err)
+	}
+	p.log.Info("Transcribed podcast audio",
+		zap.String("episode_guid", episode.GUID),
+		zap.String("backend", opts.Transcriber.Name()),
+		zap.String("language", result.Language),
+		zap.Int("segments", len(result.Segments)),
+		zap.Duration("latency", time.Since(started)),
+	)
+
+	cues = make([]subtitles.Cue, len(result.Segments))
+	for i, seg := range result.Segments {
+		cues[i] = subtitles.Cue{Start: seg.Start, End: seg.End, Text: seg.Text}
+	}
+	track := podcastTrack(cues, result.Language, models.CaptionKindAuto,
+		fmt.Sprintf("Speech recognition (%s)", opts.Transcriber.Name()))
+	track.Segments = p.transcriptService.Resegment(ctx, track.Segments)
+	return track, TranscriptProviderSTT, nil
+}
+
+// podcastTrack converts cues into a caption track.
+func podcastTrack(cues []subtitles.Cue, language string, kind models.CaptionKind, name string) *models.CaptionTrack {
+	if language == "" {
+		language = "und"
+	}
+	segments := make([]models.CaptionSegment, 0, len(cues))
+	for _, cue := range cues {
+		text := cue.PlainText()
+		if text == "" {
+			continue
+		}
+		segments = append(segments, models.CaptionSegment{
+			StartMs: int(cue.Start.Milliseconds()),
+			EndMs:   int(cue.End.Milliseconds()),
+			Text:    text,
+		})
+	}
+	return &models.CaptionTrack{Language: language, Name: name, Kind: kind, Segments: segments}
+}
+
+// baseLanguageTag reduces a feed language such as "en-us" to "en", the form
+// speech-to-text backends take as a hint.
+func baseLanguageTag(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(tag))
+}
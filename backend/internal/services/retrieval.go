@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+	"vibe-backend/internal/retrieval"
+)
+
+const (
+	// retrievalChunkChars is the target size of a retrieval chunk in runes.
+	retrievalChunkChars = 800
+	// retrievalOverlapChars is how much trailing transcript is repeated at the
+	// start of the next chunk so answers spanning a boundary are still found.
+	retrievalOverlapChars = 150
+	// retrievalCandidates widens each ranker's list before fusion.
+	retrievalCandidates = 3
+	// retrievalCacheSize bounds the number of in-memory insight indexes.
+	retrievalCacheSize = 256
+)
+
+// RetrievalService indexes insight transcripts into chunks and finds the
+// chunks most relevant to a chat question.
+type RetrievalService struct {
+	repo     *repository.InsightRepository
+	embedder llm.Embedder // nil means keyword search only
+	topK     int
+	log      *zap.Logger
+
+	mu      sync.Mutex
+	indexes map[uint]*chunkIndex
+}
+
+// chunkIndex is the in-memory search state for one insight.
+type chunkIndex struct {
+	chunks  []models.TranscriptChunk
+	bm25    *retrieval.Index
+	vectors [][]float32 // nil when chunks have no usable embeddings
+}
+
+// NewRetrievalService creates a new RetrievalService. embedder may be nil.
+func NewRetrievalService(repo *repository.InsightRepository, embedder llm.Embedder, topK int, log *zap.Logger) *RetrievalService {
+	if topK <= 0 {
+		topK = 6
+	}
+	return &RetrievalService{
+		repo:     repo,
+		embedder: embedder,
+		topK:     topK,
+		log:      log,
+		indexes:  make(map[uint]*chunkIndex),
+	}
+}
+
+// IndexInsight splits the insight's transcript into overlapping chunks,
+// embeds them when an embedder is configured and stores them.
+func (s *RetrievalService) IndexInsight(ctx context.Context, insight *models.Insight) error {
+	chunks, err := s.buildChunks(insight)
+	if err != nil {
+		return err
+	}
+
+	if s.embedder != nil && len(chunks) > 0 {
+		if err := s.embedChunks(ctx, chunks); err != nil {
+			// Keyword search still works without vectors
+			s.log.Warn("Failed to embed transcript chunks",
+				zap.Uint("insight_id", insight.ID),
+				zap.String("embedder", s.embedder.Name()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := s.repo.ReplaceTranscriptChunks(ctx, insight.ID, chunks); err != nil {
+		return fmt.Errorf("failed to save transcript chunks: %w", err)
+	}
+
+	s.mu.Lock()
+	delete(s.indexes, insight.ID)
+	s.mu.Unlock()
+
+	s.log.Info("Indexed insight transcript",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("chunks", len(chunks)),
+		zap.Bool("embedded", s.embedder != nil),
+	)
+	return nil
+}
+
+// Retrieve returns up to topK chunks of the insight most relevant to query,
+// best first. Insights processed before indexing existed are indexed on demand.
+func (s *RetrievalService) Retrieve(ctx context.Context, insight *models.Insight, query string) ([]models.TranscriptChunk, error) {
+	idx, err := s.loadIndex(ctx, insight)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.chunks) == 0 || strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	candidates := s.topK * retrievalCandidates
+	lists := [][]retrieval.Hit{idx.bm25.Search(query, candidates)}
+
+	if idx.vectors != nil {
+		vectors, err := s.embedder.Embed(ctx, []string{query})
+		if err != nil {
+			s.log.Warn("Failed to embed chat question, using keyword search only",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		} else if len(vectors) == 1 {
+			lists = append(lists, retrieval.VectorSearch(vectors[0], idx.vectors, candidates))
+		}
+	}
+
+	hits := retrieval.Fuse(s.topK, lists...)
+	chunks := make([]models.TranscriptChunk, len(hits))
+	for i, hit := range hits {
+		chunks[i] = idx.chunks[hit.Doc]
+	}
+	return chunks, nil
+}
+
+// loadIndex returns the cached index for an insight, building it from stored
+// chunks (or indexing the insight first) on a cache miss.
+func (s *RetrievalService) loadIndex(ctx context.Context, insight *models.Insight) (*chunkIndex, error) {
+	s.mu.Lock()
+	idx, ok := s.indexes[insight.ID]
+	s.mu.Unlock()
+	if ok {
+		return idx, nil
+	}
+
+	chunks, err := s.repo.GetTranscriptChunksByInsightID(ctx, insight.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transcript chunks: %w", err)
+	}
+	if len(chunks) == 0 && (len(insight.Transcripts) > 0 || strings.TrimSpace(insight.RawContent) != "") {
+		if err := s.IndexInsight(ctx, insight); err != nil {
+			return nil, err
+		}
+		if chunks, err = s.repo.GetTranscriptChunksByInsightID(ctx, insight.ID); err != nil {
+			return nil, fmt.Errorf("failed to load transcript chunks: %w", err)
+		}
+	}
+
+	idx = s.newChunkIndex(chunks)
+
+	s.mu.Lock()
+	if len(s.indexes) >= retrievalCacheSize {
+		// Drop an arbitrary entry; indexes are cheap to rebuild
+		for id := range s.indexes {
+			delete(s.indexes, id)
+			break
+		}
+	}
+	s.indexes[insight.ID] = idx
+	s.mu.Unlock()
+
+	return idx, nil
+}
+
+// newChunkIndex builds the BM25 index and, when every chunk was embedded by
+// the current model, the vector list.
+func (s *RetrievalService) newChunkIndex(chunks []models.TranscriptChunk) *chunkIndex {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	idx := &chunkIndex{
+		chunks: chunks,
+		bm25:   retrieval.NewIndex(texts),
+	}
+
+	if s.embedder == nil || len(chunks) == 0 {
+		return idx
+	}
+	vectors := make([][]float32, len(chunks))
+	for i, c := range chunks {
+		if c.EmbeddingModel != s.embedder.Model() || len(c.Embedding) == 0 {
+			return idx
+		}
+		if err := json.Unmarshal(c.Embedding, &vectors[i]); err != nil {
+			s.log.Warn("Failed to decode chunk embedding",
+				zap.Uint("insight_id", c.InsightID),
+				zap.Error(err),
+			)
+			return idx
+		}
+	}
+	idx.vectors = vectors
+	return idx
+}
+
+// embedChunks fills in Embedding and EmbeddingModel for every chunk.
+func (s *RetrievalService) embedChunks(ctx context.Context, chunks []models.TranscriptChunk) error {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(chunks) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(chunks), len(vectors))
+	}
+	for i, v := range vectors {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal embedding: %w", err)
+		}
+		chunks[i].Embedding = data
+		chunks[i].EmbeddingModel = s.embedder.Model()
+	}
+	return nil
+}
+
+// buildChunks splits the insight's timed transcript, or its raw content when
+// there is none, into retrieval chunks.
+func (s *RetrievalService) buildChunks(insight *models.Insight) ([]models.TranscriptChunk, error) {
+	var transcripts []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &transcripts); err != nil {
+			return nil, fmt.Errorf("failed to parse transcripts: %w", err)
+		}
+	}
+	if len(transcripts) > 0 {
+		return chunkForRetrieval(transcripts, retrievalChunkChars, retrievalOverlapChars), nil
+	}
+
+	var chunks []models.TranscriptChunk
+	for _, piece := range splitText(insight.RawContent, retrievalChunkChars) {
+		chunks = append(chunks, models.TranscriptChunk{Text: piece})
+	}
+	return chunks, nil
+}
+
+// chunkForRetrieval groups transcript lines into chunks of about maxChars
+// runes. Each chunk after the first starts with the trailing lines of the
+// previous one, up to overlapChars runes.
+func chunkForRetrieval(items []models.TranscriptItem, maxChars, overlapChars int) []models.TranscriptChunk {
+	type line struct {
+		seconds int
+		text    string
+		size    int
+	}
+
+	var chunks []models.TranscriptChunk
+	var window []line
+	size := 0
+	fresh := 0 // lines in window not yet emitted in a previous chunk
+
+	emit := func() {
+		texts := make([]string, len(window))
+		for i, l := range window {
+			texts[i] = l.text
+		}
+		end := window[len(window)-1].seconds
+		chunks = append(chunks, models.TranscriptChunk{
+			StartSeconds: window[0].seconds,
+			EndSeconds:   end,
+			Text:         strings.Join(texts, " "),
+		})
+
+		// Keep the tail of the window as overlap for the next chunk
+		keep, kept := len(window), 0
+		for keep > 1 && kept+window[keep-1].size <= overlapChars {
+			keep--
+			kept += window[keep].size
+		}
+		window = append([]line(nil), window[keep:]...)
+		size = kept
+		fresh = 0
+	}
+
+	for _, item := range items {
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(text) + 1
+		if fresh > 0 && size+n > maxChars {
+			emit()
+		}
+		window = append(window, line{seconds: item.Seconds, text: text, size: n})
+		size += n
+		fresh++
+	}
+	if fresh > 0 {
+		emit()
+	}
+
+	// A chunk ends where the next one begins, or at its last line
+	for i := range chunks {
+		if i+1 < len(chunks) && chunks[i+1].StartSeconds > chunks[i].EndSeconds {
+			chunks[i].EndSeconds = chunks[i+1].StartSeconds
+		}
+	}
+	return chunks
+}
+
+// formatChunkRange renders a chunk's time range as "MM:SS-MM:SS".
+func formatChunkRange(c models.TranscriptChunk) string {
+	return formatDuration(c.StartSeconds) + "-" + formatDuration(c.EndSeconds)
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+const (
+	// searchContentMaxRunes bounds indexed raw content; Postgres rejects
+	// tsvectors over 1MB.
+	searchContentMaxRunes = 100000
+	// searchQueryMaxRunes bounds the length of a search query.
+	searchQueryMaxRunes = 200
+	// searchSnippetRunes is the length of snippets highlighted without ts_headline.
+	searchSnippetRunes = 160
+	// searchSnippetContext is how much text is kept before the first match
+	// when such a snippet is cropped.
+	searchSnippetContext = 50
+	// searchBackfillBatch is how many insights Backfill loads per query.
+	searchBackfillBatch = 50
+)
+
+// ErrEmptySearchQuery is returned when a search query has no searchable text.
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+// searchConfigs maps base language codes to Postgres text search
+// configurations. Other languages, including Chinese and Japanese which are
+// split into bigrams before indexing, use "simple".
+var searchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nb": "norwegian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// searchQueryConfigs lists every configuration documents may be indexed
+// with, so one query matches all of them.
+var searchQueryConfigs = func() []string {
+	seen := map[string]bool{"simple": true}
+	configs := []string{"simple"}
+	for _, config := range searchConfigs {
+		if !seen[config] {
+			seen[config] = true
+			configs = append(configs, config)
+		}
+	}
+	sort.Strings(configs)
+	return configs
+}()
+
+// SearchOptions controls a library search.
+type SearchOptions struct {
+	Limit      int // insights per page, default 20, at most 50
+	Offset     int
+	PerInsight int // hits per insight, default 3, at most 10
+}
+
+// SearchService indexes insights for full-text search and searches a user's
+// library.
+type SearchService struct {
+	searchRepo  *repository.SearchRepository
+	insightRepo *repository.InsightRepository
+	log         *zap.Logger
+}
+
+// NewSearchService creates a new SearchService.
+func NewSearchService(searchRepo *repository.SearchRepository, insightRepo *repository.InsightRepository, log *zap.Logger) *SearchService {
+	return &SearchService{
+		searchRepo:  searchRepo,
+		insightRepo: insightRepo,
+		log:         log,
+	}
+}
+
+// IndexInsight replaces the search documents of an insight with its title,
+// summary and transcript items, or its raw content if it has no transcript.
+func (s *SearchService) IndexInsight(ctx context.Context, insight *models.Insight) error {
+	var items []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &items); err != nil {
+			return fmt.Errorf("failed to parse transcripts: %w", err)
+		}
+	}
+
+	sourceConfig := searchConfig(insight.TranscriptLanguage)
+	targetConfig := searchConfig(insight.TargetLang)
+
+	var docs []models.SearchDocument
+	add := func(kind models.SearchDocumentKind, text, config string, index, seconds int) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		docs = append(docs, models.SearchDocument{
+			UserID:       insight.UserID,
+			Kind:         kind,
+			SegmentIndex: index,
+			Seconds:      seconds,
+			Text:         text,
+			Config:       config,
+			Terms:        searchTerms(text),
+		})
+	}
+
+	add(models.SearchKindTitle, insight.Title, sourceConfig, 0, 0)
+	add(models.SearchKindSummary, insight.Summary, targetConfig, 0, 0)
+	for i, item := range items {
+		add(models.SearchKindSegment, item.Text, sourceConfig, i, item.Seconds)
+		if item.TranslatedText != item.Text {
+			add(models.SearchKindTranslation, item.TranslatedText, targetConfig, i, item.Seconds)
+		}
+	}
+	if len(items) == 0 {
+		// For videos the raw content is the transcript, already indexed above
+		content := insight.RawContent
+		if utf8.RuneCountInString(content) > searchContentMaxRunes {
+			content = string([]rune(content)[:searchContentMaxRunes])
+		}
+		add(models.SearchKindContent, content, sourceConfig, 0, 0)
+	}
+
+	if err := s.searchRepo.ReplaceDocuments(ctx, insight.ID, docs); err != nil {
+		return fmt.Errorf("failed to save search documents: %w", err)
+	}
+
+	s.log.Info("Indexed insight for search",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("documents", len(docs)),
+		zap.String("source_config", sourceConfig),
+		zap.String("target_config", targetConfig),
+	)
+	return nil
+}
+
+// Search finds the user's insights matching query, best first, each with its
+// best matching title, summary or transcript hits. query accepts web search
+// syntax: "quoted phrases", OR and -excluded words.
+func (s *SearchService) Search(ctx context.Context, userID uint, query string, opts SearchOptions) (*models.SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) > searchQueryMaxRunes {
+		query = string([]rune(query)[:searchQueryMaxRunes])
+	}
+	highlightTerms := searchHighlightTerms(query)
+	if len(highlightTerms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	opts.Limit = min(opts.Limit, 50)
+	opts.Offset = max(opts.Offset, 0)
+	if opts.PerInsight <= 0 {
+		opts.PerInsight = 3
+	}
+	opts.PerInsight = min(opts.PerInsight, 10)
+
+	tsQuery := repository.SearchQuery{Text: searchTerms(query), Configs: searchQueryConfigs}
+	ranks, total, err := s.searchRepo.SearchInsights(ctx, userID, tsQuery, opts.Limit, opts.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank insights: %w", err)
+	}
+
+	response := &models.SearchResponse{
+		Query:   query,
+		Results: make([]models.SearchResult, 0, len(ranks)),
+		Total:   total,
+	}
+	if len(ranks) == 0 {
+		return response, nil
+	}
+
+	ids := make([]uint, len(ranks))
+	for i, rank := range ranks {
+		ids[i] = rank.InsightID
+	}
+	insights, err := s.insightRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load insights: %w", err)
+	}
+	matches, err := s.searchRepo.SearchMatches(ctx, ids, tsQuery, opts.PerInsight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search hits: %w", err)
+	}
+
+	byID := make(map[uint]*models.Insight, len(insights))
+	for i := range insights {
+		byID[insights[i].ID] = &insights[i]
+	}
+	hits := make(map[uint][]models.SearchHit, len(ranks))
+	for _, match := range matches {
+		hits[match.InsightID] = append(hits[match.InsightID], searchHit(match, highlightTerms))
+	}
+
+	for _, rank := range ranks {
+		insight, ok := byID[rank.InsightID]
+		if !ok {
+			continue
+		}
+		response.Results = append(response.Results, models.SearchResult{
+			Insight: models.InsightListItem{
+				ID:           insight.ID,
+				SourceType:   insight.SourceType,
+				Title:        insight.Title,
+				Author:       insight.Author,
+				ThumbnailURL: insight.ThumbnailURL,
+				Status:       insight.Status,
+				CreatedAt:    insight.CreatedAt,
+			},
+			Score:   rank.Score,
+			Matches: rank.Matches,
+			Hits:    hits[rank.InsightID],
+		})
+	}
+	return response, nil
+}
+
+// StartBackfill runs Backfill in the background. It is a job manager startup
+// hook and returns at once, so the server does not wait for the index.
+func (s *SearchService) StartBackfill(ctx context.Context) error {
+	go func() {
+		if err := s.Backfill(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("Search backfill failed", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// Backfill indexes completed insights that were never indexed, such as
+// those processed before search existed.
+func (s *SearchService) Backfill(ctx context.Context) error {
+	var after uint
+	indexed := 0
+	for {
+		ids, err := s.searchRepo.GetUnindexedInsightIDs(ctx, after, searchBackfillBatch)
+		if err != nil {
+			return fmt.Errorf("failed to list unindexed insights: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			after = id
+			insight, err := s.insightRepo.GetByID(ctx, id)
+			if err != nil {
+				s.log.Warn("Failed to load insight for search backfill", zap.Uint("insight_id", id), zap.Error(err))
+				continue
+			}
+			if err := s.IndexInsight(ctx, insight); err != nil {
+				s.log.Warn("Failed to index insight for search", zap.Uint("insight_id", id), zap.Error(err))
+				continue
+			}
+			indexed++
+		}
+	}
+	if indexed > 0 {
+		s.log.Info("Backfilled search index", zap.Int("insights", indexed))
+	}
+	return nil
+}
+
+// searchHit converts a matching document to a hit with a highlighted snippet.
+func searchHit(match repository.SearchMatch, terms []string) models.SearchHit {
+	hit := models.SearchHit{
+		Kind:    match.Kind,
+		Text:    match.Text,
+		Seconds: match.Seconds,
+		Score:   match.Rank,
+	}
+	if match.Kind == models.SearchKindSegment || match.Kind == models.SearchKindTranslation {
+		hit.Timestamp = fmt.Sprintf("%02d:%02d", match.Seconds/60, match.Seconds%60)
+	}
+
+	if strings.Contains(match.Headline, "\x02") {
+		snippet := html.EscapeString(match.Headline)
+		snippet = strings.ReplaceAll(snippet, "\x02", "<mark>")
+		hit.Snippet = strings.ReplaceAll(snippet, "\x03", "</mark>")
+	} else {
+		// ts_headline cannot find matches that were split into bigrams
+		hit.Snippet = highlightSnippet(match.Text, terms)
+	}
+	return hit
+}
+
+// searchConfig returns the text search configuration for a language code.
+func searchConfig(language string) string {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	if config, ok := searchConfigs[base]; ok {
+		return config
+	}
+	return "simple"
+}
+
+// searchTerms prepares text for indexing or querying. Runs of CJK
+// characters, which Postgres parsers keep as a single word, are replaced by
+// their overlapping bigrams so words inside them can be matched. Bigrams of
+// one run are adjacent, so quoted phrases still match.
+func searchTerms(text string) string {
+	var b strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		b.WriteByte(' ')
+		if len(run) == 1 {
+			b.WriteRune(run[0])
+		}
+		for i := 0; i+1 < len(run); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(string(run[i : i+2]))
+		}
+		b.WriteByte(' ')
+		run = run[:0]
+	}
+
+	for _, r := range text {
+		if isCJK(r) {
+			run = append(run, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// searchHighlightTerms returns the words of a web search style query that
+// should be highlighted: quoted phrases and words, without the OR keyword
+// and excluded words.
+func searchHighlightTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(word, "-") || strings.EqualFold(word, "or") {
+				continue
+			}
+			word = strings.TrimFunc(word, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+			})
+			if word != "" {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
+
+// highlightSnippet wraps case-insensitive occurrences of terms in <mark>
+// tags, cropping long text around the first match. The text is HTML-escaped.
+func highlightSnippet(text string, terms []string) string {
+	runes := []rune(text)
+	lengths := make([]int, len(terms))
+	for i, term := range terms {
+		lengths[i] = utf8.RuneCountInString(term)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < len(runes); {
+		matched := 0
+		for t, term := range terms {
+			n := lengths[t]
+			if n > matched && i+n <= len(runes) && strings.EqualFold(string(runes[i:i+n]), term) {
+				matched = n
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		spans = append(spans, span{i, i + matched})
+		i += matched
+	}
+
+	start, end := 0, len(runes)
+	if end > searchSnippetRunes {
+		if len(spans) > 0 {
+			start = max(spans[0].start-searchSnippetContext, 0)
+		}
+		end = min(start+searchSnippetRunes, len(runes))
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	pos := start
+	for _, sp := range spans {
+		if sp.start < pos {
+			continue
+		}
+		if sp.end > end {
+			break
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[sp.start:sp.end])))
+		b.WriteString("</mark>")
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+// Sentence re-segmentation limits.
+const (
+	// sentencePauseMs is the silence that ends a sentence in unpunctuated
+	// captions.
+	sentencePauseMs = 800
+	// sentenceMaxMs and sentenceMaxWords force a break in run-on text.
+	sentenceMaxMs    = 20000
+	sentenceMaxWords = 40
+	// heuristicSentenceWords is the sentence length the heuristic aims for
+	// when there is no pause to break at.
+	heuristicSentenceWords = 25
+	// punctuationBatchWords is the number of words sent per LLM request.
+	punctuationBatchWords = 250
+)
+
+// sentenceTerminators end a sentence.
+const sentenceTerminators = ".?!。？！…"
+
+// sentenceAbbreviations end with a period but rarely end a sentence.
+var sentenceAbbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "prof.": true,
+	"st.": true, "vs.": true, "e.g.": true, "i.e.": true, "no.": true,
+}
+
+const punctuationPrompt = `You restore punctuation and capitalization in speech-recognition transcripts.
+Return the transcript below with the same words in the same order, adding only punctuation and capitalization.
+Do not add, remove, translate or correct words. Reply with the transcript text only.
+
+Transcript:
+%s`
+
+// SentenceSegmenter rebuilds caption fragments into full sentences. YouTube
+// speech-recognition captions arrive as unpunctuated lines a few words long,
+// which translate and summarize badly; manual captions often split or join
+// sentences at arbitrary points.
+type SentenceSegmenter struct {
+	llmClient *llm.Client
+	log       *zap.Logger
+}
+
+// NewSentenceSegmenter creates a new SentenceSegmenter. llmClient is used to
+// restore punctuation in unpunctuated captions; when nil, or when a request
+// fails, punctuation is restored heuristically from pauses.
+func NewSentenceSegmenter(llmClient *llm.Client, log *zap.Logger) *SentenceSegmenter {
+	return &SentenceSegmenter{
+		llmClient: llmClient,
+		log:       log,
+	}
+}
+
+// timedWord is a word (or, in CJK text, a character) with a time estimated
+// from its caption segment.
+type timedWord struct {
+	text    string
+	startMs int
+	endMs   int
+}
+
+// Resegment returns segments regrouped into sentences, with punctuation and
+// casing restored if the captions have none. Each sentence starts when its
+// first word is spoken and ends with its last.
+func (s *SentenceSegmenter) Resegment(ctx context.Context, segments []models.CaptionSegment) []models.CaptionSegment {
+	words := splitTimedWords(segments)
+	if len(words) == 0 {
+		return segments
+	}
+
+	if !isPunctuated(words) {
+		for start := 0; start < len(words); start += punctuationBatchWords {
+			end := start + punctuationBatchWords
+			if end > len(words) {
+				end = len(words)
+			}
+			batch := words[start:end]
+			if err := s.punctuateWithLLM(ctx, batch); err != nil {
+				if s.llmClient != nil {
+					s.log.Debug("LLM punctuation failed, using pauses", zap.Error(err))
+				}
+				punctuateByPauses(batch)
+			}
+		}
+	}
+
+	return buildSentences(words)
+}
+
+// splitTimedWords splits segments into words and spreads each segment's
+// time over its words in proportion to their length.
+func splitTimedWords(segments []models.CaptionSegment) []timedWord {
+	var words []timedWord
+	for i, seg := range segments {
+		tokens := splitWords(seg.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		// Rolling captions overlap the next line; stop where it starts
+		end := seg.EndMs
+		if i+1 < len(segments) && segments[i+1].StartMs > seg.StartMs && segments[i+1].StartMs < end {
+			end = segments[i+1].StartMs
+		}
+		if end < seg.StartMs {
+			end = seg.StartMs
+		}
+
+		total := 0
+		for _, t := range tokens {
+			total += utf8.RuneCountInString(t) + 1
+		}
+		elapsed := 0
+		for _, t := range tokens {
+			weight := utf8.RuneCountInString(t) + 1
+			words = append(words, timedWord{
+				text:    t,
+				startMs: seg.StartMs + (end-seg.StartMs)*elapsed/total,
+				endMs:   seg.StartMs + (end-seg.StartMs)*(elapsed+weight)/total,
+			})
+			elapsed += weight
+		}
+	}
+	return words
+}
+
+// splitWords splits text at spaces and between CJK characters, keeping
+// punctuation attached to the preceding character.
+func splitWords(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		var word strings.Builder
+		for _, r := range field {
+			switch {
+			case isCJK(r):
+				if word.Len() > 0 {
+					words = append(words, word.String())
+					word.Reset()
+				}
+				word.WriteRune(r)
+				words = append(words, word.String())
+				word.Reset()
+			case !unicode.IsLetter(r) && !unicode.IsNumber(r) && word.Len() == 0 && len(words) > 0 && isCJKWord(words[len(words)-1]):
+				// Punctuation after a CJK character belongs to it
+				words[len(words)-1] += string(r)
+			default:
+				word.WriteRune(r)
+			}
+		}
+		if word.Len() > 0 {
+			words = append(words, word.String())
+		}
+	}
+	return words
+}
+
+// isPunctuated reports whether the captions already mark sentence ends, at
+// least once every sentenceMaxWords words.
+func isPunctuated(words []timedWord) bool {
+	ends := 0
+	for _, w := range words {
+		if endsSentence(w.text) {
+			ends++
+		}
+	}
+	return ends > 0 && ends*sentenceMaxWords >= len(words)
+}
+
+// punctuateWithLLM asks the LLM to punctuate words and copies the result
+// back word by word. The words are left unchanged if the reply does not
+// contain exactly the same words.
+func (s *SentenceSegmenter) punctuateWithLLM(ctx context.Context, words []timedWord) error {
+	if s.llmClient == nil {
+		return fmt.Errorf("no LLM configured")
+	}
+
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+
+	ctx = llm.WithTags(ctx, llm.Tags{Feature: llm.FeaturePunctuation})
+	resp, err := s.llmClient.Complete(ctx, llm.Request{
+		Messages:    []llm.Message{llm.User(fmt.Sprintf(punctuationPrompt, joinWords(texts)))},
+		Temperature: llm.Temperature(0),
+		MaxTokens:   4*len(words) + 200,
+	})
+	if err != nil {
+		return err
+	}
+
+	punctuated, ok := alignPunctuation(texts, resp.Content)
+	if !ok {
+		return fmt.Errorf("LLM changed the words of the transcript")
+	}
+	for i := range words {
+		words[i].text = punctuated[i]
+	}
+	return nil
+}
+
+// alignPunctuation maps a punctuated rewrite of words back onto them. Letters
+// and digits in output must match words exactly (ignoring case); any other
+// characters are attached to the word they follow, or precede for the first
+// word. It returns false if the letters differ.
+func alignPunctuation(words []string, output string) ([]string, bool) {
+	keys := make([][]rune, len(words))
+	for i, w := range words {
+		keys[i] = []rune(wordKey(w))
+	}
+
+	result := make([]strings.Builder, len(words))
+	var leading strings.Builder
+	i, j := 0, 0 // word, and letter within its key
+	for _, r := range strings.TrimSpace(output) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			for i < len(words) && len(keys[i]) == 0 {
+				i++ // words with no letters keep their own text
+			}
+			if i >= len(words) || unicode.ToLower(r) != keys[i][j] {
+				return nil, false
+			}
+			if j == 0 && leading.Len() > 0 {
+				result[i].WriteString(leading.String())
+				leading.Reset()
+			}
+			result[i].WriteRune(r)
+			if j++; j == len(keys[i]) {
+				i, j = i+1, 0
+			}
+			continue
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case j > 0:
+			result[i].WriteRune(r) // inside a word, e.g. "don't"
+		case i > 0 && !isOpening(r):
+			result[i-1].WriteRune(r)
+		default:
+			leading.WriteRune(r)
+		}
+	}
+	for i < len(words) && len(keys[i]) == 0 {
+		i++
+	}
+	if i != len(words) || j != 0 {
+		return nil, false
+	}
+
+	out := make([]string, len(words))
+	for k := range words {
+		out[k] = result[k].String()
+		if len(keys[k]) == 0 {
+			out[k] = words[k]
+		}
+	}
+	return out, true
+}
+
+// wordKey returns the lowercased letters and digits of a word.
+func wordKey(w string) string {
+	var b strings.Builder
+	for _, r := range w {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// isOpening reports whether r opens a quotation or parenthesis.
+func isOpening(r rune) bool {
+	return strings.ContainsRune("\"'(“‘「『（《", r)
+}
+
+// punctuateByPauses ends a sentence at each long pause, or at the longest
+// pause in a run of heuristicSentenceWords words, then capitalizes.
+func punctuateByPauses(words []timedWord) {
+	start := 0
+	for i := range words {
+		last := i == len(words)-1
+		pause := !last && words[i+1].startMs-words[i].endMs >= sentencePauseMs
+		if !last && !pause && i-start+1 < heuristicSentenceWords {
+			continue
+		}
+
+		end := i
+		if !last && !pause {
+			// Break after the longest gap in the second half of the run
+			best := -1
+			for k := start + heuristicSentenceWords/2; k < i; k++ {
+				if gap := words[k+1].startMs - words[k].endMs; best < 0 || gap > words[best+1].startMs-words[best].endMs {
+					best = k
+				}
+			}
+			if best >= 0 {
+				end = best
+			}
+		}
+
+		words[end].text = terminate(words[end].text)
+		start = end + 1
+	}
+
+	capitalizeSentences(words)
+}
+
+// terminate appends a full stop suited to the script of w, unless it already
+// ends a sentence.
+func terminate(w string) string {
+	if endsSentence(w) {
+		return w
+	}
+	w = strings.TrimRight(w, ",，、;；:：")
+	if isCJKWord(w) {
+		return w + "。"
+	}
+	return w + "."
+}
+
+// capitalizeSentences upper-cases the first letter of each sentence and the
+// pronoun "I" in Latin text.
+func capitalizeSentences(words []timedWord) {
+	sentenceStart := true
+	for i, w := range words {
+		if sentenceStart || w.text == "i" || strings.HasPrefix(w.text, "i'") {
+			words[i].text = upperFirst(w.text)
+		}
+		sentenceStart = endsSentence(w.text)
+	}
+}
+
+// upperFirst upper-cases the first letter of s.
+func upperFirst(s string) string {
+	for i, r := range s {
+		if unicode.IsLetter(r) {
+			return s[:i] + string(unicode.ToUpper(r)) + s[i+utf8.RuneLen(r):]
+		}
+	}
+	return s
+}
+
+// buildSentences groups punctuated words into sentences, breaking run-on
+// text at sentenceMaxWords words or sentenceMaxMs.
+func buildSentences(words []timedWord) []models.CaptionSegment {
+	var sentences []models.CaptionSegment
+	start := 0
+	for i, w := range words {
+		last := i == len(words)-1
+		if !last && !endsSentence(w.text) &&
+			i-start+1 < sentenceMaxWords && w.endMs-words[start].startMs < sentenceMaxMs {
+			continue
+		}
+
+		texts := make([]string, 0, i-start+1)
+		for _, sw := range words[start : i+1] {
+			texts = append(texts, sw.text)
+		}
+		sentences = append(sentences, models.CaptionSegment{
+			StartMs: words[start].startMs,
+			EndMs:   w.endMs,
+			Text:    joinWords(texts),
+		})
+		start = i + 1
+	}
+	return sentences
+}
+
+// endsSentence reports whether a word ends with sentence-final punctuation
+// (closing quotes aside) and is not a common abbreviation.
+func endsSentence(w string) bool {
+	trimmed := strings.TrimRight(w, "\"')”’」』）")
+	r, _ := utf8.DecodeLastRuneInString(trimmed)
+	if !strings.ContainsRune(sentenceTerminators, r) {
+		return false
+	}
+	return !sentenceAbbreviations[strings.ToLower(trimmed)]
+}
+
+// joinWords joins words with spaces, except between CJK words.
+func joinWords(words []string) string {
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 && !(isCJKWord(words[i-1]) && isCJKWord(w)) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+// isCJKWord reports whether a word starts with a CJK character.
+func isCJKWord(w string) bool {
+	r, _ := utf8.DecodeRuneInString(w)
+	return isCJK(r)
+}
+
+// isCJK reports whether r is a Chinese or Japanese character, which are
+// written without spaces between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
@@ -0,0 +1,604 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+	"vibe-backend/internal/ytfeed"
+)
+
+// pushPollDelay gives the public feed time to list an upload the hub has
+// just announced.
+const pushPollDelay = time.Minute
+
+// ErrAlreadySubscribed is returned when a user subscribes to a channel twice.
+var ErrAlreadySubscribed = errors.New("already subscribed to channel")
+
+// SubscriptionOptions configures a SubscriptionService.
+type SubscriptionOptions struct {
+	// PollInterval is how often every followed channel is polled.
+	PollInterval time.Duration
+	// PushCallbackURL is the public URL of the WebSub callback route, to
+	// which the channel ID is appended. Empty disables push.
+	PushCallbackURL string
+	// PushLease is the lease requested from the hub.
+	PushLease time.Duration
+}
+
+// SubscriptionService follows the YouTube channels users subscribe to and
+// creates insights for their new uploads. Channels are polled on a schedule
+// through the job queue; with push enabled, hub notifications trigger an
+// extra poll as soon as a channel uploads.
+type SubscriptionService struct {
+	repo           *repository.SubscriptionRepository
+	insightRepo    *repository.InsightRepository
+	userRepo       *repository.UserRepository
+	feeds          *ytfeed.Client
+	youtubeService *YouTubeService
+	processor      *InsightProcessor
+	queue          *jobs.Manager
+	opts           SubscriptionOptions
+	log            *zap.Logger
+}
+
+// NewSubscriptionService creates a new SubscriptionService.
+func NewSubscriptionService(
+	repo *repository.SubscriptionRepository,
+	insightRepo *repository.InsightRepository,
+	userRepo *repository.UserRepository,
+	feeds *ytfeed.Client,
+	youtubeService *YouTubeService,
+	processor *InsightProcessor,
+	opts SubscriptionOptions,
+	log *zap.Logger,
+) *SubscriptionService {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 15 * time.Minute
+	}
+	if opts.PushLease <= 0 {
+		opts.PushLease = 5 * 24 * time.Hour
+	}
+	return &SubscriptionService{
+		repo:           repo,
+		insightRepo:    insightRepo,
+		userRepo:       userRepo,
+		feeds:          feeds,
+		youtubeService: youtubeService,
+		processor:      processor,
+		opts:           opts,
+		log:            log,
+	}
+}
+
+// SetJobQueue sets the job queue used to schedule channel polls.
+func (s *SubscriptionService) SetJobQueue(queue *jobs.Manager) {
+	s.queue = queue
+}
+
+// Subscribe subscribes a user to a channel. The channel's current uploads
+// are recorded as existing, so only videos uploaded from now on become
+// insights.
+func (s *SubscriptionService) Subscribe(ctx context.Context, userID uint, req *models.CreateSubscriptionRequest) (*models.ChannelSubscription, error) {
+	channelID, handle, err := s.feeds.ResolveChannel(ctx, req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByChannel(ctx, userID, channelID); err == nil {
+		return nil, ErrAlreadySubscribed
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	feed, err := s.feeds.Fetch(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := json.Marshal(cleanKeywords(req.Keywords))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode keywords: %w", err)
+	}
+
+	sub := &models.ChannelSubscription{
+		UserID:             userID,
+		ChannelID:          channelID,
+		ChannelTitle:       feed.Title,
+		Handle:             handle,
+		Keywords:           keywords,
+		MinDurationSeconds: req.MinDurationSeconds,
+		SkipShorts:         req.SkipShorts,
+	}
+	existing := make([]models.SubscriptionVideo, len(feed.Videos))
+	for i, video := range feed.Videos {
+		existing[i] = models.SubscriptionVideo{
+			VideoID:     video.ID,
+			Title:       video.Title,
+			PublishedAt: timePtr(video.Published),
+			Outcome:     models.SubscriptionVideoExisting,
+		}
+	}
+
+	secret, err := newPushSecret()
+	if err != nil {
+		return nil, err
+	}
+	channelFeed, err := s.repo.EnsureFeed(ctx, channelID, feed.Title, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save channel feed: %w", err)
+	}
+	if err := s.repo.Create(ctx, sub, existing); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Subscribed to channel",
+		zap.Uint("subscription_id", sub.ID),
+		zap.Uint("user_id", userID),
+		zap.String("channel_id", channelID),
+	)
+	s.renewPush(ctx, channelFeed)
+	return sub, nil
+}
+
+// Update changes a subscription's filters or pauses it.
+func (s *SubscriptionService) Update(ctx context.Context, sub *models.ChannelSubscription, req *models.UpdateSubscriptionRequest) error {
+	if req.Keywords != nil {
+		keywords, err := json.Marshal(cleanKeywords(*req.Keywords))
+		if err != nil {
+			return fmt.Errorf("failed to encode keywords: %w", err)
+		}
+		sub.Keywords = keywords
+	}
+	if req.MinDurationSeconds != nil {
+		sub.MinDurationSeconds = *req.MinDurationSeconds
+	}
+	if req.SkipShorts != nil {
+		sub.SkipShorts = *req.SkipShorts
+	}
+	if req.Paused != nil {
+		sub.Paused = *req.Paused
+	}
+	return s.repo.Update(ctx, sub)
+}
+
+// Unsubscribe deletes a subscription. The channel is no longer followed
+// once its last subscription is gone.
+func (s *SubscriptionService) Unsubscribe(ctx context.Context, sub *models.ChannelSubscription) error {
+	if err := s.repo.Delete(ctx, sub.ID); err != nil {
+		return err
+	}
+
+	remaining, err := s.repo.CountByChannel(ctx, sub.ChannelID)
+	if err != nil || remaining > 0 {
+		return err
+	}
+	if s.pushEnabled() {
+		if feed, err := s.repo.GetFeed(ctx, sub.ChannelID); err == nil && feed.PushExpiresAt != nil {
+			if err := s.feeds.Subscribe(ctx, s.pushCallback(sub.ChannelID), sub.ChannelID, "", 0, false); err != nil {
+				s.log.Warn("Failed to unsubscribe channel from hub",
+					zap.String("channel_id", sub.ChannelID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+	return s.repo.DeleteFeed(ctx, sub.ChannelID)
+}
+
+// Start is a job manager startup hook that polls every followed channel now
+// and then every PollInterval until ctx is cancelled.
+func (s *SubscriptionService) Start(ctx context.Context) error {
+	if s.queue == nil {
+		return fmt.Errorf("job queue not configured")
+	}
+	go func() {
+		ticker := time.NewTicker(s.opts.PollInterval)
+		defer ticker.Stop()
+		for {
+			s.enqueuePolls(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// enqueuePolls schedules a poll of every channel with an active
+// subscription. Channels whose previous poll is still pending are skipped.
+func (s *SubscriptionService) enqueuePolls(ctx context.Context) {
+	channelIDs, err := s.repo.ListActiveChannelIDs(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("Failed to list subscribed channels", zap.Error(err))
+		}
+		return
+	}
+	for _, channelID := range channelIDs {
+		if err := s.EnqueuePoll(ctx, channelID, time.Time{}); err != nil {
+			s.log.Error("Failed to enqueue channel poll",
+				zap.String("channel_id", channelID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// EnqueuePoll schedules a poll of a channel at runAt (zero = now).
+func (s *SubscriptionService) EnqueuePoll(ctx context.Context, channelID string, runAt time.Time) error {
+	if s.queue == nil {
+		return fmt.Errorf("job queue not configured")
+	}
+	_, err := s.queue.Enqueue(ctx, jobs.QueueSubscription, jobs.SubscriptionPayload{ChannelID: channelID}, jobs.EnqueueOptions{
+		DedupeKey: jobs.SubscriptionKey(channelID),
+		RunAt:     runAt,
+	})
+	return err
+}
+
+// HandleJob is the jobs.Handler for the subscription queue.
+func (s *SubscriptionService) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload jobs.SubscriptionPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	return s.PollChannel(ctx, payload.ChannelID)
+}
+
+// PollChannel reads a channel's feed and creates insights for the uploads
+// its active subscriptions have not seen yet.
+func (s *SubscriptionService) PollChannel(ctx context.Context, channelID string) error {
+	subs, err := s.repo.ListActiveByChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to list channel subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	secret, err := newPushSecret()
+	if err != nil {
+		return err
+	}
+	channelFeed, err := s.repo.EnsureFeed(ctx, channelID, subs[0].ChannelTitle, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save channel feed: %w", err)
+	}
+
+	feed, err := s.feeds.Fetch(ctx, channelID)
+	if err != nil {
+		if updateErr := s.repo.UpdateFeedPolled(ctx, channelID, "", err.Error()); updateErr != nil {
+			s.log.Error("Failed to record channel poll", zap.String("channel_id", channelID), zap.Error(updateErr))
+		}
+		if errors.Is(err, ytfeed.ErrChannelNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if err := s.repo.UpdateFeedPolled(ctx, channelID, feed.Title, ""); err != nil {
+		s.log.Error("Failed to record channel poll", zap.String("channel_id", channelID), zap.Error(err))
+	}
+
+	uploads := &uploadInfo{durations: map[string]int{}, shorts: map[string]bool{}}
+	var firstErr error
+	for i := range subs {
+		if err := s.processUploads(ctx, &subs[i], feed.Videos, uploads); err != nil {
+			s.log.Error("Failed to process channel uploads",
+				zap.Uint("subscription_id", subs[i].ID),
+				zap.String("channel_id", channelID),
+				zap.Error(err),
+			)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	s.renewPush(ctx, channelFeed)
+	return firstErr
+}
+
+// uploadInfo caches what was looked up about uploads during one poll, so
+// subscriptions to the same channel share the lookups.
+type uploadInfo struct {
+	durations map[string]int
+	shorts    map[string]bool
+}
+
+// processUploads records the uploads a subscription has not seen, oldest
+// first, creating and enqueueing insights for those that pass its filters.
+// Uploads whose filters cannot be checked yet are left for the next poll.
+func (s *SubscriptionService) processUploads(ctx context.Context, sub *models.ChannelSubscription, videos []ytfeed.Video, uploads *uploadInfo) error {
+	if len(videos) == 0 {
+		return nil
+	}
+	ids := make([]string, len(videos))
+	for i, video := range videos {
+		ids[i] = video.ID
+	}
+	seen, err := s.repo.SeenVideoIDs(ctx, sub.ID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list seen uploads: %w", err)
+	}
+
+	var user *models.User
+	for i := len(videos) - 1; i >= 0; i-- {
+		video := &videos[i]
+		if seen[video.ID] {
+			continue
+		}
+
+		reason, err := s.filter(ctx, sub, video, uploads)
+		if err != nil {
+			s.log.Warn("Failed to check upload against subscription filters, retrying on next poll",
+				zap.Uint("subscription_id", sub.ID),
+				zap.String("video_id", video.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		record := &models.SubscriptionVideo{
+			SubscriptionID: sub.ID,
+			VideoID:        video.ID,
+			Title:          video.Title,
+			PublishedAt:    timePtr(video.Published),
+		}
+		var insight *models.Insight
+		if reason != "" {
+			record.Outcome = models.SubscriptionVideoFiltered
+			record.Reason = reason
+		} else {
+			existing, err := s.insightRepo.GetBySourceID(ctx, video.ID, sub.UserID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to check for duplicate insight: %w", err)
+			}
+			if existing != nil {
+				record.Outcome = models.SubscriptionVideoDuplicate
+				record.InsightID = &existing.ID
+			} else {
+				if user == nil {
+					if user, err = s.userRepo.GetByID(ctx, sub.UserID); err != nil {
+						return fmt.Errorf("failed to get subscriber: %w", err)
+					}
+				}
+				record.Outcome = models.SubscriptionVideoCreated
+				insight = &models.Insight{
+					UserID:       sub.UserID,
+					SourceType:   models.SourceTypeYouTube,
+					SourceURL:    "https://www.youtube.com/watch?v=" + video.ID,
+					SourceID:     video.ID,
+					Title:        video.Title,
+					Author:       sub.ChannelTitle,
+					ThumbnailURL: video.Thumbnail,
+					PublishedAt:  timePtr(video.Published),
+					TargetLang:   user.TargetLang,
+					Status:       models.InsightStatusPending,
+				}
+				if insight.TargetLang == "" {
+					insight.TargetLang = "zh"
+				}
+			}
+		}
+
+		recorded, err := s.repo.RecordVideo(ctx, record, insight)
+		if err != nil {
+			return fmt.Errorf("failed to record upload %s: %w", video.ID, err)
+		}
+		if !recorded || insight == nil {
+			continue
+		}
+
+		s.log.Info("Created insight for new upload",
+			zap.Uint("subscription_id", sub.ID),
+			zap.Uint("insight_id", insight.ID),
+			zap.String("video_id", video.ID),
+		)
+		// A failed enqueue leaves the insight pending; it is recovered on next startup
+		if err := s.processor.EnqueueInsight(ctx, insight.ID); err != nil {
+			s.log.Error("Failed to enqueue insight processing",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// filter returns the filter that rejects an upload for a subscription, or ""
+// if it passes. Cheap checks run first so lookups are only made when needed.
+func (s *SubscriptionService) filter(ctx context.Context, sub *models.ChannelSubscription, video *ytfeed.Video, uploads *uploadInfo) (string, error) {
+	var keywords []string
+	if len(sub.Keywords) > 0 {
+		if err := json.Unmarshal(sub.Keywords, &keywords); err != nil {
+			return "", fmt.Errorf("invalid subscription keywords: %w", err)
+		}
+	}
+	if len(keywords) > 0 && !matchesKeyword(video, keywords) {
+		return models.SubscriptionFilterKeywords, nil
+	}
+
+	if sub.SkipShorts {
+		short, ok := uploads.shorts[video.ID]
+		if !ok {
+			var err error
+			if short, err = s.feeds.IsShort(ctx, video); err != nil {
+				return "", err
+			}
+			uploads.shorts[video.ID] = short
+		}
+		if short {
+			return models.SubscriptionFilterShorts, nil
+		}
+	}
+
+	if sub.MinDurationSeconds > 0 {
+		duration, ok := uploads.durations[video.ID]
+		if !ok {
+			var err error
+			if duration, err = s.videoDuration(ctx, video.ID); err != nil {
+				return "", err
+			}
+			uploads.durations[video.ID] = duration
+		}
+		if duration < sub.MinDurationSeconds {
+			return models.SubscriptionFilterDuration, nil
+		}
+	}
+	return "", nil
+}
+
+// videoDuration looks up a video's duration in seconds with the YouTube Data
+// API, falling back to yt-dlp. Upcoming premieres and live streams have no
+// duration yet and are reported as errors, so they are checked again later.
+func (s *SubscriptionService) videoDuration(ctx context.Context, videoID string) (int, error) {
+	metadata, err := s.youtubeService.GetVideoMetadataFromAPI(ctx, videoID)
+	if err != nil {
+		metadata, err = s.youtubeService.GetVideoMetadataWithYtDlp(ctx, videoID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get video duration: %w", err)
+		}
+	}
+	if metadata.Duration <= 0 {
+		return 0, fmt.Errorf("video %s has no duration yet", videoID)
+	}
+	return metadata.Duration, nil
+}
+
+// --- WebSub push ---
+
+// pushEnabled reports whether channels are subscribed at the WebSub hub.
+func (s *SubscriptionService) pushEnabled() bool {
+	return s.opts.PushCallbackURL != ""
+}
+
+// pushCallback returns the callback URL the hub notifies for a channel.
+func (s *SubscriptionService) pushCallback(channelID string) string {
+	return strings.TrimRight(s.opts.PushCallbackURL, "/") + "/" + channelID
+}
+
+// renewPush subscribes a channel at the hub unless its lease outlasts the
+// next few polls. Failures are only logged: polling still finds uploads.
+func (s *SubscriptionService) renewPush(ctx context.Context, feed *models.ChannelFeed) {
+	if !s.pushEnabled() {
+		return
+	}
+	renewBefore := max(3*s.opts.PollInterval, time.Hour)
+	if feed.PushExpiresAt != nil && time.Until(*feed.PushExpiresAt) > renewBefore {
+		return
+	}
+	if err := s.feeds.Subscribe(ctx, s.pushCallback(feed.ChannelID), feed.ChannelID, feed.PushSecret, s.opts.PushLease, true); err != nil {
+		s.log.Warn("Failed to subscribe channel at hub",
+			zap.String("channel_id", feed.ChannelID),
+			zap.Error(err),
+		)
+	}
+}
+
+// ConfirmPush answers the hub's verification of a subscription change for a
+// channel. It reports false for changes this service did not ask for.
+func (s *SubscriptionService) ConfirmPush(ctx context.Context, channelID, mode, topic string, lease time.Duration) (bool, error) {
+	if topic != ytfeed.Topic(channelID) {
+		return false, nil
+	}
+	_, err := s.repo.GetFeed(ctx, channelID)
+	followed := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	switch mode {
+	case "subscribe":
+		if !followed {
+			return false, nil
+		}
+		if lease <= 0 {
+			lease = s.opts.PushLease
+		}
+		expiresAt := time.Now().Add(lease)
+		return true, s.repo.UpdateFeedPush(ctx, channelID, &expiresAt)
+	case "unsubscribe":
+		return !followed, nil
+	case "denied":
+		if followed {
+			s.log.Warn("Hub denied channel subscription", zap.String("channel_id", channelID))
+			return true, s.repo.UpdateFeedPush(ctx, channelID, nil)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// HandlePush handles a hub notification of a channel's uploads by polling
+// the channel shortly after. Notifications with a bad signature are ignored.
+func (s *SubscriptionService) HandlePush(ctx context.Context, channelID string, body []byte, signature string) error {
+	feed, err := s.repo.GetFeed(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !ytfeed.VerifySignature(body, feed.PushSecret, signature) {
+		s.log.Warn("Ignoring hub notification with invalid signature", zap.String("channel_id", channelID))
+		return nil
+	}
+	return s.EnqueuePoll(ctx, channelID, time.Now().Add(pushPollDelay))
+}
+
+// cleanKeywords trims keywords and drops empty and repeated ones.
+func cleanKeywords(keywords []string) []string {
+	cleaned := make([]string, 0, len(keywords))
+	seen := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		key := strings.ToLower(keyword)
+		if keyword == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, keyword)
+	}
+	return cleaned
+}
+
+// matchesKeyword reports whether a video's title or description mentions
+// one of the keywords, ignoring case.
+func matchesKeyword(video *ytfeed.Video, keywords []string) bool {
+	text := strings.ToLower(video.Title + "\n" + video.Description)
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPushSecret generates the HMAC key of a channel's hub notifications.
+func newPushSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate push secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// timePtr returns a pointer to t, or nil for the zero time.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
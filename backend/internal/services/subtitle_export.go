@@ -0,0 +1,42 @@
+package services
+
+import (
+	"time"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/subtitles"
+)
+
+// DualSubtitleCues converts a translation's dual subtitles to bilingual cues
+// for export. Unparseable end times are left for subtitles.FixTimings.
+func DualSubtitleCues(subs []models.DualSubtitle) []subtitles.BilingualCue {
+	cues := make([]subtitles.BilingualCue, 0, len(subs))
+	for _, sub := range subs {
+		start, ok := subtitles.ParseTimestamp(sub.StartTime)
+		if !ok {
+			continue
+		}
+		end, _ := subtitles.ParseTimestamp(sub.EndTime)
+		cues = append(cues, subtitles.BilingualCue{
+			Start:      start,
+			End:        end,
+			Original:   sub.Original,
+			Translated: sub.Translated,
+		})
+	}
+	return cues
+}
+
+// TranscriptItemCues converts an insight's transcript to bilingual cues for
+// export. Items carry no end time, so each cue runs until the next.
+func TranscriptItemCues(items []models.TranscriptItem) []subtitles.BilingualCue {
+	cues := make([]subtitles.BilingualCue, len(items))
+	for i, item := range items {
+		cues[i] = subtitles.BilingualCue{
+			Start:      time.Duration(item.Seconds) * time.Second,
+			Original:   item.Text,
+			Translated: item.TranslatedText,
+		}
+	}
+	return cues
+}
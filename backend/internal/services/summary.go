@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+const (
+	// summaryChunkChars bounds the content sent in a single summarization call.
+	summaryChunkChars = 12000
+	// summaryMapConcurrency limits parallel map calls for long content.
+	summaryMapConcurrency = 4
+	// summaryMaxKeyPoints is the number of key points kept in the final result.
+	summaryMaxKeyPoints = 8
+)
+
+// SummaryService generates insight summaries and key points.
+//
+// Short content is summarized in a single call. Long content (e.g. a
+// three-hour podcast) is split into chunks that are summarized independently
+// (map) and then merged into one summary (reduce), recursively if the partial
+// summaries themselves do not fit in one call.
+type SummaryService struct {
+	llmClient *llm.Client
+	log       *zap.Logger
+}
+
+// NewSummaryService creates a new SummaryService.
+func NewSummaryService(llmClient *llm.Client, log *zap.Logger) *SummaryService {
+	return &SummaryService{
+		llmClient: llmClient,
+		log:       log,
+	}
+}
+
+// Model returns the LLM provider and model summaries are generated with.
+func (s *SummaryService) Model() (provider, model string) {
+	return s.llmClient.ProviderName(), s.llmClient.Model()
+}
+
+// SummaryInput is the content to summarize.
+type SummaryInput struct {
+	Title       string
+	Author      string
+	Transcripts []models.TranscriptItem // preferred; key points are anchored to these
+	RawContent  string                  // used when there are no transcripts
+	TargetLang  string                  // language of the generated output
+}
+
+// SummaryResult is the generated summary and key points.
+type SummaryResult struct {
+	Summary   string
+	KeyPoints []models.InsightKeyPoint
+}
+
+// contentChunk is one piece of content for the map step.
+type contentChunk struct {
+	Start int // seconds of the first transcript line
+	End   int // seconds of the last transcript line
+	Text  string
+}
+
+// partialSummary is the output of a map or intermediate reduce step.
+type partialSummary struct {
+	Start     int
+	End       int
+	Summary   string
+	KeyPoints []models.InsightKeyPoint
+}
+
+// summaryResponse is the JSON shape the model is asked to return.
+type summaryResponse struct {
+	Summary   string `json:"summary"`
+	KeyPoints []struct {
+		Text    string  `json:"text"`
+		Seconds float64 `json:"seconds"`
+	} `json:"key_points"`
+}
+
+// Summarize generates a summary and key points written in in.TargetLang.
+func (s *SummaryService) Summarize(ctx context.Context, in SummaryInput) (*SummaryResult, error) {
+	ctx = llm.WithFeature(ctx, llm.FeatureSummary)
+
+	timed := len(in.Transcripts) > 0
+	var chunks []contentChunk
+	if timed {
+		chunks = chunkTranscripts(in.Transcripts, summaryChunkChars)
+	} else {
+		for _, text := range splitText(in.RawContent, summaryChunkChars) {
+			chunks = append(chunks, contentChunk{Text: text})
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no content to summarize")
+	}
+
+	s.log.Info("Generating insight summary",
+		zap.Int("chunks", len(chunks)),
+		zap.Bool("timed", timed),
+		zap.String("target_lang", in.TargetLang),
+	)
+
+	var final *partialSummary
+	var err error
+	if len(chunks) == 1 {
+		final, err = s.summarizeChunk(ctx, in, chunks[0], timed, true)
+	} else {
+		var partials []partialSummary
+		partials, err = s.mapChunks(ctx, in, chunks, timed)
+		if err == nil {
+			final, err = s.reduce(ctx, in, partials, timed)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyPoints := final.KeyPoints
+	if timed {
+		keyPoints = anchorKeyPoints(keyPoints, in.Transcripts)
+	}
+	if len(keyPoints) > summaryMaxKeyPoints {
+		keyPoints = keyPoints[:summaryMaxKeyPoints]
+	}
+
+	return &SummaryResult{
+		Summary:   strings.TrimSpace(final.Summary),
+		KeyPoints: keyPoints,
+	}, nil
+}
+
+// mapChunks summarizes each chunk independently.
+func (s *SummaryService) mapChunks(ctx context.Context, in SummaryInput, chunks []contentChunk, timed bool) ([]partialSummary, error) {
+	partials := make([]partialSummary, len(chunks))
+	err := forEachConcurrently(len(chunks), summaryMapConcurrency, func(i int) error {
+		partial, err := s.summarizeChunk(ctx, in, chunks[i], timed, false)
+		if err != nil {
+			return fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		partials[i] = *partial
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return partials, nil
+}
+
+// forEachConcurrently calls fn for 0..n-1 with at most limit calls in flight
+// and returns the first error by index.
+func forEachConcurrently(n, limit int, fn func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reduce merges partial summaries into one, in several rounds if needed.
+func (s *SummaryService) reduce(ctx context.Context, in SummaryInput, partials []partialSummary, timed bool) (*partialSummary, error) {
+	for {
+		groups := groupPartials(partials, summaryChunkChars)
+		if len(groups) == 1 {
+			return s.mergePartials(ctx, in, groups[0], timed, true)
+		}
+
+		s.log.Debug("Reducing partial summaries",
+			zap.Int("partials", len(partials)),
+			zap.Int("groups", len(groups)),
+		)
+
+		next := make([]partialSummary, 0, len(groups))
+		for _, group := range groups {
+			merged, err := s.mergePartials(ctx, in, group, timed, false)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, *merged)
+		}
+		partials = next
+	}
+}
+
+// summarizeChunk summarizes one chunk of original content.
+func (s *SummaryService) summarizeChunk(ctx context.Context, in SummaryInput, chunk contentChunk, timed, final bool) (*partialSummary, error) {
+	var sb strings.Builder
+	sb.WriteString(summaryPreamble(in, final))
+	if timed {
+		sb.WriteString("Each transcript line starts with the second it begins at, in square brackets.\n")
+	}
+	if !final {
+		sb.WriteString("This is one part of a longer piece of content; summarize only this part.\n")
+	}
+	sb.WriteString(summaryFormat(in.TargetLang, timed, final))
+	sb.WriteString("\nContent:\n")
+	sb.WriteString(chunk.Text)
+
+	partial, err := s.complete(ctx, sb.String())
+	if err != nil {
+		return nil, err
+	}
+	partial.Start, partial.End = chunk.Start, chunk.End
+	return partial, nil
+}
+
+// mergePartials combines several partial summaries into one.
+func (s *SummaryService) mergePartials(ctx context.Context, in SummaryInput, partials []partialSummary, timed, final bool) (*partialSummary, error) {
+	var sb strings.Builder
+	sb.WriteString(summaryPreamble(in, final))
+	sb.WriteString("Below are summaries of consecutive parts of the content, each with candidate key points. Merge them into one coherent summary and pick the most important key points.\n")
+	sb.WriteString(summaryFormat(in.TargetLang, timed, final))
+	sb.WriteString("\nPart summaries:\n")
+	for i, p := range partials {
+		sb.WriteString(formatPartial(i+1, p, timed))
+	}
+
+	merged, err := s.complete(ctx, sb.String())
+	if err != nil {
+		return nil, err
+	}
+	merged.Start, merged.End = partials[0].Start, partials[len(partials)-1].End
+	return merged, nil
+}
+
+// complete sends a summarization prompt and parses the JSON response.
+func (s *SummaryService) complete(ctx context.Context, prompt string) (*partialSummary, error) {
+	resp, err := s.llmClient.Complete(ctx, llm.Request{
+		Messages:    []llm.Message{llm.User(prompt)},
+		Temperature: llm.Temperature(0.3),
+		MaxTokens:   2000,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summary generation failed: %w", err)
+	}
+
+	var parsed summaryResponse
+	if err := json.Unmarshal([]byte(cleanJSONResponse(resp.Content)), &parsed); err != nil {
+		s.log.Warn("Failed to parse summary response",
+			zap.Error(err),
+			zap.String("raw_response", resp.Content),
+		)
+		return nil, fmt.Errorf("failed to parse summary response: %w", err)
+	}
+
+	result := &partialSummary{Summary: parsed.Summary}
+	for _, kp := range parsed.KeyPoints {
+		text := strings.TrimSpace(kp.Text)
+		if text == "" {
+			continue
+		}
+		result.KeyPoints = append(result.KeyPoints, models.InsightKeyPoint{
+			Text:    text,
+			Seconds: int(kp.Seconds),
+		})
+	}
+	return result, nil
+}
+
+// summaryPreamble describes the content being summarized.
+func summaryPreamble(in SummaryInput, final bool) string {
+	var sb strings.Builder
+	if final {
+		sb.WriteString("You are summarizing a piece of content for a reader who has not seen it.\n")
+	} else {
+		sb.WriteString("You are helping summarize a long piece of content for a reader who has not seen it.\n")
+	}
+	if in.Title != "" {
+		fmt.Fprintf(&sb, "Title: %s\n", in.Title)
+	}
+	if in.Author != "" {
+		fmt.Fprintf(&sb, "Author: %s\n", in.Author)
+	}
+	return sb.String()
+}
+
+// summaryFormat describes the expected output.
+func summaryFormat(targetLang string, timed, final bool) string {
+	lang := outputLanguage(targetLang)
+
+	length := "a concise summary of 2-4 sentences"
+	count := "3-5"
+	if final {
+		length = "a summary of 1-3 short paragraphs"
+		count = fmt.Sprintf("%d-%d", summaryMaxKeyPoints-3, summaryMaxKeyPoints)
+	}
+
+	seconds := "0"
+	secondsRule := ""
+	if timed {
+		seconds = "<integer seconds>"
+		secondsRule = "- \"seconds\" is the start second of the transcript line the key point comes from\n"
+	}
+
+	return fmt.Sprintf(`Write %s and %s key points, in %s.
+Return ONLY JSON in this format, without any other text:
+{"summary": "...", "key_points": [{"text": "...", "seconds": %s}]}
+Rules:
+- Key points are single sentences stating a concrete idea, claim or takeaway, in the order they appear
+%s`, length, count, lang, seconds, secondsRule)
+}
+
+// formatPartial renders a partial summary as reduce input.
+func formatPartial(index int, p partialSummary, timed bool) string {
+	var sb strings.Builder
+	if timed {
+		fmt.Fprintf(&sb, "\n## Part %d [%s - %s]\n", index, formatDuration(p.Start), formatDuration(p.End))
+	} else {
+		fmt.Fprintf(&sb, "\n## Part %d\n", index)
+	}
+	sb.WriteString(strings.TrimSpace(p.Summary))
+	sb.WriteString("\nKey points:\n")
+	for _, kp := range p.KeyPoints {
+		if timed {
+			fmt.Fprintf(&sb, "- [%d] %s\n", kp.Seconds, kp.Text)
+		} else {
+			fmt.Fprintf(&sb, "- %s\n", kp.Text)
+		}
+	}
+	return sb.String()
+}
+
+// groupPartials splits partials into consecutive groups that each fit in maxChars.
+func groupPartials(partials []partialSummary, maxChars int) [][]partialSummary {
+	var groups [][]partialSummary
+	var current []partialSummary
+	size := 0
+	for i, p := range partials {
+		n := utf8.RuneCountInString(formatPartial(i+1, p, true))
+		if len(current) > 0 && size+n > maxChars {
+			groups = append(groups, current)
+			current, size = nil, 0
+		}
+		current = append(current, p)
+		size += n
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	// Always make progress: merging a single partial with itself would loop forever
+	if len(groups) == len(partials) && len(groups) > 1 {
+		groups = nil
+		for i := 0; i < len(partials); i += 2 {
+			end := i + 2
+			if end > len(partials) {
+				end = len(partials)
+			}
+			groups = append(groups, partials[i:end])
+		}
+	}
+	return groups
+}
+
+// chunkTranscripts renders transcript lines as "[seconds] text" and groups
+// them into chunks of at most maxChars.
+func chunkTranscripts(items []models.TranscriptItem, maxChars int) []contentChunk {
+	var chunks []contentChunk
+	var sb strings.Builder
+	current := contentChunk{}
+	size := 0
+
+	for _, item := range items {
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			continue
+		}
+		line := fmt.Sprintf("[%d] %s\n", item.Seconds, text)
+		n := utf8.RuneCountInString(line)
+		if size > 0 && size+n > maxChars {
+			current.Text = sb.String()
+			chunks = append(chunks, current)
+			sb.Reset()
+			size = 0
+		}
+		if size == 0 {
+			current = contentChunk{Start: item.Seconds}
+		}
+		sb.WriteString(line)
+		current.End = item.Seconds
+		size += n
+	}
+	if size > 0 {
+		current.Text = sb.String()
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitText splits text into pieces of at most maxChars runes, preferring to
+// break at a sentence end or whitespace.
+func splitText(text string, maxChars int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var pieces []string
+	for len(runes) > 0 {
+		if len(runes) <= maxChars {
+			pieces = append(pieces, string(runes))
+			break
+		}
+
+		cut := maxChars
+		for i := maxChars - 1; i > maxChars*4/5; i-- {
+			if strings.ContainsRune("。！？.!?\n", runes[i]) {
+				cut = i + 1
+				break
+			}
+			if cut == maxChars && runes[i] == ' ' {
+				// Remember the last space but keep looking for a sentence end
+				cut = i + 1
+			}
+		}
+
+		pieces = append(pieces, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	return pieces
+}
+
+// anchorKeyPoints snaps each key point to the start of the transcript line
+// containing its seconds, fills in the timestamp and orders points by time.
+func anchorKeyPoints(keyPoints []models.InsightKeyPoint, items []models.TranscriptItem) []models.InsightKeyPoint {
+	if len(items) == 0 {
+		return keyPoints
+	}
+
+	anchored := make([]models.InsightKeyPoint, 0, len(keyPoints))
+	for _, kp := range keyPoints {
+		// Last line starting at or before kp.Seconds (items are in time order)
+		idx := sort.Search(len(items), func(i int) bool { return items[i].Seconds > kp.Seconds }) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		kp.Seconds = items[idx].Seconds
+		kp.Timestamp = formatDuration(kp.Seconds)
+		anchored = append(anchored, kp)
+	}
+
+	sort.SliceStable(anchored, func(i, j int) bool {
+		return anchored[i].Seconds < anchored[j].Seconds
+	})
+	return anchored
+}
@@ -2,26 +2,69 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/subtitles"
 )
 
-// TranscriptService handles YouTube transcript extraction.
+// TranscriptService fetches YouTube transcripts through an ordered chain of
+// providers and tracks how each provider performs.
 type TranscriptService struct {
-	log *zap.Logger
+	providers []TranscriptProvider
+	languages []string // default caption language preference
+	health    *providerHealth
+	store     *TranscriptStore
+	segmenter *SentenceSegmenter
+	log       *zap.Logger
 }
 
-// NewTranscriptService creates a new TranscriptService.
-func NewTranscriptService(log *zap.Logger) *TranscriptService {
+// NewTranscriptService creates a new TranscriptService that tries providers
+// in order. languages is the caption language preference used when a caller
+// does not pass one.
+func NewTranscriptService(providers []TranscriptProvider, languages []string, log *zap.Logger) *TranscriptService {
 	return &TranscriptService{
-		log: log,
+		providers: providers,
+		languages: languages,
+		health:    newProviderHealth(providers),
+		log:       log,
+	}
+}
+
+// SetStore sets the persistent cache consulted before any provider.
+func (s *TranscriptService) SetStore(store *TranscriptStore) {
+	s.store = store
+}
+
+// SetSegmenter sets the segmenter that regroups captions into sentences.
+// Only the tracks that are read are regrouped, see Fetch and SegmentedTrack.
+func (s *TranscriptService) SetSegmenter(segmenter *SentenceSegmenter) {
+	s.segmenter = segmenter
+}
+
+// Resegment regroups caption segments into sentences with the configured
+// segmenter, for transcripts that come from outside the provider chain. It
+// returns segments unchanged when no segmenter is set.
+func (s *TranscriptService) Resegment(ctx context.Context, segments []models.CaptionSegment) []models.CaptionSegment {
+	if s.segmenter == nil {
+		return segments
 	}
+	return s.segmenter.Resegment(ctx, segments)
+}
+
+// TranscriptFetchOptions controls how a transcript is fetched.
+type TranscriptFetchOptions struct {
+	// Languages orders the returned tracks, most preferred first. Empty uses
+	// the service's default preference.
+	Languages []string
+	// Refresh skips the cache and fetches the transcript from the providers
+	// again, replacing the cached copy.
+	Refresh bool
 }
 
 // TranscriptSegment represents a single transcript segment.
@@ -37,7 +80,10 @@ type TranscriptResponse struct {
 	Title       string              `json:"title"`
 	Author      string              `json:"author"`
 	Duration    string              `json:"duration"`
+	Language    string              `json:"language"`
+	Kind        models.CaptionKind  `json:"kind"`
 	Transcripts []TranscriptSegment `json:"transcripts"`
+	CacheHit    bool                `json:"cacheHit"`
 }
 
 // ExtractVideoID extracts YouTube video ID from URL or returns the ID if already provided.
@@ -64,539 +110,253 @@ func ExtractVideoID(input string) (string, error) {
 	return "", fmt.Errorf("invalid YouTube URL or video ID: %s", input)
 }
 
-// GetTranscript fetches transcript using yt-dlp with multiple fallback methods.
-func (s *TranscriptService) GetTranscript(ctx context.Context, input string) (*TranscriptResponse, error) {
-	// Extract video ID
-	videoID, err := ExtractVideoID(input)
-	if err != nil {
-		return nil, err
-	}
-
-	s.log.Info("Fetching transcript",
-		zap.String("video_id", videoID),
-	)
-
-	// Step 1: Get video metadata
-	metadata, err := s.getVideoMetadata(ctx, videoID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch video metadata: %w", err)
-	}
-
-	// Step 2: Try multiple methods to get subtitles
-	// Method 1: Try to get subtitles using yt-dlp --write-auto-sub with json3 format
-	segments, err := s.getSubtitlesMethod1(ctx, videoID)
-	if err == nil && len(segments) > 0 {
-		s.log.Info("Successfully fetched subtitles using method 1 (json3)",
-			zap.String("video_id", videoID),
-			zap.Int("segments", len(segments)),
-		)
-		return &TranscriptResponse{
-			VideoID:     videoID,
-			Title:       metadata.Title,
-			Author:      metadata.Uploader,
-			Duration:    formatDuration(metadata.Duration),
-			Transcripts: segments,
-		}, nil
-	}
-	s.log.Warn("Method 1 failed, trying method 2",
-		zap.String("video_id", videoID),
-		zap.Error(err),
-	)
-
-	// Method 2: Download subtitle file directly (VTT format)
-	segments, err = s.getSubtitlesMethod2(ctx, videoID)
-	if err == nil && len(segments) > 0 {
-		s.log.Info("Successfully fetched subtitles using method 2 (VTT download)",
-			zap.String("video_id", videoID),
-			zap.Int("segments", len(segments)),
-		)
-		return &TranscriptResponse{
-			VideoID:     videoID,
-			Title:       metadata.Title,
-			Author:      metadata.Uploader,
-			Duration:    formatDuration(metadata.Duration),
-			Transcripts: segments,
-		}, nil
-	}
-	s.log.Warn("Method 2 failed, trying method 3",
-		zap.String("video_id", videoID),
-		zap.Error(err),
-	)
-
-	// Method 3: Use yt-dlp to list available subtitles and download the best one
-	segments, err = s.getSubtitlesMethod3(ctx, videoID)
-	if err == nil && len(segments) > 0 {
-		s.log.Info("Successfully fetched subtitles using method 3 (list and download)",
-			zap.String("video_id", videoID),
-			zap.Int("segments", len(segments)),
-		)
-		return &TranscriptResponse{
-			VideoID:     videoID,
-			Title:       metadata.Title,
-			Author:      metadata.Uploader,
-			Duration:    formatDuration(metadata.Duration),
-			Transcripts: segments,
-		}, nil
-	}
-	s.log.Warn("Method 3 failed, trying method 4",
-		zap.String("video_id", videoID),
-		zap.Error(err),
-	)
-
-	// Method 4: Try to get subtitles using --write-subs (manual subtitles) instead of --write-auto-sub
-	segments, err = s.getSubtitlesMethod4(ctx, videoID)
-	if err == nil && len(segments) > 0 {
-		s.log.Info("Successfully fetched subtitles using method 4 (manual subs)",
-			zap.String("video_id", videoID),
-			zap.Int("segments", len(segments)),
-		)
-		return &TranscriptResponse{
-			VideoID:     videoID,
-			Title:       metadata.Title,
-			Author:      metadata.Uploader,
-			Duration:    formatDuration(metadata.Duration),
-			Transcripts: segments,
-		}, nil
-	}
-
-	// All methods failed
-	s.log.Error("All subtitle extraction methods failed",
-		zap.String("video_id", videoID),
-	)
-	return nil, fmt.Errorf("无法获取该视频的字幕。可能原因：1) 视频没有字幕 2) 字幕功能被禁用 3) 视频不可访问")
-}
-
-// getVideoMetadata fetches video metadata using yt-dlp.
-func (s *TranscriptService) getVideoMetadata(ctx context.Context, videoID string) (struct {
-	Title    string `json:"title"`
-	Uploader string `json:"uploader"`
-	Duration int    `json:"duration"`
-}, error) {
-	var metadata struct {
-		Title    string `json:"title"`
-		Uploader string `json:"uploader"`
-		Duration int    `json:"duration"`
-	}
-
-	cmd := exec.CommandContext(ctx,
-		"yt-dlp",
-		"--dump-json",
-		"--no-warnings",
-		"--skip-download",
-		"--no-playlist",
-		fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-	)
-
-	output, err := cmd.Output()
-	if err != nil {
-		return metadata, fmt.Errorf("yt-dlp metadata extraction failed: %w", err)
-	}
-
-	if err := json.Unmarshal(output, &metadata); err != nil {
-		return metadata, fmt.Errorf("failed to parse metadata: %w", err)
+// PreferLanguages returns the default caption language preference with the
+// given languages moved to the front. Empty entries are ignored.
+func (s *TranscriptService) PreferLanguages(first ...string) []string {
+	languages := make([]string, 0, len(first)+len(s.languages))
+	seen := make(map[string]bool)
+	for _, lang := range append(append([]string{}, first...), s.languages...) {
+		key := strings.ToLower(strings.TrimSpace(lang))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		languages = append(languages, strings.TrimSpace(lang))
 	}
-
-	return metadata, nil
+	return languages
 }
 
-// getSubtitlesMethod1: Try json3 format with --write-auto-sub
-func (s *TranscriptService) getSubtitlesMethod1(ctx context.Context, videoID string) ([]TranscriptSegment, error) {
-	// Try json3 format first
-	cmd := exec.CommandContext(ctx,
-		"yt-dlp",
-		"--write-auto-sub",
-		"--sub-lang", "en,zh-Hans,zh-Hant,zh,en-US,en-GB",
-		"--sub-format", "json3",
-		"--skip-download",
-		"--no-warnings",
-		"--no-playlist",
-		"--output", fmt.Sprintf("/tmp/%%(id)s.%%(lang)s.%%(ext)s"),
-		fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-	)
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to download json3 subtitle: %w", err)
-	}
-
-	// Try to find and read json3 files
-	languages := []string{"en", "zh-Hans", "zh-Hant", "zh", "en-US", "en-GB"}
-	for _, lang := range languages {
-		json3Path := fmt.Sprintf("/tmp/%s.%s.json3", videoID, lang)
-		content, err := os.ReadFile(json3Path)
-		if err == nil {
-			// Parse JSON3 format
-			segments := s.parseSubtitleOutput(string(content))
-			if len(segments) > 0 {
-				return segments, nil
+// Fetch returns the caption tracks of a video from the cache, or from the
+// first provider that succeeds, trying them in the configured order. Tracks
+// are ordered by language preference (see orderTracks). Only the primary
+// track is regrouped into sentences; the others keep the captions as
+// fetched until SegmentedTrack is asked for them. It is the single entry
+// point for YouTube transcripts.
+func (s *TranscriptService) Fetch(ctx context.Context, videoID string, opts TranscriptFetchOptions) (*models.VideoTranscript, error) {
+	languages := opts.Languages
+	if len(languages) == 0 {
+		languages = s.languages
+	}
+
+	if s.store != nil && !opts.Refresh {
+		if transcript := s.store.Get(ctx, videoID); transcript != nil {
+			orderTracks(transcript.Tracks, languages)
+			if s.segmentTrack(ctx, transcript.PrimaryTrack()) {
+				s.put(ctx, transcript)
 			}
+			transcript.CacheHit = true
+			s.log.Debug("Transcript cache hit", zap.String("video_id", videoID))
+			return transcript, nil
 		}
 	}
 
-	// If json3 not found, yt-dlp might have downloaded VTT instead
-	// Try to parse VTT files as fallback within method1
-	// yt-dlp may save files as: videoID.NA.lang.vtt or videoID.lang.vtt
-	var lastErr error
-	for _, lang := range languages {
-		// Try different filename patterns that yt-dlp might use
-		patterns := []string{
-			fmt.Sprintf("/tmp/%s.NA.%s.vtt", videoID, lang), // Most common: videoID.NA.lang.vtt
-			fmt.Sprintf("/tmp/%s.%s.vtt", videoID, lang),
-			fmt.Sprintf("/tmp/%s.vtt", videoID),
-		}
-		
-		for _, vttPath := range patterns {
-			segments, err := s.parseVTTFile(vttPath)
-			if err == nil && len(segments) > 0 {
-				s.log.Info("Found VTT subtitle in method1 fallback",
-					zap.String("video_id", videoID),
-					zap.String("lang", lang),
-					zap.String("path", vttPath),
-					zap.Int("segments", len(segments)),
-				)
-				return segments, nil
-			}
-			if err != nil {
-				lastErr = err
-				s.log.Debug("Failed to parse VTT file",
-					zap.String("path", vttPath),
-					zap.Error(err),
-				)
-			}
+	for _, provider := range s.providers {
+		started := time.Now()
+		transcript, err := provider.Fetch(ctx, videoID)
+		if ctx.Err() != nil {
+			// Cancelled by the caller, not a provider failure
+			return nil, ctx.Err()
 		}
-	}
-
-	// If we got here, no valid subtitle files were found
-	s.log.Warn("Method1: No json3 or vtt subtitle files found",
-		zap.String("video_id", videoID),
-		zap.Error(lastErr),
-	)
-	return nil, fmt.Errorf("no json3 or vtt subtitle files found: %v", lastErr)
-}
-
-// getSubtitlesMethod2: Download VTT file directly (most reliable method)
-func (s *TranscriptService) getSubtitlesMethod2(ctx context.Context, videoID string) ([]TranscriptSegment, error) {
-	// Languages to try in order
-	languages := []string{"en", "zh-Hans", "zh-Hant", "zh", "en-US", "en-GB", "auto"}
-	
-	for _, lang := range languages {
-		cmd := exec.CommandContext(ctx,
-			"yt-dlp",
-			"--write-auto-sub",
-			"--sub-lang", lang,
-			"--sub-format", "vtt",
-			"--skip-download",
-			"--no-warnings",
-			"--no-playlist",
-			"--output", fmt.Sprintf("/tmp/%%(id)s.%%(lang)s.%%(ext)s"),
-			fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-		)
+		s.health.record(provider.Name(), time.Since(started), err)
 
-		// Capture stderr to check for errors
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			s.log.Debug("Failed to download subtitle for language",
-				zap.String("lang", lang),
+		if err != nil {
+			s.log.Warn("Transcript provider failed",
+				zap.String("provider", provider.Name()),
+				zap.String("video_id", videoID),
+				zap.Duration("latency", time.Since(started)),
 				zap.Error(err),
 			)
 			continue
 		}
 
-		// Try different filename patterns that yt-dlp might use
-		patterns := []string{
-			fmt.Sprintf("/tmp/%s.%s.vtt", videoID, lang),
-			fmt.Sprintf("/tmp/%s.NA.%s.vtt", videoID, lang), // yt-dlp sometimes adds .NA.
-			fmt.Sprintf("/tmp/%s.vtt", videoID),
-			fmt.Sprintf("/tmp/%s.en.vtt", videoID),
-		}
-
-		for _, vttPath := range patterns {
-			segments, err := s.parseVTTFile(vttPath)
-			if err == nil && len(segments) > 0 {
-				s.log.Info("Successfully parsed VTT subtitle",
-					zap.String("video_id", videoID),
-					zap.String("lang", lang),
-					zap.String("path", vttPath),
-					zap.Int("segments", len(segments)),
-				)
-				return segments, nil
-			}
-		}
+		transcript.Provider = provider.Name()
+		transcript.FetchedAt = time.Now()
+		orderTracks(transcript.Tracks, languages)
+		s.segmentTrack(ctx, transcript.PrimaryTrack())
+		s.put(ctx, transcript)
+		s.log.Info("Fetched transcript",
+			zap.String("provider", provider.Name()),
+			zap.String("video_id", videoID),
+			zap.Int("tracks", len(transcript.Tracks)),
+			zap.Duration("latency", time.Since(started)),
+		)
+		return transcript, nil
 	}
 
-	return nil, fmt.Errorf("failed to download and parse VTT subtitles")
+	return nil, ErrNoCaptions
 }
 
-// getSubtitlesMethod3: List available subtitles and download the best one
-func (s *TranscriptService) getSubtitlesMethod3(ctx context.Context, videoID string) ([]TranscriptSegment, error) {
-	// First, list available subtitles
-	listCmd := exec.CommandContext(ctx,
-		"yt-dlp",
-		"--list-subs",
-		"--skip-download",
-		"--no-warnings",
-		"--no-playlist",
-		fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-	)
-
-	listOutput, err := listCmd.Output()
+// SegmentedTrack returns a video's track with the given language and kind,
+// regrouped into sentences, or ErrTranscriptTrackNotFound. An empty kind
+// matches either kind.
+func (s *TranscriptService) SegmentedTrack(ctx context.Context, videoID, language string, kind models.CaptionKind) (*models.CaptionTrack, error) {
+	transcript, err := s.Fetch(ctx, videoID, TranscriptFetchOptions{Languages: []string{language}})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list subtitles: %w", err)
-	}
-
-	// Parse available languages from output
-	availableLangs := s.parseAvailableLanguages(string(listOutput))
-	if len(availableLangs) == 0 {
-		return nil, fmt.Errorf("no subtitles available")
+		return nil, err
 	}
-
-	// Try each available language
-	for _, lang := range availableLangs {
-		outputPath := fmt.Sprintf("/tmp/%s.%s.vtt", videoID, lang)
-		cmd := exec.CommandContext(ctx,
-			"yt-dlp",
-			"--write-auto-sub",
-			"--sub-lang", lang,
-			"--sub-format", "vtt",
-			"--skip-download",
-			"--no-warnings",
-			"--no-playlist",
-			"--output", fmt.Sprintf("/tmp/%%(id)s.%%(lang)s.%%(ext)s"),
-			fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-		)
-
-		if err := cmd.Run(); err != nil {
-			continue
-		}
-
-		segments, err := s.parseVTTFile(outputPath)
-		if err == nil && len(segments) > 0 {
-			return segments, nil
-		}
+	track := transcript.Track(language, kind)
+	if track == nil {
+		return nil, ErrTranscriptTrackNotFound
 	}
-
-	return nil, fmt.Errorf("failed to download subtitles from available languages")
-}
-
-// getSubtitlesMethod4: Try manual subtitles (--write-subs instead of --write-auto-sub)
-func (s *TranscriptService) getSubtitlesMethod4(ctx context.Context, videoID string) ([]TranscriptSegment, error) {
-	languages := []string{"en", "zh-Hans", "zh-Hant", "zh", "en-US", "en-GB"}
-	
-	for _, lang := range languages {
-		outputPath := fmt.Sprintf("/tmp/%s.%s.vtt", videoID, lang)
-		cmd := exec.CommandContext(ctx,
-			"yt-dlp",
-			"--write-subs", // Manual subtitles instead of auto-generated
-			"--sub-lang", lang,
-			"--sub-format", "vtt",
-			"--skip-download",
-			"--no-warnings",
-			"--no-playlist",
-			"--output", fmt.Sprintf("/tmp/%%(id)s.%%(lang)s.%%(ext)s"),
-			fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-		)
-
-		if err := cmd.Run(); err != nil {
-			continue
-		}
-
-		segments, err := s.parseVTTFile(outputPath)
-		if err == nil && len(segments) > 0 {
-			return segments, nil
-		}
+	if s.segmentTrack(ctx, track) {
+		s.put(ctx, transcript)
 	}
-
-	return nil, fmt.Errorf("failed to download manual subtitles")
+	return track, nil
 }
 
-// parseAvailableLanguages extracts available language codes from yt-dlp --list-subs output
-func (s *TranscriptService) parseAvailableLanguages(output string) []string {
-	var langs []string
-	lines := strings.Split(output, "\n")
-	
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Look for lines like "Language Name (lang_code)"
-		re := regexp.MustCompile(`\(([a-z-]+)\)`)
-		matches := re.FindStringSubmatch(line)
-		if len(matches) >= 2 {
-			lang := matches[1]
-			// Avoid duplicates
-			found := false
-			for _, existing := range langs {
-				if existing == lang {
-					found = true
-					break
-				}
-			}
-			if !found {
-				langs = append(langs, lang)
-			}
-		}
-	}
-	
-	return langs
+// segmentTrack regroups a track's captions into sentences, keeping the
+// captions as fetched in RawSegments. It reports whether the track changed,
+// which it does once per track: the segmenter's punctuation requests are
+// too costly to repeat for tracks nobody reads.
+func (s *TranscriptService) segmentTrack(ctx context.Context, track *models.CaptionTrack) bool {
+	if s.segmenter == nil || track == nil || track.RawSegments != nil {
+		return false
+	}
+	track.RawSegments = track.Segments
+	track.Segments = s.segmenter.Resegment(ctx, track.Segments)
+	return true
 }
 
-// parseSubtitleOutput parses yt-dlp subtitle output (JSON3 format).
-func (s *TranscriptService) parseSubtitleOutput(output string) []TranscriptSegment {
-	// Clean the output - remove any non-JSON content
-	output = strings.TrimSpace(output)
-	
-	// Try to find JSON content in the output
-	jsonStart := strings.Index(output, "{")
-	if jsonStart > 0 {
-		output = output[jsonStart:]
+// put caches a transcript, logging failures.
+func (s *TranscriptService) put(ctx context.Context, transcript *models.VideoTranscript) {
+	if s.store == nil {
+		return
 	}
-	
-	// Try to find the last valid JSON object
-	jsonEnd := strings.LastIndex(output, "}")
-	if jsonEnd > 0 && jsonEnd < len(output)-1 {
-		output = output[:jsonEnd+1]
+	if err := s.store.Put(ctx, transcript); err != nil {
+		s.log.Warn("Failed to cache transcript", zap.String("video_id", transcript.VideoID), zap.Error(err))
 	}
+}
 
-	// Parse JSON3 format from yt-dlp
-	var data struct {
-		Events []struct {
-			TStartMs    int `json:"tStartMs"`
-			DDurationMs int `json:"dDurationMs"`
-			Segs        []struct {
-				Utf8 string `json:"utf8"`
-			} `json:"segs"`
-		} `json:"events"`
-	}
+// ProviderStats returns the success and latency counters of each provider in
+// the configured order.
+func (s *TranscriptService) ProviderStats() []TranscriptProviderStats {
+	return s.health.snapshot(s.providers)
+}
 
-	if err := json.Unmarshal([]byte(output), &data); err != nil {
-		s.log.Warn("Failed to parse JSON3 subtitle format",
-			zap.Error(err),
-			zap.String("output_preview", func() string {
-				if len(output) > 200 {
-					return output[:200]
-				}
-				return output
-			}()),
-		)
-		return []TranscriptSegment{}
+// GetTranscript fetches the primary caption track of a video given its URL
+// or ID.
+func (s *TranscriptService) GetTranscript(ctx context.Context, input string, opts TranscriptFetchOptions) (*TranscriptResponse, error) {
+	videoID, err := ExtractVideoID(input)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(data.Events) == 0 {
-		s.log.Warn("No events found in subtitle JSON")
-		return []TranscriptSegment{}
+	transcript, err := s.Fetch(ctx, videoID, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	segments := make([]TranscriptSegment, 0, len(data.Events))
-	for _, event := range data.Events {
-		if len(event.Segs) == 0 {
-			continue
-		}
-
-		// Combine all text segments
-		var text strings.Builder
-		for _, seg := range event.Segs {
-			if seg.Utf8 != "" {
-				text.WriteString(seg.Utf8)
-			}
+	track := transcript.PrimaryTrack()
+	segments := make([]TranscriptSegment, len(track.Segments))
+	for i, seg := range track.Segments {
+		segments[i] = TranscriptSegment{
+			Start: formatTimestamp(seg.StartMs),
+			End:   formatTimestamp(seg.EndMs),
+			Text:  seg.Text,
 		}
-
-		textStr := strings.TrimSpace(text.String())
-		if textStr == "" {
-			continue
-		}
-
-		// Convert milliseconds to HH:MM:SS format
-		startMs := event.TStartMs
-		endMs := startMs + event.DDurationMs
-
-		segments = append(segments, TranscriptSegment{
-			Start: formatTimestamp(startMs),
-			End:   formatTimestamp(endMs),
-			Text:  textStr,
-		})
 	}
 
-	return segments
+	return &TranscriptResponse{
+		VideoID:     videoID,
+		Title:       transcript.Title,
+		Author:      transcript.Author,
+		Duration:    formatDuration(transcript.Duration),
+		Language:    track.Language,
+		Kind:        track.Kind,
+		Transcripts: segments,
+		CacheHit:    transcript.CacheHit,
+	}, nil
 }
 
-// parseVTTFile parses WebVTT subtitle file.
-func (s *TranscriptService) parseVTTFile(filePath string) ([]TranscriptSegment, error) {
-	// Use os.ReadFile instead of cat command for better cross-platform support
-	content, err := os.ReadFile(filePath)
+// GetTranscriptText returns the primary caption track of a video as
+// "[MM:SS] text" lines.
+func (s *TranscriptService) GetTranscriptText(ctx context.Context, videoID string) (string, error) {
+	transcript, err := s.Fetch(ctx, videoID, TranscriptFetchOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read VTT file: %w", err)
+		return "", err
 	}
 
-	contentStr := string(content)
-	lines := strings.Split(contentStr, "\n")
-
-	var segments []TranscriptSegment
-	var currentText strings.Builder
-	var start, end string
-
-	// VTT format:
-	// WEBVTT
-	//
-	// 00:00:00.000 --> 00:00:09.000
-	// Text here
-	//
-	// 00:00:09.000 --> 00:00:15.000
-	// More text
-
-	timestampRegex := regexp.MustCompile(`(\d{2}:\d{2}:\d{2}\.\d{3})\s*-->\s*(\d{2}:\d{2}:\d{2}\.\d{3})`)
-
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
+	var sb strings.Builder
+	for _, seg := range transcript.PrimaryTrack().Segments {
+		sb.WriteString(fmt.Sprintf("[%s] %s\n", formatDuration(seg.StartMs/1000), seg.Text))
+	}
+	return sb.String(), nil
+}
 
-		// Skip empty lines and WEBVTT header
-		if line == "" || strings.HasPrefix(line, "WEBVTT") || strings.HasPrefix(line, "Kind:") || strings.HasPrefix(line, "Language:") {
-			continue
-		}
+// GetStructuredTranscript returns every caption track of a video grouped by
+// language, with the video's metadata.
+func (s *TranscriptService) GetStructuredTranscript(ctx context.Context, videoID string) (*models.YouTubeTranscriptResponse, error) {
+	transcript, err := s.Fetch(ctx, videoID, TranscriptFetchOptions{})
+	if err != nil {
+		return nil, err
+	}
 
-		// Check if this line contains timestamp
-		matches := timestampRegex.FindStringSubmatch(line)
-		if len(matches) == 3 {
-			// Save previous segment if exists
-			if start != "" && currentText.Len() > 0 {
-				segments = append(segments, TranscriptSegment{
-					Start: start,
-					End:   end,
-					Text:  strings.TrimSpace(currentText.String()),
-				})
-				currentText.Reset()
+	languageCodes := make([]models.YouTubeLanguageCode, 0, len(transcript.Tracks))
+	transcripts := make(map[string]models.TranscriptData)
+	for _, track := range transcript.Tracks {
+		segments := make([]models.TranscriptSegment, len(track.Segments))
+		for i, seg := range track.Segments {
+			segments[i] = models.TranscriptSegment{
+				Start: formatTimestamp(seg.StartMs),
+				End:   formatTimestamp(seg.EndMs),
+				Text:  seg.Text,
 			}
+		}
 
-			// Parse new timestamp
-			start = matches[1]
-			end = matches[2]
-		} else if start != "" && !regexp.MustCompile(`^\d+$`).MatchString(line) {
-			// This is text content (not a sequence number)
-			// Remove HTML tags and formatting
-			text := removeVTTFormatting(line)
-			if text != "" {
-				if currentText.Len() > 0 {
-					currentText.WriteString(" ")
-				}
-				currentText.WriteString(text)
+		data, ok := transcripts[track.Language]
+		if !ok {
+			data = models.TranscriptData{
+				Custom:  []models.TranscriptSegment{},
+				Default: []models.TranscriptSegment{},
+				Auto:    []models.TranscriptSegment{},
 			}
+			languageCodes = append(languageCodes, models.YouTubeLanguageCode{
+				Code: track.Language,
+				Name: track.Name,
+			})
 		}
-	}
-
-	// Add last segment
-	if start != "" && currentText.Len() > 0 {
-		segments = append(segments, TranscriptSegment{
-			Start: start,
-			End:   end,
-			Text:  strings.TrimSpace(currentText.String()),
-		})
-	}
-
-	return segments, nil
+		if track.Kind == models.CaptionKindAuto {
+			data.Auto = segments
+		} else {
+			data.Default = segments
+			// Merged into ~20 second chunks for reading
+			data.Custom = mergeTranscriptSegments(segments, 20)
+		}
+		transcripts[track.Language] = data
+	}
+
+	return &models.YouTubeTranscriptResponse{
+		VideoID:      videoID,
+		LanguageCode: languageCodes,
+		Transcripts:  transcripts,
+		VideoInfo: models.YouTubeVideoInfo{
+			Name: transcript.Title,
+			ThumbnailURL: models.YouTubeThumbnailURLs{
+				Hqdefault:     fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", videoID),
+				Maxresdefault: fmt.Sprintf("https://i.ytimg.com/vi/%s/maxresdefault.jpg", videoID),
+			},
+			EmbedURL:    fmt.Sprintf("https://www.youtube.com/embed/%s", videoID),
+			Duration:    fmt.Sprintf("%d", transcript.Duration),
+			Description: transcript.Description,
+			Author:      transcript.Author,
+			ChannelID:   transcript.ChannelID,
+			Chapters:    ParseDescriptionChapters(transcript.Description),
+		},
+	}, nil
 }
 
-// removeVTTFormatting removes VTT formatting tags from text.
-func removeVTTFormatting(text string) string {
-	// Remove <c> tags, <i> tags, etc.
-	re := regexp.MustCompile(`<[^>]+>`)
-	text = re.ReplaceAllString(text, "")
-	return strings.TrimSpace(text)
+// captionSegments converts parsed subtitle cues to caption segments, one
+// line of text each.
+func captionSegments(cues []subtitles.Cue) []models.CaptionSegment {
+	segments := make([]models.CaptionSegment, len(cues))
+	for i, c := range cues {
+		segments[i] = models.CaptionSegment{
+			StartMs: int(c.Start.Milliseconds()),
+			EndMs:   int(c.End.Milliseconds()),
+			Text:    c.PlainText(),
+		}
+	}
+	return segments
 }
 
 // formatDuration converts seconds to HH:MM:SS format.
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/cache"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// transcriptHotTTL caps how long a transcript stays in Redis; Postgres keeps
+// it for the full cache TTL.
+const transcriptHotTTL = 6 * time.Hour
+
+// TranscriptStore keeps fetched transcripts in Postgres, with Redis as a hot
+// layer in front, so each video is downloaded from YouTube once per TTL.
+type TranscriptStore struct {
+	repo  *repository.TranscriptRepository
+	cache *cache.RedisCache
+	ttl   time.Duration
+	log   *zap.Logger
+}
+
+// NewTranscriptStore creates a new TranscriptStore. Transcripts older than
+// ttl are fetched again; a ttl of 0 keeps them until refreshed on demand.
+// cache may be nil to use Postgres only.
+func NewTranscriptStore(repo *repository.TranscriptRepository, cache *cache.RedisCache, ttl time.Duration, log *zap.Logger) *TranscriptStore {
+	return &TranscriptStore{
+		repo:  repo,
+		cache: cache,
+		ttl:   ttl,
+		log:   log,
+	}
+}
+
+// Get returns the cached transcript of a video, or nil if it is missing or
+// older than the TTL. Storage errors are logged and treated as a miss.
+func (s *TranscriptStore) Get(ctx context.Context, videoID string) *models.VideoTranscript {
+	if s.cache != nil {
+		cached, err := s.cache.Get(ctx, transcriptCacheKey(videoID))
+		if err == nil && cached != "" {
+			var transcript models.VideoTranscript
+			if err := json.Unmarshal([]byte(cached), &transcript); err == nil && s.fresh(&transcript) {
+				return &transcript
+			}
+		}
+	}
+
+	entry, err := s.repo.GetByVideoID(ctx, videoID)
+	if err != nil {
+		s.log.Warn("Failed to read cached transcript", zap.String("video_id", videoID), zap.Error(err))
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+
+	transcript, err := transcriptFromCache(entry)
+	if err != nil {
+		s.log.Warn("Failed to decode cached transcript", zap.String("video_id", videoID), zap.Error(err))
+		return nil
+	}
+	if !s.fresh(transcript) {
+		return nil
+	}
+
+	s.warm(ctx, transcript)
+	return transcript
+}
+
+// Put stores a freshly fetched transcript in both layers.
+func (s *TranscriptStore) Put(ctx context.Context, transcript *models.VideoTranscript) error {
+	entry, err := transcriptToCache(transcript)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Save(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+
+	s.warm(ctx, transcript)
+	return nil
+}
+
+// fresh reports whether a transcript is still within the TTL.
+func (s *TranscriptStore) fresh(transcript *models.VideoTranscript) bool {
+	return s.ttl <= 0 || time.Since(transcript.FetchedAt) < s.ttl
+}
+
+// warm copies a transcript into Redis until it expires or transcriptHotTTL
+// passes, whichever comes first.
+func (s *TranscriptStore) warm(ctx context.Context, transcript *models.VideoTranscript) {
+	if s.cache == nil {
+		return
+	}
+
+	expiry := transcriptHotTTL
+	if s.ttl > 0 {
+		if remaining := s.ttl - time.Since(transcript.FetchedAt); remaining < expiry {
+			expiry = remaining
+		}
+	}
+	if expiry <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return
+	}
+	if err := s.cache.Set(ctx, transcriptCacheKey(transcript.VideoID), string(data), expiry); err != nil {
+		s.log.Debug("Failed to cache transcript in Redis", zap.String("video_id", transcript.VideoID), zap.Error(err))
+	}
+}
+
+func transcriptCacheKey(videoID string) string {
+	return fmt.Sprintf("transcript:video:%s", videoID)
+}
+
+// transcriptToCache converts a transcript to its stored form.
+func transcriptToCache(transcript *models.VideoTranscript) (*models.TranscriptCache, error) {
+	entry := &models.TranscriptCache{
+		VideoID:     transcript.VideoID,
+		Title:       transcript.Title,
+		Author:      transcript.Author,
+		ChannelID:   transcript.ChannelID,
+		Description: transcript.Description,
+		Duration:    transcript.Duration,
+		Provider:    transcript.Provider,
+		FetchedAt:   transcript.FetchedAt,
+	}
+
+	// The table holds one row per language and kind; keep the first
+	seen := make(map[string]bool)
+	for _, track := range transcript.Tracks {
+		key := track.Language + "/" + string(track.Kind)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		segments, err := json.Marshal(track.Segments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal segments: %w", err)
+		}
+		var raw []byte
+		if track.RawSegments != nil {
+			if raw, err = json.Marshal(track.RawSegments); err != nil {
+				return nil, fmt.Errorf("failed to marshal raw segments: %w", err)
+			}
+		}
+		entry.Tracks = append(entry.Tracks, models.TranscriptCacheTrack{
+			VideoID:     transcript.VideoID,
+			Language:    track.Language,
+			Kind:        track.Kind,
+			Name:        track.Name,
+			Position:    len(entry.Tracks),
+			Segments:    segments,
+			RawSegments: raw,
+		})
+	}
+	return entry, nil
+}
+
+// transcriptFromCache converts a stored transcript back to the normalized form.
+func transcriptFromCache(entry *models.TranscriptCache) (*models.VideoTranscript, error) {
+	transcript := &models.VideoTranscript{
+		VideoID:     entry.VideoID,
+		Title:       entry.Title,
+		Author:      entry.Author,
+		ChannelID:   entry.ChannelID,
+		Description: entry.Description,
+		Duration:    entry.Duration,
+		Provider:    entry.Provider,
+		FetchedAt:   entry.FetchedAt,
+	}
+
+	for _, track := range entry.Tracks {
+		var segments []models.CaptionSegment
+		if err := json.Unmarshal(track.Segments, &segments); err != nil {
+			return nil, fmt.Errorf("failed to parse segments of %s track: %w", track.Language, err)
+		}
+		var raw []models.CaptionSegment
+		if len(track.RawSegments) > 0 {
+			if err := json.Unmarshal(track.RawSegments, &raw); err != nil {
+				return nil, fmt.Errorf("failed to parse raw segments of %s track: %w", track.Language, err)
+			}
+		}
+		transcript.Tracks = append(transcript.Tracks, models.CaptionTrack{
+			Language:    track.Language,
+			Name:        track.Name,
+			Kind:        track.Kind,
+			Segments:    segments,
+			RawSegments: raw,
+		})
+	}
+	return transcript, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"vibe-backend/internal/models"
+)
+
+// orderTracks sorts tracks by preference, in place:
+//
+//  1. manual tracks in a preferred language, in the order of languages
+//  2. auto-generated tracks in a preferred language, in the same order
+//  3. the remaining manual tracks
+//  4. the remaining auto-generated tracks
+//
+// Within a language an exact match ("zh-Hans") ranks above a related one
+// ("zh" for "zh-Hans", "en-US" for "en"). Ties keep the provider's order, so
+// the result is the same on every run.
+func orderTracks(tracks []models.CaptionTrack, languages []string) {
+	sort.SliceStable(tracks, func(i, j int) bool {
+		return trackRank(tracks[i], languages) < trackRank(tracks[j], languages)
+	})
+}
+
+// trackRank returns the sort key used by orderTracks; lower is better.
+func trackRank(track models.CaptionTrack, languages []string) int {
+	kind := 0
+	if track.Kind == models.CaptionKindAuto {
+		kind = 1
+	}
+
+	n := 2 * len(languages) // number of language ranks
+	if lang := languageRank(track.Language, languages); lang < n {
+		return kind*n + lang
+	}
+	return 2*n + kind
+}
+
+// languageRank returns 2*i for an exact match with languages[i] and 2*i+1
+// for a related tag, or 2*len(languages) if the track matches none.
+func languageRank(code string, languages []string) int {
+	for i, pref := range languages {
+		switch {
+		case strings.EqualFold(code, pref):
+			return 2 * i
+		case relatedLanguage(code, pref):
+			// An exact match later in the list never beats this one
+			return 2*i + 1
+		}
+	}
+	return 2 * len(languages)
+}
+
+// relatedLanguage reports whether one language tag extends the other, e.g.
+// "zh" and "zh-Hans" or "en-US" and "en". Sibling tags such as "zh-Hans" and
+// "zh-Hant" are not related.
+func relatedLanguage(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return strings.HasPrefix(a, b+"-") || strings.HasPrefix(b, a+"-")
+}
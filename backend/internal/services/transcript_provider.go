@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/stt"
+)
+
+// TranscriptProvider fetches the caption tracks of a YouTube video.
+type TranscriptProvider interface {
+	// Name identifies the provider in configuration, logs and stats.
+	Name() string
+	// Fetch returns the video's caption tracks, or an error when the provider
+	// cannot read any captions for it.
+	Fetch(ctx context.Context, videoID string) (*models.VideoTranscript, error)
+}
+
+// Transcript provider names accepted in TRANSCRIPT_PROVIDERS.
+const (
+	TranscriptProviderInnertube = "innertube"
+	TranscriptProviderWatchPage = "watchpage"
+	TranscriptProviderYtDlp     = "ytdlp"
+	TranscriptProviderSTT       = "stt"
+)
+
+// ErrNoCaptions is returned when no provider could fetch a video's captions.
+var ErrNoCaptions = errors.New("无法获取该视频的字幕。可能原因：1) 视频没有字幕 2) 字幕功能被禁用 3) 视频不可访问")
+
+// TranscriptProviderOptions configures the providers built by
+// NewTranscriptProviders.
+type TranscriptProviderOptions struct {
+	// Languages is the default caption language preference, used by
+	// providers that must pick tracks.
+	Languages []string
+	// Transcriber backs the speech-to-text provider; nil leaves "stt" out
+	// of the chain.
+	Transcriber stt.Transcriber
+	// STTChunkDuration is the longest audio piece sent to the transcriber.
+	STTChunkDuration time.Duration
+	// STTMaxDuration skips speech-to-text for longer videos (0 = no limit).
+	STTMaxDuration time.Duration
+}
+
+// NewTranscriptProviders builds the named providers in order. Names are
+// case-insensitive and duplicates are ignored.
+func NewTranscriptProviders(names []string, opts TranscriptProviderOptions, log *zap.Logger) ([]TranscriptProvider, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	var providers []TranscriptProvider
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case TranscriptProviderInnertube:
+			providers = append(providers, &innertubeProvider{client: client, log: log})
+		case TranscriptProviderWatchPage:
+			providers = append(providers, &watchPageProvider{client: client, log: log})
+		case TranscriptProviderYtDlp:
+			providers = append(providers, &ytDlpProvider{client: client, languages: opts.Languages, log: log})
+		case TranscriptProviderSTT:
+			if opts.Transcriber == nil {
+				log.Info("Speech-to-text transcript provider disabled: STT_BACKEND is not set")
+				continue
+			}
+			providers = append(providers, &sttProvider{
+				transcriber:   opts.Transcriber,
+				chunkDuration: opts.STTChunkDuration,
+				maxDuration:   opts.STTMaxDuration,
+				log:           log,
+			})
+		default:
+			return nil, fmt.Errorf("unknown transcript provider %q", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, errors.New("no transcript providers configured")
+	}
+	return providers, nil
+}
+
+// TranscriptProviderStats reports how a provider has performed since startup.
+type TranscriptProviderStats struct {
+	Name          string     `json:"name"`
+	Attempts      int64      `json:"attempts"`
+	Successes     int64      `json:"successes"`
+	Failures      int64      `json:"failures"`
+	SuccessRate   float64    `json:"success_rate"`
+	AvgLatencyMs  int64      `json:"avg_latency_ms"`
+	LastLatencyMs int64      `json:"last_latency_ms"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// providerHealth accumulates TranscriptProviderStats for each provider.
+type providerHealth struct {
+	mu           sync.Mutex
+	stats        map[string]*TranscriptProviderStats
+	totalLatency map[string]time.Duration
+}
+
+func newProviderHealth(providers []TranscriptProvider) *providerHealth {
+	h := &providerHealth{
+		stats:        make(map[string]*TranscriptProviderStats, len(providers)),
+		totalLatency: make(map[string]time.Duration, len(providers)),
+	}
+	for _, p := range providers {
+		h.stats[p.Name()] = &TranscriptProviderStats{Name: p.Name()}
+	}
+	return h
+}
+
+// record adds the outcome of one Fetch call.
+func (h *providerHealth) record(name string, latency time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st, ok := h.stats[name]
+	if !ok {
+		st = &TranscriptProviderStats{Name: name}
+		h.stats[name] = st
+	}
+	now := time.Now()
+
+	st.Attempts++
+	h.totalLatency[name] += latency
+	st.LastLatencyMs = latency.Milliseconds()
+	st.AvgLatencyMs = (h.totalLatency[name] / time.Duration(st.Attempts)).Milliseconds()
+	if err != nil {
+		st.Failures++
+		st.LastFailureAt = &now
+		st.LastError = err.Error()
+	} else {
+		st.Successes++
+		st.LastSuccessAt = &now
+	}
+	st.SuccessRate = float64(st.Successes) / float64(st.Attempts)
+}
+
+// snapshot returns a copy of the stats in provider order.
+func (h *providerHealth) snapshot(providers []TranscriptProvider) []TranscriptProviderStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]TranscriptProviderStats, 0, len(providers))
+	for _, p := range providers {
+		result = append(result, *h.stats[p.Name()])
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/stt"
+)
+
+// sttProvider downloads a video's audio with yt-dlp and transcribes it with a
+// speech-to-text backend. It is meant to be last in the chain, for videos
+// without any captions; the result is cached like any other transcript.
+type sttProvider struct {
+	transcriber   stt.Transcriber
+	chunkDuration time.Duration
+	maxDuration   time.Duration
+	log           *zap.Logger
+}
+
+func (p *sttProvider) Name() string { return TranscriptProviderSTT }
+
+func (p *sttProvider) Fetch(ctx context.Context, videoID string) (*models.VideoTranscript, error) {
+	videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
+
+	// Check the length before downloading anything
+	output, err := exec.CommandContext(ctx,
+		"yt-dlp",
+		"--dump-json",
+		"--no-warnings",
+		"--skip-download",
+		"--no-playlist",
+		videoURL,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp metadata extraction failed: %w", err)
+	}
+
+	var info struct {
+		Title       string  `json:"title"`
+		Uploader    string  `json:"uploader"`
+		ChannelID   string  `json:"channel_id"`
+		Description string  `json:"description"`
+		Duration    float64 `json:"duration"`
+		Language    string  `json:"language"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp output: %w", err)
+	}
+	if p.maxDuration > 0 && time.Duration(info.Duration*float64(time.Second)) > p.maxDuration {
+		return nil, fmt.Errorf("video is %s long, speech-to-text is limited to %s",
+			formatDuration(int(info.Duration)), p.maxDuration)
+	}
+
+	dir, err := os.MkdirTemp("", "stt-"+videoID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	audio, err := p.downloadAudio(ctx, videoURL, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	result, err := stt.TranscribeFile(ctx, p.transcriber, audio, info.Language, p.chunkDuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s transcription failed: %w", p.transcriber.Name(), err)
+	}
+	p.log.Info("Transcribed video audio",
+		zap.String("video_id", videoID),
+		zap.String("backend", p.transcriber.Name()),
+		zap.String("language", result.Language),
+		zap.Int("segments", len(result.Segments)),
+		zap.Duration("latency", time.Since(started)),
+	)
+
+	segments := make([]models.CaptionSegment, len(result.Segments))
+	for i, seg := range result.Segments {
+		segments[i] = models.CaptionSegment{
+			StartMs: int(seg.Start.Milliseconds()),
+			EndMs:   int(seg.End.Milliseconds()),
+			Text:    seg.Text,
+		}
+	}
+
+	language := result.Language
+	if language == "" {
+		language = "und"
+	}
+
+	return &models.VideoTranscript{
+		VideoID:     videoID,
+		Title:       info.Title,
+		Author:      info.Uploader,
+		ChannelID:   info.ChannelID,
+		Description: info.Description,
+		Duration:    int(info.Duration),
+		Tracks: []models.CaptionTrack{{
+			Language: language,
+			Name:     fmt.Sprintf("Speech recognition (%s)", p.transcriber.Name()),
+			Kind:     models.CaptionKindAuto,
+			Segments: segments,
+		}},
+	}, nil
+}
+
+// downloadAudio saves the video's best audio-only format in dir and returns
+// its path.
+func (p *sttProvider) downloadAudio(ctx context.Context, videoURL, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx,
+		"yt-dlp",
+		"--no-warnings",
+		"--no-playlist",
+		"--no-progress",
+		"-f", "bestaudio/best",
+		"-o", filepath.Join(dir, "audio.%(ext)s"),
+		videoURL,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("yt-dlp audio download failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "audio.*"))
+	for _, m := range matches {
+		if !strings.HasSuffix(m, ".part") {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("yt-dlp did not produce an audio file")
+}
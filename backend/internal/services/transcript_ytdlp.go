@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/subtitles"
+)
+
+// ytDlpSubtitle is one downloadable format of a subtitle track in yt-dlp's
+// --dump-json output.
+type ytDlpSubtitle struct {
+	Ext  string `json:"ext"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// ytDlpProvider lists caption tracks with yt-dlp and downloads them directly.
+type ytDlpProvider struct {
+	client    *http.Client
+	languages []string // auto-generated track order when the original is unmarked
+	log       *zap.Logger
+}
+
+func (p *ytDlpProvider) Name() string { return TranscriptProviderYtDlp }
+
+func (p *ytDlpProvider) Fetch(ctx context.Context, videoID string) (*models.VideoTranscript, error) {
+	cmd := exec.CommandContext(ctx,
+		"yt-dlp",
+		"--dump-json",
+		"--no-warnings",
+		"--skip-download",
+		"--no-playlist",
+		fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp metadata extraction failed: %w", err)
+	}
+
+	var info struct {
+		Title             string                     `json:"title"`
+		Uploader          string                     `json:"uploader"`
+		ChannelID         string                     `json:"channel_id"`
+		Description       string                     `json:"description"`
+		Duration          float64                    `json:"duration"`
+		Subtitles         map[string][]ytDlpSubtitle `json:"subtitles"`
+		AutomaticCaptions map[string][]ytDlpSubtitle `json:"automatic_captions"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp output: %w", err)
+	}
+
+	transcript := &models.VideoTranscript{
+		VideoID:     videoID,
+		Title:       info.Title,
+		Author:      info.Uploader,
+		ChannelID:   info.ChannelID,
+		Description: info.Description,
+		Duration:    int(info.Duration),
+	}
+
+	// Every manual track, in a stable order
+	langs := make([]string, 0, len(info.Subtitles))
+	for lang := range info.Subtitles {
+		if lang != "live_chat" {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		p.addTrack(ctx, transcript, lang, models.CaptionKindManual, info.Subtitles[lang])
+	}
+
+	// One auto-generated track: automatic_captions also lists every language
+	// YouTube can machine-translate into, so only the original is kept
+	if lang := ytDlpOriginalLanguage(info.AutomaticCaptions, p.languages); lang != "" {
+		p.addTrack(ctx, transcript, strings.TrimSuffix(lang, "-orig"), models.CaptionKindAuto, info.AutomaticCaptions[lang])
+	}
+
+	if len(transcript.Tracks) == 0 {
+		return nil, fmt.Errorf("yt-dlp found no readable subtitles")
+	}
+	return transcript, nil
+}
+
+// addTrack downloads a subtitle track, preferring json3 over vtt, and appends
+// it to transcript. Failures are logged and the track skipped.
+func (p *ytDlpProvider) addTrack(ctx context.Context, transcript *models.VideoTranscript, lang string, kind models.CaptionKind, formats []ytDlpSubtitle) {
+	var lastErr error
+	for _, ext := range []string{"json3", "vtt"} {
+		for _, f := range formats {
+			if f.Ext != ext || f.URL == "" {
+				continue
+			}
+			segments, err := p.download(ctx, f)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			transcript.Tracks = append(transcript.Tracks, models.CaptionTrack{
+				Language: lang,
+				Name:     f.Name,
+				Kind:     kind,
+				Segments: segments,
+			})
+			return
+		}
+	}
+
+	p.log.Debug("Failed to download subtitle track",
+		zap.String("video_id", transcript.VideoID),
+		zap.String("lang", lang),
+		zap.Error(lastErr),
+	)
+}
+
+// download fetches and parses one subtitle format.
+func (p *ytDlpProvider) download(ctx context.Context, f ytDlpSubtitle) ([]models.CaptionSegment, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subtitle request: %w", err)
+	}
+	req.Header.Set("User-Agent", youtubeUserAgent)
+
+	body, err := doYouTubeRequest(p.client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	format := subtitles.FormatVTT
+	if f.Ext == "json3" {
+		format = subtitles.FormatJSON3
+	}
+	cues, err := subtitles.Parse(body, format)
+	if err != nil {
+		return nil, err
+	}
+	return captionSegments(cues), nil
+}
+
+// ytDlpOriginalLanguage picks the auto-generated track in the video's spoken
+// language: the one yt-dlp marks "-orig", else the first of languages that
+// is available.
+func ytDlpOriginalLanguage(captions map[string][]ytDlpSubtitle, languages []string) string {
+	for lang := range captions {
+		if strings.HasSuffix(lang, "-orig") {
+			return lang
+		}
+	}
+	for _, lang := range languages {
+		if _, ok := captions[lang]; ok {
+			return lang
+		}
+	}
+	return ""
+}
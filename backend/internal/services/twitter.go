@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/twitter"
+)
+
+// maxTweetTitleRunes bounds titles taken from the first line of a thread.
+const maxTweetTitleRunes = 100
+
+// processTwitterInsight unrolls the thread a tweet belongs to and stores its
+// text as the insight's content.
+func (p *InsightProcessor) processTwitterInsight(ctx context.Context, insight *models.Insight) error {
+	p.log.Info("Processing Twitter insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("source_url", insight.SourceURL),
+	)
+
+	if p.tweetFetcher == nil {
+		return jobs.Permanent(fmt.Errorf("未配置 Twitter/X 抓取"))
+	}
+
+	tweetID, err := twitter.ParseTweetURL(insight.SourceURL)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("无效的 Twitter/X 链接: %v", err))
+	}
+	insight.SourceID = tweetID
+
+	thread, err := twitter.Unroll(ctx, p.tweetFetcher, tweetID)
+	if err != nil {
+		if errors.Is(err, twitter.ErrNotFound) {
+			return jobs.Permanent(fmt.Errorf("推文不存在或不可公开访问"))
+		}
+		return fmt.Errorf("获取推文失败: %w", err)
+	}
+	if thread.Partial {
+		p.log.Warn("Twitter thread may be incomplete",
+			zap.Uint("insight_id", insight.ID),
+			zap.String("tweet_id", tweetID),
+			zap.Int("tweets", len(thread.Tweets)),
+		)
+	}
+
+	content := thread.Text()
+	if content == "" {
+		return jobs.Permanent(fmt.Errorf("推文没有文字内容"))
+	}
+
+	first := thread.Tweets[0]
+	insight.Title = thread.Title(maxTweetTitleRunes)
+	insight.Author = first.Author()
+	insight.ThumbnailURL = thread.Thumbnail()
+	if !first.CreatedAt.IsZero() {
+		publishedAt := first.CreatedAt
+		insight.PublishedAt = &publishedAt
+	}
+	insight.RawContent = content
+	insight.TransContent = p.translateContent(ctx, content, insight.TargetLang)
+
+	meta, err := json.Marshal(thread)
+	if err != nil {
+		return fmt.Errorf("failed to encode tweet thread: %w", err)
+	}
+	insight.SourceMeta = meta
+
+	return p.completeInsight(ctx, insight, nil, p.tweetFetcher.Name())
+}
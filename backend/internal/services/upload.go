@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/subtitles"
+)
+
+const (
+	// MaxUploadSize is the maximum size of an uploaded transcript or text file (10MB).
+	MaxUploadSize = 10 * 1024 * 1024
+)
+
+var (
+	// ErrUnsupportedUpload is returned for files that are not SRT, VTT, TXT or Markdown.
+	ErrUnsupportedUpload = errors.New("unsupported file type: only .srt, .vtt, .txt and .md are supported")
+	// ErrUploadTooLarge is returned when an uploaded file exceeds MaxUploadSize.
+	ErrUploadTooLarge = errors.New("file too large: maximum size is 10MB")
+	// ErrUploadUnreadable is returned for files that are empty, not UTF-8 or
+	// subtitle files without cues.
+	ErrUploadUnreadable = errors.New("file has no readable text")
+)
+
+// UploadedFile is the parsed content of an uploaded transcript or text file.
+type UploadedFile struct {
+	Name        string
+	Title       string                  // first Markdown heading, or the file name without extension
+	Transcripts []models.TranscriptItem // subtitle files only
+	Text        string                  // plain text of the file
+	Hash        string                  // SHA-256 of the file, used to find duplicate uploads
+}
+
+// ParseUpload reads and parses an uploaded .srt, .vtt, .txt or .md file.
+func ParseUpload(file *multipart.FileHeader) (*UploadedFile, error) {
+	if file.Size > MaxUploadSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	switch ext {
+	case ".srt", ".vtt", ".txt", ".md", ".markdown":
+	default:
+		return nil, ErrUnsupportedUpload
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(data) > MaxUploadSize {
+		return nil, ErrUploadTooLarge
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		return nil, ErrUploadUnreadable
+	}
+
+	sum := sha256.Sum256(data)
+	name := filepath.Base(file.Filename)
+	upload := &UploadedFile{
+		Name:  name,
+		Title: strings.TrimSuffix(name, filepath.Ext(name)),
+		Hash:  hex.EncodeToString(sum[:]),
+	}
+
+	switch ext {
+	case ".srt", ".vtt":
+		format := subtitles.FormatSRT
+		if ext == ".vtt" {
+			format = subtitles.FormatVTT
+		}
+		cues, err := subtitles.Parse(data, format)
+		if err != nil {
+			if errors.Is(err, subtitles.ErrNoCues) {
+				return nil, ErrUploadUnreadable
+			}
+			return nil, fmt.Errorf("failed to parse %s file: %w", format, err)
+		}
+		upload.Transcripts = captionTrackItems(&models.CaptionTrack{Segments: captionSegments(cues)})
+		texts := make([]string, len(upload.Transcripts))
+		for i, item := range upload.Transcripts {
+			texts[i] = item.Text
+		}
+		upload.Text = strings.Join(texts, "\n")
+	default:
+		upload.Text = strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n"))
+		if heading := markdownTitle(upload.Text); heading != "" && ext != ".txt" {
+			upload.Title = heading
+		}
+	}
+
+	if strings.TrimSpace(upload.Text) == "" {
+		return nil, ErrUploadUnreadable
+	}
+	return upload, nil
+}
+
+// markdownTitle returns the text of the first top-level Markdown heading.
+func markdownTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return ""
+}
+
+// processUploadInsight processes an insight created from an uploaded file.
+// Subtitle files were stored as the insight's only transcript track when it
+// was created; text files are kept in RawContent.
+func (p *InsightProcessor) processUploadInsight(ctx context.Context, insight *models.Insight) error {
+	p.log.Info("Processing uploaded insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("source_url", insight.SourceURL),
+	)
+
+	tracks, err := p.repo.GetTranscriptsByInsightID(ctx, insight.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load uploaded transcript: %w", err)
+	}
+
+	if len(tracks) > 0 {
+		track := tracks[0]
+		var items []models.TranscriptItem
+		if err := json.Unmarshal(track.Items, &items); err != nil {
+			return jobs.Permanent(fmt.Errorf("上传的字幕无法解析: %v", err))
+		}
+		transcripts, err := p.convertTranscriptsToInsightFormat(ctx, items, insight.TargetLang)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("上传的字幕没有内容: %v", err))
+		}
+		insight.Transcripts = transcripts
+		insight.TranscriptLanguage = track.Language
+		insight.TranscriptKind = track.Kind
+		insight.RawContent = p.extractRawContentFromTranscripts(items)
+		if n := len(items); n > 0 && items[n-1].Seconds > insight.Duration {
+			insight.Duration = items[n-1].Seconds
+		}
+	} else {
+		if strings.TrimSpace(insight.RawContent) == "" {
+			return jobs.Permanent(fmt.Errorf("上传的文件没有内容"))
+		}
+		insight.TransContent = p.translateContent(ctx, insight.RawContent, insight.TargetLang)
+	}
+
+	return p.completeInsight(ctx, insight, nil, "upload")
+}
+
+// translateContent translates text content line by line. It returns an empty
+// string when translation is unavailable, fails or is not needed.
+func (p *InsightProcessor) translateContent(ctx context.Context, content, targetLang string) string {
+	if p.translationService == nil || targetLang == "" {
+		return ""
+	}
+	lines := splitParagraphs(content)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	sourceLang, err := p.translationService.DetectLanguage(ctx, lines[0])
+	if err != nil {
+		p.log.Warn("Failed to detect source language, skipping translation", zap.Error(err))
+		return ""
+	}
+	if sourceLang == targetLang {
+		return ""
+	}
+
+	translations, err := p.translationService.TranslateBatch(ctx, lines, sourceLang, targetLang)
+	if err != nil {
+		p.log.Warn("Failed to translate content",
+			zap.String("source_lang", sourceLang),
+			zap.String("target_lang", targetLang),
+			zap.Error(err),
+		)
+		return ""
+	}
+	return strings.Join(translations, "\n")
+}
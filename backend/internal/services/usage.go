@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// UsageBudgets configures monthly AI spending limits. Zero means unlimited.
+type UsageBudgets struct {
+	UserMonthlyUSD    float64
+	UserMonthlyTokens int64
+	GlobalMonthlyUSD  float64
+}
+
+// UsageService records LLM usage and enforces monthly budgets.
+// It implements llm.Meter.
+type UsageService struct {
+	repo    *repository.UsageRepository
+	pricing llm.Pricing
+	budgets UsageBudgets
+	log     *zap.Logger
+}
+
+// NewUsageService creates a new UsageService.
+func NewUsageService(repo *repository.UsageRepository, pricing llm.Pricing, budgets UsageBudgets, log *zap.Logger) *UsageService {
+	return &UsageService{
+		repo:    repo,
+		pricing: pricing,
+		budgets: budgets,
+		log:     log,
+	}
+}
+
+// monthStart returns the start of the current UTC month.
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Allow implements llm.Meter. It rejects calls once the user or global
+// monthly budget has been used up.
+func (s *UsageService) Allow(ctx context.Context, tags llm.Tags) error {
+	since := monthStart(time.Now())
+
+	if tags.UserID != 0 && (s.budgets.UserMonthlyUSD > 0 || s.budgets.UserMonthlyTokens > 0) {
+		userID := tags.UserID
+		totals, err := s.repo.Totals(ctx, &userID, since)
+		if err != nil {
+			// Don't block AI features because accounting is unavailable.
+			s.log.Warn("Failed to check user AI budget", zap.Uint("user_id", userID), zap.Error(err))
+			return nil
+		}
+		if s.budgets.UserMonthlyUSD > 0 && totals.CostUSD >= s.budgets.UserMonthlyUSD {
+			return fmt.Errorf("%w: 本月 AI 使用额度已用完（%.2f / %.2f USD）", llm.ErrBudgetExceeded, totals.CostUSD, s.budgets.UserMonthlyUSD)
+		}
+		if s.budgets.UserMonthlyTokens > 0 && totals.TotalTokens >= s.budgets.UserMonthlyTokens {
+			return fmt.Errorf("%w: 本月 AI token 额度已用完（%d / %d）", llm.ErrBudgetExceeded, totals.TotalTokens, s.budgets.UserMonthlyTokens)
+		}
+	}
+
+	if s.budgets.GlobalMonthlyUSD > 0 {
+		totals, err := s.repo.Totals(ctx, nil, since)
+		if err != nil {
+			s.log.Warn("Failed to check global AI budget", zap.Error(err))
+			return nil
+		}
+		if totals.CostUSD >= s.budgets.GlobalMonthlyUSD {
+			return fmt.Errorf("%w: 本月 AI 服务总额度已用完", llm.ErrBudgetExceeded)
+		}
+	}
+
+	return nil
+}
+
+// Record implements llm.Meter.
+func (s *UsageService) Record(ctx context.Context, rec llm.UsageRecord) {
+	cost := rec.Usage.Cost
+	if cost == 0 {
+		cost = s.pricing.Cost(rec.Model, rec.Usage)
+	}
+
+	feature := rec.Feature
+	if feature == "" {
+		feature = "other"
+	}
+
+	usage := &models.LLMUsage{
+		Feature:          feature,
+		Provider:         rec.Provider,
+		Model:            rec.Model,
+		PromptTokens:     rec.Usage.PromptTokens,
+		CompletionTokens: rec.Usage.CompletionTokens,
+		TotalTokens:      rec.Usage.TotalTokens,
+		CostUSD:          cost,
+		Estimated:        rec.Estimated,
+	}
+	if rec.UserID != 0 {
+		userID := rec.UserID
+		usage.UserID = &userID
+	}
+	if rec.InsightID != 0 {
+		insightID := rec.InsightID
+		usage.InsightID = &insightID
+	}
+
+	// Record even if the originating request was cancelled.
+	if err := s.repo.Create(context.WithoutCancel(ctx), usage); err != nil {
+		s.log.Error("Failed to record LLM usage",
+			zap.String("feature", feature),
+			zap.String("model", rec.Model),
+			zap.Error(err),
+		)
+	}
+}
+
+// GetUsage returns daily rollups for the last `days` days, monthly rollups for
+// the last 12 months, the current month's per-feature breakdown and budget status.
+func (s *UsageService) GetUsage(ctx context.Context, userID uint, days int) (*models.UsageResponse, error) {
+	now := time.Now().UTC()
+	start := monthStart(now)
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+	daily, err := s.repo.Rollup(ctx, userID, "day", dayStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily usage: %w", err)
+	}
+
+	monthly, err := s.repo.Rollup(ctx, userID, "month", start.AddDate(0, -11, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly usage: %w", err)
+	}
+
+	byFeature, err := s.repo.RollupByFeature(ctx, userID, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage by feature: %w", err)
+	}
+
+	totals, err := s.repo.Totals(ctx, &userID, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage totals: %w", err)
+	}
+
+	budget := models.BudgetStatus{
+		LimitUSD:   s.budgets.UserMonthlyUSD,
+		UsedUSD:    totals.CostUSD,
+		TokenLimit: s.budgets.UserMonthlyTokens,
+		TokensUsed: totals.TotalTokens,
+		ResetsAt:   start.AddDate(0, 1, 0),
+	}
+	if budget.LimitUSD > 0 {
+		budget.RemainingUSD = max(budget.LimitUSD-budget.UsedUSD, 0)
+		budget.Exceeded = budget.UsedUSD >= budget.LimitUSD
+	}
+	if budget.TokenLimit > 0 && budget.TokensUsed >= budget.TokenLimit {
+		budget.Exceeded = true
+	}
+
+	if daily == nil {
+		daily = []models.UsageRollup{}
+	}
+	if monthly == nil {
+		monthly = []models.UsageRollup{}
+	}
+	if byFeature == nil {
+		byFeature = []models.UsageRollup{}
+	}
+
+	return &models.UsageResponse{
+		Daily:     daily,
+		Monthly:   monthly,
+		ByFeature: byFeature,
+		Budget:    budget,
+	}, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -37,9 +38,14 @@ const (
 	// Quota costs (YouTube Data API v3)
 	quotaVideoMetadata = 1
 	quotaPlaylist      = 1
+	quotaChannel       = 1
 	quotaCaptions      = 50
 )
 
+// ErrYouTubeAPINotConfigured is returned by calls that need the YouTube Data
+// API when no API key is set.
+var ErrYouTubeAPINotConfigured = errors.New("YouTube Data API key not configured")
+
 // NewYouTubeAPIService creates a new YouTubeAPIService.
 func NewYouTubeAPIService(apiKey string, cache *cache.RedisCache, oauthService *OAuthService, log *zap.Logger) *YouTubeAPIService {
 	return &YouTubeAPIService{
@@ -193,6 +199,118 @@ func (s *YouTubeAPIService) GetPlaylist(ctx context.Context, playlistID string,
 	return result, nil
 }
 
+// ListPlaylistVideos pages through a playlist and returns up to limit of its
+// videos in playlist order, together with the playlist's title. Unlike
+// GetPlaylist it reads past the first page and is not cached, since imports
+// need the playlist as it is now.
+func (s *YouTubeAPIService) ListPlaylistVideos(ctx context.Context, playlistID string, limit int) (*models.YouTubePlaylistResponse, error) {
+	service, err := s.apiKeyService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.YouTubePlaylistResponse{}
+	playlists, err := service.Playlists.List([]string{"snippet"}).Id(playlistID).Context(ctx).Do()
+	s.incrementQuota(quotaPlaylist)
+	if err != nil {
+		s.log.Error("Failed to fetch playlist", zap.Error(err), zap.String("playlist_id", playlistID))
+		return nil, fmt.Errorf("PLAYLIST_NOT_FOUND: failed to fetch playlist: %w", err)
+	}
+	if len(playlists.Items) > 0 {
+		result.Title = playlists.Items[0].Snippet.Title
+	}
+
+	pageToken := ""
+	for len(result.Items) < limit {
+		call := service.PlaylistItems.List([]string{"snippet", "status"}).
+			PlaylistId(playlistID).
+			MaxResults(50).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		s.incrementQuota(quotaPlaylist)
+		if err != nil {
+			s.log.Error("Failed to fetch playlist items", zap.Error(err), zap.String("playlist_id", playlistID))
+			if strings.Contains(err.Error(), "forbidden") || strings.Contains(err.Error(), "unauthorized") {
+				return nil, fmt.Errorf("UNAUTHORIZED: playlist is private")
+			}
+			return nil, fmt.Errorf("PLAYLIST_NOT_FOUND: failed to fetch playlist: %w", err)
+		}
+
+		for _, item := range response.Items {
+			if len(result.Items) == limit {
+				break
+			}
+			if item.Snippet == nil || item.Snippet.ResourceId == nil || item.Snippet.ResourceId.VideoId == "" {
+				continue
+			}
+			entry := models.YouTubePlaylistItem{
+				VideoID: item.Snippet.ResourceId.VideoId,
+				Title:   item.Snippet.Title,
+			}
+			if t := item.Snippet.Thumbnails; t != nil && t.Default != nil {
+				entry.Thumbnail = t.Default.Url
+			}
+			// Private and deleted videos stay in playlists as placeholders
+			// without thumbnails
+			entry.Unavailable = entry.Thumbnail == "" ||
+				(item.Status != nil && item.Status.PrivacyStatus == "private")
+			result.Items = append(result.Items, entry)
+		}
+
+		pageToken = response.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	if len(result.Items) == 0 && result.Title == "" {
+		return nil, fmt.Errorf("PLAYLIST_NOT_FOUND: playlist does not exist or is private")
+	}
+	return result, nil
+}
+
+// GetChannelUploads returns the ID of the playlist holding a channel's
+// uploads, newest first, and the channel's title.
+func (s *YouTubeAPIService) GetChannelUploads(ctx context.Context, channelID string) (playlistID, title string, err error) {
+	service, err := s.apiKeyService(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	response, err := service.Channels.List([]string{"snippet", "contentDetails"}).Id(channelID).Context(ctx).Do()
+	s.incrementQuota(quotaChannel)
+	if err != nil {
+		s.log.Error("Failed to fetch channel", zap.Error(err), zap.String("channel_id", channelID))
+		return "", "", fmt.Errorf("CHANNEL_NOT_FOUND: failed to fetch channel: %w", err)
+	}
+	if len(response.Items) == 0 || response.Items[0].ContentDetails == nil ||
+		response.Items[0].ContentDetails.RelatedPlaylists == nil ||
+		response.Items[0].ContentDetails.RelatedPlaylists.Uploads == "" {
+		return "", "", fmt.Errorf("CHANNEL_NOT_FOUND: channel does not exist")
+	}
+
+	channel := response.Items[0]
+	if channel.Snippet != nil {
+		title = channel.Snippet.Title
+	}
+	return channel.ContentDetails.RelatedPlaylists.Uploads, title, nil
+}
+
+// apiKeyService creates a YouTube client authenticated with the API key.
+func (s *YouTubeAPIService) apiKeyService(ctx context.Context) (*youtube.Service, error) {
+	if s.apiKey == "" {
+		return nil, ErrYouTubeAPINotConfigured
+	}
+	service, err := youtube.NewService(ctx, option.WithAPIKey(s.apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create YouTube service: %w", err)
+	}
+	return service, nil
+}
+
 // GetCaptions fetches caption tracks for a video.
 func (s *YouTubeAPIService) GetCaptions(ctx context.Context, videoID string, token *oauth2.Token) (*models.YouTubeCaptionsResponse, error) {
 	// Check cache first (if cache is available)
@@ -0,0 +1,136 @@
+package stt
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chunk is one piece of a longer recording.
+type Chunk struct {
+	Path   string
+	Offset time.Duration // start of the chunk in the original recording
+}
+
+// SplitAudio converts any audio or video file to 16 kHz mono WAV chunks of at
+// most chunkDuration each, written to dir. A chunkDuration of 0 produces a
+// single chunk. It requires ffmpeg on PATH.
+func SplitAudio(ctx context.Context, input, dir string, chunkDuration time.Duration) ([]Chunk, error) {
+	args := []string{
+		"-nostdin", "-hide_banner", "-loglevel", "error",
+		"-i", input,
+		"-vn", "-ac", "1", "-ar", "16000", "-c:a", "pcm_s16le",
+	}
+	if chunkDuration <= 0 {
+		out := filepath.Join(dir, "chunk-0000.wav")
+		args = append(args, "-y", out)
+		if output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("ffmpeg conversion failed: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return []Chunk{{Path: out}}, nil
+	}
+
+	// The segment list records where each chunk starts, which is more exact
+	// than multiplying by chunkDuration
+	list := filepath.Join(dir, "chunks.csv")
+	args = append(args,
+		"-f", "segment",
+		"-segment_time", strconv.FormatFloat(chunkDuration.Seconds(), 'f', 3, 64),
+		"-segment_list", list,
+		"-segment_list_type", "csv",
+		"-reset_timestamps", "1",
+		filepath.Join(dir, "chunk-%04d.wav"),
+	)
+	if output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg split failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	f, err := os.Open(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk list: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chunk list: %w", err)
+	}
+
+	chunks := make([]Chunk, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < 2 {
+			continue
+		}
+		start, err := strconv.ParseFloat(rec[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk start %q: %w", rec[1], err)
+		}
+		chunks = append(chunks, Chunk{
+			Path:   filepath.Join(dir, rec[0]),
+			Offset: time.Duration(start * float64(time.Second)),
+		})
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no audio chunks")
+	}
+	return chunks, nil
+}
+
+// TranscribeFile transcribes an audio or video file of any length. The file
+// is split into chunks of at most chunkDuration, each chunk is transcribed in
+// turn and the segments are shifted onto the original timeline. The language
+// detected in the first chunk is used as the hint for the rest, so a long
+// recording is not split across languages.
+func TranscribeFile(ctx context.Context, t Transcriber, path, language string, chunkDuration time.Duration) (*Result, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), "chunks-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	chunks, err := SplitAudio(ctx, path, dir, chunkDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Language: language}
+	for i, chunk := range chunks {
+		r, err := t.Transcribe(ctx, chunk.Path, result.Language)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		if result.Language == "" {
+			result.Language = r.Language
+		}
+
+		for _, seg := range r.Segments {
+			text := strings.TrimSpace(seg.Text)
+			if text == "" || isNonSpeech(text) {
+				continue
+			}
+			result.Segments = append(result.Segments, Segment{
+				Start: chunk.Offset + seg.Start,
+				End:   chunk.Offset + seg.End,
+				Text:  text,
+			})
+		}
+	}
+
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("no speech recognized")
+	}
+	return result, nil
+}
+
+// isNonSpeech reports whether a segment is only a marker such as
+// "[BLANK_AUDIO]" or "(music)" that whisper emits for silence and noise.
+func isNonSpeech(text string) bool {
+	return (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) ||
+		(strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")"))
+}
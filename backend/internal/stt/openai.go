@@ -0,0 +1,164 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// openAITranscriber calls an OpenAI-compatible /audio/transcriptions
+// endpoint (OpenAI, Groq, faster-whisper-server, whisper.cpp's server, ...).
+type openAITranscriber struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+func newOpenAITranscriber(baseURL, apiKey, model string, timeout time.Duration) *openAITranscriber {
+	if model == "" {
+		model = "whisper-1"
+	}
+	return &openAITranscriber{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// transcriptionResponse is the verbose_json response format.
+type transcriptionResponse struct {
+	Language string `json:"language"`
+	Segments []struct {
+		Start float64 `json:"start"` // seconds
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Name implements Transcriber.
+func (o *openAITranscriber) Name() string {
+	return BackendOpenAI
+}
+
+// Transcribe implements Transcriber.
+func (o *openAITranscriber) Transcribe(ctx context.Context, path string, language string) (*Result, error) {
+	body, contentType, err := o.form(path, language)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/audio/transcriptions", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcription response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transcription request failed (status %d): %s", resp.StatusCode, tail(string(data), 500))
+	}
+
+	var out transcriptionResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse transcription response: %w", err)
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("transcription failed: %s", out.Error.Message)
+	}
+
+	result := &Result{Language: languageCode(out.Language)}
+	for _, s := range out.Segments {
+		result.Segments = append(result.Segments, Segment{
+			Start: time.Duration(s.Start * float64(time.Second)),
+			End:   time.Duration(s.End * float64(time.Second)),
+			Text:  s.Text,
+		})
+	}
+	return result, nil
+}
+
+// form builds the multipart request body.
+func (o *openAITranscriber) form(path string, language string) (io.Reader, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return nil, "", fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":                     o.model,
+		"response_format":           "verbose_json",
+		"timestamp_granularities[]": "segment",
+	}
+	if lang := baseLanguage(language); lang != "" {
+		fields["language"] = lang
+	}
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+// languageNames maps the language names OpenAI returns in verbose_json to
+// codes; compatible servers usually return codes already.
+var languageNames = map[string]string{
+	"english":    "en",
+	"chinese":    "zh",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"spanish":    "es",
+	"french":     "fr",
+	"german":     "de",
+	"russian":    "ru",
+	"portuguese": "pt",
+	"italian":    "it",
+}
+
+// languageCode normalizes a reported language to a code where known.
+func languageCode(language string) string {
+	if code, ok := languageNames[strings.ToLower(language)]; ok {
+		return code
+	}
+	return language
+}
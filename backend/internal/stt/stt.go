@@ -0,0 +1,99 @@
+// Package stt transcribes audio with a pluggable speech-to-text backend. It
+// is the last resort for videos that have no captions at all.
+//
+// Backends receive 16 kHz mono WAV files no longer than the configured chunk
+// duration; TranscribeFile does the conversion and splitting with ffmpeg and
+// stitches the results back together on a single timeline.
+package stt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported backend names for Config.Backend.
+const (
+	BackendWhisperCLI = "whisper-cli"
+	BackendOpenAI     = "openai"
+)
+
+// Segment is one timestamped piece of recognized speech.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Result is the transcription of one audio file.
+type Result struct {
+	// Language is the spoken language as reported by the backend, usually an
+	// ISO 639-1 code; empty if unknown.
+	Language string
+	Segments []Segment
+}
+
+// Transcriber turns speech into timestamped text.
+type Transcriber interface {
+	// Name identifies the backend in logs and track names.
+	Name() string
+	// Transcribe recognizes the speech in a WAV file. language is a hint
+	// such as "en"; empty lets the backend detect it.
+	Transcribe(ctx context.Context, path string, language string) (*Result, error)
+}
+
+// Config selects and configures a backend.
+type Config struct {
+	Backend string
+
+	// whisper.cpp-compatible CLI
+	Binary    string
+	ModelPath string
+
+	// OpenAI-compatible /audio/transcriptions endpoint
+	BaseURL string
+	APIKey  string
+	Model   string
+
+	// Timeout bounds the transcription of a single chunk.
+	Timeout time.Duration
+}
+
+// New builds a Transcriber from configuration. It returns nil when no backend
+// is configured, in which case videos without captions have no transcript.
+func New(cfg Config) (Transcriber, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, nil
+	case BackendWhisperCLI:
+		if cfg.ModelPath == "" {
+			return nil, fmt.Errorf("stt: STT_WHISPER_MODEL is required for backend %q", BackendWhisperCLI)
+		}
+		binary := cfg.Binary
+		if binary == "" {
+			binary = "whisper-cli"
+		}
+		return &whisperCLI{binary: binary, model: cfg.ModelPath, timeout: cfg.Timeout}, nil
+	case BackendOpenAI:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("stt: STT_BASE_URL is required for backend %q", BackendOpenAI)
+		}
+		return newOpenAITranscriber(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("stt: unknown backend %q", cfg.Backend)
+	}
+}
+
+// baseLanguage reduces a language tag to the primary subtag backends accept,
+// e.g. "en" for "en-US".
+func baseLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
@@ -0,0 +1,95 @@
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// whisperCLI runs a whisper.cpp-compatible command line tool, e.g.
+//
+//	whisper-cli -m ggml-base.bin -f chunk.wav -l auto -oj -of chunk -np
+//
+// and reads the JSON file it writes.
+type whisperCLI struct {
+	binary  string
+	model   string
+	timeout time.Duration
+}
+
+// whisperOutput is the subset of whisper.cpp's -oj output used here.
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			From int64 `json:"from"` // milliseconds
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+// Name implements Transcriber.
+func (w *whisperCLI) Name() string {
+	return BackendWhisperCLI
+}
+
+// Transcribe implements Transcriber.
+func (w *whisperCLI) Transcribe(ctx context.Context, path string, language string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	lang := baseLanguage(language)
+	if lang == "" {
+		lang = "auto"
+	}
+
+	outPrefix := strings.TrimSuffix(path, ".wav")
+	cmd := exec.CommandContext(ctx, w.binary,
+		"-m", w.model,
+		"-f", path,
+		"-l", lang,
+		"-oj",
+		"-of", outPrefix,
+		"-np",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", w.binary, err, tail(string(output), 500))
+	}
+
+	data, err := os.ReadFile(outPrefix + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s output: %w", w.binary, err)
+	}
+	defer os.Remove(outPrefix + ".json")
+
+	var out whisperOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", w.binary, err)
+	}
+
+	result := &Result{Language: out.Result.Language}
+	for _, t := range out.Transcription {
+		result.Segments = append(result.Segments, Segment{
+			Start: time.Duration(t.Offsets.From) * time.Millisecond,
+			End:   time.Duration(t.Offsets.To) * time.Millisecond,
+			Text:  t.Text,
+		})
+	}
+	return result, nil
+}
+
+// tail returns at most the last n bytes of s, for error messages.
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) > n {
+		return "..." + s[len(s)-n:]
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ASS style names used by WriteASS and bilingual exports.
+const (
+	assStyleDefault    = "Default"
+	assStyleOriginal   = "Original"
+	assStyleTranslated = "Translated"
+)
+
+// assHeader is the script header for a 1080p canvas. The original language
+// is white and the translation a smaller yellow, both bottom-centered.
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,60,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1
+Style: Original,Arial,60,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1
+Style: Translated,Arial,52,&H0000E6FF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// assEscaper keeps cue text from being read as override tags.
+var assEscaper = strings.NewReplacer("{", "(", "}", ")", "\\", "\\\\")
+
+// WriteASS encodes cues as an Advanced SubStation Alpha script.
+func WriteASS(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(assHeader)
+	for _, c := range cues {
+		writeASSEvent(bw, c.Start, c.End, assStyleDefault, assText(strings.Split(c.Text, "\n")))
+	}
+	return bw.Flush()
+}
+
+// writeASSEvent writes one Dialogue line.
+func writeASSEvent(w *bufio.Writer, start, end time.Duration, style, text string) {
+	fmt.Fprintf(w, "Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n", formatASSTime(start), formatASSTime(end), style, text)
+}
+
+// assText joins escaped lines with ASS hard line breaks.
+func assText(lines []string) string {
+	for i, line := range lines {
+		lines[i] = assEscaper.Replace(line)
+	}
+	return strings.Join(lines, `\N`)
+}
+
+// formatASSTime formats d as "H:MM:SS.cc".
+func formatASSTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
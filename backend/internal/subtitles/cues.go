@@ -0,0 +1,105 @@
+package subtitles
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// parseTimedBlocks parses the block structure shared by SRT and WebVTT: cues
+// separated by blank lines, each with a "start --> end" timing line followed
+// by text. Lines before the timing line (SRT indexes, VTT identifiers) and
+// blocks without one (the VTT header, NOTE and STYLE blocks) are skipped.
+func parseTimedBlocks(data []byte, format Format) ([]Cue, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		cues   []Cue
+		cur    *Cue
+		text   []string
+		lineNo int
+	)
+	flush := func() {
+		if cur != nil {
+			cur.Text = cleanText(strings.Join(text, "\n"))
+			cues = append(cues, *cur)
+		}
+		cur, text = nil, nil
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.TrimSpace(line) == "" {
+			// YouTube's rolling captions put a " " line right after the
+			// timing line; only a blank line after some text ends the cue
+			if line != "" && cur != nil && len(text) == 0 {
+				continue
+			}
+			flush()
+			continue
+		}
+		if cur == nil {
+			if start, end, ok := strings.Cut(line, "-->"); ok {
+				s, okStart := ParseTimestamp(start)
+				fields := strings.Fields(end)
+				if len(fields) == 0 || !okStart {
+					return nil, fmt.Errorf("subtitles: %s line %d: invalid timing %q", format, lineNo, line)
+				}
+				e, okEnd := ParseTimestamp(fields[0]) // VTT cue settings may follow
+				if !okEnd {
+					return nil, fmt.Errorf("subtitles: %s line %d: invalid timing %q", format, lineNo, line)
+				}
+				cur = &Cue{Start: s, End: e}
+			}
+			continue
+		}
+		text = append(text, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("subtitles: failed to read %s: %w", format, err)
+	}
+	flush()
+
+	// Only YouTube's WebVTT rolls
+	return finish(cues, format == FormatVTT)
+}
+
+// ParseSRT parses SubRip (.srt) captions.
+func ParseSRT(data []byte) ([]Cue, error) {
+	return parseTimedBlocks(data, FormatSRT)
+}
+
+// ParseVTT parses WebVTT captions, including YouTube's rolling
+// auto-generated captions with inline word timestamps.
+func ParseVTT(data []byte) ([]Cue, error) {
+	return parseTimedBlocks(data, FormatVTT)
+}
+
+// WriteSRT encodes cues as SubRip.
+func WriteSRT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatClockTime(c.Start, ","), formatClockTime(c.End, ","), c.Text)
+	}
+	return bw.Flush()
+}
+
+// vttEscaper escapes the characters WebVTT cue text cannot contain.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WriteVTT encodes cues as WebVTT.
+func WriteVTT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n",
+			formatClockTime(c.Start, "."), formatClockTime(c.End, "."), vttEscaper.Replace(c.Text))
+	}
+	return bw.Flush()
+}
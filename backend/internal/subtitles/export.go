@@ -0,0 +1,299 @@
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Layout selects which languages a bilingual export contains.
+type Layout string
+
+// Supported layouts.
+const (
+	// LayoutStacked shows the original with the translation below it.
+	LayoutStacked    Layout = "stacked"
+	LayoutOriginal   Layout = "original"
+	LayoutTranslated Layout = "translated"
+)
+
+// Cue timing limits applied by FixTimings.
+const (
+	minCueDuration = 700 * time.Millisecond
+	maxCueDuration = 8 * time.Second
+)
+
+// DefaultLineWidth is the line width, in columns, exports wrap text to when
+// ExportOptions.LineWidth is 0. Wide (CJK) characters count as two columns.
+const DefaultLineWidth = 42
+
+// BilingualCue is a cue with its text in two languages. Translated may be
+// empty.
+type BilingualCue struct {
+	Start      time.Duration
+	End        time.Duration
+	Original   string
+	Translated string
+}
+
+// ExportOptions controls Export.
+type ExportOptions struct {
+	Format    Format // srt, vtt or ass
+	Layout    Layout
+	LineWidth int
+}
+
+// ContentType returns the MIME type to serve an exported file with.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatASS:
+		return "text/x-ssa; charset=utf-8"
+	case FormatTTML:
+		return "application/ttml+xml; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// ParseExportOptions validates user-supplied format and layout names;
+// empty values default to SRT and the stacked layout.
+func ParseExportOptions(format, layout string) (ExportOptions, error) {
+	opts := ExportOptions{Format: FormatSRT, Layout: LayoutStacked}
+
+	switch f := Format(strings.ToLower(strings.TrimSpace(format))); f {
+	case "":
+	case FormatSRT, FormatVTT, FormatASS:
+		opts.Format = f
+	default:
+		return opts, fmt.Errorf("unsupported subtitle format %q (srt, vtt or ass)", format)
+	}
+
+	switch l := Layout(strings.ToLower(strings.TrimSpace(layout))); l {
+	case "":
+	case LayoutStacked, LayoutOriginal, LayoutTranslated:
+		opts.Layout = l
+	default:
+		return opts, fmt.Errorf("unsupported subtitle layout %q (stacked, original or translated)", layout)
+	}
+	return opts, nil
+}
+
+// Export writes bilingual cues as a subtitle file players such as VLC and
+// mpv load directly. Timings are fixed with FixTimings and text is wrapped
+// to the line width. A cue without a translation falls back to the
+// original in the translated layout. In ASS the two languages get separate
+// styles.
+func Export(w io.Writer, cues []BilingualCue, opts ExportOptions) error {
+	width := opts.LineWidth
+	if width <= 0 {
+		width = DefaultLineWidth
+	}
+	cues = FixTimings(cues)
+
+	if opts.Format == FormatASS {
+		bw := bufio.NewWriter(w)
+		bw.WriteString(assHeader)
+		for _, c := range cues {
+			original, translated := layoutLines(c, opts.Layout, width)
+			var parts []string
+			if len(original) > 0 {
+				parts = append(parts, fmt.Sprintf(`{\r%s}`, assStyleOriginal)+assText(original))
+			}
+			if len(translated) > 0 {
+				parts = append(parts, fmt.Sprintf(`{\r%s}`, assStyleTranslated)+assText(translated))
+			}
+			if len(parts) > 0 {
+				writeASSEvent(bw, c.Start, c.End, assStyleOriginal, strings.Join(parts, `\N`))
+			}
+		}
+		return bw.Flush()
+	}
+
+	plain := make([]Cue, 0, len(cues))
+	for _, c := range cues {
+		original, translated := layoutLines(c, opts.Layout, width)
+		if lines := append(original, translated...); len(lines) > 0 {
+			plain = append(plain, Cue{Start: c.Start, End: c.End, Text: strings.Join(lines, "\n")})
+		}
+	}
+	return Write(w, opts.Format, plain)
+}
+
+// layoutLines returns the wrapped lines of each language shown by layout.
+func layoutLines(c BilingualCue, layout Layout, width int) (original, translated []string) {
+	switch layout {
+	case LayoutOriginal:
+		return Wrap(c.Original, width), nil
+	case LayoutTranslated:
+		if strings.TrimSpace(c.Translated) == "" {
+			return Wrap(c.Original, width), nil
+		}
+		return nil, Wrap(c.Translated, width)
+	default:
+		return Wrap(c.Original, width), Wrap(c.Translated, width)
+	}
+}
+
+// FixTimings returns a copy of cues sorted by start time with usable
+// timings: cues starting at the same moment are merged, missing or
+// inverted end times run to the next cue, no cue overlaps the next one or
+// stays on screen longer than 8s, and short cues are lengthened to 0.7s
+// where the next cue leaves room.
+func FixTimings(cues []BilingualCue) []BilingualCue {
+	sorted := append([]BilingualCue(nil), cues...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := make([]BilingualCue, 0, len(sorted))
+	for _, c := range sorted {
+		if n := len(merged); n > 0 && merged[n-1].Start == c.Start {
+			prev := &merged[n-1]
+			prev.Original = joinText(prev.Original, c.Original)
+			prev.Translated = joinText(prev.Translated, c.Translated)
+			if c.End > prev.End {
+				prev.End = c.End
+			}
+			continue
+		}
+		merged = append(merged, c)
+	}
+
+	for i := range merged {
+		c := &merged[i]
+		hasNext := i+1 < len(merged)
+		var next time.Duration
+		if hasNext {
+			next = merged[i+1].Start
+		}
+
+		if c.End <= c.Start {
+			c.End = c.Start + defaultCueDuration
+			if hasNext {
+				c.End = next
+			}
+		}
+		if c.End-c.Start > maxCueDuration {
+			c.End = c.Start + maxCueDuration
+		}
+		if c.End-c.Start < minCueDuration {
+			c.End = c.Start + minCueDuration
+		}
+		if hasNext && c.End > next {
+			c.End = next
+		}
+	}
+	return merged
+}
+
+// joinText joins two pieces of cue text, skipping empties. CJK text is
+// joined without a space.
+func joinText(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if isWide(last) && isWide(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// noLineStart holds punctuation that must not begin a line.
+const noLineStart = "，。！？、；：」』）》〉】,.!?;:)]}%"
+
+// Wrap breaks text into lines of at most width columns. Latin text breaks at
+// spaces; CJK text, which has none, may break between any two characters
+// except before closing punctuation. A word longer than width gets a line
+// of its own.
+func Wrap(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+
+	emit := func() {
+		if s := strings.TrimSpace(line.String()); s != "" {
+			lines = append(lines, s)
+		}
+		line.Reset()
+		lineWidth = 0
+	}
+
+	for _, tok := range wrapTokens(strings.Join(strings.Fields(text), " ")) {
+		if tok == " " {
+			if lineWidth > 0 {
+				line.WriteString(" ")
+				lineWidth++
+			}
+			continue
+		}
+		w := textWidth(tok)
+		r, _ := utf8.DecodeRuneInString(tok)
+		if lineWidth > 0 && lineWidth+w > width && !strings.ContainsRune(noLineStart, r) {
+			emit()
+		}
+		line.WriteString(tok)
+		lineWidth += w
+	}
+	emit()
+	return lines
+}
+
+// wrapTokens splits text into break units: runs of non-wide characters,
+// single wide characters and single spaces.
+func wrapTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r == ' ':
+			flush()
+			tokens = append(tokens, " ")
+		case isWide(r) || strings.ContainsRune(noLineStart, r) && word.Len() == 0:
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// textWidth returns the display width of s in columns.
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if isWide(r) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// isWide reports whether r is displayed two columns wide.
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) // full-width forms
+}
@@ -0,0 +1,165 @@
+package subtitles
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestExportASS(t *testing.T) {
+	cues := []BilingualCue{
+		{Start: at(1), End: at(3), Original: "Hello there", Translated: "你好"},
+		{Start: at(4), End: at(6), Original: "No translation"},
+	}
+
+	tests := []struct {
+		layout Layout
+		want   []assEvent
+	}{
+		{
+			layout: LayoutStacked,
+			want: []assEvent{
+				{Start: at(1), End: at(3), Style: assStyleOriginal, Text: "{\\rOriginal}Hello there\n{\\rTranslated}你好"},
+				{Start: at(4), End: at(6), Style: assStyleOriginal, Text: "{\\rOriginal}No translation"},
+			},
+		},
+		{
+			layout: LayoutTranslated,
+			want: []assEvent{
+				{Start: at(1), End: at(3), Style: assStyleOriginal, Text: "{\\rTranslated}你好"},
+				{Start: at(4), End: at(6), Style: assStyleOriginal, Text: "{\\rOriginal}No translation"},
+			},
+		},
+		{
+			layout: LayoutOriginal,
+			want: []assEvent{
+				{Start: at(1), End: at(3), Style: assStyleOriginal, Text: "{\\rOriginal}Hello there"},
+				{Start: at(4), End: at(6), Style: assStyleOriginal, Text: "{\\rOriginal}No translation"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.layout), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, cues, ExportOptions{Format: FormatASS, Layout: tt.layout}); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if got := parseASSEvents(t, buf.String()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	cues := []BilingualCue{
+		{Start: at(1), End: at(3), Original: "Hello there", Translated: "你好"},
+		{Start: at(4), End: at(6), Original: "No translation"},
+	}
+	want := []Cue{
+		{Start: at(1), End: at(3), Text: "Hello there\n你好"},
+		{Start: at(4), End: at(6), Text: "No translation"},
+	}
+
+	for _, format := range []Format{FormatSRT, FormatVTT, FormatTTML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, cues, ExportOptions{Format: format, Layout: LayoutStacked}); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			got, err := Parse(buf.Bytes(), format)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip =\n%#v\nwant\n%#v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseExportOptions(t *testing.T) {
+	tests := []struct {
+		format, layout string
+		want           ExportOptions
+		wantErr        bool
+	}{
+		{format: "", layout: "", want: ExportOptions{Format: FormatSRT, Layout: LayoutStacked}},
+		{format: " VTT ", layout: "Translated", want: ExportOptions{Format: FormatVTT, Layout: LayoutTranslated}},
+		{format: "ass", layout: "original", want: ExportOptions{Format: FormatASS, Layout: LayoutOriginal}},
+		{format: "json3", wantErr: true},
+		{format: "srt", layout: "side-by-side", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.layout, func(t *testing.T) {
+			got, err := ParseExportOptions(tt.format, tt.layout)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseExportOptions() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseExportOptions() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseExportOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixTimings(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []BilingualCue
+		want []BilingualCue
+	}{
+		{
+			name: "sorted and merged at the same start",
+			in: []BilingualCue{
+				{Start: at(3), End: at(4), Original: "b"},
+				{Start: at(1), End: at(2), Original: "a", Translated: "甲"},
+				{Start: at(1), End: at(2.5), Original: "c", Translated: "乙"},
+			},
+			want: []BilingualCue{
+				{Start: at(1), End: at(2.5), Original: "a c", Translated: "甲乙"},
+				{Start: at(3), End: at(4), Original: "b"},
+			},
+		},
+		{
+			name: "missing end runs to next cue, overlaps trimmed",
+			in: []BilingualCue{
+				{Start: at(1), End: 0, Original: "a"},
+				{Start: at(3), End: at(6), Original: "b"},
+				{Start: at(5), End: at(6), Original: "c"},
+			},
+			want: []BilingualCue{
+				{Start: at(1), End: at(3), Original: "a"},
+				{Start: at(3), End: at(5), Original: "b"},
+				{Start: at(5), End: at(6), Original: "c"},
+			},
+		},
+		{
+			name: "long cues capped, short cues lengthened",
+			in: []BilingualCue{
+				{Start: at(0), End: at(20), Original: "a"},
+				{Start: at(30), End: at(30.1), Original: "b"},
+			},
+			want: []BilingualCue{
+				{Start: at(0), End: at(8), Original: "a"},
+				{Start: at(30), End: at(30.7), Original: "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FixTimings(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FixTimings() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
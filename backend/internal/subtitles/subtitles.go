@@ -0,0 +1,287 @@
+// Package subtitles reads and writes caption files. Every format is parsed
+// into the same Cue model, so callers never deal with format quirks such as
+// double-encoded entities, inline styling or YouTube's rolling auto-captions.
+//
+// Supported formats: SRT, WebVTT and TTML (read and write), ASS (write
+// only), and YouTube's json3, srv1 and srv3 timedtext formats (read only).
+// Export writes bilingual cues for translated videos.
+package subtitles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format names a caption file format.
+type Format string
+
+// Supported formats.
+const (
+	FormatSRT   Format = "srt"
+	FormatVTT   Format = "vtt"
+	FormatTTML  Format = "ttml"
+	FormatJSON3 Format = "json3"
+	FormatSrv1  Format = "srv1"
+	FormatSrv3  Format = "srv3"
+	FormatASS   Format = "ass"
+)
+
+// defaultCueDuration is used for cues without an end time when the next
+// cue's start cannot be used instead.
+const defaultCueDuration = 2 * time.Second
+
+// ErrNoCues is returned when a file parses but contains no caption text.
+var ErrNoCues = errors.New("subtitles: no cues found")
+
+// Cue is one caption shown from Start to End. Text may span several lines,
+// separated by "\n", and contains no markup.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// PlainText returns the cue's text on a single line.
+func (c Cue) PlainText() string {
+	return strings.Join(strings.Fields(c.Text), " ")
+}
+
+var (
+	markupTag     = regexp.MustCompile(`<[^>]*>`)
+	spaceRun      = regexp.MustCompile(`[ \t\f\v\r]+`)
+	directionMark = strings.NewReplacer("\u200e", "", "\u200f", "")
+)
+
+// Parse reads a caption file in the given format. An empty format is
+// detected from the content.
+func Parse(data []byte, format Format) ([]Cue, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+
+	switch format {
+	case FormatSRT:
+		return ParseSRT(data)
+	case FormatVTT:
+		return ParseVTT(data)
+	case FormatTTML:
+		return ParseTTML(data)
+	case FormatJSON3:
+		return ParseJSON3(data)
+	case FormatSrv1:
+		return ParseSrv1(data)
+	case FormatSrv3:
+		return ParseSrv3(data)
+	default:
+		return nil, fmt.Errorf("subtitles: unsupported format %q", format)
+	}
+}
+
+// Detect guesses the format of a caption file from its content. It returns
+// "" if the content matches no supported format.
+func Detect(data []byte) Format {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	head = bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\ufeff")))
+
+	switch {
+	case bytes.HasPrefix(head, []byte("{")):
+		return FormatJSON3
+	case bytes.HasPrefix(head, []byte("WEBVTT")):
+		return FormatVTT
+	case bytes.Contains(head, []byte("<tt")):
+		return FormatTTML
+	case bytes.Contains(head, []byte("<timedtext")):
+		return FormatSrv3
+	case bytes.Contains(head, []byte("<transcript")):
+		return FormatSrv1
+	case bytes.Contains(head, []byte("-->")):
+		return FormatSRT
+	}
+	return ""
+}
+
+// Write encodes cues in the given format.
+func Write(w io.Writer, format Format, cues []Cue) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, cues)
+	case FormatVTT:
+		return WriteVTT(w, cues)
+	case FormatTTML:
+		return WriteTTML(w, cues)
+	case FormatASS:
+		return WriteASS(w, cues)
+	default:
+		return fmt.Errorf("subtitles: cannot write format %q", format)
+	}
+}
+
+// cleanText removes markup, decodes entities (twice, as YouTube often
+// double-encodes them) and normalizes whitespace, keeping line breaks.
+func cleanText(text string) string {
+	text = markupTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(html.UnescapeString(text))
+	text = directionMark.Replace(text)
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(spaceRun.ReplaceAllString(line, " ")); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// finish sorts cues by start time, fills in missing end times and drops
+// empty cues. Parsers mark a missing end time with End < Start. rolling is
+// set by formats that carry YouTube's rolling auto-captions, whose repeats
+// are then removed; other formats keep cues that repeat text as they are.
+func finish(cues []Cue, rolling bool) ([]Cue, error) {
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+
+	kept := cues[:0]
+	for i, c := range cues {
+		if c.Text == "" {
+			continue
+		}
+		if c.End < c.Start {
+			c.End = c.Start + defaultCueDuration
+			if i+1 < len(cues) && cues[i+1].Start > c.Start {
+				c.End = cues[i+1].Start
+			}
+		}
+		kept = append(kept, c)
+	}
+
+	if rolling {
+		kept = DedupeRolling(kept)
+	}
+	if len(kept) == 0 {
+		return nil, ErrNoCues
+	}
+	return kept, nil
+}
+
+// DedupeRolling removes the repetition in "rolling" captions, where each cue
+// repeats the line(s) shown by the previous cue and adds one new line below,
+// as YouTube's auto-generated WebVTT does. Lines carried over from the
+// previous cue are dropped; a cue that only repeats earlier text extends the
+// previous cue instead of being kept. Only a cue that overlaps or touches the
+// previous one can repeat it, so separate cues with the same text, such as a
+// chorus sung twice, are kept. Cues without repeats pass unchanged.
+func DedupeRolling(cues []Cue) []Cue {
+	out := make([]Cue, 0, len(cues))
+	var prev []string
+	var prevEnd time.Duration
+	for i, c := range cues {
+		lines := strings.Split(c.Text, "\n")
+		fresh := lines
+		if i > 0 && c.Start <= prevEnd {
+			fresh = lines[overlap(prev, lines):]
+		}
+		prev, prevEnd = lines, c.End
+
+		if len(fresh) == 0 {
+			if n := len(out); n > 0 && c.End > out[n-1].End {
+				out[n-1].End = c.End
+			}
+			continue
+		}
+		c.Text = strings.Join(fresh, "\n")
+		out = append(out, c)
+	}
+	return out
+}
+
+// overlap returns the largest k such that the last k lines of prev equal the
+// first k lines of next.
+func overlap(prev, next []string) int {
+	max := len(prev)
+	if len(next) < max {
+		max = len(next)
+	}
+	for k := max; k > 0; k-- {
+		match := true
+		for i := 0; i < k; i++ {
+			if prev[len(prev)-k+i] != next[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k
+		}
+	}
+	return 0
+}
+
+// splitClock splits "HH:MM:SS", "MM:SS" or "HH:MM:SS:FF" into its numeric
+// fields.
+func splitClock(s string) ([]int, bool) {
+	parts := strings.Split(s, ":")
+	fields := make([]int, len(parts))
+	for i, p := range parts {
+		n := 0
+		if p == "" {
+			return nil, false
+		}
+		for _, r := range p {
+			if r < '0' || r > '9' {
+				return nil, false
+			}
+			n = n*10 + int(r-'0')
+		}
+		fields[i] = n
+	}
+	return fields, true
+}
+
+// ParseTimestamp parses "HH:MM:SS.mmm" or "MM:SS.mmm", with "." or "," before
+// the fraction. The fraction is optional.
+func ParseTimestamp(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	clock, frac, _ := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+
+	fields, ok := splitClock(clock)
+	if !ok || len(fields) < 2 || len(fields) > 3 {
+		return 0, false
+	}
+	var seconds int
+	for _, f := range fields {
+		seconds = seconds*60 + f
+	}
+
+	d := time.Duration(seconds) * time.Second
+	if frac != "" {
+		// Scale to milliseconds regardless of the number of digits
+		for len(frac) < 3 {
+			frac += "0"
+		}
+		ms, ok := splitClock(frac[:3])
+		if !ok {
+			return 0, false
+		}
+		d += time.Duration(ms[0]) * time.Millisecond
+	}
+	return d, true
+}
+
+// formatClockTime formats d as "HH:MM:SS<sep>mmm".
+func formatClockTime(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,288 @@
+package subtitles
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a cue time given in seconds.
+func at(s float64) time.Duration {
+	return seconds(s)
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		format Format
+		want   []Cue
+	}{
+		{
+			file:   "basic.srt",
+			format: FormatSRT,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "Hello, world!"},
+				{Start: at(3), End: at(5), Text: "Tom & Jerry\nsecond line"},
+				// Touching cues with the same text are separate lines, not
+				// rolling captions
+				{Start: at(5), End: at(6), Text: "la la"},
+				{Start: at(6), End: at(7), Text: "la la"},
+			},
+		},
+		{
+			file:   "basic.vtt",
+			format: FormatVTT,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "Hello, world!"},
+				{Start: at(3), End: at(5), Text: "Tom & Jerry\nsecond line"},
+				{Start: at(7), End: at(8), Text: "la la"},
+				{Start: at(9), End: at(10), Text: "la la"},
+			},
+		},
+		{
+			file:   "youtube_auto.vtt",
+			format: FormatVTT,
+			want: []Cue{
+				{Start: at(0), End: at(2.32), Text: "hello everyone welcome"},
+				{Start: at(2.32), End: at(5.12), Text: "to the show"},
+				{Start: at(5.12), End: at(7), Text: "thanks for watching"},
+			},
+		},
+		{
+			file:   "basic.ttml",
+			format: FormatTTML,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "Hello, world!"},
+				{Start: at(3), End: at(5), Text: "Tom & Jerry\nsecond line"},
+				{Start: at(5.5), End: at(6.5), Text: "la la"},
+				{Start: at(7.5), End: at(8), Text: "frames"},
+			},
+		},
+		{
+			file:   "basic.json3",
+			format: FormatJSON3,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "hello everyone"},
+				{Start: at(3), End: at(5), Text: "Tom & Jerry"},
+				{Start: at(6), End: at(8), Text: "bye"},
+			},
+		},
+		{
+			file:   "basic.srv1",
+			format: FormatSrv1,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "Hello, world!"},
+				{Start: at(3.04), End: at(5.04), Text: "Tom & Jerry"},
+				{Start: at(5.5), End: at(7), Text: "I'm here"},
+				{Start: at(7), End: at(8), Text: "la la"},
+				{Start: at(8), End: at(9), Text: "la la"},
+			},
+		},
+		{
+			file:   "basic.srv3",
+			format: FormatSrv3,
+			want: []Cue{
+				{Start: at(1), End: at(2.5), Text: "hello everyone"},
+				{Start: at(3), End: at(5), Text: "Tom & Jerry"},
+				{Start: at(6), End: at(8), Text: "bye"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data := readFixture(t, tt.file)
+			if got := Detect(data); got != tt.format {
+				t.Errorf("Detect() = %q, want %q", got, tt.format)
+			}
+
+			cues, err := Parse(data, "")
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if !reflect.DeepEqual(cues, tt.want) {
+				t.Errorf("Parse() =\n%#v\nwant\n%#v", cues, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  Format
+		wantErr error
+	}{
+		{name: "srt without text", data: "1\n00:00:01,000 --> 00:00:02,000\n\n", format: FormatSRT, wantErr: ErrNoCues},
+		{name: "srt bad timing", data: "1\n00:00:xx,000 --> 00:00:02,000\nhi\n", format: FormatSRT},
+		{name: "json3 malformed", data: `{"events": [`, format: FormatJSON3},
+		{name: "unknown format", data: "hello", format: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), tt.format)
+			if err == nil {
+				t.Fatal("Parse() succeeded, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// roundTripCues exercises multi-line text, characters the formats escape
+// and timestamps past an hour.
+var roundTripCues = []Cue{
+	{Start: at(1), End: at(2.5), Text: "Hello, world!"},
+	{Start: at(3), End: at(5.25), Text: "Tom & Jerry\nsecond line"},
+	{Start: at(3723.456), End: at(3725), Text: "x < y"},
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatSRT, FormatVTT, FormatTTML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, roundTripCues); err != nil {
+				t.Fatalf("Write() error: %v", err)
+			}
+			if got := Detect(buf.Bytes()); got != format {
+				t.Errorf("Detect() = %q, want %q", got, format)
+			}
+
+			cues, err := Parse(buf.Bytes(), format)
+			if err != nil {
+				t.Fatalf("Parse() error: %v\n%s", err, buf.String())
+			}
+			if !reflect.DeepEqual(cues, roundTripCues) {
+				t.Errorf("round trip =\n%#v\nwant\n%#v", cues, roundTripCues)
+			}
+		})
+	}
+}
+
+func TestWriteASS(t *testing.T) {
+	cues := append(roundTripCues, Cue{Start: at(3726), End: at(3727), Text: `{\b1}bold\`})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatASS, cues); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "[Script Info]\n") {
+		t.Errorf("script does not start with [Script Info]:\n%s", buf.String())
+	}
+
+	// ASS keeps centiseconds; braces would start override tags
+	want := []assEvent{
+		{Start: at(1), End: at(2.5), Style: assStyleDefault, Text: "Hello, world!"},
+		{Start: at(3), End: at(5.25), Style: assStyleDefault, Text: "Tom & Jerry\nsecond line"},
+		{Start: at(3723.45), End: at(3725), Style: assStyleDefault, Text: "x < y"},
+		{Start: at(3726), End: at(3727), Style: assStyleDefault, Text: `(\\b1)bold\\`},
+	}
+	if got := parseASSEvents(t, buf.String()); !reflect.DeepEqual(got, want) {
+		t.Errorf("events =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+// assEvent is a Dialogue line read back from an ASS script.
+type assEvent struct {
+	Start, End time.Duration
+	Style      string
+	Text       string
+}
+
+// parseASSEvents reads the Dialogue lines of an ASS script, turning hard
+// line breaks back into "\n".
+func parseASSEvents(t *testing.T, script string) []assEvent {
+	t.Helper()
+	var events []assEvent
+	for _, line := range strings.Split(script, "\n") {
+		rest, ok := strings.CutPrefix(line, "Dialogue: ")
+		if !ok {
+			continue
+		}
+		fields := strings.SplitN(rest, ",", 10)
+		if len(fields) != 10 {
+			t.Fatalf("malformed Dialogue line %q", line)
+		}
+		start, okStart := ParseTimestamp(fields[1])
+		end, okEnd := ParseTimestamp(fields[2])
+		if !okStart || !okEnd {
+			t.Fatalf("malformed Dialogue timing %q", line)
+		}
+		events = append(events, assEvent{
+			Start: start,
+			End:   end,
+			Style: fields[3],
+			Text:  strings.ReplaceAll(fields[9], `\N`, "\n"),
+		})
+	}
+	return events
+}
+
+func TestDedupeRolling(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []Cue
+		want []Cue
+	}{
+		{
+			name: "rolling lines",
+			in: []Cue{
+				{Start: at(0), End: at(2), Text: "one"},
+				{Start: at(2), End: at(4), Text: "one\ntwo"},
+				{Start: at(4), End: at(6), Text: "two\nthree"},
+			},
+			want: []Cue{
+				{Start: at(0), End: at(2), Text: "one"},
+				{Start: at(2), End: at(4), Text: "two"},
+				{Start: at(4), End: at(6), Text: "three"},
+			},
+		},
+		{
+			name: "pure repeat extends previous cue",
+			in: []Cue{
+				{Start: at(0), End: at(2), Text: "one"},
+				{Start: at(1.5), End: at(3), Text: "one"},
+			},
+			want: []Cue{
+				{Start: at(0), End: at(3), Text: "one"},
+			},
+		},
+		{
+			name: "separate repeats are kept",
+			in: []Cue{
+				{Start: at(1), End: at(2), Text: "la la"},
+				{Start: at(3), End: at(4), Text: "la la"},
+			},
+			want: []Cue{
+				{Start: at(1), End: at(2), Text: "la la"},
+				{Start: at(3), End: at(4), Text: "la la"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DedupeRolling(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DedupeRolling() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package subtitles
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ttmlLineBreak matches <br/>, with or without a namespace prefix.
+var ttmlLineBreak = regexp.MustCompile(`<(?:\w+:)?br\s*/?>`)
+
+// ttmlOffsetTime matches TTML offset times such as "1.5s", "250ms" or "40t".
+var ttmlOffsetTime = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|m|s|ms|f|t)$`)
+
+// ParseTTML parses TTML (and DFXP) captions. Only <p> elements are read;
+// timing is taken from their begin, end and dur attributes.
+func ParseTTML(data []byte) ([]Cue, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	tickRate, frameRate := 1.0, 30.0
+	var cues []Cue
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("subtitles: invalid ttml: %w", err)
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "tt":
+			for _, a := range se.Attr {
+				switch a.Name.Local {
+				case "tickRate":
+					if v, err := strconv.ParseFloat(a.Value, 64); err == nil && v > 0 {
+						tickRate = v
+					}
+				case "frameRate":
+					if v, err := strconv.ParseFloat(a.Value, 64); err == nil && v > 0 {
+						frameRate = v
+					}
+				}
+			}
+		case "p":
+			var p struct {
+				Begin string `xml:"begin,attr"`
+				End   string `xml:"end,attr"`
+				Dur   string `xml:"dur,attr"`
+				Inner string `xml:",innerxml"`
+			}
+			if err := d.DecodeElement(&p, &se); err != nil {
+				return nil, fmt.Errorf("subtitles: invalid ttml paragraph: %w", err)
+			}
+			start, ok := parseTTMLTime(p.Begin, tickRate, frameRate)
+			if !ok {
+				continue
+			}
+			c := Cue{Start: start, End: -1}
+			if end, ok := parseTTMLTime(p.End, tickRate, frameRate); ok {
+				c.End = end
+			} else if dur, ok := parseTTMLTime(p.Dur, tickRate, frameRate); ok {
+				c.End = start + dur
+			}
+			c.Text = cleanText(ttmlLineBreak.ReplaceAllString(p.Inner, "\n"))
+			cues = append(cues, c)
+		}
+	}
+	return finish(cues, false)
+}
+
+// parseTTMLTime parses a TTML clock time ("00:00:01.500", "00:00:01:12"
+// with frames) or offset time ("1.5s", "1500ms", "15000000t").
+func parseTTMLTime(s string, tickRate, frameRate float64) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+
+	if m := ttmlOffsetTime.FindStringSubmatch(s); m != nil {
+		v, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "h":
+			v *= 3600
+		case "m":
+			v *= 60
+		case "ms":
+			v /= 1000
+		case "f":
+			v /= frameRate
+		case "t":
+			v /= tickRate
+		}
+		return seconds(v), true
+	}
+
+	// HH:MM:SS:FF
+	if fields, ok := splitClock(s); ok && len(fields) == 4 {
+		secs := float64(fields[0]*3600+fields[1]*60+fields[2]) + float64(fields[3])/frameRate
+		return seconds(secs), true
+	}
+	return ParseTimestamp(s)
+}
+
+// WriteTTML encodes cues as a minimal TTML document.
+func WriteTTML(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml">` + "\n")
+	bw.WriteString("  <body>\n    <div>\n")
+	for _, c := range cues {
+		fmt.Fprintf(bw, `      <p begin="%s" end="%s">`, formatClockTime(c.Start, "."), formatClockTime(c.End, "."))
+		for i, line := range strings.Split(c.Text, "\n") {
+			if i > 0 {
+				bw.WriteString("<br/>")
+			}
+			if err := xml.EscapeText(bw, []byte(line)); err != nil {
+				return err
+			}
+		}
+		bw.WriteString("</p>\n")
+	}
+	bw.WriteString("    </div>\n  </body>\n</tt>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,119 @@
+package subtitles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseJSON3 parses YouTube's json3 timedtext format.
+func ParseJSON3(data []byte) ([]Cue, error) {
+	var doc struct {
+		Events []struct {
+			TStartMs    int64 `json:"tStartMs"`
+			DDurationMs int64 `json:"dDurationMs"`
+			Segs        []struct {
+				Utf8 string `json:"utf8"`
+			} `json:"segs"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &doc); err != nil {
+		return nil, fmt.Errorf("subtitles: invalid json3: %w", err)
+	}
+
+	cues := make([]Cue, 0, len(doc.Events))
+	for _, ev := range doc.Events {
+		var text strings.Builder
+		for _, seg := range ev.Segs {
+			text.WriteString(seg.Utf8)
+		}
+		start := time.Duration(ev.TStartMs) * time.Millisecond
+		end := start + time.Duration(ev.DDurationMs)*time.Millisecond
+		if ev.DDurationMs <= 0 {
+			end = -1
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: cleanText(text.String())})
+	}
+	return finish(cues, true)
+}
+
+// ParseSrv1 parses YouTube's srv1 timedtext format, the default of the
+// timedtext endpoint:
+//
+//	<transcript><text start="1.23" dur="4.5">...</text></transcript>
+func ParseSrv1(data []byte) ([]Cue, error) {
+	var doc struct {
+		Texts []struct {
+			Start string `xml:"start,attr"`
+			Dur   string `xml:"dur,attr"`
+			Text  string `xml:",chardata"`
+		} `xml:"text"`
+	}
+	if err := decodeXML(data, &doc); err != nil {
+		return nil, fmt.Errorf("subtitles: invalid srv1: %w", err)
+	}
+
+	cues := make([]Cue, 0, len(doc.Texts))
+	for _, t := range doc.Texts {
+		start, err := strconv.ParseFloat(t.Start, 64)
+		if err != nil {
+			continue
+		}
+		c := Cue{Start: seconds(start), End: -1, Text: cleanText(t.Text)}
+		if dur, err := strconv.ParseFloat(t.Dur, 64); err == nil {
+			c.End = c.Start + seconds(dur)
+		}
+		cues = append(cues, c)
+	}
+	return finish(cues, false)
+}
+
+// ParseSrv3 parses YouTube's srv3 timedtext format:
+//
+//	<timedtext format="3"><body><p t="1230" d="4500"><s>...</s></p></body></timedtext>
+func ParseSrv3(data []byte) ([]Cue, error) {
+	var doc struct {
+		Paragraphs []struct {
+			T     string `xml:"t,attr"`
+			D     string `xml:"d,attr"`
+			Inner string `xml:",innerxml"`
+		} `xml:"body>p"`
+	}
+	if err := decodeXML(data, &doc); err != nil {
+		return nil, fmt.Errorf("subtitles: invalid srv3: %w", err)
+	}
+
+	cues := make([]Cue, 0, len(doc.Paragraphs))
+	for _, p := range doc.Paragraphs {
+		start, err := strconv.ParseInt(p.T, 10, 64)
+		if err != nil {
+			continue
+		}
+		c := Cue{Start: time.Duration(start) * time.Millisecond, End: -1, Text: cleanText(p.Inner)}
+		if dur, err := strconv.ParseInt(p.D, 10, 64); err == nil {
+			c.End = c.Start + time.Duration(dur)*time.Millisecond
+		}
+		cues = append(cues, c)
+	}
+	return finish(cues, true)
+}
+
+// decodeXML unmarshals caption XML leniently: HTML entities such as &nbsp;
+// are accepted and unknown elements are ignored.
+func decodeXML(data []byte, v interface{}) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	d.AutoClose = xml.HTMLAutoClose
+	return d.Decode(v)
+}
+
+// seconds converts fractional seconds to a Duration, rounded to the
+// millisecond.
+func seconds(s float64) time.Duration {
+	return time.Duration(s*1000+0.5) * time.Millisecond
+}
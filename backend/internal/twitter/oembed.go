@@ -0,0 +1,142 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultOEmbedURL = "https://publish.twitter.com/oembed"
+
+// oEmbedFetcher reads the embed HTML of a tweet. It only sees the text and
+// author of one tweet, so threads stop at the requested tweet.
+type oEmbedFetcher struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newOEmbedFetcher(baseURL string, timeout time.Duration) *oEmbedFetcher {
+	if baseURL == "" {
+		baseURL = defaultOEmbedURL
+	}
+	return &oEmbedFetcher{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type oEmbedResponse struct {
+	URL        string `json:"url"`
+	AuthorName string `json:"author_name"`
+	AuthorURL  string `json:"author_url"`
+	HTML       string `json:"html"`
+}
+
+var (
+	oEmbedParagraph = regexp.MustCompile(`(?s)<p[^>]*>(.*?)</p>`)
+	oEmbedDate      = regexp.MustCompile(`<a [^>]*>([A-Z][a-z]+ \d{1,2}, \d{4})</a>\s*</blockquote>`)
+	oEmbedLink      = regexp.MustCompile(`(?s)<a [^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	oEmbedBreak     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	oEmbedTag       = regexp.MustCompile(`<[^>]+>`)
+)
+
+// Name implements Fetcher.
+func (o *oEmbedFetcher) Name() string {
+	return BackendOEmbed
+}
+
+// Tweet implements Fetcher.
+func (o *oEmbedFetcher) Tweet(ctx context.Context, id string) (*Tweet, error) {
+	if !isTweetID(id) {
+		return nil, ErrNotFound
+	}
+	query := url.Values{}
+	query.Set("url", StatusURL("", id))
+	query.Set("omit_script", "true")
+	query.Set("dnt", "true")
+
+	endpoint := o.baseURL
+	if strings.Contains(endpoint, "?") {
+		endpoint += "&" + query.Encode()
+	} else {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oembed request: %w", err)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oembed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oembed response: %w", err)
+	}
+	// Deleted, private and suspended tweets answer 403 or 404
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oembed returned status %d", resp.StatusCode)
+	}
+
+	var raw oEmbedResponse
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse oembed response: %w", err)
+	}
+
+	handle := ""
+	if u, err := url.Parse(raw.AuthorURL); err == nil {
+		handle = strings.Trim(u.Path, "/")
+	}
+	t := &Tweet{
+		ID:           id,
+		URL:          StatusURL(handle, id),
+		AuthorName:   raw.AuthorName,
+		AuthorHandle: handle,
+	}
+	if m := oEmbedParagraph.FindStringSubmatch(raw.HTML); m != nil {
+		t.Text = oEmbedText(m[1])
+	}
+	if m := oEmbedDate.FindStringSubmatch(raw.HTML); m != nil {
+		if createdAt, err := time.Parse("January 2, 2006", m[1]); err == nil {
+			t.CreatedAt = createdAt
+		}
+	}
+	return t, nil
+}
+
+// AuthorReplyIDs implements Fetcher.
+func (o *oEmbedFetcher) AuthorReplyIDs(ctx context.Context, tweet *Tweet) ([]string, error) {
+	return nil, ErrRepliesUnavailable
+}
+
+// oEmbedText turns the embed paragraph into plain text. Shortened link
+// labels ending in "…" are replaced by their target; media links are dropped.
+func oEmbedText(fragment string) string {
+	fragment = oEmbedBreak.ReplaceAllString(fragment, "\n")
+	fragment = oEmbedLink.ReplaceAllStringFunc(fragment, func(link string) string {
+		m := oEmbedLink.FindStringSubmatch(link)
+		label := oEmbedTag.ReplaceAllString(m[2], "")
+		switch plain := html.UnescapeString(label); {
+		case strings.HasPrefix(plain, "pic.twitter.com/"):
+			return ""
+		case strings.HasSuffix(plain, "…"):
+			return m[1]
+		default:
+			return label
+		}
+	})
+	return strings.TrimSpace(html.UnescapeString(oEmbedTag.ReplaceAllString(fragment, "")))
+}
@@ -0,0 +1,266 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSyndicationURL = "https://cdn.syndication.twimg.com"
+	defaultTimelineURL    = "https://syndication.twitter.com"
+)
+
+// syndicationFetcher reads the JSON that powers embedded tweets, and the
+// profile timeline embed to find a thread's continuation.
+type syndicationFetcher struct {
+	baseURL     string
+	timelineURL string
+	httpClient  *http.Client
+}
+
+func newSyndicationFetcher(baseURL, timelineURL string, timeout time.Duration) *syndicationFetcher {
+	if baseURL == "" {
+		baseURL = defaultSyndicationURL
+	}
+	if timelineURL == "" {
+		timelineURL = defaultTimelineURL
+	}
+	return &syndicationFetcher{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		timelineURL: strings.TrimRight(timelineURL, "/"),
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// syndicationTweet is the tweet-result response; parents and quoted tweets
+// share its shape.
+type syndicationTweet struct {
+	Typename          string `json:"__typename"`
+	IDStr             string `json:"id_str"`
+	Text              string `json:"text"`
+	CreatedAt         string `json:"created_at"`
+	DisplayTextRange  []int  `json:"display_text_range"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+	InReplyToUser     string `json:"in_reply_to_screen_name"`
+	User              struct {
+		Name            string `json:"name"`
+		ScreenName      string `json:"screen_name"`
+		ProfileImageURL string `json:"profile_image_url_https"`
+	} `json:"user"`
+	Entities struct {
+		URLs  []syndicationURL `json:"urls"`
+		Media []syndicationURL `json:"media"`
+	} `json:"entities"`
+	MediaDetails []struct {
+		Type          string `json:"type"`
+		MediaURLHTTPS string `json:"media_url_https"`
+		ExpandedURL   string `json:"expanded_url"`
+	} `json:"mediaDetails"`
+	QuotedTweet *syndicationTweet `json:"quoted_tweet"`
+}
+
+type syndicationURL struct {
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url"`
+}
+
+// Name implements Fetcher.
+func (s *syndicationFetcher) Name() string {
+	return BackendSyndication
+}
+
+// Tweet implements Fetcher.
+func (s *syndicationFetcher) Tweet(ctx context.Context, id string) (*Tweet, error) {
+	if !isTweetID(id) {
+		return nil, ErrNotFound
+	}
+	query := url.Values{}
+	query.Set("id", id)
+	query.Set("lang", "en")
+	query.Set("token", syndicationToken(id))
+
+	data, err := s.get(ctx, s.baseURL+"/tweet-result?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var raw syndicationTweet
+	if len(data) == 0 || string(data) == "{}" {
+		return nil, ErrNotFound
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse tweet %s: %w", id, err)
+	}
+	if raw.Typename == "TweetTombstone" || raw.IDStr == "" {
+		return nil, ErrNotFound
+	}
+	return raw.tweet(), nil
+}
+
+// AuthorReplyIDs implements Fetcher. The timeline embed only covers the
+// author's recent tweets, so older threads end where it stops.
+func (s *syndicationFetcher) AuthorReplyIDs(ctx context.Context, tweet *Tweet) ([]string, error) {
+	if tweet.AuthorHandle == "" {
+		return nil, ErrRepliesUnavailable
+	}
+	page, err := s.get(ctx, s.timelineURL+"/srv/timeline-profile/screen-name/"+url.PathEscape(tweet.AuthorHandle))
+	if err != nil {
+		return nil, err
+	}
+
+	match := nextDataPattern.FindSubmatch(page)
+	if match == nil {
+		return nil, fmt.Errorf("timeline of @%s has no tweet data", tweet.AuthorHandle)
+	}
+	var timeline struct {
+		Props struct {
+			PageProps struct {
+				Timeline struct {
+					Entries []struct {
+						Content struct {
+							Tweet *struct {
+								IDStr             string `json:"id_str"`
+								InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+								User              struct {
+									ScreenName string `json:"screen_name"`
+								} `json:"user"`
+							} `json:"tweet"`
+						} `json:"content"`
+					} `json:"entries"`
+				} `json:"timeline"`
+			} `json:"pageProps"`
+		} `json:"props"`
+	}
+	if err := json.Unmarshal(match[1], &timeline); err != nil {
+		return nil, fmt.Errorf("failed to parse timeline of @%s: %w", tweet.AuthorHandle, err)
+	}
+
+	var ids []string
+	for _, entry := range timeline.Props.PageProps.Timeline.Entries {
+		t := entry.Content.Tweet
+		if t == nil || t.InReplyToStatusID != tweet.ID ||
+			!strings.EqualFold(t.User.ScreenName, tweet.AuthorHandle) {
+			continue
+		}
+		ids = append(ids, t.IDStr)
+	}
+	// Tweet IDs grow with time; equal-length IDs sort numerically as strings
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
+
+var nextDataPattern = regexp.MustCompile(`(?s)<script id="__NEXT_DATA__" type="application/json">(.*?)</script>`)
+
+func (s *syndicationFetcher) get(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create twitter request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; vibe-backend)")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("twitter request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read twitter response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("twitter returned status %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// tweet converts the response into a Tweet.
+func (raw *syndicationTweet) tweet() *Tweet {
+	t := &Tweet{
+		ID:              raw.IDStr,
+		URL:             StatusURL(raw.User.ScreenName, raw.IDStr),
+		AuthorName:      raw.User.Name,
+		AuthorHandle:    raw.User.ScreenName,
+		AuthorAvatar:    strings.Replace(raw.User.ProfileImageURL, "_normal.", "_400x400.", 1),
+		Text:            raw.text(),
+		InReplyToID:     raw.InReplyToStatusID,
+		InReplyToHandle: raw.InReplyToUser,
+	}
+	if createdAt, err := time.Parse(time.RFC3339, raw.CreatedAt); err == nil {
+		t.CreatedAt = createdAt
+	}
+	for _, m := range raw.MediaDetails {
+		media := Media{Type: m.Type, URL: m.MediaURLHTTPS, ThumbnailURL: m.MediaURLHTTPS}
+		if m.Type != "photo" {
+			// Videos and GIFs have a poster image, not a playable URL
+			media.URL = m.ExpandedURL
+		}
+		t.Media = append(t.Media, media)
+	}
+	if raw.QuotedTweet != nil && raw.QuotedTweet.IDStr != "" {
+		t.Quoted = raw.QuotedTweet.tweet()
+	}
+	return t
+}
+
+// text returns the displayed text: without the leading reply mentions and
+// trailing media link, and with t.co links expanded.
+func (raw *syndicationTweet) text() string {
+	text := raw.Text
+	if r := raw.DisplayTextRange; len(r) == 2 {
+		runes := []rune(text)
+		if 0 <= r[0] && r[0] <= r[1] && r[1] <= len(runes) {
+			text = string(runes[r[0]:r[1]])
+		}
+	}
+	for _, u := range raw.Entities.Media {
+		text = strings.ReplaceAll(text, u.URL, "")
+	}
+	for _, u := range raw.Entities.URLs {
+		if u.URL != "" && u.ExpandedURL != "" {
+			text = strings.ReplaceAll(text, u.URL, u.ExpandedURL)
+		}
+	}
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+// syndicationToken derives the token the tweet-result endpoint expects from
+// the tweet ID: (id / 1e15 * π) in base 36, without zeros and the point.
+func syndicationToken(id string) string {
+	n, err := strconv.ParseFloat(id, 64)
+	if err != nil {
+		return ""
+	}
+	v := n / 1e15 * math.Pi
+	whole := math.Floor(v)
+
+	var b strings.Builder
+	b.WriteString(strconv.FormatInt(int64(whole), 36))
+	frac := v - whole
+	for i := 0; i < 10 && frac > 0; i++ {
+		frac *= 36
+		digit := math.Floor(frac)
+		b.WriteString(strconv.FormatInt(int64(digit), 36))
+		frac -= digit
+	}
+	return strings.ReplaceAll(b.String(), "0", "")
+}
@@ -0,0 +1,308 @@
+// Package twitter fetches tweets from Twitter/X and unrolls the threads their
+// authors wrote, without API credentials.
+//
+// Fetchers wrap one set of public endpoints. The syndication fetcher uses the
+// JSON behind embedded tweets and sees quoted tweets, media and reply chains;
+// the oEmbed fetcher only sees the text of a single tweet. Both take their
+// base URLs from Config so tests can point them at a stub server.
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Supported fetcher names for Config.Backend.
+const (
+	BackendSyndication = "syndication"
+	BackendOEmbed      = "oembed"
+)
+
+// MaxThreadLength bounds the number of tweets Unroll collects.
+const MaxThreadLength = 100
+
+var (
+	// ErrNotFound is returned for tweets that do not exist, were deleted or
+	// are not publicly visible.
+	ErrNotFound = errors.New("tweet not found")
+	// ErrRepliesUnavailable is returned by fetchers that cannot list replies.
+	ErrRepliesUnavailable = errors.New("tweet replies unavailable")
+	// ErrInvalidURL is returned for URLs that do not point at a tweet.
+	ErrInvalidURL = errors.New("not a tweet URL")
+)
+
+// Media is a photo, video or GIF attached to a tweet.
+type Media struct {
+	Type         string `json:"type"` // photo | video | animated_gif
+	URL          string `json:"url"`  // full-size image, or the tweet's media page for videos
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// Tweet is one tweet with the parts of it an insight keeps.
+type Tweet struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	AuthorName   string    `json:"author_name"`
+	AuthorHandle string    `json:"author_handle"` // screen name without "@"
+	AuthorAvatar string    `json:"author_avatar,omitempty"`
+	Text         string    `json:"text"` // links expanded, media links and reply mentions removed
+	CreatedAt    time.Time `json:"created_at"`
+	Media        []Media   `json:"media,omitempty"`
+	Quoted       *Tweet    `json:"quoted,omitempty"`
+
+	InReplyToID     string `json:"in_reply_to_id,omitempty"`
+	InReplyToHandle string `json:"in_reply_to_handle,omitempty"`
+}
+
+// Author formats the tweet's author as "Name (@handle)".
+func (t *Tweet) Author() string {
+	switch {
+	case t.AuthorName == "":
+		return "@" + t.AuthorHandle
+	case t.AuthorHandle == "":
+		return t.AuthorName
+	default:
+		return fmt.Sprintf("%s (@%s)", t.AuthorName, t.AuthorHandle)
+	}
+}
+
+// Thread is a tweet together with the tweets its author chained to it by
+// replying to themselves, oldest first.
+type Thread struct {
+	// Tweets starts at the first tweet of the thread, which is not
+	// necessarily the one that was requested.
+	Tweets []Tweet `json:"tweets"`
+	// Partial is set when the end of the thread could not be followed,
+	// e.g. because the fetcher cannot list replies.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Fetcher reads tweets from one set of endpoints.
+type Fetcher interface {
+	// Name identifies the fetcher in logs and insight versions.
+	Name() string
+	// Tweet fetches a single tweet with its quoted tweet and media.
+	Tweet(ctx context.Context, id string) (*Tweet, error)
+	// AuthorReplyIDs lists the replies the author of tweet posted directly
+	// under it, oldest first. Fetchers that cannot see replies return
+	// ErrRepliesUnavailable.
+	AuthorReplyIDs(ctx context.Context, tweet *Tweet) ([]string, error)
+}
+
+// Config selects and configures a fetcher.
+type Config struct {
+	Backend string
+
+	// Syndication endpoints behind embedded tweets and profile timelines
+	SyndicationURL string
+	TimelineURL    string
+
+	// oEmbed endpoint
+	OEmbedURL string
+
+	// Timeout bounds a single request.
+	Timeout time.Duration
+}
+
+// New builds a Fetcher from configuration. It returns nil when no backend is
+// configured, in which case tweets cannot be processed.
+func New(cfg Config) (Fetcher, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, nil
+	case BackendSyndication:
+		return newSyndicationFetcher(cfg.SyndicationURL, cfg.TimelineURL, cfg.Timeout), nil
+	case BackendOEmbed:
+		return newOEmbedFetcher(cfg.OEmbedURL, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("twitter: unknown backend %q", cfg.Backend)
+	}
+}
+
+// ParseTweetURL extracts the tweet ID from a twitter.com or x.com status URL
+// such as https://x.com/user/status/1234567890.
+func ParseTweetURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "mobile.")
+	if host != "twitter.com" && host != "x.com" {
+		return "", ErrInvalidURL
+	}
+
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i, part := range parts {
+		if (part == "status" || part == "statuses") && i+1 < len(parts) && isTweetID(parts[i+1]) {
+			return parts[i+1], nil
+		}
+	}
+	return "", ErrInvalidURL
+}
+
+// StatusURL returns the canonical URL of a tweet.
+func StatusURL(handle, id string) string {
+	if handle == "" {
+		handle = "i"
+	}
+	return fmt.Sprintf("https://x.com/%s/status/%s", handle, id)
+}
+
+// Unroll fetches the tweet with the given ID and the rest of its author's
+// thread: the self-replies it continues and the self-replies that continue
+// it. Replies to other accounts end the thread in either direction.
+func Unroll(ctx context.Context, f Fetcher, id string) (*Thread, error) {
+	tweet, err := f.Tweet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &Thread{Tweets: []Tweet{*tweet}}
+	seen := map[string]bool{tweet.ID: true}
+
+	// Walk up to the first tweet of the thread
+	for len(thread.Tweets) < MaxThreadLength {
+		first := thread.Tweets[0]
+		if first.InReplyToID == "" || seen[first.InReplyToID] ||
+			!strings.EqualFold(first.InReplyToHandle, first.AuthorHandle) {
+			break
+		}
+		parent, err := f.Tweet(ctx, first.InReplyToID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				// Deleted earlier tweets leave the rest of the thread readable
+				break
+			}
+			return nil, err
+		}
+		seen[parent.ID] = true
+		thread.Tweets = append([]Tweet{*parent}, thread.Tweets...)
+	}
+
+	// Walk down, following the author's earliest reply at each step
+	for {
+		if len(thread.Tweets) >= MaxThreadLength {
+			thread.Partial = true
+			break
+		}
+		last := &thread.Tweets[len(thread.Tweets)-1]
+		ids, err := f.AuthorReplyIDs(ctx, last)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// What has been collected so far is still a readable thread
+			thread.Partial = true
+			break
+		}
+
+		var next *Tweet
+		for _, replyID := range ids {
+			if seen[replyID] {
+				continue
+			}
+			reply, err := f.Tweet(ctx, replyID)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			if strings.EqualFold(reply.AuthorHandle, last.AuthorHandle) {
+				next = reply
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		seen[next.ID] = true
+		thread.Tweets = append(thread.Tweets, *next)
+	}
+
+	return thread, nil
+}
+
+// Text renders the thread as plain text: one paragraph per tweet, with
+// quoted tweets as "> Name (@handle): text" lines after the quoting tweet.
+func (t *Thread) Text() string {
+	var b strings.Builder
+	for _, tweet := range t.Tweets {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(tweet.Text)
+		if q := tweet.Quoted; q != nil && q.Text != "" {
+			b.WriteString("\n\n> ")
+			b.WriteString(q.Author())
+			b.WriteString(": ")
+			b.WriteString(strings.ReplaceAll(q.Text, "\n", "\n> "))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Title returns the first line of the thread, shortened to maxRunes.
+func (t *Thread) Title(maxRunes int) string {
+	for _, tweet := range t.Tweets {
+		line, _, _ := strings.Cut(strings.TrimSpace(tweet.Text), "\n")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxRunes {
+			line = string([]rune(line)[:maxRunes-1]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// Thumbnail returns the first media thumbnail in the thread, including
+// quoted tweets, and falls back to the author's avatar.
+func (t *Thread) Thumbnail() string {
+	for _, tweet := range t.Tweets {
+		for _, m := range tweet.Media {
+			if m.ThumbnailURL != "" {
+				return m.ThumbnailURL
+			}
+		}
+	}
+	for _, tweet := range t.Tweets {
+		if tweet.Quoted == nil {
+			continue
+		}
+		for _, m := range tweet.Quoted.Media {
+			if m.ThumbnailURL != "" {
+				return m.ThumbnailURL
+			}
+		}
+	}
+	if len(t.Tweets) > 0 {
+		return t.Tweets[0].AuthorAvatar
+	}
+	return ""
+}
+
+// isTweetID reports whether s looks like a numeric tweet ID.
+func isTweetID(s string) bool {
+	if s == "" || len(s) > 20 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
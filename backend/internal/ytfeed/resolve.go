@@ -0,0 +1,85 @@
+package ytfeed
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Patterns that give away a channel's ID in its page, in order of trust.
+var channelPagePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[0-9A-Za-z_-]{22})"`),
+	regexp.MustCompile(`<meta itemprop="identifier" content="(UC[0-9A-Za-z_-]{22})"`),
+	regexp.MustCompile(`"externalId":"(UC[0-9A-Za-z_-]{22})"`),
+	regexp.MustCompile(`"channelId":"(UC[0-9A-Za-z_-]{22})"`),
+}
+
+// handlePattern matches a channel handle without its @.
+var handlePattern = regexp.MustCompile(`^[0-9A-Za-z_.-]{3,30}$`)
+
+// ResolveChannel turns a channel reference into a channel ID. It accepts a
+// channel ID, an @handle, or a channel URL: /channel/ID, /@handle, and the
+// legacy /c/name and /user/name forms. Anything but a channel ID is looked
+// up on the channel's page. handle is the @handle the reference named, if
+// any.
+func (c *Client) ResolveChannel(ctx context.Context, ref string) (channelID, handle string, err error) {
+	ref = strings.TrimSpace(ref)
+	if IsChannelID(ref) {
+		return ref, "", nil
+	}
+	if strings.HasPrefix(ref, "@") {
+		handle = strings.TrimPrefix(ref, "@")
+		if !handlePattern.MatchString(handle) {
+			return "", "", ErrInvalidChannel
+		}
+		channelID, err = c.channelIDFromPage(ctx, "/@"+url.PathEscape(handle))
+		return channelID, "@" + handle, err
+	}
+
+	if !strings.Contains(ref, "://") {
+		ref = "https://" + ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", "", ErrInvalidChannel
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), "m.")
+	if host != "youtube.com" {
+		return "", "", ErrInvalidChannel
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	first := segments[0]
+	switch {
+	case strings.HasPrefix(first, "@"):
+		handle = strings.TrimPrefix(first, "@")
+		if !handlePattern.MatchString(handle) {
+			return "", "", ErrInvalidChannel
+		}
+		channelID, err = c.channelIDFromPage(ctx, "/@"+url.PathEscape(handle))
+		return channelID, "@" + handle, err
+	case len(segments) < 2:
+		return "", "", ErrInvalidChannel
+	case first == "channel" && IsChannelID(segments[1]):
+		return segments[1], "", nil
+	case first == "c" || first == "user":
+		channelID, err = c.channelIDFromPage(ctx, "/"+first+"/"+url.PathEscape(segments[1]))
+		return channelID, "", err
+	}
+	return "", "", ErrInvalidChannel
+}
+
+// channelIDFromPage reads the channel ID from a channel page.
+func (c *Client) channelIDFromPage(ctx context.Context, path string) (string, error) {
+	page, err := c.get(ctx, c.baseURL+path)
+	if err != nil {
+		return "", err
+	}
+	for _, pattern := range channelPagePatterns {
+		if m := pattern.FindSubmatch(page); m != nil {
+			return string(m[1]), nil
+		}
+	}
+	return "", ErrChannelNotFound
+}
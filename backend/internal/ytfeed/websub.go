@@ -0,0 +1,87 @@
+package ytfeed
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subscribe asks the hub to push a channel's uploads to callback, or to stop
+// doing so. The hub confirms asynchronously with a GET to callback carrying
+// hub.challenge, which must be echoed back; the subscription lapses after
+// lease unless renewed. Notifications are signed with secret.
+func (c *Client) Subscribe(ctx context.Context, callback, channelID, secret string, lease time.Duration, subscribe bool) error {
+	mode := "unsubscribe"
+	if subscribe {
+		mode = "subscribe"
+	}
+	form := url.Values{
+		"hub.callback": {callback},
+		"hub.topic":    {Topic(channelID)},
+		"hub.mode":     {mode},
+		"hub.verify":   {"async"},
+	}
+	if subscribe {
+		form.Set("hub.secret", secret)
+		form.Set("hub.lease_seconds", strconv.Itoa(int(lease/time.Second)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.hubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("invalid hub URL %q: %w", c.hubURL, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("hub rejected %s (status %d): %s", mode, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// VerifySignature checks a notification's X-Hub-Signature header, such as
+// "sha1=<hex>", against its body and the subscription secret.
+func VerifySignature(body []byte, secret, signature string) bool {
+	algo, digest, ok := strings.Cut(signature, "=")
+	if !ok || secret == "" {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch algo {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	want, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
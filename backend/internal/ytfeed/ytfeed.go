@@ -0,0 +1,270 @@
+// Package ytfeed follows YouTube channels without the Data API: it reads a
+// channel's public Atom feed of recent uploads, resolves @handles and channel
+// URLs to channel IDs, and subscribes to upload notifications through the
+// WebSub (PubSubHubbub) hub YouTube publishes to.
+//
+// The feed lists a channel's 15 most recent videos and carries neither
+// durations nor a reliable Shorts marker; IsShort answers the latter.
+package ytfeed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://www.youtube.com"
+	defaultHubURL  = "https://pubsubhubbub.appspot.com/subscribe"
+	userAgent      = "Mozilla/5.0 (compatible; vibe-backend channel reader)"
+
+	// topicURL is the feed YouTube publishes to the hub. It differs from the
+	// polling URL and must match exactly in hub requests.
+	topicURL = "https://www.youtube.com/xml/feeds/videos.xml?channel_id="
+
+	// maxPageSize bounds feeds and channel pages.
+	maxPageSize = 8 << 20
+)
+
+var (
+	// ErrChannelNotFound is returned for channels that do not exist or have
+	// been terminated.
+	ErrChannelNotFound = errors.New("youtube channel not found")
+	// ErrInvalidChannel is returned for references that are neither a
+	// channel ID, a handle nor a channel URL.
+	ErrInvalidChannel = errors.New("not a youtube channel reference")
+)
+
+// channelIDPattern matches a channel ID.
+var channelIDPattern = regexp.MustCompile(`^UC[0-9A-Za-z_-]{22}$`)
+
+// Video is an entry of a channel feed.
+type Video struct {
+	ID          string    `json:"id"`
+	ChannelID   string    `json:"channel_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url"`
+	Thumbnail   string    `json:"thumbnail,omitempty"`
+	Published   time.Time `json:"published"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Feed is a channel's recent uploads, newest first.
+type Feed struct {
+	ChannelID string  `json:"channel_id"`
+	Title     string  `json:"title"`
+	Videos    []Video `json:"videos"`
+}
+
+// Config configures a Client.
+type Config struct {
+	// Timeout bounds each request. Defaults to 15s.
+	Timeout time.Duration
+	// BaseURL serves feeds, channel pages and Shorts (empty = youtube.com).
+	BaseURL string
+	// HubURL is the WebSub hub (empty = Google's public hub).
+	HubURL string
+}
+
+// Client reads channel feeds and talks to the WebSub hub.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	hubURL     string
+}
+
+// NewClient creates a Client.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	if cfg.HubURL == "" {
+		cfg.HubURL = defaultHubURL
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		hubURL:     cfg.HubURL,
+	}
+}
+
+// IsChannelID reports whether s is a channel ID (UC followed by 22 characters).
+func IsChannelID(s string) bool {
+	return channelIDPattern.MatchString(s)
+}
+
+// Topic returns the WebSub topic URL of a channel's uploads.
+func Topic(channelID string) string {
+	return topicURL + channelID
+}
+
+// Fetch reads a channel's feed.
+func (c *Client) Fetch(ctx context.Context, channelID string) (*Feed, error) {
+	if !IsChannelID(channelID) {
+		return nil, ErrInvalidChannel
+	}
+	data, err := c.get(ctx, c.baseURL+"/feeds/videos.xml?channel_id="+url.QueryEscape(channelID))
+	if err != nil {
+		return nil, err
+	}
+	feed, err := ParseFeed(data)
+	if err != nil {
+		return nil, err
+	}
+	// Some feeds drop the UC prefix from their own channel ID
+	feed.ChannelID = channelID
+	return feed, nil
+}
+
+// atomFeed is the subset of a YouTube Atom feed that Feed carries. Elements
+// are matched by local name; the yt: and media: namespaces add no clashes.
+type atomFeed struct {
+	ChannelID string      `xml:"channelId"`
+	Title     string      `xml:"title"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	VideoID   string     `xml:"videoId"`
+	ChannelID string     `xml:"channelId"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Media     struct {
+		Description string `xml:"description"`
+		Thumbnail   struct {
+			URL string `xml:"url,attr"`
+		} `xml:"thumbnail"`
+	} `xml:"group"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// ParseFeed parses a channel feed, or the body of a hub notification, which
+// has the same format.
+func ParseFeed(data []byte) (*Feed, error) {
+	var doc atomFeed
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid channel feed: %w", err)
+	}
+
+	feed := &Feed{
+		ChannelID: strings.TrimSpace(doc.ChannelID),
+		Title:     strings.TrimSpace(doc.Title),
+		Videos:    make([]Video, 0, len(doc.Entries)),
+	}
+	for _, entry := range doc.Entries {
+		id := strings.TrimSpace(entry.VideoID)
+		if id == "" {
+			continue
+		}
+		video := Video{
+			ID:          id,
+			ChannelID:   strings.TrimSpace(entry.ChannelID),
+			Title:       strings.TrimSpace(entry.Title),
+			Description: strings.TrimSpace(entry.Media.Description),
+			URL:         "https://www.youtube.com/watch?v=" + id,
+			Thumbnail:   entry.Media.Thumbnail.URL,
+			Published:   parseTime(entry.Published),
+			Updated:     parseTime(entry.Updated),
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "alternate" && link.Href != "" {
+				video.URL = link.Href
+			}
+		}
+		feed.Videos = append(feed.Videos, video)
+	}
+	return feed, nil
+}
+
+// IsShort reports whether a video is a Short. The feed links Shorts to their
+// /shorts/ page; otherwise YouTube is asked, as it serves /shorts/ID only for
+// Shorts and redirects every other video to its watch page.
+func (c *Client) IsShort(ctx context.Context, video *Video) (bool, error) {
+	if strings.Contains(video.URL, "/shorts/") {
+		return true, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL+"/shorts/"+url.PathEscape(video.ID), nil)
+	if err != nil {
+		return false, fmt.Errorf("invalid video ID %q: %w", video.ID, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	noRedirect := *c.httpClient
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+}
+
+// get fetches a feed or page.
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrChannelNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	if len(data) > maxPageSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", rawURL, maxPageSize>>20)
+	}
+	return data, nil
+}
+
+// parseTime parses an RFC 3339 timestamp, returning the zero time if it is
+// malformed.
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
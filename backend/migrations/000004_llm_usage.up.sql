@@ -0,0 +1,25 @@
+-- Create llm_usage table for per-user token and cost accounting
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER,
+    insight_id INTEGER REFERENCES insights(id) ON DELETE SET NULL,
+    feature VARCHAR(50) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(200) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_llm_usage_user_created ON llm_usage(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_insight_id ON llm_usage(insight_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_feature ON llm_usage(feature);
+
+-- Add comments
+COMMENT ON TABLE llm_usage IS 'One row per LLM call for token and cost accounting';
+COMMENT ON COLUMN llm_usage.feature IS 'Feature: chat, entities, translate, detect_language, video_metadata, video_analysis, ...';
+COMMENT ON COLUMN llm_usage.estimated IS 'True when token counts were estimated locally because the provider did not report usage';
@@ -0,0 +1,27 @@
+-- Create jobs table for durable background work
+CREATE TABLE IF NOT EXISTS jobs (
+    id SERIAL PRIMARY KEY,
+    queue VARCHAR(50) NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    run_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    dedupe_key VARCHAR(100),
+    locked_by VARCHAR(100),
+    locked_until TIMESTAMPTZ,
+    last_error TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_jobs_claim ON jobs(queue, status, run_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_dedupe_active ON jobs(dedupe_key) WHERE status IN ('queued', 'running');
+
+-- Add comments
+COMMENT ON TABLE jobs IS 'Leased background jobs (insight processing, video analysis, ...)';
+COMMENT ON COLUMN jobs.status IS 'Job status: queued, running, completed, dead';
+COMMENT ON COLUMN jobs.locked_until IS 'Lease expiry; running jobs past this are reclaimed by another worker';
+COMMENT ON COLUMN jobs.dedupe_key IS 'Prevents enqueueing the same work twice while a job is queued or running';
@@ -0,0 +1,20 @@
+-- Create insight_chapters table
+CREATE TABLE IF NOT EXISTS insight_chapters (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    title VARCHAR(500) NOT NULL,
+    summary TEXT,
+    timestamp VARCHAR(20),
+    start_seconds INTEGER NOT NULL,
+    end_seconds INTEGER NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    order_index INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_insight_chapters_insight_id ON insight_chapters(insight_id);
+
+-- Add comments
+COMMENT ON TABLE insight_chapters IS 'Titled sections of an insight with start/end seconds and a short summary';
+COMMENT ON COLUMN insight_chapters.source IS 'Chapter source: description (creator timestamps), generated (LLM topic shifts)';
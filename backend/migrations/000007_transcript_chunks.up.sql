@@ -0,0 +1,19 @@
+-- Create transcript_chunks table
+CREATE TABLE IF NOT EXISTS transcript_chunks (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    start_seconds INTEGER NOT NULL,
+    end_seconds INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    embedding JSONB,
+    embedding_model VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_transcript_chunks_insight_id ON transcript_chunks(insight_id);
+
+-- Add comments
+COMMENT ON TABLE transcript_chunks IS 'Overlapping transcript windows used to ground insight chat answers';
+COMMENT ON COLUMN transcript_chunks.embedding IS 'Embedding vector as a JSON float array, NULL when no embedding provider is configured';
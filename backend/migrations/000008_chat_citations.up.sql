@@ -0,0 +1,4 @@
+-- Store citations of assistant answers
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS citations JSONB;
+
+COMMENT ON COLUMN chat_messages.citations IS 'Array of {type, seconds, timestamp, quote, highlight_id} cited by an assistant answer';
@@ -0,0 +1,17 @@
+-- Create chat_memories table
+CREATE TABLE IF NOT EXISTS chat_memories (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    covered_message_id INTEGER NOT NULL,
+    covered_messages INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_memories_insight_id ON chat_memories(insight_id);
+
+-- Add comments
+COMMENT ON TABLE chat_memories IS 'Rolling summary of older chat turns per insight, replacing them once they exceed the context budget';
+COMMENT ON COLUMN chat_memories.covered_message_id IS 'Last chat_messages.id folded into the summary';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transcript_cache_tracks;
+DROP TABLE IF EXISTS transcript_cache;
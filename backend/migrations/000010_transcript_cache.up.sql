@@ -0,0 +1,34 @@
+-- Create transcript_cache table
+CREATE TABLE IF NOT EXISTS transcript_cache (
+    id SERIAL PRIMARY KEY,
+    video_id VARCHAR(20) NOT NULL UNIQUE,
+    title VARCHAR(500),
+    author VARCHAR(255),
+    channel_id VARCHAR(100),
+    description TEXT,
+    duration INTEGER,
+    provider VARCHAR(50),
+    fetched_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create transcript_cache_tracks table
+CREATE TABLE IF NOT EXISTS transcript_cache_tracks (
+    id SERIAL PRIMARY KEY,
+    video_id VARCHAR(20) NOT NULL REFERENCES transcript_cache(video_id) ON DELETE CASCADE,
+    language VARCHAR(20) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    name VARCHAR(255),
+    position INTEGER NOT NULL DEFAULT 0,
+    segments JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transcript_cache_tracks_video_lang_kind ON transcript_cache_tracks(video_id, language, kind);
+
+-- Add comments
+COMMENT ON TABLE transcript_cache IS 'YouTube transcripts fetched once and shared by all pipelines until the cache TTL passes';
+COMMENT ON COLUMN transcript_cache_tracks.kind IS 'manual (creator captions) or auto (speech recognition)';
+COMMENT ON COLUMN transcript_cache_tracks.segments IS 'Caption segments as a JSON array of {start_ms, end_ms, text}';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS insight_transcripts;
+
+ALTER TABLE insights DROP COLUMN IF EXISTS transcript_kind;
+ALTER TABLE insights DROP COLUMN IF EXISTS transcript_language;
@@ -0,0 +1,23 @@
+-- Track which caption track an insight shows
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS transcript_language VARCHAR(20);
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS transcript_kind VARCHAR(10);
+
+-- Create insight_transcripts table
+CREATE TABLE IF NOT EXISTS insight_transcripts (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    language VARCHAR(20) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    name VARCHAR(255),
+    items JSONB,
+    order_index INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_insight_transcripts_insight_lang_kind ON insight_transcripts(insight_id, language, kind);
+
+-- Add comments
+COMMENT ON TABLE insight_transcripts IS 'Every caption track of an insight''s video, so the insight can switch transcript language';
+COMMENT ON COLUMN insight_transcripts.order_index IS 'Language preference order, 0 = most preferred';
+COMMENT ON COLUMN insight_transcripts.items IS 'Untranslated transcript as a JSON array of {timestamp, seconds, text}';
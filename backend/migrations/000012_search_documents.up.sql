@@ -0,0 +1,24 @@
+-- Create search_documents table
+CREATE TABLE IF NOT EXISTS search_documents (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    segment_index INTEGER NOT NULL DEFAULT 0,
+    seconds INTEGER NOT NULL DEFAULT 0,
+    text TEXT NOT NULL,
+    config VARCHAR(32) NOT NULL,
+    search_vector TSVECTOR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_search_documents_insight_id ON search_documents(insight_id);
+CREATE INDEX IF NOT EXISTS idx_search_documents_user_id ON search_documents(user_id);
+CREATE INDEX IF NOT EXISTS idx_search_documents_vector ON search_documents USING GIN(search_vector);
+
+-- Add comments
+COMMENT ON TABLE search_documents IS 'Full-text index of insight titles, summaries, raw content and transcript items';
+COMMENT ON COLUMN search_documents.kind IS 'Indexed part: title, summary, content, segment or translation';
+COMMENT ON COLUMN search_documents.config IS 'Text search configuration of the document language, simple for Chinese and Japanese';
+COMMENT ON COLUMN search_documents.search_vector IS 'Weighted tsvector; CJK runs are indexed as overlapping bigrams';
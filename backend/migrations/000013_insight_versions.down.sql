@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS insight_versions;
+
+ALTER TABLE highlights DROP COLUMN IF EXISTS version;
+ALTER TABLE insights DROP COLUMN IF EXISTS version_pinned;
+ALTER TABLE insights DROP COLUMN IF EXISTS current_version;
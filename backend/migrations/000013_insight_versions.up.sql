@@ -0,0 +1,43 @@
+-- Track the current processing run of each insight
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS current_version INTEGER DEFAULT 0;
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS version_pinned BOOLEAN DEFAULT FALSE;
+ALTER TABLE highlights ADD COLUMN IF NOT EXISTS version INTEGER DEFAULT 0;
+
+-- Create insight_versions table
+CREATE TABLE IF NOT EXISTS insight_versions (
+    id SERIAL PRIMARY KEY,
+    insight_id INTEGER NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+
+    -- Run metadata
+    processed_at TIMESTAMPTZ,
+    transcript_provider VARCHAR(50),
+    llm_provider VARCHAR(50),
+    model VARCHAR(100),
+
+    -- Artifacts
+    title VARCHAR(500),
+    author VARCHAR(255),
+    thumbnail_url VARCHAR(1000),
+    duration INTEGER DEFAULT 0,
+    summary TEXT,
+    key_points JSONB,
+    target_lang VARCHAR(10),
+    raw_content TEXT,
+    trans_content TEXT,
+    transcripts JSONB,
+    transcript_language VARCHAR(20),
+    transcript_kind VARCHAR(10),
+    chapters JSONB,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_insight_versions_insight_version ON insight_versions(insight_id, version);
+
+-- Add comments
+COMMENT ON TABLE insight_versions IS 'Artifacts of every processing run of an insight, for diff, rollback and pinning';
+COMMENT ON COLUMN insights.current_version IS 'insight_versions.version shown in the insight, 0 = processed before versioning';
+COMMENT ON COLUMN insights.version_pinned IS 'Reprocessing stores a new version but keeps current_version current';
+COMMENT ON COLUMN highlights.version IS 'Insight version whose content the highlight offsets refer to';
@@ -0,0 +1 @@
+ALTER TABLE insights DROP COLUMN IF EXISTS source_meta;
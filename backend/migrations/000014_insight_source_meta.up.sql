@@ -0,0 +1,4 @@
+-- Source-specific details such as the tweets of a Twitter/X thread
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS source_meta JSONB;
+
+COMMENT ON COLUMN insights.source_meta IS 'Source-specific details, e.g. the tweets of a thread with quoted tweets and media';
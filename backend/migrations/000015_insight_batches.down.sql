@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_insights_batch_id;
+ALTER TABLE insights DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS insight_batches;
@@ -0,0 +1,26 @@
+-- Create insight_batches table
+CREATE TABLE IF NOT EXISTS insight_batches (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    source_kind VARCHAR(20) NOT NULL,
+    source_ref VARCHAR(255),
+    title VARCHAR(500),
+    target_lang VARCHAR(10),
+    requested INTEGER DEFAULT 0,
+    skipped JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Link imported insights to their batch
+ALTER TABLE insights ADD COLUMN IF NOT EXISTS batch_id INTEGER REFERENCES insight_batches(id) ON DELETE SET NULL;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_insight_batches_user_id ON insight_batches(user_id);
+CREATE INDEX IF NOT EXISTS idx_insights_batch_id ON insights(batch_id);
+
+-- Add comments
+COMMENT ON TABLE insight_batches IS 'Bulk imports of a YouTube playlist, channel or list of URLs';
+COMMENT ON COLUMN insight_batches.requested IS 'Items found in the source, including skipped ones';
+COMMENT ON COLUMN insight_batches.skipped IS 'Items without a new insight: duplicates, unavailable videos and invalid URLs';
+COMMENT ON COLUMN insights.batch_id IS 'insight_batches.id of the import that created the insight';
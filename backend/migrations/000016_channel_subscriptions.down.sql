@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_videos;
+DROP TABLE IF EXISTS channel_subscriptions;
+DROP TABLE IF EXISTS channel_feeds;
+
+ALTER TABLE users DROP COLUMN IF EXISTS target_lang;
@@ -0,0 +1,58 @@
+-- Default target language of insights created for a user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS target_lang VARCHAR(10) DEFAULT 'zh';
+
+-- Create channel_feeds table
+CREATE TABLE IF NOT EXISTS channel_feeds (
+    id SERIAL PRIMARY KEY,
+    channel_id VARCHAR(30) NOT NULL,
+    title VARCHAR(255),
+    last_polled_at TIMESTAMPTZ,
+    last_error TEXT,
+    push_secret VARCHAR(64),
+    push_expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create channel_subscriptions table
+CREATE TABLE IF NOT EXISTS channel_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    channel_id VARCHAR(30) NOT NULL,
+    channel_title VARCHAR(255),
+    handle VARCHAR(100),
+    keywords JSONB,
+    min_duration_seconds INTEGER DEFAULT 0,
+    skip_shorts BOOLEAN DEFAULT FALSE,
+    paused BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create subscription_videos table
+CREATE TABLE IF NOT EXISTS subscription_videos (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES channel_subscriptions(id) ON DELETE CASCADE,
+    video_id VARCHAR(20) NOT NULL,
+    title VARCHAR(500),
+    published_at TIMESTAMPTZ,
+    outcome VARCHAR(20) NOT NULL,
+    reason VARCHAR(50),
+    insight_id INTEGER REFERENCES insights(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channel_feeds_channel_id ON channel_feeds(channel_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channel_subscriptions_user_channel ON channel_subscriptions(user_id, channel_id);
+CREATE INDEX IF NOT EXISTS idx_channel_subscriptions_channel_id ON channel_subscriptions(channel_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_videos_subscription_video ON subscription_videos(subscription_id, video_id);
+
+-- Add comments
+COMMENT ON COLUMN users.target_lang IS 'Default target language of insights created on the user''s behalf, such as by channel subscriptions';
+COMMENT ON TABLE channel_feeds IS 'YouTube channels followed by subscriptions, with their polling and WebSub state';
+COMMENT ON COLUMN channel_feeds.push_expires_at IS 'End of the lease confirmed by the WebSub hub; NULL when push is off';
+COMMENT ON TABLE channel_subscriptions IS 'Users following a YouTube channel to get insights for its new uploads';
+COMMENT ON COLUMN channel_subscriptions.keywords IS 'Uploads must mention one of these in title or description; empty = any';
+COMMENT ON TABLE subscription_videos IS 'Uploads seen by a subscription and what was done with them';
+COMMENT ON COLUMN subscription_videos.outcome IS 'created, filtered, duplicate, or existing (uploaded before the subscription)';